@@ -3,6 +3,7 @@ package action
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
 	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
 )
@@ -21,7 +22,7 @@ func NewAttachDisk(
 	return
 }
 
-func (a AttachDiskAction) Run(vmCID VMCID, diskCID DiskCID) (interface{}, error) {
+func (a AttachDiskAction) Run(context api.RequestContext, vmCID VMCID, diskCID DiskCID) (interface{}, error) {
 	vm, found, err := a.vmFinder.Find(vmCID.Int())
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Finding VM '%s'", vmCID)
@@ -40,10 +41,15 @@ func (a AttachDiskAction) Run(vmCID VMCID, diskCID DiskCID) (interface{}, error)
 		return nil, bosherr.Errorf("Expected to find disk '%s'", diskCID)
 	}
 
-	err = vm.AttachDisk(disk)
+	diskHint, err := vm.AttachDisk(disk)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Attaching disk '%s' to VM '%s'", diskCID, vmCID)
 	}
 
+	// CPI API v2 returns the disk hint so that the director can pass it to the agent directly
+	if context.IsApiVersion2() {
+		return diskHint, nil
+	}
+
 	return nil, nil
 }
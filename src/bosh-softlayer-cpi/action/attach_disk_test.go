@@ -7,6 +7,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "bosh-softlayer-cpi/action"
+	"bosh-softlayer-cpi/api"
+	. "bosh-softlayer-cpi/softlayer/common"
 
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
 	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
@@ -34,17 +36,20 @@ var _ = Describe("AttachDisk", func() {
 		var (
 			vmCid   VMCID
 			diskCID DiskCID
+			context api.RequestContext
 
-			err error
+			result interface{}
+			err    error
 		)
 
 		BeforeEach(func() {
 			vmCid = VMCID(123456)
 			diskCID = DiskCID(123456)
+			context = api.RequestContext{ApiVersion: api.ApiVersion1}
 		})
 
 		JustBeforeEach(func() {
-			_, err = action.Run(vmCid, diskCID)
+			result, err = action.Run(context, vmCid, diskCID)
 		})
 
 		Context("when attach disk succeeds", func() {
@@ -52,7 +57,7 @@ var _ = Describe("AttachDisk", func() {
 				fakeVmFinder.FindReturns(fakeVm, true, nil)
 				fakeDiskFinder.FindReturns(fakeDisk, true, nil)
 
-				fakeVm.AttachDiskReturns(nil)
+				fakeVm.AttachDiskReturns("/dev/mapper/fake-device", nil)
 			})
 
 			It("fetches vm by cid", func() {
@@ -72,6 +77,42 @@ var _ = Describe("AttachDisk", func() {
 				actualDisk := fakeVm.AttachDiskArgsForCall(0)
 				Expect(actualDisk).To(Equal(fakeDisk))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+
+			Context("when CPI API version 2 is negotiated", func() {
+				BeforeEach(func() {
+					context = api.RequestContext{ApiVersion: api.ApiVersion2}
+				})
+
+				It("returns the disk hint", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal("/dev/mapper/fake-device"))
+				})
+
+				Context("when the agent attaches the disk", func() {
+					var diskSettings PersistentDiskSettings
+
+					BeforeEach(func() {
+						diskSettings = PersistentDiskSettings{
+							ID:       "123456",
+							VolumeID: "123456",
+							Lun:      "1",
+							ISCSISettings: ISCSISettings{
+								InitiatorName: "fake-iqn",
+								Target:        "fake-target",
+								Username:      "fake-username",
+								Password:      "fake-password",
+							},
+						}
+						fakeVm.AttachDiskReturns(diskSettings, nil)
+					})
+
+					It("returns the iSCSI settings as the disk hint", func() {
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(diskSettings))
+					})
+				})
 			})
 		})
 
@@ -124,7 +165,7 @@ var _ = Describe("AttachDisk", func() {
 				fakeVmFinder.FindReturns(fakeVm, true, nil)
 				fakeDiskFinder.FindReturns(fakeDisk, true, nil)
 
-				fakeVm.AttachDiskReturns(errors.New("kaboom"))
+				fakeVm.AttachDiskReturns("", errors.New("kaboom"))
 			})
 
 			It("provides relevant error information", func() {
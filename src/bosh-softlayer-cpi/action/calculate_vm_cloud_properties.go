@@ -0,0 +1,36 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+)
+
+// CalculatedVMCloudProperties holds only the flavor properties of VMCloudProperties so that the
+// director can merge them with the cloud properties of the AZ and the VM extensions
+type CalculatedVMCloudProperties struct {
+	StartCpus         int  `json:"startCpus"`
+	MaxMemory         int  `json:"maxMemory"`
+	EphemeralDiskSize int  `json:"ephemeralDiskSize"`
+	LocalDiskFlag     bool `json:"localDiskFlag"`
+}
+
+type CalculateVMCloudPropertiesAction struct{}
+
+func NewCalculateVMCloudProperties() (action CalculateVMCloudPropertiesAction) {
+	return
+}
+
+func (a CalculateVMCloudPropertiesAction) Run(resources VMResources) (CalculatedVMCloudProperties, error) {
+	cloudProps, err := resources.CloudProperties()
+	if err != nil {
+		return CalculatedVMCloudProperties{}, bosherr.WrapErrorf(err, "Calculating cloud properties for %d CPUs, %d MB of memory and a %d MB ephemeral disk", resources.CPU, resources.RAM, resources.EphemeralDiskSize)
+	}
+
+	return CalculatedVMCloudProperties{
+		StartCpus:         cloudProps.StartCpus,
+		MaxMemory:         cloudProps.MaxMemory,
+		EphemeralDiskSize: cloudProps.EphemeralDiskSize,
+		LocalDiskFlag:     cloudProps.LocalDiskFlag,
+	}, nil
+}
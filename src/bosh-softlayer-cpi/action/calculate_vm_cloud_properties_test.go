@@ -0,0 +1,45 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/action"
+	. "bosh-softlayer-cpi/softlayer/common"
+)
+
+var _ = Describe("CalculateVMCloudProperties", func() {
+	var (
+		action CalculateVMCloudPropertiesAction
+	)
+
+	BeforeEach(func() {
+		action = NewCalculateVMCloudProperties()
+	})
+
+	Describe("Run", func() {
+		It("rounds the resources up to the smallest virtual guest SoftLayer can order", func() {
+			cloudProps, err := action.Run(VMResources{CPU: 3, RAM: 5000, EphemeralDiskSize: 30 * 1024})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProps).To(Equal(CalculatedVMCloudProperties{
+				StartCpus:         4,
+				MaxMemory:         6144,
+				EphemeralDiskSize: 100,
+				LocalDiskFlag:     true,
+			}))
+		})
+
+		It("puts an ephemeral disk larger than any local disk on SAN", func() {
+			cloudProps, err := action.Run(VMResources{CPU: 2, RAM: 4096, EphemeralDiskSize: 320 * 1024})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProps.EphemeralDiskSize).To(Equal(350))
+			Expect(cloudProps.LocalDiskFlag).To(BeFalse())
+		})
+
+		It("returns an error when no virtual guest is large enough", func() {
+			_, err := action.Run(VMResources{CPU: 64, RAM: 4096, EphemeralDiskSize: 1024})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No virtual guest has 64 CPUs"))
+		})
+	})
+})
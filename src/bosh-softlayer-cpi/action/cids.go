@@ -89,3 +89,33 @@ func (diskCID DiskCID) String() string {
 func (diskCID DiskCID) Int() int {
 	return int(diskCID)
 }
+
+type SnapshotCID int
+
+func (snapshotCID *SnapshotCID) UnmarshalJSON(data []byte) error {
+	if snapshotCID == nil {
+		return errors.New("SnapshotCID: UnmarshalJSON on nil pointer")
+	}
+
+	dataString := strings.Trim(string(data), "\"")
+	intValue, err := strconv.Atoi(dataString)
+	if err != nil {
+		return err
+	}
+
+	*snapshotCID = SnapshotCID(intValue)
+
+	return nil
+}
+
+func (snapshotCID SnapshotCID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int(snapshotCID))
+}
+
+func (snapshotCID SnapshotCID) String() string {
+	return strconv.Itoa(int(snapshotCID))
+}
+
+func (snapshotCID SnapshotCID) Int() int {
+	return int(snapshotCID)
+}
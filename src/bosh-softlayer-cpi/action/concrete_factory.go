@@ -5,7 +5,6 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	bmsclient "github.com/cloudfoundry-community/bosh-softlayer-tools/clients"
-	slclient "github.com/maximilien/softlayer-go/client"
 
 	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
 	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
@@ -15,27 +14,36 @@ import (
 	httptransport "github.com/go-openapi/runtime/client"
 
 	. "bosh-softlayer-cpi/softlayer/common"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	"fmt"
 	"github.com/go-openapi/strfmt"
 )
 
 type concreteFactory struct {
 	availableActions map[string]Action
+	rateLimiter      *slh.FileRateLimiter
 }
 
 func NewConcreteFactory(options ConcreteFactoryOptions, logger boshlog.Logger) concreteFactory {
-	softLayerClient := slclient.NewSoftLayerClient(options.Softlayer.Username, options.Softlayer.ApiKey)
+	options.Softlayer.FeatureOptions = options.Softlayer.FeatureOptions.WithDefaults()
+	waitPolicies := options.Softlayer.FeatureOptions.WaitPolicies
+	runtimeOptions := options.Softlayer.FeatureOptions.RuntimeOptions()
+
+	rateLimiter := slh.NewFileRateLimiter(runtimeOptions.ApiRateLimit, logger)
+	softLayerClient := NewSoftLayerClientWithRateLimiter(options.Softlayer.Username, options.Softlayer.ApiKey, runtimeOptions, rateLimiter, logger)
 	baremetalClient := bmsclient.NewBmpClient(options.Baremetal.Username, options.Baremetal.Password, options.Baremetal.EndPoint, nil, "")
 	poolClient := apiclient.New(httptransport.New(fmt.Sprintf("%s:%d", options.Pool.Host, options.Pool.Port), "v2", []string{"https"}), strfmt.Default).VM
 
-	stemcellFinder := bslcstem.NewSoftLayerStemcellFinder(softLayerClient, logger)
+	stemcellFinder := bslcstem.NewSoftLayerStemcellFinder(softLayerClient, waitPolicies, logger)
 
-	agentEnvServiceFactory := NewSoftLayerAgentEnvServiceFactory(options.Registry, logger)
+	agentEnvServiceFactory := NewSoftLayerAgentEnvServiceFactory(options.Registry, runtimeOptions, logger)
 
 	vmFinder := bslcvm.NewSoftLayerFinder(
 		softLayerClient,
 		baremetalClient,
 		agentEnvServiceFactory,
+		waitPolicies,
+		options.Softlayer.FeatureOptions.DiskAttachMode,
 		logger,
 	)
 
@@ -44,18 +52,21 @@ func NewConcreteFactory(options ConcreteFactoryOptions, logger boshlog.Logger) c
 		baremetalClient,
 		poolClient,
 		options,
+		runtimeOptions,
 		logger,
 	)
 
 	vmDeleterProvider := NewDeleterProvider(
 		softLayerClient,
 		poolClient,
+		waitPolicies,
 		logger,
 		vmFinder,
 	)
 
 	diskCreator := bslcdisk.NewSoftLayerDiskCreator(
 		softLayerClient,
+		runtimeOptions.CreateISCSIVolume,
 		logger,
 	)
 
@@ -64,15 +75,47 @@ func NewConcreteFactory(options ConcreteFactoryOptions, logger boshlog.Logger) c
 		logger,
 	)
 
+	diskResizer := bslcdisk.NewSoftLayerDiskResizer(
+		softLayerClient,
+		waitPolicies.DiskResize,
+		logger,
+	)
+
+	vmQuoter := bslcvm.NewSoftLayerVirtualGuestQuoter(
+		softLayerClient,
+		logger,
+	)
+
+	diskQuoter := bslcdisk.NewSoftLayerDiskQuoter(
+		softLayerClient,
+		logger,
+	)
+
+	snapshotCreator := bslcdisk.NewSoftLayerSnapshotCreator(
+		softLayerClient,
+		logger,
+	)
+
+	snapshotFinder := bslcdisk.NewSoftLayerSnapshotFinder(
+		softLayerClient,
+		logger,
+	)
+
 	return concreteFactory{
 		availableActions: map[string]Action{
+			// CPI information
+			"info": NewInfo(),
+
+			// Cloud properties
+			"calculate_vm_cloud_properties": NewCalculateVMCloudProperties(),
+
 			// Stemcell management
 			"create_stemcell": NewCreateStemcell(stemcellFinder),
 			"delete_stemcell": NewDeleteStemcell(stemcellFinder, logger),
 
 			// VM management
-			"create_vm":          NewCreateVM(stemcellFinder, vmCreatorProvider, options),
-			"delete_vm":          NewDeleteVM(vmDeleterProvider, options),
+			"create_vm":          NewCreateVM(stemcellFinder, vmCreatorProvider, options, logger),
+			"delete_vm":          NewDeleteVM(vmFinder, vmDeleterProvider, options),
 			"has_vm":             NewHasVM(vmFinder),
 			"reboot_vm":          NewRebootVM(vmFinder),
 			"set_vm_metadata":    NewSetVMMetadata(vmFinder),
@@ -83,19 +126,30 @@ func NewConcreteFactory(options ConcreteFactoryOptions, logger boshlog.Logger) c
 			"delete_disk": NewDeleteDisk(diskFinder),
 			"attach_disk": NewAttachDisk(vmFinder, diskFinder),
 			"detach_disk": NewDetachDisk(vmFinder, diskFinder),
+			"has_disk":    NewHasDisk(diskFinder),
+			"get_disks":   NewGetDisks(vmFinder, diskFinder),
+			"resize_disk": NewResizeDisk(diskFinder, diskResizer, vmFinder),
 
-			// Not implemented (disk related):
-			//   snapshot_disk
-			//   delete_snapshot
-			//   get_disks
+			// Snapshot management
+			"snapshot_disk":   NewSnapshotDisk(diskFinder, snapshotCreator),
+			"delete_snapshot": NewDeleteSnapshot(snapshotFinder),
+
+			// Operator tooling, never called by the director
+			"quote_orders": NewQuoteOrders(stemcellFinder, vmQuoter, diskQuoter, options),
 
 			// Not implemented (others):
 			//   current_vm_id
 			//   ping
 		},
+		rateLimiter: rateLimiter,
 	}
 }
 
+// LogAPICalls logs how often the actions called each SoftLayer API method
+func (f concreteFactory) LogAPICalls() {
+	f.rateLimiter.LogCallCounts()
+}
+
 func (f concreteFactory) Create(method string) (Action, error) {
 	action, found := f.availableActions[method]
 	if !found {
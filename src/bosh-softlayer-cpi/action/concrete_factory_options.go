@@ -1,9 +1,6 @@
 package action
 
 import (
-	"os"
-	"strconv"
-
 	. "bosh-softlayer-cpi/softlayer/common"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
@@ -42,6 +39,9 @@ type SoftLayerConfig struct {
 	Username       string         `json:"username"`
 	ApiKey         string         `json:"apiKey"`
 	FeatureOptions FeatureOptions `json:"featureOptions,omitempty"`
+
+	InstanceTypes       InstanceTypes `json:"instanceTypes,omitempty"`
+	DefaultInstanceType string        `json:"defaultInstanceType,omitempty"`
 }
 
 type BaremetalConfig struct {
@@ -64,44 +64,24 @@ func (c SoftLayerConfig) Validate() error {
 		return bosherr.Error("Must provide non-empty ApiKey")
 	}
 
-	if c.FeatureOptions.ApiWaitTime == 0 {
-		c.FeatureOptions.ApiWaitTime = 0
-	}
-	err := os.Setenv("SL_API_WAIT_TIME", strconv.Itoa(c.FeatureOptions.ApiWaitTime))
-	if err != nil {
-		return bosherr.WrapError(err, "Setting Environment Variable")
-	}
-
-	if c.FeatureOptions.ApiRetryCount == 0 {
-		c.FeatureOptions.ApiRetryCount = 1
-	}
-	err = os.Setenv("SL_API_RETRY_COUNT", strconv.Itoa(c.FeatureOptions.ApiRetryCount))
+	err := c.FeatureOptions.RuntimeOptions().Validate()
 	if err != nil {
-		return bosherr.WrapError(err, "Setting Environment Variable")
+		return bosherr.WrapError(err, "Validating FeatureOptions")
 	}
 
-	if c.FeatureOptions.ApiEndpoint == "" {
-		c.FeatureOptions.ApiEndpoint = "api.softlayer.com"
-	}
-	err = os.Setenv("SL_API_ENDPOINT", c.FeatureOptions.ApiEndpoint)
-	if err != nil {
-		return bosherr.WrapError(err, "Setting Environment Variable")
+	switch c.FeatureOptions.DiskAttachMode {
+	case "", DiskAttachModeAgent, DiskAttachModeSSH:
+	default:
+		return bosherr.Errorf("DiskAttachMode '%s' must be '%s' or '%s'", c.FeatureOptions.DiskAttachMode, DiskAttachModeAgent, DiskAttachModeSSH)
 	}
 
-	if c.FeatureOptions.CreateISCSIVolumeTimeout == 0 {
-		c.FeatureOptions.CreateISCSIVolumeTimeout = 600
-	}
-	err = os.Setenv("SL_CREATE_ISCSI_VOLUME_TIMEOUT", strconv.Itoa(c.FeatureOptions.CreateISCSIVolumeTimeout))
+	err = c.InstanceTypes.Validate()
 	if err != nil {
-		return bosherr.WrapError(err, "Setting Environment Variable")
+		return bosherr.WrapError(err, "Validating InstanceTypes")
 	}
 
-	if c.FeatureOptions.CreateISCSIVolumePollingInterval == 0 {
-		c.FeatureOptions.CreateISCSIVolumePollingInterval = 10
-	}
-	err = os.Setenv("SL_CREATE_ISCSI_VOLUME_POLLING_INTERVAL", strconv.Itoa(c.FeatureOptions.CreateISCSIVolumePollingInterval))
-	if err != nil {
-		return bosherr.WrapError(err, "Setting Environment Variable")
+	if _, found := c.InstanceTypes[c.DefaultInstanceType]; c.DefaultInstanceType != "" && !found {
+		return bosherr.Errorf("DefaultInstanceType '%s' is not one of the InstanceTypes", c.DefaultInstanceType)
 	}
 
 	return nil
@@ -1,13 +1,14 @@
 package action_test
 
 import (
-	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "bosh-softlayer-cpi/action"
 	. "bosh-softlayer-cpi/softlayer/common"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 )
 
 var _ = Describe("ConcreteFactoryOptions", func() {
@@ -40,19 +41,113 @@ var _ = Describe("ConcreteFactoryOptions", func() {
 				ApiRetryCount:                    5,
 				CreateISCSIVolumeTimeout:         1200,
 				CreateISCSIVolumePollingInterval: 20,
+				ApiBackoff:                       slh.BackoffPolicy{Attempts: 8, Delay: 2 * time.Second},
+				ApiRateLimit:                     5,
+				ApiRateLimitStateFile:            "/fake-state-file",
 			}
 			options = validOptions
 		})
 
-		It("sets environment variables correctly if specified", func() {
+		It("builds runtime options from the specified values", func() {
 			err := options.Validate()
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(os.Getenv("SL_API_ENDPOINT")).To(Equal("api.service.softlayer.com"))
-			Expect(os.Getenv("SL_API_WAIT_TIME")).To(Equal("3"))
-			Expect(os.Getenv("SL_API_RETRY_COUNT")).To(Equal("5"))
-			Expect(os.Getenv("SL_CREATE_ISCSI_VOLUME_TIMEOUT")).To(Equal("1200"))
-			Expect(os.Getenv("SL_CREATE_ISCSI_VOLUME_POLLING_INTERVAL")).To(Equal("20"))
+			runtimeOptions := options.Softlayer.FeatureOptions.RuntimeOptions()
+			Expect(runtimeOptions.ApiEndpoint).To(Equal("api.service.softlayer.com"))
+			Expect(runtimeOptions.ApiWaitTime).To(Equal(3 * time.Second))
+			Expect(runtimeOptions.ApiRetryCount).To(Equal(5))
+			Expect(runtimeOptions.CreateISCSIVolume.Timeout).To(Equal(1200 * time.Second))
+			Expect(runtimeOptions.CreateISCSIVolume.Interval).To(Equal(20 * time.Second))
+			Expect(runtimeOptions.ApiBackoff).To(Equal(slh.BackoffPolicy{Attempts: 8, Delay: 2 * time.Second, MaxDelay: 30 * time.Second}))
+			Expect(runtimeOptions.ApiRateLimit).To(Equal(slh.RateLimit{CallsPerSecond: 5, Burst: 10, StateFile: "/fake-state-file"}))
+		})
+
+		It("returns error if the api endpoint is unknown", func() {
+			options.Softlayer.FeatureOptions.ApiEndpoint = "fake-endpoint"
+
+			err := options.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown ApiEndpoint 'fake-endpoint'"))
+		})
+
+		It("accepts an http:// api endpoint on the loopback interface", func() {
+			options.Softlayer.FeatureOptions.ApiEndpoint = "http://127.0.0.1:34567"
+
+			err := options.Validate()
+			Expect(err).ToNot(HaveOccurred())
+
+			runtimeOptions := options.Softlayer.FeatureOptions.RuntimeOptions()
+			Expect(runtimeOptions.ApiUrl()).To(Equal("127.0.0.1:34567/rest/v3"))
+			Expect(runtimeOptions.ApiUsesHttps()).To(BeFalse())
+		})
+
+		It("returns error if an http:// api endpoint is not on the loopback interface", func() {
+			options.Softlayer.FeatureOptions.ApiEndpoint = "http://api.softlayer.com"
+
+			err := options.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown ApiEndpoint 'http://api.softlayer.com'"))
+		})
+
+		It("returns error if the api retry count is negative", func() {
+			options.Softlayer.FeatureOptions.ApiRetryCount = -1
+
+			err := options.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ApiRetryCount must be positive"))
+		})
+
+		It("returns error if the api backoff delay exceeds its maximum", func() {
+			options.Softlayer.FeatureOptions.ApiBackoff.Delay = 60 * time.Second
+
+			err := options.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ApiBackoff delay must not exceed maxDelay"))
+		})
+
+		It("accepts attaching disks through the agent", func() {
+			options.Softlayer.FeatureOptions.DiskAttachMode = DiskAttachModeAgent
+
+			Expect(options.Validate()).To(Succeed())
+		})
+
+		It("returns error if the disk attach mode is unknown", func() {
+			options.Softlayer.FeatureOptions.DiskAttachMode = "fake-mode"
+
+			err := options.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("DiskAttachMode 'fake-mode' must be 'agent' or 'ssh'"))
+		})
+	})
+
+	Context("when instance types are specified", func() {
+		BeforeEach(func() {
+			validOptions.Softlayer.FeatureOptions = FeatureOptions{}
+			options = validOptions
+			options.Softlayer.InstanceTypes = InstanceTypes{
+				"small": InstanceType{StartCpus: 2, MaxMemory: 4096},
+			}
+			options.Softlayer.DefaultInstanceType = "small"
+		})
+
+		It("accepts a default instance type from the catalog", func() {
+			Expect(options.Validate()).To(Succeed())
+		})
+
+		It("returns error if the default instance type is not in the catalog", func() {
+			options.Softlayer.DefaultInstanceType = "huge"
+
+			err := options.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("DefaultInstanceType 'huge' is not one of the InstanceTypes"))
+		})
+
+		It("returns error if an instance type is impossible", func() {
+			options.Softlayer.InstanceTypes["small"] = InstanceType{EphemeralDiskSize: 1000}
+
+			err := options.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating InstanceTypes"))
 		})
 	})
 
@@ -70,15 +165,26 @@ var _ = Describe("ConcreteFactoryOptions", func() {
 			Expect(err.Error()).To(ContainSubstring("Validating Agent configuration"))
 		})
 
-		It("sets the default values to the environment variables if not specified", func() {
+		It("uses the default runtime options if not specified", func() {
 			err := options.Validate()
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(os.Getenv("SL_API_ENDPOINT")).To(Equal("api.softlayer.com"))
-			Expect(os.Getenv("SL_API_WAIT_TIME")).To(Equal("0"))
-			Expect(os.Getenv("SL_API_RETRY_COUNT")).To(Equal("1"))
-			Expect(os.Getenv("SL_CREATE_ISCSI_VOLUME_TIMEOUT")).To(Equal("600"))
-			Expect(os.Getenv("SL_CREATE_ISCSI_VOLUME_POLLING_INTERVAL")).To(Equal("10"))
+			Expect(options.Softlayer.FeatureOptions.RuntimeOptions()).To(Equal(DefaultRuntimeOptions()))
+		})
+
+		It("defaults the runtime options to the SoftLayer public API", func() {
+			runtimeOptions := DefaultRuntimeOptions()
+			Expect(runtimeOptions.ApiUrl()).To(Equal("api.softlayer.com/rest/v3"))
+			Expect(runtimeOptions.ApiUsesHttps()).To(BeTrue())
+			Expect(runtimeOptions.ApiRetryCount).To(Equal(1))
+			Expect(runtimeOptions.CreateISCSIVolume.Timeout).To(Equal(600 * time.Second))
+			Expect(runtimeOptions.CreateISCSIVolume.Interval).To(Equal(10 * time.Second))
+			Expect(runtimeOptions.UpdateAgentEnvRetryCount).To(Equal(5))
+			Expect(runtimeOptions.UpdateAgentEnvWaitTime).To(Equal(5 * time.Second))
+		})
+
+		It("defaults to attaching disks over SSH", func() {
+			Expect(options.Softlayer.FeatureOptions.WithDefaults().DiskAttachMode).To(Equal(DiskAttachModeSSH))
 		})
 	})
 })
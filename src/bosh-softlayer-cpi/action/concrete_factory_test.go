@@ -43,6 +43,22 @@ var _ = Describe("ConcreteFactory", func() {
 		})
 	})
 
+	Context("CPI information methods", func() {
+		It("info", func() {
+			action, err := factory.Create("info")
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Cloud properties methods", func() {
+		It("calculate_vm_cloud_properties", func() {
+			action, err := factory.Create("calculate_vm_cloud_properties")
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 	Context("VM methods", func() {
 		It("create_vm", func() {
 			action, err := factory.Create("create_vm")
@@ -105,29 +121,49 @@ var _ = Describe("ConcreteFactory", func() {
 			Expect(action).ToNot(BeNil())
 			Expect(err).ToNot(HaveOccurred())
 		})
-	})
 
-	Context("Unsupported methods", func() {
-		It("returns error because CPI machine is not self-aware if action is current_vm_id", func() {
-			action, err := factory.Create("current_vm_id")
-			Expect(err).To(HaveOccurred())
-			Expect(action).To(BeNil())
+		It("checks whether an iSCSI disk exists", func() {
+			action, err := factory.Create("has_disk")
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("lists the iSCSI disks attached to virtual guest", func() {
+			action, err := factory.Create("get_disks")
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
 		})
 
-		It("returns error because snapshotting is not implemented if action is snapshot_disk", func() {
+		It("resizes an iSCSI disk", func() {
+			action, err := factory.Create("resize_disk")
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Snapshot methods", func() {
+		It("snapshot_disk", func() {
 			action, err := factory.Create("snapshot_disk")
-			Expect(err).To(HaveOccurred())
-			Expect(action).To(BeNil())
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
 		})
 
-		It("returns error because snapshotting is not implemented if action is delete_snapshot", func() {
+		It("delete_snapshot", func() {
 			action, err := factory.Create("delete_snapshot")
-			Expect(err).To(HaveOccurred())
-			Expect(action).To(BeNil())
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
 		})
 
-		It("returns error since CPI should not keep state if action is get_disks", func() {
-			action, err := factory.Create("get_disks")
+		It("quote_orders", func() {
+			action, err := factory.Create("quote_orders")
+			Expect(action).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Unsupported methods", func() {
+		It("returns error because CPI machine is not self-aware if action is current_vm_id", func() {
+			action, err := factory.Create("current_vm_id")
 			Expect(err).To(HaveOccurred())
 			Expect(action).To(BeNil())
 		})
@@ -3,6 +3,7 @@ package action
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
 	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
 )
@@ -29,6 +30,9 @@ func (a CreateDiskAction) Run(size int, cloudProps bslcdisk.DiskCloudProperties,
 
 	disk, err := a.diskCreator.Create(size, cloudProps, vm.GetDataCenterId())
 	if err != nil {
+		if _, ok := err.(api.CloudError); ok {
+			return "0", err
+		}
 		return "0", bosherr.WrapErrorf(err, "Creating disk of size '%d'", size)
 	}
 
@@ -8,6 +8,8 @@ import (
 
 	. "bosh-softlayer-cpi/action"
 
+	"bosh-softlayer-cpi/api"
+
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
 	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
 
@@ -53,6 +55,7 @@ var _ = Describe("CreateDisk", func() {
 			BeforeEach(func() {
 				fakeVm.GetDataCenterIdReturns(123456)
 				fakeVmFinder.FindReturns(fakeVm, true, nil)
+				fakeDisk.IDReturns(1234)
 				fakeDiskCreator.CreateReturns(fakeDisk, nil)
 			})
 
@@ -70,6 +73,10 @@ var _ = Describe("CreateDisk", func() {
 				Expect(actualDataCenterId).To(Equal(123456))
 				Expect(err).NotTo(HaveOccurred())
 			})
+
+			It("returns the disk cid", func() {
+				Expect(diskCidStr).To(Equal("1234"))
+			})
 		})
 
 		Context("when find vm error out", func() {
@@ -102,5 +109,16 @@ var _ = Describe("CreateDisk", func() {
 				Expect(err).To(HaveOccurred())
 			})
 		})
+
+		Context("when the cloud properties are invalid", func() {
+			BeforeEach(func() {
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+				fakeDiskCreator.CreateReturns(nil, api.NewCloudPropertiesError("kaboom"))
+			})
+
+			It("returns the cloud error as it is", func() {
+				Expect(err).To(Equal(api.NewCloudPropertiesError("kaboom")))
+			})
+		})
 	})
 })
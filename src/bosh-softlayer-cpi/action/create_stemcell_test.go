@@ -37,6 +37,7 @@ var _ = Describe("CreateStemcell", func() {
 
 		Context("when create stemcell succeeds", func() {
 			BeforeEach(func() {
+				fakeStemcell.IDReturns(123456)
 				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
 			})
 
@@ -49,6 +50,10 @@ var _ = Describe("CreateStemcell", func() {
 			It("no error return", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
+
+			It("returns the stemcell cid", func() {
+				Expect(stemcellIdStr).To(Equal("123456"))
+			})
 		})
 
 		Context("when find stemcell error return", func() {
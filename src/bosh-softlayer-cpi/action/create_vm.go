@@ -4,9 +4,10 @@ import (
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
-	helper "bosh-softlayer-cpi/softlayer/common/helper"
 	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
 
 	sldatatypes "github.com/maximilien/softlayer-go/data_types"
@@ -18,95 +19,159 @@ type CreateVMAction struct {
 	vmCreator         VMCreator
 	vmCloudProperties *VMCloudProperties
 	options           ConcreteFactoryOptions
+	logger            boshlog.Logger
 }
 
+const createVMLogTag = "CreateVMAction"
+
 func NewCreateVM(
 	stemcellFinder bslcstem.StemcellFinder,
 	vmCreatorProvider CreatorProvider,
 	options ConcreteFactoryOptions,
+	logger boshlog.Logger,
 ) (action CreateVMAction) {
 	action.options = options
+	action.logger = logger
 	action.stemcellFinder = stemcellFinder
 	action.vmCreatorProvider = vmCreatorProvider
 	action.vmCloudProperties = &VMCloudProperties{}
 	return
 }
 
-func (a CreateVMAction) Run(agentID string, stemcellCID StemcellCID, cloudProps VMCloudProperties, networks Networks, diskIDs []DiskCID, env Environment) (string, error) {
-	a.updateCloudProperties(&cloudProps)
+func (a CreateVMAction) Run(context api.RequestContext, agentID string, stemcellCID StemcellCID, cloudProps VMCloudProperties, networks Networks, diskIDs []DiskCID, env Environment) (interface{}, error) {
+	vm, err := a.createVM(agentID, stemcellCID, cloudProps, networks, env)
+	if err != nil {
+		return "0", err
+	}
+
+	vmCID := VMCID(vm.ID()).String()
+
+	// Tag the VM with the director UUID so that it can be tied back to its director when several directors share one account.
+	// The VM is up by now, and failing would leak it, so a failure is only logged and set_vm_metadata tags it again.
+	if context.DirectorUUID != "" {
+		err = vm.SetMetadata(VMMetadata{"director_uuid": context.DirectorUUID})
+		if err != nil {
+			a.logger.Warn(createVMLogTag, "Setting director UUID tag on VM '%s': %s", vmCID, err.Error())
+		}
+	}
+
+	// CPI API v2 returns the VM CID together with the networks the VM was created with
+	if context.IsApiVersion2() {
+		return []interface{}{vmCID, networks}, nil
+	}
+
+	return vmCID, nil
+}
+
+func (a CreateVMAction) createVM(agentID string, stemcellCID StemcellCID, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+	warnings, err := CheckCloudProperties(cloudProps, networks)
+	for _, warning := range warnings {
+		a.logger.Warn(createVMLogTag, warning)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	helper.TIMEOUT = 30 * time.Second
-	helper.POLLING_INTERVAL = 5 * time.Second
-	helper.NetworkInterface = "eth0"
-	helper.LocalDNSConfigurationFile = "/etc/hosts"
+	err = a.updateCloudProperties(&cloudProps)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Resolving cloud properties")
+	}
 
 	stemcell, err := a.stemcellFinder.FindById(int(stemcellCID))
 	if err != nil {
-		return "0", bosherr.WrapErrorf(err, "Finding stemcell '%s'", stemcellCID)
+		return nil, bosherr.WrapErrorf(err, "Finding stemcell '%s'", stemcellCID)
 	}
 
 	if a.options.Softlayer.FeatureOptions.EnablePool {
 		a.vmCreator = a.vmCreatorProvider.Get("pool")
 		vm, err := a.vmCreator.Create(agentID, stemcell, cloudProps, networks, env)
 		if err != nil {
-			return "0", bosherr.WrapErrorf(err, "Creating vm with agent ID '%s'", agentID)
+			return nil, bosherr.WrapErrorf(err, "Creating vm with agent ID '%s'", agentID)
 		}
 
-		return VMCID(vm.ID()).String(), nil
+		return vm, nil
 	}
 
 	if cloudProps.Baremetal {
 		a.vmCreator = a.vmCreatorProvider.Get("baremetal")
 		vm, err := a.vmCreator.Create(agentID, stemcell, cloudProps, networks, env)
 		if err != nil {
-			return "0", bosherr.WrapErrorf(err, "Creating Baremetal with agent ID '%s'", agentID)
+			return nil, bosherr.WrapErrorf(err, "Creating Baremetal with agent ID '%s'", agentID)
 		}
 
-		return VMCID(vm.ID()).String(), nil
+		return vm, nil
 	} else {
 		a.vmCreator = a.vmCreatorProvider.Get("virtualguest")
 		vm, err := a.vmCreator.Create(agentID, stemcell, cloudProps, networks, env)
 		if err != nil {
-			return "0", bosherr.WrapErrorf(err, "Creating Virtual_Guest with agent ID '%s'", agentID)
+			return nil, bosherr.WrapErrorf(err, "Creating Virtual_Guest with agent ID '%s'", agentID)
 		}
 
-		return VMCID(vm.ID()).String(), nil
+		return vm, nil
 	}
 }
 
-func (a CreateVMAction) updateCloudProperties(cloudProps *VMCloudProperties) {
+func (a CreateVMAction) updateCloudProperties(cloudProps *VMCloudProperties) error {
 	a.vmCloudProperties = cloudProps
 
+	return setVMCloudPropertiesDefaults(a.vmCloudProperties, a.options.Softlayer)
+}
+
+// setVMCloudPropertiesDefaults fills in what create_vm orders when the cloud properties leave it out,
+// first from their instance type, or the default one, then from the hard-coded defaults. It rejects
+// the properties when SoftLayer could not order a virtual guest with them.
+func setVMCloudPropertiesDefaults(cloudProps *VMCloudProperties, config SoftLayerConfig) error {
+	instanceTypeName := cloudProps.InstanceType
+	if instanceTypeName == "" {
+		instanceTypeName = config.DefaultInstanceType
+	}
+
+	if instanceTypeName != "" {
+		// The instance type is resolved once, for the first datacenter, so it cannot vary
+		// between the datacenters the virtual guest fails over to
+		for _, datacenter := range cloudProps.Datacenters {
+			if config.InstanceTypes.Overrides(instanceTypeName, datacenter.Name) {
+				return bosherr.Errorf("Instance type '%s' overrides its properties in datacenter '%s', which cannot be in a list of datacenters to fail over between", instanceTypeName, datacenter.Name)
+			}
+		}
+
+		instanceType, err := config.InstanceTypes.Resolve(instanceTypeName, cloudProps.Datacenter.Name)
+		if err != nil {
+			return err
+		}
+		cloudProps.ApplyInstanceType(instanceType)
+	}
+
 	if cloudProps.DeployedByBoshCLI {
-		a.vmCloudProperties.VmNamePrefix = updateHostNameInCloudProps(cloudProps, "")
+		cloudProps.VmNamePrefix = updateHostNameInCloudProps(cloudProps, "")
 	} else {
-		a.vmCloudProperties.VmNamePrefix = updateHostNameInCloudProps(cloudProps, TimeStampForTime(time.Now().UTC()))
+		cloudProps.VmNamePrefix = updateHostNameInCloudProps(cloudProps, TimeStampForTime(time.Now().UTC()))
 	}
 
 	if cloudProps.StartCpus == 0 {
-		a.vmCloudProperties.StartCpus = 4
+		cloudProps.StartCpus = 4
 	}
 
 	if cloudProps.MaxMemory == 0 {
-		a.vmCloudProperties.MaxMemory = 8192
+		cloudProps.MaxMemory = 8192
 	}
 
 	if len(cloudProps.Domain) == 0 {
-		a.vmCloudProperties.Domain = "softlayer.com"
+		cloudProps.Domain = "softlayer.com"
 	}
-	helper.LengthOfHostName = len(a.vmCloudProperties.VmNamePrefix + "." + a.vmCloudProperties.Domain)
 	// A workaround for the issue #129 in bosh-softlayer-cpi
-	if helper.LengthOfHostName == 64 {
-		a.vmCloudProperties.VmNamePrefix = a.vmCloudProperties.VmNamePrefix + "-1"
-		helper.LengthOfHostName = len(a.vmCloudProperties.VmNamePrefix + "." + a.vmCloudProperties.Domain)
+	if len(cloudProps.VmNamePrefix+"."+cloudProps.Domain) == 64 {
+		cloudProps.VmNamePrefix = cloudProps.VmNamePrefix + "-1"
 	}
 	if len(cloudProps.NetworkComponents) == 0 {
-		a.vmCloudProperties.NetworkComponents = []sldatatypes.NetworkComponents{{MaxSpeed: 1000}}
+		cloudProps.NetworkComponents = []sldatatypes.NetworkComponents{{MaxSpeed: 1000}}
 	}
 
-	if helper.LocalDiskFlagNotSet == true {
-		a.vmCloudProperties.LocalDiskFlag = true
+	if cloudProps.Baremetal {
+		return nil
 	}
+
+	return cloudProps.Validate()
 }
 
 func updateHostNameInCloudProps(cloudProps *VMCloudProperties, timeStampPostfix string) string {
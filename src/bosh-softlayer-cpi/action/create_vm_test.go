@@ -1,6 +1,7 @@
 package action_test
 
 import (
+	"encoding/json"
 	"errors"
 
 	. "github.com/onsi/ginkgo"
@@ -8,12 +9,13 @@ import (
 
 	. "bosh-softlayer-cpi/action"
 	fakeaction "bosh-softlayer-cpi/action/fakes"
+	"bosh-softlayer-cpi/api"
 
 	. "bosh-softlayer-cpi/softlayer/common"
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
-	helper "bosh-softlayer-cpi/softlayer/common/helper"
 	fakestem "bosh-softlayer-cpi/softlayer/stemcell/fakes"
 
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	sldatatypes "github.com/maximilien/softlayer-go/data_types"
 )
 
@@ -24,6 +26,7 @@ var _ = Describe("CreateVM", func() {
 		fakeVmCreator       *fakescommon.FakeVMCreator
 		fakeVm              *fakescommon.FakeVM
 		fakeCreatorProvider *fakeaction.FakeCreatorProvider
+		logger              boshlog.Logger
 	)
 
 	BeforeEach(func() {
@@ -32,11 +35,13 @@ var _ = Describe("CreateVM", func() {
 		fakeStemcell = &fakestem.FakeStemcell{}
 		fakeVmCreator = &fakescommon.FakeVMCreator{}
 		fakeCreatorProvider = &fakeaction.FakeCreatorProvider{}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
 	})
 
 	Describe("Run", func() {
 		var (
-			vmCidString   string
+			vmCidString   interface{}
+			context       api.RequestContext
 			stemcellCID   StemcellCID
 			err           error
 			networks      Networks
@@ -52,10 +57,11 @@ var _ = Describe("CreateVM", func() {
 			networks = Networks{"fake-net-name": Network{IP: "fake-ip"}}
 			env = Environment{"fake-env-key": "fake-env-value"}
 			diskLocality = []DiskCID{1234}
+			context = api.RequestContext{ApiVersion: api.ApiVersion1}
 		})
 
 		JustBeforeEach(func() {
-			vmCidString, err = action.Run("fake-agent-id", stemcellCID, fakeCloudProp, networks, diskLocality, env)
+			vmCidString, err = action.Run(context, "fake-agent-id", stemcellCID, fakeCloudProp, networks, diskLocality, env)
 		})
 
 		Context("when create vm with enabled pool succeeds", func() {
@@ -73,7 +79,7 @@ var _ = Describe("CreateVM", func() {
 						sldatatypes.SshKey{Id: 1234},
 					},
 				}
-				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions)
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
 
 				fakeVm.IDReturns(1234567)
 				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
@@ -105,6 +111,178 @@ var _ = Describe("CreateVM", func() {
 			})
 		})
 
+		Context("when CPI API version 2 is negotiated", func() {
+			BeforeEach(func() {
+				fakeOptions = &ConcreteFactoryOptions{}
+				fakeCloudProp = VMCloudProperties{
+					Datacenter:   sldatatypes.Datacenter{Name: "fake-datacenter"},
+					VmNamePrefix: "fake-hostname",
+				}
+				context = api.RequestContext{ApiVersion: api.ApiVersion2}
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
+
+				fakeVm.IDReturns(1234567)
+				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
+				fakeCreatorProvider.GetReturns(fakeVmCreator)
+				fakeVmCreator.CreateReturns(fakeVm, nil)
+			})
+
+			It("returns vm cid together with networks", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmCidString).To(Equal([]interface{}{"1234567", networks}))
+			})
+		})
+
+		Context("when director uuid is provided in context", func() {
+			BeforeEach(func() {
+				fakeOptions = &ConcreteFactoryOptions{}
+				fakeCloudProp = VMCloudProperties{
+					Datacenter:   sldatatypes.Datacenter{Name: "fake-datacenter"},
+					VmNamePrefix: "fake-hostname",
+				}
+				context = api.RequestContext{DirectorUUID: "fake-director-uuid"}
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
+
+				fakeVm.IDReturns(1234567)
+				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
+				fakeCreatorProvider.GetReturns(fakeVmCreator)
+				fakeVmCreator.CreateReturns(fakeVm, nil)
+			})
+
+			It("tags vm with director uuid", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeVm.SetMetadataCallCount()).To(Equal(1))
+				Expect(fakeVm.SetMetadataArgsForCall(0)).To(Equal(VMMetadata{"director_uuid": "fake-director-uuid"}))
+			})
+
+			Context("when tagging vm fails", func() {
+				BeforeEach(func() {
+					fakeVm.SetMetadataReturns(errors.New("kaboom"))
+				})
+
+				It("returns the vm cid all the same", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vmCidString).To(Equal("1234567"))
+				})
+			})
+		})
+
+		Context("when an instance type is specified", func() {
+			BeforeEach(func() {
+				fakeOptions = &ConcreteFactoryOptions{
+					Softlayer: SoftLayerConfig{
+						InstanceTypes: InstanceTypes{
+							"small": InstanceType{
+								StartCpus:         2,
+								MaxMemory:         4096,
+								EphemeralDiskSize: 100,
+								Datacenters: map[string]InstanceType{
+									"fake-datacenter": InstanceType{MaxMemory: 6144},
+								},
+							},
+						},
+					},
+				}
+				fakeCloudProp = VMCloudProperties{
+					Datacenter:   sldatatypes.Datacenter{Name: "fake-datacenter"},
+					VmNamePrefix: "fake-hostname",
+					StartCpus:    8,
+					InstanceType: "small",
+				}
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
+
+				fakeVm.IDReturns(1234567)
+				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
+				fakeCreatorProvider.GetReturns(fakeVmCreator)
+				fakeVmCreator.CreateReturns(fakeVm, nil)
+			})
+
+			It("fills the cloud properties from the instance type of the datacenter", func() {
+				Expect(err).NotTo(HaveOccurred())
+				_, _, cloudProps, _, _ := fakeVmCreator.CreateArgsForCall(0)
+				Expect(cloudProps.StartCpus).To(Equal(8))
+				Expect(cloudProps.MaxMemory).To(Equal(6144))
+				Expect(cloudProps.EphemeralDiskSize).To(Equal(100))
+			})
+
+			Context("when the cloud properties list datacenters to fail over between", func() {
+				BeforeEach(func() {
+					fakeCloudProp.Datacenters = []FailoverDatacenter{{Name: "fake-other-datacenter"}, {Name: "fake-datacenter"}}
+					fakeCloudProp.Datacenter = sldatatypes.Datacenter{Name: "fake-other-datacenter"}
+				})
+
+				It("rejects an instance type overriding its properties in one of them", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Instance type 'small' overrides its properties in datacenter 'fake-datacenter'"))
+					Expect(fakeVmCreator.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the instance type is unknown", func() {
+				BeforeEach(func() {
+					fakeCloudProp.InstanceType = "huge"
+				})
+
+				It("returns an error before ordering anything", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Unknown instance type 'huge'"))
+					Expect(fakeVmCreator.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the cloud properties ask for a local disk over the size limit", func() {
+				BeforeEach(func() {
+					fakeCloudProp.LocalDiskFlag = true
+					fakeCloudProp.EphemeralDiskSize = 500
+				})
+
+				It("returns an error before ordering anything", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("limit of local disks"))
+					Expect(fakeVmCreator.CreateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the cloud properties are malformed", func() {
+			BeforeEach(func() {
+				fakeOptions = &ConcreteFactoryOptions{}
+				fakeCloudProp = VMCloudProperties{}
+				err := json.Unmarshal([]byte(`{
+					"startCpus": "two",
+					"maxMemory": 2048,
+					"datacenter": {"name": "fake-datacenter", "nmae": "typo"},
+					"vmNamePrefx": "fake-hostname"
+				}`), &fakeCloudProp)
+				Expect(err).NotTo(HaveOccurred())
+
+				networks = Networks{
+					"fake-net-name": Network{
+						Type: "dynamic",
+						CloudProperties: map[string]interface{}{
+							"PrimaryNetworkComponent": map[string]interface{}{
+								"NetworkVlan": map[string]interface{}{"Id": "fake-vlan"},
+							},
+							"PrivateNetworkOnlyFlag": "yes",
+						},
+					},
+				}
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
+			})
+
+			It("reports every problem in one error before ordering anything", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(api.CloudPropertiesError{}))
+				Expect(err.(api.CloudPropertiesError).Problems).To(ConsistOf(
+					"'startCpus' must be an integer, got string",
+					"network 'fake-net-name': 'PrimaryNetworkComponent.NetworkVlan.Id' must be an integer, got string",
+					"network 'fake-net-name': 'PrivateNetworkOnlyFlag' must be a boolean, got string",
+				))
+				Expect(fakeStemcellFinder.FindByIdCallCount()).To(Equal(0))
+				Expect(fakeVmCreator.CreateCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when vm name prefix is specified", func() {
 			BeforeEach(func() {
 				fakeOptions = &ConcreteFactoryOptions{
@@ -120,7 +298,7 @@ var _ = Describe("CreateVM", func() {
 						sldatatypes.SshKey{Id: 1234},
 					},
 				}
-				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions)
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
 
 				fakeVm.IDReturns(1234567)
 				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
@@ -132,7 +310,8 @@ var _ = Describe("CreateVM", func() {
 					fakeCloudProp.VmNamePrefix = "63charac_long_name_with_suffix"
 				})
 				It("does not modify the name length", func() {
-					Expect(helper.LengthOfHostName).To(Equal(63))
+					_, _, cloudProps, _, _ := fakeVmCreator.CreateArgsForCall(0)
+					Expect(len(cloudProps.VmNamePrefix + "." + cloudProps.Domain)).To(Equal(63))
 					Expect(fakeStemcellFinder.FindByIdCallCount()).To(Equal(1))
 				})
 			})
@@ -141,7 +320,8 @@ var _ = Describe("CreateVM", func() {
 					fakeCloudProp.VmNamePrefix = "64charact_long_name_with_suffix"
 				})
 				It("adds 2 additional characters to the name", func() {
-					Expect(helper.LengthOfHostName).To(Equal(66))
+					_, _, cloudProps, _, _ := fakeVmCreator.CreateArgsForCall(0)
+					Expect(len(cloudProps.VmNamePrefix + "." + cloudProps.Domain)).To(Equal(66))
 					Expect(fakeStemcellFinder.FindByIdCallCount()).To(Equal(1))
 				})
 			})
@@ -150,7 +330,8 @@ var _ = Describe("CreateVM", func() {
 					fakeCloudProp.VmNamePrefix = "65characte_long_name_with_suffix"
 				})
 				It("does not modify the name length", func() {
-					Expect(helper.LengthOfHostName).To(Equal(65))
+					_, _, cloudProps, _, _ := fakeVmCreator.CreateArgsForCall(0)
+					Expect(len(cloudProps.VmNamePrefix + "." + cloudProps.Domain)).To(Equal(65))
 					Expect(fakeStemcellFinder.FindByIdCallCount()).To(Equal(1))
 				})
 			})
@@ -161,7 +342,7 @@ var _ = Describe("CreateVM", func() {
 				fakeOptions = &ConcreteFactoryOptions{
 					Softlayer: SoftLayerConfig{FeatureOptions: FeatureOptions{EnablePool: true}},
 				}
-				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions)
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
 
 				fakeStemcellFinder.FindByIdReturns(nil, errors.New("kaboom"))
 			})
@@ -177,7 +358,7 @@ var _ = Describe("CreateVM", func() {
 				fakeOptions = &ConcreteFactoryOptions{
 					Softlayer: SoftLayerConfig{FeatureOptions: FeatureOptions{EnablePool: true}},
 				}
-				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions)
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
 
 				fakeCreatorProvider.GetReturns(fakeVmCreator)
 				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
@@ -205,7 +386,7 @@ var _ = Describe("CreateVM", func() {
 						sldatatypes.SshKey{Id: 1234},
 					},
 				}
-				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions)
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
 
 				fakeVm.IDReturns(1234567)
 				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
@@ -236,7 +417,7 @@ var _ = Describe("CreateVM", func() {
 						sldatatypes.SshKey{Id: 1234},
 					},
 				}
-				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions)
+				action = NewCreateVM(fakeStemcellFinder, fakeCreatorProvider, *fakeOptions, logger)
 
 				fakeVm.IDReturns(1234567)
 				fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
@@ -3,6 +3,7 @@ package action
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"bosh-softlayer-cpi/api"
 	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
 )
 
@@ -20,14 +21,16 @@ func NewDeleteDisk(
 func (a DeleteDiskAction) Run(diskCID DiskCID) (interface{}, error) {
 	disk, found, err := a.diskFinder.Find(int(diskCID))
 	if err != nil {
-		return nil, bosherr.WrapErrorf(err, "Finding disk '%s'", diskCID)
+		return nil, api.NewRetryableCloudError(bosherr.WrapErrorf(err, "Finding disk '%s'", diskCID))
 	}
 
-	if found {
-		err := disk.Delete()
-		if err != nil {
-			return nil, bosherr.WrapErrorf(err, "Deleting disk '%s'", diskCID)
-		}
+	if !found {
+		return nil, api.NewDiskNotFoundError(diskCID.String())
+	}
+
+	err = disk.Delete()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Deleting disk '%s'", diskCID)
 	}
 
 	return nil, nil
@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "bosh-softlayer-cpi/action"
+	"bosh-softlayer-cpi/api"
 
 	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
 )
@@ -60,8 +61,10 @@ var _ = Describe("DeleteDisk", func() {
 				fakeDiskFinder.FindReturns(nil, false, nil)
 			})
 
-			It("no err return", func() {
-				Expect(err).NotTo(HaveOccurred())
+			It("returns a DiskNotFound error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.(api.CloudError).Type()).To(Equal("Bosh::Clouds::DiskNotFound"))
+				Expect(err.Error()).To(Equal("Disk '123456' not found"))
 			})
 		})
 
@@ -72,6 +75,7 @@ var _ = Describe("DeleteDisk", func() {
 
 			It("provides relevant error information", func() {
 				Expect(err.Error()).To(ContainSubstring("kaboom"))
+				Expect(err.(api.RetryableError).CanRetry()).To(BeTrue())
 			})
 		})
 
@@ -0,0 +1,34 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+)
+
+type DeleteSnapshotAction struct {
+	snapshotFinder bslcdisk.SnapshotFinder
+}
+
+func NewDeleteSnapshot(
+	snapshotFinder bslcdisk.SnapshotFinder,
+) (action DeleteSnapshotAction) {
+	action.snapshotFinder = snapshotFinder
+	return
+}
+
+func (a DeleteSnapshotAction) Run(snapshotCID SnapshotCID) (interface{}, error) {
+	snapshot, found, err := a.snapshotFinder.Find(snapshotCID.Int())
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding snapshot '%s'", snapshotCID)
+	}
+
+	if found {
+		err := snapshot.Delete()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Deleting snapshot '%s'", snapshotCID)
+		}
+	}
+
+	return nil, nil
+}
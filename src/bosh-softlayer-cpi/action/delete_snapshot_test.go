@@ -0,0 +1,93 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/action"
+
+	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
+)
+
+var _ = Describe("DeleteSnapshot", func() {
+	var (
+		fakeSnapshotFinder *fakedisk.FakeSnapshotFinder
+		fakeSnapshot       *fakedisk.FakeSnapshot
+		action             DeleteSnapshotAction
+	)
+
+	BeforeEach(func() {
+		fakeSnapshotFinder = &fakedisk.FakeSnapshotFinder{}
+		fakeSnapshot = &fakedisk.FakeSnapshot{}
+		action = NewDeleteSnapshot(fakeSnapshotFinder)
+	})
+
+	Describe("Run", func() {
+		var (
+			snapshotCid SnapshotCID
+			err         error
+		)
+
+		BeforeEach(func() {
+			snapshotCid = SnapshotCID(567890)
+		})
+
+		JustBeforeEach(func() {
+			_, err = action.Run(snapshotCid)
+		})
+
+		Context("when delete snapshot succeeds", func() {
+			BeforeEach(func() {
+				fakeSnapshotFinder.FindReturns(fakeSnapshot, true, nil)
+				fakeSnapshot.DeleteReturns(nil)
+			})
+
+			It("fetches snapshot by cid", func() {
+				Expect(fakeSnapshotFinder.FindCallCount()).To(Equal(1))
+				actualCid := fakeSnapshotFinder.FindArgsForCall(0)
+				Expect(actualCid).To(Equal(567890))
+			})
+
+			It("deletes snapshot", func() {
+				Expect(fakeSnapshot.DeleteCallCount()).To(Equal(1))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when snapshot is not found", func() {
+			BeforeEach(func() {
+				fakeSnapshotFinder.FindReturns(nil, false, nil)
+			})
+
+			It("does not return error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeSnapshot.DeleteCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when find snapshot error out", func() {
+			BeforeEach(func() {
+				fakeSnapshotFinder.FindReturns(nil, false, errors.New("kaboom"))
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+			})
+		})
+
+		Context("when delete snapshot error out", func() {
+			BeforeEach(func() {
+				fakeSnapshotFinder.FindReturns(fakeSnapshot, true, nil)
+				fakeSnapshot.DeleteReturns(errors.New("kaboom"))
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+			})
+		})
+	})
+})
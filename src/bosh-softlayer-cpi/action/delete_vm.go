@@ -3,25 +3,38 @@ package action
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
 	"fmt"
 )
 
 type DeleteVMAction struct {
+	vmFinder          VMFinder
 	vmDeleterProvider DeleterProvider
 	options           ConcreteFactoryOptions
 }
 
 func NewDeleteVM(
+	vmFinder VMFinder,
 	vmDeleterProvider DeleterProvider,
 	options ConcreteFactoryOptions,
 ) (action DeleteVMAction) {
+	action.vmFinder = vmFinder
 	action.vmDeleterProvider = vmDeleterProvider
 	action.options = options
 	return
 }
 
 func (a DeleteVMAction) Run(vmCID VMCID) (interface{}, error) {
+	_, found, err := a.vmFinder.Find(int(vmCID))
+	if err != nil {
+		return nil, api.NewRetryableCloudError(bosherr.WrapErrorf(err, "Finding vm %d", int(vmCID)))
+	}
+
+	if !found {
+		return nil, api.NewVMNotFoundError(vmCID.String())
+	}
+
 	var vmDeleter VMDeleter
 	if a.options.Softlayer.FeatureOptions.EnablePool {
 		vmDeleter = a.vmDeleterProvider.Get("pool")
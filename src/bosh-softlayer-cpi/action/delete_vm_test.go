@@ -7,6 +7,7 @@ import (
 
 	. "bosh-softlayer-cpi/action"
 	fakeaction "bosh-softlayer-cpi/action/fakes"
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
 
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
@@ -21,6 +22,7 @@ var _ = Describe("DeleteVM", func() {
 
 	BeforeEach(func() {
 		fakeVmFinder = &fakescommon.FakeVMFinder{}
+		fakeVmFinder.FindReturns(&fakescommon.FakeVM{}, true, nil)
 		fakeVmDeleter = &fakescommon.FakeVMDeleter{}
 		fakeVmDeleterProvider = &fakeaction.FakeDeleterProvider{}
 	})
@@ -46,7 +48,7 @@ var _ = Describe("DeleteVM", func() {
 				fakeOptions = &ConcreteFactoryOptions{
 					Softlayer: SoftLayerConfig{FeatureOptions: FeatureOptions{EnablePool: true}},
 				}
-				action = NewDeleteVM(fakeVmDeleterProvider, *fakeOptions)
+				action = NewDeleteVM(fakeVmFinder, fakeVmDeleterProvider, *fakeOptions)
 
 				fakeVmDeleterProvider.GetReturns(fakeVmDeleter)
 				fakeVmDeleter.DeleteReturns(nil)
@@ -71,7 +73,7 @@ var _ = Describe("DeleteVM", func() {
 				fakeOptions = &ConcreteFactoryOptions{
 					Softlayer: SoftLayerConfig{FeatureOptions: FeatureOptions{EnablePool: false}},
 				}
-				action = NewDeleteVM(fakeVmDeleterProvider, *fakeOptions)
+				action = NewDeleteVM(fakeVmFinder, fakeVmDeleterProvider, *fakeOptions)
 
 				fakeVmDeleterProvider.GetReturns(fakeVmDeleter)
 			})
@@ -88,7 +90,7 @@ var _ = Describe("DeleteVM", func() {
 				fakeOptions = &ConcreteFactoryOptions{
 					Softlayer: SoftLayerConfig{FeatureOptions: FeatureOptions{EnablePool: true}},
 				}
-				action = NewDeleteVM(fakeVmDeleterProvider, *fakeOptions)
+				action = NewDeleteVM(fakeVmFinder, fakeVmDeleterProvider, *fakeOptions)
 
 				fakeVmDeleterProvider.GetReturns(fakeVmDeleter)
 				fakeVmDeleter.DeleteReturns(errors.New("kaboom"))
@@ -98,5 +100,37 @@ var _ = Describe("DeleteVM", func() {
 				Expect(err.Error()).To(ContainSubstring("kaboom"))
 			})
 		})
+
+		Context("when vm is not found", func() {
+			BeforeEach(func() {
+				fakeOptions = &ConcreteFactoryOptions{}
+				action = NewDeleteVM(fakeVmFinder, fakeVmDeleterProvider, *fakeOptions)
+
+				fakeVmFinder.FindReturns(nil, false, nil)
+			})
+
+			It("returns a VMNotFound error without deleting", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.(api.CloudError).Type()).To(Equal("Bosh::Clouds::VMNotFound"))
+				Expect(err.Error()).To(Equal("VM '1234' not found"))
+				Expect(fakeVmDeleterProvider.GetCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when find vm error out", func() {
+			BeforeEach(func() {
+				fakeOptions = &ConcreteFactoryOptions{}
+				action = NewDeleteVM(fakeVmFinder, fakeVmDeleterProvider, *fakeOptions)
+
+				fakeVmFinder.FindReturns(nil, false, errors.New("kaboom"))
+			})
+
+			It("returns a retryable cloud error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+				Expect(err.(api.RetryableError).CanRetry()).To(BeTrue())
+				Expect(fakeVmDeleterProvider.GetCallCount()).To(Equal(0))
+			})
+		})
 	})
 })
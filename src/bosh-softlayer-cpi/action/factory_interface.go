@@ -4,3 +4,8 @@ package action
 type Factory interface {
 	Create(method string) (Action, error)
 }
+
+// APICallLogger is a Factory that logs the SoftLayer API calls made by its actions
+type APICallLogger interface {
+	LogAPICalls()
+}
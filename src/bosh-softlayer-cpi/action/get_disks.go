@@ -0,0 +1,46 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-softlayer-cpi/api"
+	. "bosh-softlayer-cpi/softlayer/common"
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+)
+
+type GetDisksAction struct {
+	vmFinder   VMFinder
+	diskFinder bslcdisk.DiskFinder
+}
+
+func NewGetDisks(
+	vmFinder VMFinder,
+	diskFinder bslcdisk.DiskFinder,
+) (action GetDisksAction) {
+	action.vmFinder = vmFinder
+	action.diskFinder = diskFinder
+	return
+}
+
+func (a GetDisksAction) Run(vmCID VMCID) ([]string, error) {
+	vm, found, err := a.vmFinder.Find(vmCID.Int())
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding VM '%s'", vmCID)
+	}
+
+	if !found {
+		return nil, api.NewVMNotFoundError(vmCID.String())
+	}
+
+	disks, err := a.diskFinder.FindAllowedByHost(vm.GetAllowedHostType(), vm.ID())
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding disks attached to VM '%s'", vmCID)
+	}
+
+	diskCIDs := []string{}
+	for _, disk := range disks {
+		diskCIDs = append(diskCIDs, DiskCID(disk.ID()).String())
+	}
+
+	return diskCIDs, nil
+}
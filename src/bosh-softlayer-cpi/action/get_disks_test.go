@@ -0,0 +1,129 @@
+package action_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/action"
+
+	"bosh-softlayer-cpi/api"
+
+	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
+)
+
+var _ = Describe("GetDisks", func() {
+	var (
+		fakeVmFinder   *fakescommon.FakeVMFinder
+		fakeVm         *fakescommon.FakeVM
+		fakeDiskFinder *fakedisk.FakeDiskFinder
+		action         GetDisksAction
+	)
+
+	BeforeEach(func() {
+		fakeVmFinder = &fakescommon.FakeVMFinder{}
+		fakeVm = &fakescommon.FakeVM{}
+		fakeDiskFinder = &fakedisk.FakeDiskFinder{}
+		action = NewGetDisks(fakeVmFinder, fakeDiskFinder)
+	})
+
+	Describe("Run", func() {
+		var (
+			vmCid    VMCID
+			diskCIDs []string
+
+			err error
+		)
+
+		BeforeEach(func() {
+			vmCid = VMCID(123456)
+		})
+
+		JustBeforeEach(func() {
+			diskCIDs, err = action.Run(vmCid)
+		})
+
+		Context("when the vm has attached disks", func() {
+			BeforeEach(func() {
+				fakeVm.IDReturns(123456)
+				fakeVm.GetAllowedHostTypeReturns(bslcdisk.ALLOWED_VIRTUAL_GUESTS)
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+
+				fakeDisk1 := &fakedisk.FakeDisk{}
+				fakeDisk1.IDReturns(1234)
+				fakeDisk2 := &fakedisk.FakeDisk{}
+				fakeDisk2.IDReturns(5678)
+				fakeDiskFinder.FindAllowedByHostReturns([]bslcdisk.Disk{fakeDisk1, fakeDisk2}, nil)
+			})
+
+			It("fetches vm by cid", func() {
+				Expect(fakeVmFinder.FindCallCount()).To(Equal(1))
+				Expect(fakeVmFinder.FindArgsForCall(0)).To(Equal(123456))
+			})
+
+			It("lists the disks allowed by the vm", func() {
+				Expect(fakeDiskFinder.FindAllowedByHostCallCount()).To(Equal(1))
+				hostType, hostID := fakeDiskFinder.FindAllowedByHostArgsForCall(0)
+				Expect(hostType).To(Equal(bslcdisk.ALLOWED_VIRTUAL_GUESTS))
+				Expect(hostID).To(Equal(123456))
+			})
+
+			It("returns the disk cids", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskCIDs).To(Equal([]string{"1234", "5678"}))
+			})
+		})
+
+		Context("when the vm has no attached disks", func() {
+			BeforeEach(func() {
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+				fakeDiskFinder.FindAllowedByHostReturns([]bslcdisk.Disk{}, nil)
+			})
+
+			It("returns an empty list", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskCIDs).ToNot(BeNil())
+				Expect(diskCIDs).To(BeEmpty())
+			})
+		})
+
+		Context("when find vm error out", func() {
+			BeforeEach(func() {
+				fakeVmFinder.FindReturns(nil, false, errors.New("kaboom"))
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+			})
+		})
+
+		Context("when find vm return false", func() {
+			BeforeEach(func() {
+				fakeVmFinder.FindReturns(nil, false, nil)
+			})
+
+			It("returns a VMNotFound error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.(api.CloudError).Type()).To(Equal("Bosh::Clouds::VMNotFound"))
+				Expect(err).To(MatchError(fmt.Sprintf("VM '%s' not found", vmCid)))
+			})
+		})
+
+		Context("when listing disks error out", func() {
+			BeforeEach(func() {
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+				fakeDiskFinder.FindAllowedByHostReturns(nil, errors.New("kaboom"))
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+			})
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package action
 
 import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/disk"
 )
 
@@ -16,13 +19,9 @@ func NewHasDisk(
 }
 
 func (a HasDiskAction) Run(diskCID DiskCID) (bool, error) {
-	result, found, err := a.diskFinder.Find(int(diskCID))
+	_, found, err := a.diskFinder.Find(int(diskCID))
 	if err != nil {
-		return false, err
-	} else {
-		if result == nil {
-			return false, nil
-		}
+		return false, api.NewRetryableCloudError(bosherr.WrapErrorf(err, "Finding disk '%s'", diskCID))
 	}
 
 	return found, nil
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	. "bosh-softlayer-cpi/action"
+	"bosh-softlayer-cpi/api"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -72,8 +73,9 @@ var _ = Describe("HasDisk", func() {
 				fakeDiskFinder.FindReturns(nil, false, errors.New("disk not found"))
 			})
 
-			It("returns no error", func() {
+			It("returns a retryable cloud error", func() {
 				Expect(err).To(HaveOccurred())
+				Expect(err.(api.RetryableError).CanRetry()).To(BeTrue())
 				Expect(found).To(BeFalse())
 			})
 		})
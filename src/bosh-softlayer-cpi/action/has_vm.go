@@ -1,6 +1,9 @@
 package action
 
 import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
 )
 
@@ -18,7 +21,7 @@ func NewHasVM(
 func (a HasVMAction) Run(vmCID VMCID) (bool, error) {
 	_, found, err := a.vmFinder.Find(int(vmCID))
 	if err != nil {
-		return false, nil
+		return false, api.NewRetryableCloudError(bosherr.WrapErrorf(err, "Finding VM '%s'", vmCID))
 	}
 
 	return found, nil
@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "bosh-softlayer-cpi/action"
+	"bosh-softlayer-cpi/api"
 
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
 )
@@ -45,14 +46,27 @@ var _ = Describe("HasVM", func() {
 			})
 		})
 
-		Context("when has vm fails", func() {
+		Context("when vm is not found", func() {
 			BeforeEach(func() {
-				fakeVmFinder.FindReturns(nil, false, errors.New("kaboom"))
+				fakeVmFinder.FindReturns(nil, false, nil)
 			})
 			It("no error return", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(found).To(BeFalse())
 			})
 		})
+
+		Context("when has vm fails", func() {
+			BeforeEach(func() {
+				fakeVmFinder.FindReturns(nil, false, errors.New("kaboom"))
+			})
+			It("returns a retryable cloud error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+				Expect(err.(api.CloudError).Type()).To(Equal("Bosh::Clouds::CloudError"))
+				Expect(err.(api.RetryableError).CanRetry()).To(BeTrue())
+				Expect(found).To(BeFalse())
+			})
+		})
 	})
 })
@@ -0,0 +1,23 @@
+package action
+
+import (
+	"bosh-softlayer-cpi/api"
+)
+
+type InfoResult struct {
+	ApiVersion      int      `json:"api_version"`
+	StemcellFormats []string `json:"stemcell_formats"`
+}
+
+type InfoAction struct{}
+
+func NewInfo() (action InfoAction) {
+	return
+}
+
+func (a InfoAction) Run() (InfoResult, error) {
+	return InfoResult{
+		ApiVersion:      api.MaxSupportedApiVersion,
+		StemcellFormats: []string{"softlayer-legacy-light"},
+	}, nil
+}
@@ -0,0 +1,27 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/action"
+)
+
+var _ = Describe("Info", func() {
+	var (
+		action InfoAction
+	)
+
+	BeforeEach(func() {
+		action = NewInfo()
+	})
+
+	Describe("Run", func() {
+		It("reports the supported CPI API version and stemcell formats", func() {
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.ApiVersion).To(Equal(2))
+			Expect(result.StemcellFormats).To(Equal([]string{"softlayer-legacy-light"}))
+		})
+	})
+})
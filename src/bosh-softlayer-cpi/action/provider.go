@@ -5,6 +5,7 @@ import (
 	sl "github.com/maximilien/softlayer-go/softlayer"
 
 	. "bosh-softlayer-cpi/softlayer/common"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	slhw "bosh-softlayer-cpi/softlayer/hardware"
 	slpool "bosh-softlayer-cpi/softlayer/pool"
 	operations "bosh-softlayer-cpi/softlayer/pool/client/vm"
@@ -30,13 +31,15 @@ type deleterProvider struct {
 	deleters map[string]VMDeleter
 }
 
-func NewCreatorProvider(softLayerClient sl.Client, baremetalClient bmscl.BmpClient, softLayerPoolClient operations.SoftLayerPoolClient, options ConcreteFactoryOptions, logger boshlog.Logger) CreatorProvider {
-	agentEnvServiceFactory := NewSoftLayerAgentEnvServiceFactory(options.Registry, logger)
+func NewCreatorProvider(softLayerClient sl.Client, baremetalClient bmscl.BmpClient, softLayerPoolClient operations.SoftLayerPoolClient, options ConcreteFactoryOptions, runtimeOptions RuntimeOptions, logger boshlog.Logger) CreatorProvider {
+	agentEnvServiceFactory := NewSoftLayerAgentEnvServiceFactory(options.Registry, runtimeOptions, logger)
 
 	vmFinder := slvm.NewSoftLayerFinder(
 		softLayerClient,
 		baremetalClient,
 		agentEnvServiceFactory,
+		options.Softlayer.FeatureOptions.WaitPolicies,
+		options.Softlayer.FeatureOptions.DiskAttachMode,
 		logger,
 	)
 
@@ -54,6 +57,7 @@ func NewCreatorProvider(softLayerClient sl.Client, baremetalClient bmscl.BmpClie
 		softLayerClient,
 		baremetalClient,
 		options.Agent,
+		options.Softlayer.FeatureOptions,
 		logger,
 	)
 
@@ -80,9 +84,10 @@ func (p creatorProvider) Get(name string) VMCreator {
 	return p.creators[name]
 }
 
-func NewDeleterProvider(softLayerClient sl.Client, softLayerPoolClient operations.SoftLayerPoolClient, logger boshlog.Logger, vmFinder VMFinder) DeleterProvider {
+func NewDeleterProvider(softLayerClient sl.Client, softLayerPoolClient operations.SoftLayerPoolClient, waitPolicies slh.WaitPolicies, logger boshlog.Logger, vmFinder VMFinder) DeleterProvider {
 	virtualGuestDeleter := slvm.NewSoftLayerVMDeleter(
 		softLayerClient,
+		waitPolicies,
 		logger,
 		vmFinder,
 	)
@@ -0,0 +1,149 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
+)
+
+// QuoteOrdersRequest is the cloud config fragment quote_orders prices. VM types and
+// disk types may carry an instances count, which defaults to 1.
+type QuoteOrdersRequest struct {
+	Stemcell   StemcellCID            `json:"stemcell"`
+	Datacenter string                 `json:"datacenter,omitempty"`
+	VMTypes    []VMTypeQuoteRequest   `json:"vm_types,omitempty"`
+	DiskTypes  []DiskTypeQuoteRequest `json:"disk_types,omitempty"`
+	Networks   []NetworkQuoteRequest  `json:"networks,omitempty"`
+}
+
+type VMTypeQuoteRequest struct {
+	Name            string            `json:"name"`
+	Instances       int               `json:"instances,omitempty"`
+	CloudProperties VMCloudProperties `json:"cloud_properties"`
+}
+
+type DiskTypeQuoteRequest struct {
+	Name            string                       `json:"name"`
+	Instances       int                          `json:"instances,omitempty"`
+	DiskSize        int                          `json:"disk_size"`
+	CloudProperties bslcdisk.DiskCloudProperties `json:"cloud_properties"`
+}
+
+type NetworkQuoteRequest struct {
+	Name            string                 `json:"name"`
+	Type            string                 `json:"type"`
+	CloudProperties map[string]interface{} `json:"cloud_properties,omitempty"`
+}
+
+type TypeQuote struct {
+	Name      string               `json:"name"`
+	Instances int                  `json:"instances"`
+	Quote     *slhelper.OrderQuote `json:"quote,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// QuoteOrdersResult totals the price of every type that SoftLayer accepted,
+// multiplied by its instances. Types SoftLayer rejected carry the reason instead.
+type QuoteOrdersResult struct {
+	VMTypes   []TypeQuote `json:"vm_types"`
+	DiskTypes []TypeQuote `json:"disk_types"`
+	Hourly    float64     `json:"hourly"`
+	Monthly   float64     `json:"monthly"`
+	Valid     bool        `json:"valid"`
+}
+
+type QuoteOrdersAction struct {
+	stemcellFinder bslcstem.StemcellFinder
+	vmQuoter       VMQuoter
+	diskQuoter     bslcdisk.DiskQuoter
+	options        ConcreteFactoryOptions
+}
+
+func NewQuoteOrders(
+	stemcellFinder bslcstem.StemcellFinder,
+	vmQuoter VMQuoter,
+	diskQuoter bslcdisk.DiskQuoter,
+	options ConcreteFactoryOptions,
+) (action QuoteOrdersAction) {
+	action.stemcellFinder = stemcellFinder
+	action.vmQuoter = vmQuoter
+	action.diskQuoter = diskQuoter
+	action.options = options
+	return
+}
+
+func (a QuoteOrdersAction) Run(request QuoteOrdersRequest) (QuoteOrdersResult, error) {
+	result := QuoteOrdersResult{
+		VMTypes:   []TypeQuote{},
+		DiskTypes: []TypeQuote{},
+		Valid:     true,
+	}
+
+	if len(request.VMTypes) > 0 {
+		stemcell, err := a.stemcellFinder.FindById(int(request.Stemcell))
+		if err != nil {
+			return QuoteOrdersResult{}, bosherr.WrapErrorf(err, "Finding stemcell '%s'", request.Stemcell)
+		}
+
+		networks := Networks{}
+		for _, network := range request.Networks {
+			networks[network.Name] = Network{
+				Type:            network.Type,
+				CloudProperties: network.CloudProperties,
+			}
+		}
+
+		for _, vmType := range request.VMTypes {
+			cloudProps := vmType.CloudProperties
+			_, err := CheckCloudProperties(cloudProps, networks)
+			if err == nil {
+				err = setVMCloudPropertiesDefaults(&cloudProps, a.options.Softlayer)
+			}
+			if err != nil {
+				result.VMTypes = append(result.VMTypes, result.add(vmType.Name, vmType.Instances, slhelper.OrderQuote{}, err))
+				continue
+			}
+
+			quote, err := a.vmQuoter.Quote(stemcell, cloudProps, networks)
+			result.VMTypes = append(result.VMTypes, result.add(vmType.Name, vmType.Instances, quote, err))
+		}
+	}
+
+	for _, diskType := range request.DiskTypes {
+		if request.Datacenter == "" {
+			result.DiskTypes = append(result.DiskTypes, result.add(diskType.Name, diskType.Instances, slhelper.OrderQuote{}, bosherr.Error("Quoting disk types requires the datacenter the disks are created in")))
+			continue
+		}
+
+		quote, err := a.diskQuoter.Quote(diskType.DiskSize, diskType.CloudProperties, request.Datacenter)
+		result.DiskTypes = append(result.DiskTypes, result.add(diskType.Name, diskType.Instances, quote, err))
+	}
+
+	return result, nil
+}
+
+func (r *QuoteOrdersResult) add(name string, instances int, quote slhelper.OrderQuote, err error) TypeQuote {
+	if instances == 0 {
+		instances = 1
+	}
+
+	typeQuote := TypeQuote{
+		Name:      name,
+		Instances: instances,
+	}
+
+	if err != nil {
+		typeQuote.Error = err.Error()
+		r.Valid = false
+		return typeQuote
+	}
+
+	typeQuote.Quote = &quote
+	r.Hourly += quote.Hourly * float64(instances)
+	r.Monthly += quote.Monthly * float64(instances)
+
+	return typeQuote
+}
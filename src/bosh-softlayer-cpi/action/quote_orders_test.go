@@ -0,0 +1,148 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/action"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
+	fakestem "bosh-softlayer-cpi/softlayer/stemcell/fakes"
+
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+var _ = Describe("QuoteOrders", func() {
+	var (
+		fakeStemcellFinder *fakestem.FakeStemcellFinder
+		fakeStemcell       *fakestem.FakeStemcell
+		fakeVmQuoter       *fakescommon.FakeVMQuoter
+		fakeDiskQuoter     *fakedisk.FakeDiskQuoter
+		action             QuoteOrdersAction
+
+		request QuoteOrdersRequest
+		result  QuoteOrdersResult
+		err     error
+	)
+
+	BeforeEach(func() {
+		fakeStemcellFinder = &fakestem.FakeStemcellFinder{}
+		fakeStemcell = &fakestem.FakeStemcell{}
+		fakeVmQuoter = &fakescommon.FakeVMQuoter{}
+		fakeDiskQuoter = &fakedisk.FakeDiskQuoter{}
+		action = NewQuoteOrders(fakeStemcellFinder, fakeVmQuoter, fakeDiskQuoter, ConcreteFactoryOptions{})
+
+		fakeStemcellFinder.FindByIdReturns(fakeStemcell, nil)
+		fakeVmQuoter.QuoteReturns(slhelper.OrderQuote{Hourly: 0.5, Monthly: 300}, nil)
+		fakeDiskQuoter.QuoteReturns(slhelper.OrderQuote{Hourly: 0.1, Monthly: 50}, nil)
+
+		request = QuoteOrdersRequest{
+			Stemcell:   StemcellCID(1234),
+			Datacenter: "265592",
+			VMTypes: []VMTypeQuoteRequest{
+				{
+					Name:      "small",
+					Instances: 3,
+					CloudProperties: VMCloudProperties{
+						VmNamePrefix: "fake-prefix",
+						Datacenter:   sldatatypes.Datacenter{Name: "lon02"},
+					},
+				},
+			},
+			DiskTypes: []DiskTypeQuoteRequest{
+				{
+					Name:            "default",
+					DiskSize:        10240,
+					CloudProperties: bslcdisk.DiskCloudProperties{Iops: 1000},
+				},
+			},
+			Networks: []NetworkQuoteRequest{
+				{
+					Name:            "default",
+					Type:            "dynamic",
+					CloudProperties: map[string]interface{}{"PrivateNetworkOnlyFlag": true},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result, err = action.Run(request)
+	})
+
+	Context("when SoftLayer accepts every order", func() {
+		It("quotes every VM type with the defaults create_vm applies", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeStemcellFinder.FindByIdArgsForCall(0)).To(Equal(1234))
+
+			stemcell, cloudProps, networks := fakeVmQuoter.QuoteArgsForCall(0)
+			Expect(stemcell).To(Equal(fakeStemcell))
+			Expect(cloudProps.StartCpus).To(Equal(4))
+			Expect(cloudProps.MaxMemory).To(Equal(8192))
+			Expect(cloudProps.Domain).To(Equal("softlayer.com"))
+			Expect(networks).To(HaveKeyWithValue("default", Network{
+				Type:            "dynamic",
+				CloudProperties: map[string]interface{}{"PrivateNetworkOnlyFlag": true},
+			}))
+		})
+
+		It("quotes every disk type in the given datacenter", func() {
+			size, cloudProps, location := fakeDiskQuoter.QuoteArgsForCall(0)
+			Expect(size).To(Equal(10240))
+			Expect(cloudProps.Iops).To(Equal(1000))
+			Expect(location).To(Equal("265592"))
+		})
+
+		It("totals the prices multiplied by the instances of each type", func() {
+			Expect(result.Valid).To(BeTrue())
+			Expect(result.VMTypes).To(HaveLen(1))
+			Expect(result.VMTypes[0].Instances).To(Equal(3))
+			Expect(result.DiskTypes[0].Instances).To(Equal(1))
+			Expect(result.Hourly).To(BeNumerically("~", 1.6))
+			Expect(result.Monthly).To(BeNumerically("~", 950))
+		})
+	})
+
+	Context("when SoftLayer rejects an order", func() {
+		BeforeEach(func() {
+			fakeVmQuoter.QuoteReturns(slhelper.OrderQuote{}, errors.New("fake-verify-error"))
+		})
+
+		It("reports the reason and leaves the type out of the totals", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Valid).To(BeFalse())
+			Expect(result.VMTypes[0].Error).To(ContainSubstring("fake-verify-error"))
+			Expect(result.VMTypes[0].Quote).To(BeNil())
+			Expect(result.Monthly).To(BeNumerically("~", 50))
+		})
+	})
+
+	Context("when no datacenter is given for the disk types", func() {
+		BeforeEach(func() {
+			request.Datacenter = ""
+		})
+
+		It("reports an error for each disk type without asking SoftLayer", func() {
+			Expect(fakeDiskQuoter.QuoteCallCount()).To(Equal(0))
+			Expect(result.Valid).To(BeFalse())
+			Expect(result.DiskTypes[0].Error).To(ContainSubstring("datacenter"))
+		})
+	})
+
+	Context("when the stemcell cannot be found", func() {
+		BeforeEach(func() {
+			fakeStemcellFinder.FindByIdReturns(nil, errors.New("fake-stemcell-error"))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(fakeVmQuoter.QuoteCallCount()).To(Equal(0))
+		})
+	})
+})
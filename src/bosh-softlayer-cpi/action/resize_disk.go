@@ -0,0 +1,69 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-softlayer-cpi/api"
+	. "bosh-softlayer-cpi/softlayer/common"
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+)
+
+type ResizeDiskAction struct {
+	diskFinder  bslcdisk.DiskFinder
+	diskResizer bslcdisk.DiskResizer
+	vmFinder    VMFinder
+}
+
+func NewResizeDisk(
+	diskFinder bslcdisk.DiskFinder,
+	diskResizer bslcdisk.DiskResizer,
+	vmFinder VMFinder,
+) (action ResizeDiskAction) {
+	action.diskFinder = diskFinder
+	action.diskResizer = diskResizer
+	action.vmFinder = vmFinder
+	return
+}
+
+func (a ResizeDiskAction) Run(diskCID DiskCID, newSize int) (interface{}, error) {
+	disk, found, err := a.diskFinder.Find(diskCID.Int())
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding disk '%s'", diskCID)
+	}
+
+	if !found {
+		return nil, api.NewDiskNotFoundError(diskCID.String())
+	}
+
+	err = a.diskResizer.Resize(disk.ID(), newSize)
+	if err != nil {
+		// The director copies the disk to a new one when resizing is not supported
+		if _, ok := err.(api.NotSupportedError); ok {
+			return nil, err
+		}
+		return nil, bosherr.WrapErrorf(err, "Resizing disk '%s' to '%d' MB", diskCID, newSize)
+	}
+
+	hosts, err := a.diskFinder.FindAllowedHosts(disk.ID())
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding VMs disk '%s' is attached to", diskCID)
+	}
+
+	for _, host := range hosts {
+		vm, found, err := a.vmFinder.Find(host.ID)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Finding VM '%d'", host.ID)
+		}
+
+		if !found {
+			continue
+		}
+
+		err = vm.RescanDisk(disk)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Rescanning disk '%s' on VM '%d'", diskCID, host.ID)
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,121 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/action"
+
+	"bosh-softlayer-cpi/api"
+	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
+)
+
+var _ = Describe("ResizeDisk", func() {
+	var (
+		fakeDiskFinder  *fakedisk.FakeDiskFinder
+		fakeDiskResizer *fakedisk.FakeDiskResizer
+		fakeVmFinder    *fakescommon.FakeVMFinder
+		fakeDisk        *fakedisk.FakeDisk
+		fakeVm          *fakescommon.FakeVM
+		action          ResizeDiskAction
+
+		err error
+	)
+
+	BeforeEach(func() {
+		fakeDiskFinder = &fakedisk.FakeDiskFinder{}
+		fakeDiskResizer = &fakedisk.FakeDiskResizer{}
+		fakeVmFinder = &fakescommon.FakeVMFinder{}
+		fakeDisk = &fakedisk.FakeDisk{}
+		fakeVm = &fakescommon.FakeVM{}
+		action = NewResizeDisk(fakeDiskFinder, fakeDiskResizer, fakeVmFinder)
+
+		fakeDisk.IDReturns(123456)
+		fakeDiskFinder.FindReturns(fakeDisk, true, nil)
+	})
+
+	JustBeforeEach(func() {
+		_, err = action.Run(DiskCID(123456), 40960)
+	})
+
+	Context("when the disk is attached to a VM", func() {
+		BeforeEach(func() {
+			fakeDiskFinder.FindAllowedHostsReturns([]bslcdisk.DiskHost{{Type: bslcdisk.ALLOWED_VIRTUAL_GUESTS, ID: 1234567}}, nil)
+			fakeVmFinder.FindReturns(fakeVm, true, nil)
+		})
+
+		It("resizes the disk and has the VM rescan it", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeDiskResizer.ResizeCallCount()).To(Equal(1))
+			id, size := fakeDiskResizer.ResizeArgsForCall(0)
+			Expect(id).To(Equal(123456))
+			Expect(size).To(Equal(40960))
+
+			Expect(fakeVmFinder.FindArgsForCall(0)).To(Equal(1234567))
+			Expect(fakeVm.RescanDiskCallCount()).To(Equal(1))
+			Expect(fakeVm.RescanDiskArgsForCall(0)).To(Equal(fakeDisk))
+		})
+
+		Context("when rescanning fails", func() {
+			BeforeEach(func() {
+				fakeVm.RescanDiskReturns(errors.New("fake-rescan-error"))
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-rescan-error"))
+			})
+		})
+	})
+
+	Context("when the disk is detached", func() {
+		BeforeEach(func() {
+			fakeDiskFinder.FindAllowedHostsReturns([]bslcdisk.DiskHost{}, nil)
+		})
+
+		It("resizes the disk only", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeDiskResizer.ResizeCallCount()).To(Equal(1))
+			Expect(fakeVmFinder.FindCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the disk is not found", func() {
+		BeforeEach(func() {
+			fakeDiskFinder.FindReturns(nil, false, nil)
+		})
+
+		It("returns a disk not found error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(api.NewDiskNotFoundError("123456")))
+			Expect(fakeDiskResizer.ResizeCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the disk would shrink", func() {
+		BeforeEach(func() {
+			fakeDiskResizer.ResizeReturns(api.NotSupportedError{})
+		})
+
+		It("returns the not supported error as it is", func() {
+			Expect(err).To(Equal(api.NotSupportedError{}))
+			Expect(fakeDiskFinder.FindAllowedHostsCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when resizing fails", func() {
+		BeforeEach(func() {
+			fakeDiskResizer.ResizeReturns(errors.New("fake-resize-error"))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-resize-error"))
+		})
+	})
+})
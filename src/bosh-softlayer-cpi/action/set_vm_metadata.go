@@ -3,6 +3,7 @@ package action
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
 )
 
@@ -17,7 +18,7 @@ func NewSetVMMetadata(
 	return
 }
 
-func (a SetVMMetadataAction) Run(vmCID VMCID, metadata VMMetadata) (interface{}, error) {
+func (a SetVMMetadataAction) Run(context api.RequestContext, vmCID VMCID, metadata VMMetadata) (interface{}, error) {
 	vm, found, err := a.vmFinder.Find(int(vmCID))
 	if err != nil || !found {
 		return nil, bosherr.WrapErrorf(err, "Finding VM '%s'", vmCID)
@@ -27,6 +28,16 @@ func (a SetVMMetadataAction) Run(vmCID VMCID, metadata VMMetadata) (interface{},
 		return nil, nil
 	}
 
+	// The VM keeps the tags the metadata does not set, so this only tags it with the director UUID again
+	// in case create_vm failed to
+	if context.DirectorUUID != "" {
+		metadataWithDirector := VMMetadata{"director_uuid": context.DirectorUUID}
+		for key, value := range metadata {
+			metadataWithDirector[key] = value
+		}
+		metadata = metadataWithDirector
+	}
+
 	err = vm.SetMetadata(metadata)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Setting metadata '%#v' on VM '%s'", metadata, vmCID)
@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "bosh-softlayer-cpi/action"
+	"bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/softlayer/common"
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
 )
@@ -38,16 +39,17 @@ var _ = Describe("SetVMMetadata", func() {
 
 	Describe("Run", func() {
 		var (
-			vmCid VMCID
-			err   error
+			vmCid   VMCID
+			context api.RequestContext
+			err     error
 		)
 		BeforeEach(func() {
 			vmCid = VMCID(123456)
-
+			context = api.RequestContext{}
 		})
 
 		JustBeforeEach(func() {
-			_, err = action.Run(vmCid, metadata)
+			_, err = action.Run(context, vmCid, metadata)
 		})
 		Context("when set vm metadata succeeds", func() {
 			BeforeEach(func() {
@@ -67,6 +69,24 @@ var _ = Describe("SetVMMetadata", func() {
 				Expect(actualMetadata).To(Equal(metadata))
 				Expect(err).NotTo(HaveOccurred())
 			})
+
+			Context("when director uuid is provided in context", func() {
+				BeforeEach(func() {
+					context = api.RequestContext{DirectorUUID: "fake-director-uuid"}
+				})
+
+				It("keeps director uuid tag on vm", func() {
+					Expect(fakeVm.SetMetadataCallCount()).To(Equal(1))
+					actualMetadata := fakeVm.SetMetadataArgsForCall(0)
+					Expect(actualMetadata).To(Equal(VMMetadata{
+						"director_uuid": "fake-director-uuid",
+						"tag1":          "dea",
+						"tag2":          "test-env",
+						"tag3":          "blue",
+					}))
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
 		})
 
 		Context("when find vm error out", func() {
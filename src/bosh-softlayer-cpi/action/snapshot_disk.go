@@ -0,0 +1,39 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+)
+
+type SnapshotDiskAction struct {
+	diskFinder      bslcdisk.DiskFinder
+	snapshotCreator bslcdisk.SnapshotCreator
+}
+
+func NewSnapshotDisk(
+	diskFinder bslcdisk.DiskFinder,
+	snapshotCreator bslcdisk.SnapshotCreator,
+) (action SnapshotDiskAction) {
+	action.diskFinder = diskFinder
+	action.snapshotCreator = snapshotCreator
+	return
+}
+
+func (a SnapshotDiskAction) Run(diskCID DiskCID, metadata bslcdisk.SnapshotMetadata) (string, error) {
+	disk, found, err := a.diskFinder.Find(diskCID.Int())
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Finding disk '%s'", diskCID)
+	}
+
+	if !found {
+		return "", bosherr.Errorf("Expected to find disk '%s'", diskCID)
+	}
+
+	snapshot, err := a.snapshotCreator.Create(disk.ID(), metadata)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating snapshot of disk '%s'", diskCID)
+	}
+
+	return SnapshotCID(snapshot.ID()).String(), nil
+}
@@ -0,0 +1,116 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/action"
+
+	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
+	fakedisk "bosh-softlayer-cpi/softlayer/disk/fakes"
+)
+
+var _ = Describe("SnapshotDisk", func() {
+	var (
+		fakeDiskFinder      *fakedisk.FakeDiskFinder
+		fakeSnapshotCreator *fakedisk.FakeSnapshotCreator
+		fakeDisk            *fakedisk.FakeDisk
+		fakeSnapshot        *fakedisk.FakeSnapshot
+		action              SnapshotDiskAction
+
+		metadata bslcdisk.SnapshotMetadata
+	)
+
+	BeforeEach(func() {
+		fakeDiskFinder = &fakedisk.FakeDiskFinder{}
+		fakeSnapshotCreator = &fakedisk.FakeSnapshotCreator{}
+		fakeDisk = &fakedisk.FakeDisk{}
+		fakeSnapshot = &fakedisk.FakeSnapshot{}
+		action = NewSnapshotDisk(fakeDiskFinder, fakeSnapshotCreator)
+		metadata = bslcdisk.SnapshotMetadata{
+			"deployment": "fake-deployment",
+			"job":        "fake-job",
+			"index":      "0",
+		}
+	})
+
+	Describe("Run", func() {
+		var (
+			snapshotCidStr string
+			err            error
+			diskCid        DiskCID
+		)
+
+		BeforeEach(func() {
+			diskCid = DiskCID(123456)
+		})
+
+		JustBeforeEach(func() {
+			snapshotCidStr, err = action.Run(diskCid, metadata)
+		})
+
+		Context("when snapshot disk succeeds", func() {
+			BeforeEach(func() {
+				fakeDisk.IDReturns(123456)
+				fakeSnapshot.IDReturns(567890)
+				fakeDiskFinder.FindReturns(fakeDisk, true, nil)
+				fakeSnapshotCreator.CreateReturns(fakeSnapshot, nil)
+			})
+
+			It("fetches disk by cid", func() {
+				Expect(fakeDiskFinder.FindCallCount()).To(Equal(1))
+				actualCid := fakeDiskFinder.FindArgsForCall(0)
+				Expect(actualCid).To(Equal(123456))
+			})
+
+			It("creates snapshot with metadata", func() {
+				Expect(fakeSnapshotCreator.CreateCallCount()).To(Equal(1))
+				actualDiskId, actualMetadata := fakeSnapshotCreator.CreateArgsForCall(0)
+				Expect(actualDiskId).To(Equal(123456))
+				Expect(actualMetadata).To(Equal(metadata))
+			})
+
+			It("returns snapshot cid", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(snapshotCidStr).To(Equal("567890"))
+			})
+		})
+
+		Context("when find disk error out", func() {
+			BeforeEach(func() {
+				fakeDiskFinder.FindReturns(nil, false, errors.New("kaboom"))
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+				Expect(fakeSnapshotCreator.CreateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when disk is not found", func() {
+			BeforeEach(func() {
+				fakeDiskFinder.FindReturns(nil, false, nil)
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(fakeSnapshotCreator.CreateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when create snapshot error out", func() {
+			BeforeEach(func() {
+				fakeDiskFinder.FindReturns(fakeDisk, true, nil)
+				fakeSnapshotCreator.CreateReturns(nil, errors.New("kaboom"))
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("kaboom"))
+			})
+		})
+	})
+})
@@ -0,0 +1,29 @@
+package api
+
+const (
+	ApiVersion1 = 1
+	ApiVersion2 = 2
+
+	MaxSupportedApiVersion = ApiVersion2
+)
+
+type RequestContext struct {
+	// ApiVersion is the CPI API version negotiated by the director, sent as top level api_version key
+	ApiVersion int `json:"-"`
+
+	DirectorUUID string    `json:"director_uuid"`
+	RequestID    string    `json:"request_id"`
+	VM           VMContext `json:"vm"`
+}
+
+type VMContext struct {
+	Stemcell StemcellContext `json:"stemcell"`
+}
+
+type StemcellContext struct {
+	ApiVersion int `json:"api_version"`
+}
+
+func (c RequestContext) IsApiVersion2() bool {
+	return c.ApiVersion >= ApiVersion2
+}
@@ -2,17 +2,20 @@ package fakes
 
 import (
 	bslcaction "bosh-softlayer-cpi/action"
+	bslcapi "bosh-softlayer-cpi/api"
 )
 
 type FakeCaller struct {
-	CallAction bslcaction.Action
-	CallArgs   []interface{}
-	CallResult interface{}
-	CallErr    error
+	CallAction  bslcaction.Action
+	CallArgs    []interface{}
+	CallContext bslcapi.RequestContext
+	CallResult  interface{}
+	CallErr     error
 }
 
-func (caller *FakeCaller) Call(action bslcaction.Action, args []interface{}) (interface{}, error) {
+func (caller *FakeCaller) Call(action bslcaction.Action, args []interface{}, context bslcapi.RequestContext) (interface{}, error) {
 	caller.CallAction = action
 	caller.CallArgs = args
+	caller.CallContext = context
 	return caller.CallResult, caller.CallErr
 }
@@ -6,11 +6,11 @@ import (
 
 	bslcaction "bosh-softlayer-cpi/action"
 	bslcapi "bosh-softlayer-cpi/api"
-	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	"fmt"
-	"strings"
 )
 
 const (
@@ -25,7 +25,8 @@ type Request struct {
 	Method    string        `json:"method"`
 	Arguments []interface{} `json:"arguments"`
 
-	// context key is ignored
+	Context    bslcapi.RequestContext `json:"context"`
+	ApiVersion int                    `json:"api_version"`
 }
 
 type Response struct {
@@ -72,9 +73,6 @@ func (c JSON) Dispatch(reqBytes []byte) []byte {
 
 	c.logger.DebugWithDetails(jsonLogTag, "Deserialized request", req)
 
-	// Will remove this line of code after we make sure LocalDiskFlag is defined properly in all deployment manifest files
-	c.localDiskFlagNotSet(fmt.Sprintf("%s", req))
-
 	if req.Method == "" {
 		return c.buildCpiError("Must provide method key")
 	}
@@ -83,12 +81,29 @@ func (c JSON) Dispatch(reqBytes []byte) []byte {
 		return c.buildCpiError("Must provide arguments key")
 	}
 
+	if req.ApiVersion > bslcapi.MaxSupportedApiVersion {
+		return c.buildCpiError(fmt.Sprintf("CPI API version %d is not supported, maximum supported version is %d", req.ApiVersion, bslcapi.MaxSupportedApiVersion))
+	}
+
+	if requestLogger, ok := c.logger.(*bslcapi.RequestLogger); ok {
+		requestLogger.SetRequestID(req.Context.RequestID)
+	}
+
+	req.Context.ApiVersion = req.ApiVersion
+	if req.Context.ApiVersion == 0 {
+		req.Context.ApiVersion = bslcapi.ApiVersion1
+	}
+
 	action, err := c.actionFactory.Create(req.Method)
 	if err != nil {
 		return c.buildNotImplementedError()
 	}
 
-	result, err := c.caller.Call(action, req.Arguments)
+	if apiCallLogger, ok := c.actionFactory.(bslcaction.APICallLogger); ok {
+		defer apiCallLogger.LogAPICalls()
+	}
+
+	result, err := c.caller.Call(action, req.Arguments, req.Context)
 	if err != nil {
 		return c.buildCloudError(err)
 	}
@@ -122,9 +137,7 @@ func (c JSON) buildCloudError(err error) []byte {
 
 	respErr.Error.Message = err.Error()
 
-	if typedErr, ok := err.(bslcapi.RetryableError); ok {
-		respErr.Error.CanRetry = typedErr.CanRetry()
-	}
+	respErr.Error.CanRetry = canRetry(err)
 
 	respErrBytes, err := json.Marshal(respErr)
 	if err != nil {
@@ -136,6 +149,25 @@ func (c JSON) buildCloudError(err error) []byte {
 	return respErrBytes
 }
 
+// canRetry looks through the errors wrapping err for one telling whether the request may be
+// sent again, and otherwise classifies err as a SoftLayer API error
+func canRetry(err error) bool {
+	cause := err
+	for {
+		if typedErr, ok := cause.(bslcapi.RetryableError); ok {
+			return typedErr.CanRetry()
+		}
+
+		complexErr, ok := cause.(bosherr.ComplexError)
+		if !ok {
+			break
+		}
+		cause = complexErr.Cause
+	}
+
+	return slh.IsAPIErrorRetryable(err)
+}
+
 func (c JSON) buildCpiError(message string) []byte {
 	respErr := Response{
 		Error: &ResponseError{
@@ -171,13 +203,3 @@ func (c JSON) buildNotImplementedError() []byte {
 
 	return respErrBytes
 }
-
-// This function is used to set the default value of localDiskFlag to true if it is not specified in the input json file.
-// This function will be removed if we can ensure LocalDiskFlag property is set in place in all deployment manifest
-func (c JSON) localDiskFlagNotSet(reqString string) {
-	if strings.Contains(strings.ToUpper(reqString), strings.ToUpper("localDiskFlag")) {
-		slhelper.LocalDiskFlagNotSet = false
-	} else {
-		slhelper.LocalDiskFlagNotSet = true
-	}
-}
@@ -7,20 +7,24 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	bslcaction "bosh-softlayer-cpi/action"
+	bslcapi "bosh-softlayer-cpi/api"
 )
 
 type Caller interface {
-	Call(bslcaction.Action, []interface{}) (interface{}, error)
+	Call(bslcaction.Action, []interface{}, bslcapi.RequestContext) (interface{}, error)
 }
 
-// JSONCaller unmarshals call arguments with json package and calls action.Run
+// JSONCaller unmarshals call arguments with json package and calls action.Run.
+// Actions whose Run method takes a RequestContext as first argument receive the request context in it.
 type JSONCaller struct{}
 
+var requestContextType = reflect.TypeOf(bslcapi.RequestContext{})
+
 func NewJSONCaller() JSONCaller {
 	return JSONCaller{}
 }
 
-func (r JSONCaller) Call(action bslcaction.Action, args []interface{}) (value interface{}, err error) {
+func (r JSONCaller) Call(action bslcaction.Action, args []interface{}, context bslcapi.RequestContext) (value interface{}, err error) {
 	actionValue := reflect.ValueOf(action)
 	runMethodValue := actionValue.MethodByName("Run")
 	if runMethodValue.Kind() != reflect.Func {
@@ -34,7 +38,7 @@ func (r JSONCaller) Call(action bslcaction.Action, args []interface{}) (value in
 		return
 	}
 
-	methodArgs, err := r.extractMethodArgs(runMethodType, args)
+	methodArgs, err := r.extractMethodArgs(runMethodType, args, context)
 	if err != nil {
 		err = bosherr.WrapError(err, "Extracting method arguments from payload")
 		return
@@ -63,7 +67,7 @@ func (r JSONCaller) invalidReturnTypes(methodType reflect.Type) (valid bool) {
 	return
 }
 
-func (r JSONCaller) extractMethodArgs(runMethodType reflect.Type, args []interface{}) (methodArgs []reflect.Value, err error) {
+func (r JSONCaller) extractMethodArgs(runMethodType reflect.Type, args []interface{}, context bslcapi.RequestContext) (methodArgs []reflect.Value, err error) {
 	numberOfArgs := runMethodType.NumIn()
 	numberOfReqArgs := numberOfArgs
 
@@ -71,6 +75,13 @@ func (r JSONCaller) extractMethodArgs(runMethodType reflect.Type, args []interfa
 		numberOfReqArgs--
 	}
 
+	contextOffset := 0
+	if numberOfArgs > 0 && runMethodType.In(0) == requestContextType {
+		contextOffset = 1
+		numberOfReqArgs--
+		methodArgs = append(methodArgs, reflect.ValueOf(context))
+	}
+
 	if len(args) < numberOfReqArgs {
 		err = bosherr.Errorf("Not enough arguments, expected %d, got %d", numberOfReqArgs, len(args))
 		return
@@ -85,7 +96,7 @@ func (r JSONCaller) extractMethodArgs(runMethodType reflect.Type, args []interfa
 			return
 		}
 
-		argType, typeFound := r.getMethodArgType(runMethodType, i)
+		argType, typeFound := r.getMethodArgType(runMethodType, i+contextOffset)
 		if !typeFound {
 			continue
 		}
@@ -123,8 +134,13 @@ func (r JSONCaller) getMethodArgType(methodType reflect.Type, index int) (argTyp
 func (r JSONCaller) extractReturns(values []reflect.Value) (value interface{}, err error) {
 	errValue := values[1]
 	if !errValue.IsNil() {
-		errorValues := errValue.MethodByName("Error").Call([]reflect.Value{})
-		err = bosherr.Error(errorValues[0].String())
+		// Typed cloud errors are passed through so that their type and retryability reach the director
+		if cloudErr, ok := errValue.Interface().(bslcapi.CloudError); ok {
+			err = cloudErr
+		} else {
+			errorValues := errValue.MethodByName("Error").Call([]reflect.Value{})
+			err = bosherr.Error(errorValues[0].String())
+		}
 	}
 
 	value = values[0].Interface()
@@ -6,7 +6,9 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	bslcapi "bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/api/dispatcher"
+	fakeapi "bosh-softlayer-cpi/api/fakes"
 )
 
 type valueType struct {
@@ -66,6 +68,17 @@ func (a *actionWithSecondReturnValueNotError) Run() (interface{}, string) {
 	return nil, ""
 }
 
+type actionWithRequestContext struct {
+	Context   bslcapi.RequestContext
+	SubAction string
+}
+
+func (a *actionWithRequestContext) Run(context bslcapi.RequestContext, subAction string) (interface{}, error) {
+	a.Context = context
+	a.SubAction = subAction
+	return nil, nil
+}
+
 var _ = Describe("JSONCaller", func() {
 	var (
 		caller JSONCaller
@@ -89,7 +102,7 @@ var _ = Describe("JSONCaller", func() {
 				456,
 			}
 
-			value, err := caller.Call(action, args)
+			value, err := caller.Call(action, args, bslcapi.RequestContext{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("fake-run-error"))
 
@@ -102,12 +115,41 @@ var _ = Describe("JSONCaller", func() {
 			Expect(action.SliceArgs).To(Equal([]string{"a", "b", "c"}))
 		})
 
+		It("preserves cloud errors returned by action", func() {
+			expectedErr := fakeapi.NewFakeCloudError("fake-type", "fake-message")
+
+			action := &actionWithGoodRunMethod{Err: expectedErr}
+			args := []interface{}{"setup", 123, map[string]interface{}{}, []interface{}{}}
+
+			_, err := caller.Call(action, args, bslcapi.RequestContext{})
+			Expect(err).To(Equal(expectedErr))
+		})
+
+		It("passes request context to action which takes it as first argument", func() {
+			action := &actionWithRequestContext{}
+			context := bslcapi.RequestContext{ApiVersion: 2}
+
+			_, err := caller.Call(action, []interface{}{"setup"}, context)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(action.Context).To(Equal(context))
+			Expect(action.SubAction).To(Equal("setup"))
+		})
+
+		It("does not count request context as argument expected in payload", func() {
+			action := &actionWithRequestContext{}
+
+			_, err := caller.Call(action, []interface{}{}, bslcapi.RequestContext{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("expected 1, got 0"))
+		})
+
 		It("returns error if actions not enough arguments", func() {
 			expectedValue := valueType{ID: 13, Success: true}
 
 			action := &actionWithGoodRunMethod{Value: expectedValue}
 
-			_, err := caller.Call(action, []interface{}{"setup"})
+			_, err := caller.Call(action, []interface{}{"setup"}, bslcapi.RequestContext{})
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -120,7 +162,7 @@ var _ = Describe("JSONCaller", func() {
 				123,
 				"setup",
 				map[string]interface{}{"user": "rob", "pwd": "rob123", "id": 12},
-			})
+			}, bslcapi.RequestContext{})
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -134,7 +176,7 @@ var _ = Describe("JSONCaller", func() {
 				"setup",
 				map[string]interface{}{"user": "rob", "pwd": "rob123", "id": 12},
 				map[string]interface{}{"user": "bob", "pwd": "bob123", "id": 13},
-			})
+			}, bslcapi.RequestContext{})
 
 			Expect(value).To(Equal(expectedValue))
 			Expect(err).To(Equal(expectedErr))
@@ -151,24 +193,24 @@ var _ = Describe("JSONCaller", func() {
 		It("handles optional arguments when not passed in", func() {
 			action := &actionWithOptionalRunArgument{}
 
-			caller.Call(action, []interface{}{"setup"})
+			caller.Call(action, []interface{}{"setup"}, bslcapi.RequestContext{})
 
 			Expect(action.SubAction).To(Equal("setup"))
 			Expect(action.OptionalArgs).To(Equal([]argsType{}))
 		})
 
 		It("returns error if action does not implement run", func() {
-			_, err := caller.Call(&actionWithoutRunMethod{}, []interface{}{})
+			_, err := caller.Call(&actionWithoutRunMethod{}, []interface{}{}, bslcapi.RequestContext{})
 			Expect(err).To(HaveOccurred())
 		})
 
 		It("returns error if actions run does not return two values", func() {
-			_, err := caller.Call(&actionWithOneRunReturnValue{}, []interface{}{})
+			_, err := caller.Call(&actionWithOneRunReturnValue{}, []interface{}{}, bslcapi.RequestContext{})
 			Expect(err).To(HaveOccurred())
 		})
 
 		It("returns error if actions run second return type is not error", func() {
-			_, err := caller.Call(&actionWithSecondReturnValueNotError{}, []interface{}{})
+			_, err := caller.Call(&actionWithSecondReturnValueNotError{}, []interface{}{}, bslcapi.RequestContext{})
 			Expect(err).To(HaveOccurred())
 		})
 	})
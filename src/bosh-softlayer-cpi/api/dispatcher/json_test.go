@@ -1,19 +1,22 @@
 package dispatcher_test
 
 import (
+	"bytes"
 	"errors"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	bslcapi "bosh-softlayer-cpi/api"
 	. "bosh-softlayer-cpi/api/dispatcher"
 
-	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	fakeaction "bosh-softlayer-cpi/action/fakes"
 	fakedisp "bosh-softlayer-cpi/api/dispatcher/fakes"
 	fakeapi "bosh-softlayer-cpi/api/fakes"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 )
 
 var _ = Describe("JSON", func() {
@@ -48,6 +51,56 @@ var _ = Describe("JSON", func() {
 				Expect(caller.CallArgs).To(Equal([]interface{}{"fake-arg"}))
 			})
 
+			It("runs action with request context parsed from api_version and context keys", func() {
+				dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg"],"api_version":2,"context":{"vm":{"stemcell":{"api_version":2}}}}`))
+				Expect(caller.CallContext.ApiVersion).To(Equal(2))
+				Expect(caller.CallContext.IsApiVersion2()).To(BeTrue())
+				Expect(caller.CallContext.VM.Stemcell.ApiVersion).To(Equal(2))
+			})
+
+			It("runs action with director uuid and request id from context", func() {
+				dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":[],"context":{"director_uuid":"fake-director-uuid","request_id":"fake-request-id"}}`))
+				Expect(caller.CallContext.DirectorUUID).To(Equal("fake-director-uuid"))
+				Expect(caller.CallContext.RequestID).To(Equal("fake-request-id"))
+			})
+
+			It("prefixes subsequent log lines with request id", func() {
+				outBuffer := bytes.NewBufferString("")
+				requestLogger := bslcapi.NewRequestLogger(boshlog.NewWriterLogger(boshlog.LevelDebug, outBuffer, outBuffer))
+				dispatcher = NewJSON(actionFactory, caller, requestLogger)
+
+				dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":[],"context":{"request_id":"fake-request-id"}}`))
+				Expect(outBuffer.String()).To(ContainSubstring("[fake-request-id] Deserialized response"))
+			})
+
+			It("has the action factory log the SoftLayer API calls of the request", func() {
+				apiCallLoggingFactory := &fakeAPICallLoggingFactory{FakeFactory: actionFactory}
+				dispatcher = NewJSON(apiCallLoggingFactory, caller, logger)
+
+				dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":[]}`))
+				Expect(apiCallLoggingFactory.logged).To(Equal(1))
+			})
+
+			It("defaults to CPI API version 1 when api_version is not provided", func() {
+				dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg"]}`))
+				Expect(caller.CallContext.ApiVersion).To(Equal(1))
+				Expect(caller.CallContext.IsApiVersion2()).To(BeFalse())
+			})
+
+			It("responds with CpiError when api_version is not supported", func() {
+				response := dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg"],"api_version":3}`))
+				Expect(response).To(MatchJSON(`{
+					"result": null,
+					"error": {
+						"type":"Bosh::Clouds::CpiError",
+						"message":"CPI API version 3 is not supported, maximum supported version is 2",
+						"ok_to_retry": false
+					},
+					"log": ""
+				}`))
+				Expect(caller.CallAction).To(BeNil())
+			})
+
 			Context("when running action succeeds", func() {
 				Context("when result can be serialized", func() {
 					BeforeEach(func() {
@@ -82,15 +135,6 @@ var _ = Describe("JSON", func() {
             }`))
 					})
 				})
-				Context("verify if localDiskFlagNotSet is set properly", func() {
-					It("localDiskFlagNotSet is set to true if LocalDiskFlag is not set, and false if LocalDiskFlag is set to false", func() {
-						_ = dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg"]}`))
-						Expect(slhelper.LocalDiskFlagNotSet).To(Equal(true))
-
-						_ = dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg", "localDiskFlag:false"]}`))
-						Expect(slhelper.LocalDiskFlagNotSet).To(Equal(false))
-					})
-				})
 			})
 
 			Context("when running action fails", func() {
@@ -151,6 +195,47 @@ var _ = Describe("JSON", func() {
 					})
 				})
 
+				Context("when action error wraps a SoftLayer API error that can be retried", func() {
+					BeforeEach(func() {
+						caller.CallErr = bosherr.WrapError(slh.NewAPIError(slh.APIErrorThrottled, 429, errors.New("fake-rate-limit-error")), "fake-wrapping-error")
+					})
+
+					It("returns error with ok_to_retry set to true", func() {
+						response := dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg"]}`))
+						Expect(response).To(MatchJSON(`{
+							"result": null,
+              "error": {
+                "type":"Bosh::Clouds::CloudError",
+                "message":"fake-wrapping-error: fake-rate-limit-error",
+                "ok_to_retry": true
+              },
+              "log": ""
+            }`))
+					})
+				})
+
+				Context("when action error names a SoftLayer HTTP status code that can be retried", func() {
+					BeforeEach(func() {
+						caller.CallErr = bosherr.WrapError(errors.New("softlayer-go: could not SoftLayer_Virtual_Guest#getObject, HTTP error code: '503'"), "fake-wrapping-error")
+					})
+
+					It("returns error with ok_to_retry set to true", func() {
+						response := dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg"]}`))
+						Expect(response).To(ContainSubstring(`"ok_to_retry":true`))
+					})
+				})
+
+				Context("when action error carries the response of a SoftLayer request blocked by a transaction", func() {
+					BeforeEach(func() {
+						caller.CallErr = bosherr.WrapError(errors.New("softlayer-go: could not SoftLayer_Network_Storage#allowAccessFromVirtualGuest, error message 'HTTP error code: '500', response: {\"error\": \"Unable to attach, please try again after Volume Provisioning is complete\"}'"), "fake-wrapping-error")
+					})
+
+					It("returns error with ok_to_retry set to true", func() {
+						response := dispatcher.Dispatch([]byte(`{"method":"fake-action","arguments":["fake-arg"]}`))
+						Expect(response).To(ContainSubstring(`"ok_to_retry":true`))
+					})
+				})
+
 				Context("when action error is neither CloudError or RetryableError", func() {
 					BeforeEach(func() {
 						caller.CallErr = errors.New("fake-run-err")
@@ -233,3 +318,13 @@ var _ = Describe("JSON", func() {
 		})
 	})
 })
+
+type fakeAPICallLoggingFactory struct {
+	*fakeaction.FakeFactory
+
+	logged int
+}
+
+func (f *fakeAPICallLoggingFactory) LogAPICalls() {
+	f.logged++
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"strings"
 )
 
 type CloudError interface {
@@ -16,6 +17,37 @@ type RetryableError interface {
 	CanRetry() bool
 }
 
+// -
+type RetryableCloudError struct {
+	cause error
+}
+
+func NewRetryableCloudError(cause error) RetryableCloudError {
+	return RetryableCloudError{cause: cause}
+}
+
+func (e RetryableCloudError) Type() string   { return "Bosh::Clouds::CloudError" }
+func (e RetryableCloudError) Error() string  { return e.cause.Error() }
+func (e RetryableCloudError) CanRetry() bool { return true }
+
+// -
+// CloudPropertiesError lists every problem found in the cloud properties of a request
+type CloudPropertiesError struct {
+	Problems []string
+}
+
+func NewCloudPropertiesError(problems ...string) CloudPropertiesError {
+	return CloudPropertiesError{Problems: problems}
+}
+
+func (e CloudPropertiesError) Type() string { return "Bosh::Clouds::CloudError" }
+
+func (e CloudPropertiesError) Error() string {
+	return fmt.Sprintf("Invalid cloud properties: %s", strings.Join(e.Problems, "; "))
+}
+
+func (e CloudPropertiesError) CanRetry() bool { return false }
+
 // -
 type NotSupportedError struct{}
 
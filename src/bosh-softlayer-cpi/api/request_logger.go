@@ -0,0 +1,64 @@
+package api
+
+import (
+	"strings"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// RequestLogger prefixes every logged message with the ID of the director request being served,
+// so that CPI logs can be correlated with the director task logs.
+type RequestLogger struct {
+	boshlog.Logger
+
+	requestID   string
+	requestIDMu sync.RWMutex
+}
+
+func NewRequestLogger(logger boshlog.Logger) *RequestLogger {
+	return &RequestLogger{Logger: logger}
+}
+
+func (l *RequestLogger) SetRequestID(requestID string) {
+	l.requestIDMu.Lock()
+	defer l.requestIDMu.Unlock()
+
+	l.requestID = requestID
+}
+
+func (l *RequestLogger) Debug(tag, msg string, args ...interface{}) {
+	l.Logger.Debug(tag, l.prefix(msg), args...)
+}
+
+func (l *RequestLogger) DebugWithDetails(tag, msg string, args ...interface{}) {
+	l.Logger.DebugWithDetails(tag, l.prefix(msg), args...)
+}
+
+func (l *RequestLogger) Info(tag, msg string, args ...interface{}) {
+	l.Logger.Info(tag, l.prefix(msg), args...)
+}
+
+func (l *RequestLogger) Warn(tag, msg string, args ...interface{}) {
+	l.Logger.Warn(tag, l.prefix(msg), args...)
+}
+
+func (l *RequestLogger) Error(tag, msg string, args ...interface{}) {
+	l.Logger.Error(tag, l.prefix(msg), args...)
+}
+
+func (l *RequestLogger) ErrorWithDetails(tag, msg string, args ...interface{}) {
+	l.Logger.ErrorWithDetails(tag, l.prefix(msg), args...)
+}
+
+func (l *RequestLogger) prefix(msg string) string {
+	l.requestIDMu.RLock()
+	defer l.requestIDMu.RUnlock()
+
+	if l.requestID == "" {
+		return msg
+	}
+
+	// Request ID comes from the director, so escape it before it becomes part of the format string
+	return "[" + strings.Replace(l.requestID, "%", "%%", -1) + "] " + msg
+}
@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "bosh-softlayer-cpi/api"
+)
+
+var _ = Describe("RequestLogger", func() {
+	var (
+		outBuffer *bytes.Buffer
+		errBuffer *bytes.Buffer
+		logger    *RequestLogger
+	)
+
+	BeforeEach(func() {
+		outBuffer = bytes.NewBufferString("")
+		errBuffer = bytes.NewBufferString("")
+		logger = NewRequestLogger(boshlog.NewWriterLogger(boshlog.LevelDebug, outBuffer, errBuffer))
+	})
+
+	It("logs messages unchanged when request ID is not set", func() {
+		logger.Debug("fake-tag", "fake-message %d", 1)
+		Expect(outBuffer.String()).To(ContainSubstring("[fake-tag]"))
+		Expect(outBuffer.String()).To(ContainSubstring("DEBUG - fake-message 1"))
+	})
+
+	It("prefixes messages with request ID", func() {
+		logger.SetRequestID("fake-request-id")
+
+		logger.Info("fake-tag", "fake-message %d", 1)
+		Expect(outBuffer.String()).To(ContainSubstring("INFO - [fake-request-id] fake-message 1"))
+
+		logger.Error("fake-tag", "fake-error %s", "kaboom")
+		Expect(errBuffer.String()).To(ContainSubstring("ERROR - [fake-request-id] fake-error kaboom"))
+	})
+
+	It("does not interpret format verbs in request ID", func() {
+		logger.SetRequestID("fake-%s-id")
+
+		logger.Warn("fake-tag", "fake-message")
+		Expect(errBuffer.String()).To(ContainSubstring("WARN - [fake-%s-id] fake-message"))
+	})
+})
@@ -5,9 +5,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	"testing"
+
+	testhelperscpi "bosh-softlayer-cpi/test_helpers"
 )
 
 func TestAttachDisk(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Integration: attach_disk Suite")
 }
+
+var _ = testhelperscpi.SoftLayerSimulatorSuite()
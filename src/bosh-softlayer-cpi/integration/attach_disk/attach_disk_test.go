@@ -14,7 +14,6 @@ import (
 	"log"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
@@ -34,7 +33,6 @@ var _ = Describe("BOSH Director Level Integration for attach_disk", func() {
 		createdSshKey datatypes.SoftLayer_Security_Ssh_Key
 		vmId          int
 
-		accountService      softlayer.SoftLayer_Account_Service
 		virtualGuestService softlayer.SoftLayer_Virtual_Guest_Service
 
 		rootTemplatePath, tmpConfigPath string
@@ -50,13 +48,14 @@ var _ = Describe("BOSH Director Level Integration for attach_disk", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
-		accountService, err = testhelpers.CreateAccountService()
+		_, err = testhelperscpi.CreateAccountService()
 		Expect(err).ToNot(HaveOccurred())
 
-		virtualGuestService, err = testhelpers.CreateVirtualGuestService()
+		virtualGuestService, err = testhelperscpi.CreateVirtualGuestService()
 		Expect(err).ToNot(HaveOccurred())
 
 		replacementMap = map[string]string{
@@ -102,11 +101,11 @@ var _ = Describe("BOSH Director Level Integration for attach_disk", func() {
 
 	Context("attach_disk in SoftLayer with valid virtual guest id(with multipath installed) and disk id", func() {
 		BeforeEach(func() {
-			err = testhelpers.FindAndDeleteTestSshKeys()
+			err = testhelperscpi.FindAndDeleteTestSshKeys()
 			Expect(err).ToNot(HaveOccurred())
 
-			createdSshKey, _ = testhelpers.CreateTestSshKey()
-			testhelpers.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
+			createdSshKey, _ = testhelperscpi.CreateTestSshKey()
+			testhelperscpi.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
 
 			createvmJsonPath := filepath.Join(rootTemplatePath, "dev", "create_vm.json")
 			f, err := os.Open(createvmJsonPath)
@@ -131,13 +130,13 @@ var _ = Describe("BOSH Director Level Integration for attach_disk", func() {
 			Expect(vmId).ToNot(BeNil())
 			log.Println("---> created vm ", vmId)
 
-			testhelpers.WaitForVirtualGuestToBeRunning(vmId)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactions(vmId)
+			testhelperscpi.WaitForVirtualGuestToBeRunning(vmId)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactions(vmId)
 
 			vm, err := virtualGuestService.GetObject(vmId)
 			Expect(err).ToNot(HaveOccurred())
 
-			disk = testhelpers.CreateDisk(20, strconv.Itoa(vm.Datacenter.Id))
+			disk = testhelperscpi.CreateDisk(20, strconv.Itoa(vm.Datacenter.Id))
 
 			strVGID = strconv.Itoa(vmId)
 			strDID = strconv.Itoa(disk.Id)
@@ -149,10 +148,10 @@ var _ = Describe("BOSH Director Level Integration for attach_disk", func() {
 		})
 
 		AfterEach(func() {
-			testhelpers.DeleteVirtualGuest(vmId)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(vmId)
-			testhelpers.DeleteDisk(disk.Id)
-			testhelpers.DeleteSshKey(createdSshKey.Id)
+			testhelperscpi.DeleteVirtualGuest(vmId)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(vmId)
+			testhelperscpi.DeleteDisk(disk.Id)
+			testhelperscpi.DeleteSshKey(createdSshKey.Id)
 		})
 
 		It("attach_disk successfully", func() {
@@ -13,7 +13,6 @@ import (
 	"log"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
@@ -32,9 +31,6 @@ var _ = Describe("BOSH Director Level Integration for create_disk", func() {
 		virtualGuest  datatypes.SoftLayer_Virtual_Guest
 		createdSshKey datatypes.SoftLayer_Security_Ssh_Key
 
-		accountService      softlayer.SoftLayer_Account_Service
-		virtualGuestService softlayer.SoftLayer_Virtual_Guest_Service
-
 		rootTemplatePath, tmpConfigPath, strVGID string
 		replacementMap                           map[string]string
 		resultOutput                             map[string]interface{}
@@ -47,13 +43,14 @@ var _ = Describe("BOSH Director Level Integration for create_disk", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
-		accountService, err = testhelpers.CreateAccountService()
+		_, err = testhelperscpi.CreateAccountService()
 		Expect(err).ToNot(HaveOccurred())
 
-		virtualGuestService, err = testhelpers.CreateVirtualGuestService()
+		_, err = testhelperscpi.CreateVirtualGuestService()
 		Expect(err).ToNot(HaveOccurred())
 
 		replacementMap = map[string]string{
@@ -113,16 +110,16 @@ var _ = Describe("BOSH Director Level Integration for create_disk", func() {
 
 	Context("create_disk in SoftLayer with valid virtual guest id", func() {
 		BeforeEach(func() {
-			err = testhelpers.FindAndDeleteTestSshKeys()
+			err = testhelperscpi.FindAndDeleteTestSshKeys()
 			Expect(err).ToNot(HaveOccurred())
 
-			createdSshKey, _ = testhelpers.CreateTestSshKey()
-			testhelpers.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
+			createdSshKey, _ = testhelperscpi.CreateTestSshKey()
+			testhelperscpi.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
 
-			virtualGuest = testhelpers.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
+			virtualGuest = testhelperscpi.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
 
-			testhelpers.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
 
 			strVGID = strconv.Itoa(virtualGuest.Id)
 
@@ -132,9 +129,9 @@ var _ = Describe("BOSH Director Level Integration for create_disk", func() {
 		})
 
 		AfterEach(func() {
-			testhelpers.DeleteVirtualGuest(virtualGuest.Id)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(virtualGuest.Id)
-			testhelpers.DeleteSshKey(createdSshKey.Id)
+			testhelperscpi.DeleteVirtualGuest(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(virtualGuest.Id)
+			testhelperscpi.DeleteSshKey(createdSshKey.Id)
 		})
 
 		It("returns valid result because valid parameters", func() {
@@ -151,7 +148,7 @@ var _ = Describe("BOSH Director Level Integration for create_disk", func() {
 
 			id := resultOutput["result"].(string)
 			diskId, err := strconv.Atoi(id)
-			testhelpers.DeleteDisk(diskId)
+			testhelperscpi.DeleteDisk(diskId)
 		})
 
 	})
@@ -5,9 +5,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	"testing"
+
+	testhelperscpi "bosh-softlayer-cpi/test_helpers"
 )
 
 func TestCreateStemcell(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Integration: create_stemcell Suite")
 }
+
+var _ = testhelperscpi.SoftLayerSimulatorSuite()
@@ -13,7 +13,6 @@ import (
 	. "github.com/onsi/gomega"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
@@ -51,7 +50,8 @@ var _ = Describe("BOSH Director Level Integration for create_stemcell", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
 		pwd, err := os.Getwd()
@@ -94,7 +94,7 @@ var _ = Describe("BOSH Director Level Integration for create_stemcell", func() {
 			// Wait for transaction to complete
 			testhelpers.TIMEOUT = 35 * time.Minute
 			testhelpers.POLLING_INTERVAL = 10 * time.Second
-			testhelpers.WaitForVirtualGuestBlockTemplateGroupToHaveNoActiveTransactions(vgbdtGroup.Id)
+			testhelperscpi.WaitForVirtualGuestBlockTemplateGroupToHaveNoActiveTransactions(vgbdtGroup.Id)
 		})
 
 		AfterEach(func() {
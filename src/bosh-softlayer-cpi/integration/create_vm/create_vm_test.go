@@ -13,7 +13,6 @@ import (
 	"log"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
 )
@@ -44,14 +43,17 @@ var _ = Describe("BOSH Director Level Integration for create_vm", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
-		accountService, err = testhelpers.CreateAccountService()
+		accountService, err = testhelperscpi.CreateAccountService()
 		Expect(err).ToNot(HaveOccurred())
+		Expect(accountService).ToNot(BeNil())
 
-		virtualGuestService, err = testhelpers.CreateVirtualGuestService()
+		virtualGuestService, err = testhelperscpi.CreateVirtualGuestService()
 		Expect(err).ToNot(HaveOccurred())
+		Expect(virtualGuestService).ToNot(BeNil())
 
 		replacementMap = map[string]string{
 			"Datacenter": testhelpers.GetDatacenter(),
@@ -105,8 +107,8 @@ var _ = Describe("BOSH Director Level Integration for create_vm", func() {
 			vmId, err := strconv.Atoi(id)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(vmId).ToNot(BeNil())
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactions(vmId)
-			testhelpers.DeleteVirtualGuest(vmId)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactions(vmId)
+			testhelperscpi.DeleteVirtualGuest(vmId)
 		})
 
 	})
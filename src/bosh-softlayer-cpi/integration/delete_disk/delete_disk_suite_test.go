@@ -5,9 +5,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	"testing"
+
+	testhelperscpi "bosh-softlayer-cpi/test_helpers"
 )
 
 func TestDeleteDisk(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Integration: delete_disk Suite")
 }
+
+var _ = testhelperscpi.SoftLayerSimulatorSuite()
@@ -13,7 +13,6 @@ import (
 	"log"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
@@ -33,7 +32,6 @@ var _ = Describe("BOSH Director Level Integration for delete_disk", func() {
 		createdSshKey datatypes.SoftLayer_Security_Ssh_Key
 		disk          datatypes.SoftLayer_Network_Storage
 
-		accountService      softlayer.SoftLayer_Account_Service
 		virtualGuestService softlayer.SoftLayer_Virtual_Guest_Service
 
 		rootTemplatePath, tmpConfigPath, strDID string
@@ -48,13 +46,14 @@ var _ = Describe("BOSH Director Level Integration for delete_disk", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
-		accountService, err = testhelpers.CreateAccountService()
+		_, err = testhelperscpi.CreateAccountService()
 		Expect(err).ToNot(HaveOccurred())
 
-		virtualGuestService, err = testhelpers.CreateVirtualGuestService()
+		virtualGuestService, err = testhelperscpi.CreateVirtualGuestService()
 		Expect(err).ToNot(HaveOccurred())
 
 		replacementMap = map[string]string{
@@ -99,21 +98,21 @@ var _ = Describe("BOSH Director Level Integration for delete_disk", func() {
 
 	Context("disk_disk in SoftLayer with valid disk id", func() {
 		BeforeEach(func() {
-			err = testhelpers.FindAndDeleteTestSshKeys()
+			err = testhelperscpi.FindAndDeleteTestSshKeys()
 			Expect(err).ToNot(HaveOccurred())
 
-			createdSshKey, _ = testhelpers.CreateTestSshKey()
-			testhelpers.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
+			createdSshKey, _ = testhelperscpi.CreateTestSshKey()
+			testhelperscpi.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
 
-			virtualGuest = testhelpers.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
+			virtualGuest = testhelperscpi.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
 
-			testhelpers.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
 
 			vm, err := virtualGuestService.GetObject(virtualGuest.Id)
 			Expect(err).ToNot(HaveOccurred())
 
-			disk = testhelpers.CreateDisk(20, strconv.Itoa(vm.Datacenter.Id))
+			disk = testhelperscpi.CreateDisk(20, strconv.Itoa(vm.Datacenter.Id))
 
 			strDID = strconv.Itoa(disk.Id)
 
@@ -123,9 +122,9 @@ var _ = Describe("BOSH Director Level Integration for delete_disk", func() {
 		})
 
 		AfterEach(func() {
-			testhelpers.DeleteVirtualGuest(virtualGuest.Id)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(virtualGuest.Id)
-			testhelpers.DeleteSshKey(createdSshKey.Id)
+			testhelperscpi.DeleteVirtualGuest(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(virtualGuest.Id)
+			testhelperscpi.DeleteSshKey(createdSshKey.Id)
 		})
 
 		It("delete the disk successfully", func() {
@@ -12,10 +12,10 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
 	"github.com/cloudfoundry/bosh-utils/logger"
-	slclient "github.com/maximilien/softlayer-go/client"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	"github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
@@ -50,7 +50,8 @@ var _ = Describe("BOSH Director Level Integration for delete_stemcell", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
 		pwd, err := os.Getwd()
@@ -93,11 +94,11 @@ var _ = Describe("BOSH Director Level Integration for delete_stemcell", func() {
 			// Wait for transaction to complete
 			testhelpers.TIMEOUT = 35 * time.Minute
 			testhelpers.POLLING_INTERVAL = 10 * time.Second
-			testhelpers.WaitForVirtualGuestBlockTemplateGroupToHaveNoActiveTransactions(vgbdtGroup.Id)
+			testhelperscpi.WaitForVirtualGuestBlockTemplateGroupToHaveNoActiveTransactions(vgbdtGroup.Id)
 		})
 
 		AfterEach(func() {
-			stemcell := bslcstem.NewSoftLayerStemcell(virtual_disk_image_id, "", client, logger.NewLogger(logger.LevelInfo))
+			stemcell := bslcstem.NewSoftLayerStemcell(virtual_disk_image_id, "", client, slh.DefaultWaitPolicies(), logger.NewLogger(logger.LevelInfo))
 			stemcell.Delete()
 			Expect(err).ToNot(HaveOccurred())
 		})
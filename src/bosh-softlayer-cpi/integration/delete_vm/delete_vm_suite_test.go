@@ -5,9 +5,13 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	testhelperscpi "bosh-softlayer-cpi/test_helpers"
 )
 
 func TestServices(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Integration: delete_vm Suite")
 }
+
+var _ = testhelperscpi.SoftLayerSimulatorSuite()
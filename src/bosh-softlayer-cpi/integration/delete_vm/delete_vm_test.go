@@ -11,7 +11,6 @@ import (
 	. "github.com/onsi/gomega"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
@@ -47,14 +46,17 @@ var _ = Describe("BOSH Director Level Integration for delete_vm", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
-		accountService, err = testhelpers.CreateAccountService()
+		accountService, err = testhelperscpi.CreateAccountService()
 		Expect(err).ToNot(HaveOccurred())
+		Expect(accountService).ToNot(BeNil())
 
-		virtualGuestService, err = testhelpers.CreateVirtualGuestService()
+		virtualGuestService, err = testhelperscpi.CreateVirtualGuestService()
 		Expect(err).ToNot(HaveOccurred())
+		Expect(virtualGuestService).ToNot(BeNil())
 
 		testhelpers.TIMEOUT = 35 * time.Minute
 		testhelpers.POLLING_INTERVAL = 10 * time.Second
@@ -74,16 +76,16 @@ var _ = Describe("BOSH Director Level Integration for delete_vm", func() {
 
 	Context("delete_vm with a valid VM ID", func() {
 		BeforeEach(func() {
-			err = testhelpers.FindAndDeleteTestSshKeys()
+			err = testhelperscpi.FindAndDeleteTestSshKeys()
 			Expect(err).ToNot(HaveOccurred())
 
-			createdSshKey, _ = testhelpers.CreateTestSshKey()
-			testhelpers.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
+			createdSshKey, _ = testhelperscpi.CreateTestSshKey()
+			testhelperscpi.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
 
-			virtualGuest = testhelpers.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
+			virtualGuest = testhelperscpi.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
 
-			testhelpers.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
 
 			strVGID = strconv.Itoa(virtualGuest.Id)
 
@@ -95,8 +97,8 @@ var _ = Describe("BOSH Director Level Integration for delete_vm", func() {
 
 		AfterEach(func() {
 			// assume errors are either because service was already deleted or will be caught later anyway
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(virtualGuest.Id)
-			testhelpers.DeleteSshKey(createdSshKey.Id)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(virtualGuest.Id)
+			testhelperscpi.DeleteSshKey(createdSshKey.Id)
 		})
 
 		It("deletes the VM susseccfully", func() {
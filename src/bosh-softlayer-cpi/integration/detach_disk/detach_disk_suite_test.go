@@ -5,9 +5,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	"testing"
+
+	testhelperscpi "bosh-softlayer-cpi/test_helpers"
 )
 
 func TestDetachDisk(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Integration: detach_disk Suite")
 }
+
+var _ = testhelperscpi.SoftLayerSimulatorSuite()
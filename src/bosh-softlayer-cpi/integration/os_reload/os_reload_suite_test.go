@@ -5,9 +5,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	"testing"
+
+	testhelperscpi "bosh-softlayer-cpi/test_helpers"
 )
 
 func TestCreateVm(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Integration: os_reload Suite")
 }
+
+var _ = testhelperscpi.SoftLayerSimulatorSuite()
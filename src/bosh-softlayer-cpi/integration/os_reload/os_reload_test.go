@@ -14,7 +14,6 @@ import (
 	"log"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
 )
@@ -47,14 +46,17 @@ var _ = Describe("BOSH Director Level Integration for OS Reload", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
-		accountService, err = testhelpers.CreateAccountService()
+		accountService, err = testhelperscpi.CreateAccountService()
 		Expect(err).ToNot(HaveOccurred())
+		Expect(accountService).ToNot(BeNil())
 
-		virtualGuestService, err = testhelpers.CreateVirtualGuestService()
+		virtualGuestService, err = testhelperscpi.CreateVirtualGuestService()
 		Expect(err).ToNot(HaveOccurred())
+		Expect(virtualGuestService).ToNot(BeNil())
 
 		os.Setenv("SQLITE_DB_FOLDER", "/tmp")
 
@@ -71,8 +73,8 @@ var _ = Describe("BOSH Director Level Integration for OS Reload", func() {
 	})
 
 	AfterEach(func() {
-		testhelpers.WaitForVirtualGuestToHaveNoActiveTransactions(int(vmId))
-		testhelpers.DeleteVirtualGuest(int(vmId))
+		testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactions(int(vmId))
+		testhelperscpi.DeleteVirtualGuest(int(vmId))
 
 		err = os.RemoveAll(tmpConfigPath)
 		Expect(err).ToNot(HaveOccurred())
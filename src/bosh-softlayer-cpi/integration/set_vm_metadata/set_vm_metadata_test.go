@@ -11,7 +11,6 @@ import (
 	. "github.com/onsi/gomega"
 
 	testhelperscpi "bosh-softlayer-cpi/test_helpers"
-	slclient "github.com/maximilien/softlayer-go/client"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	softlayer "github.com/maximilien/softlayer-go/softlayer"
 	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
@@ -45,13 +44,15 @@ var _ = Describe("BOSH Director Level Integration for set_vm_metadata", func() {
 		apiKey = os.Getenv("SL_API_KEY")
 		Expect(apiKey).ToNot(Equal(""), "apiKey cannot be empty, set SL_API_KEY")
 
-		client = slclient.NewSoftLayerClient(username, apiKey)
+		client, err = testhelperscpi.NewSoftLayerClient(username, apiKey)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(client).ToNot(BeNil())
 
-		accountService, err = testhelpers.CreateAccountService()
+		accountService, err = testhelperscpi.CreateAccountService()
 		Expect(err).ToNot(HaveOccurred())
+		Expect(accountService).ToNot(BeNil())
 
-		virtualGuestService, err = testhelpers.CreateVirtualGuestService()
+		virtualGuestService, err = testhelperscpi.CreateVirtualGuestService()
 		Expect(err).ToNot(HaveOccurred())
 
 		testhelpers.TIMEOUT = 35 * time.Minute
@@ -60,16 +61,16 @@ var _ = Describe("BOSH Director Level Integration for set_vm_metadata", func() {
 
 	Context("set_vm_metadata", func() {
 		BeforeEach(func() {
-			err = testhelpers.FindAndDeleteTestSshKeys()
+			err = testhelperscpi.FindAndDeleteTestSshKeys()
 			Expect(err).ToNot(HaveOccurred())
 
-			createdSshKey, _ = testhelpers.CreateTestSshKey()
-			testhelpers.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
+			createdSshKey, _ = testhelperscpi.CreateTestSshKey()
+			testhelperscpi.WaitForCreatedSshKeyToBePresent(createdSshKey.Id)
 
-			virtualGuest = testhelpers.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
+			virtualGuest = testhelperscpi.CreateVirtualGuestAndMarkItTest([]datatypes.SoftLayer_Security_Ssh_Key{createdSshKey})
 
-			testhelpers.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
-			testhelpers.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToBeRunning(virtualGuest.Id)
+			testhelperscpi.WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
 
 			pwd, err := os.Getwd()
 			Expect(err).ToNot(HaveOccurred())
@@ -86,15 +87,11 @@ var _ = Describe("BOSH Director Level Integration for set_vm_metadata", func() {
 		})
 
 		AfterEach(func() {
-			testhelpers.DeleteVirtualGuest(virtualGuest.Id)
-			testhelpers.DeleteSshKey(createdSshKey.Id)
+			testhelperscpi.DeleteVirtualGuest(virtualGuest.Id)
+			testhelperscpi.DeleteSshKey(createdSshKey.Id)
 		})
 
 		It("issues set_vm_metadata to cpi", func() {
-			pwd, err := os.Getwd()
-			Expect(err).ToNot(HaveOccurred())
-			rootTemplatePath := filepath.Join(pwd, "..", "..")
-
 			jsonPayload, err := testhelperscpi.GenerateCpiJsonPayload("set_vm_metadata", rootTemplatePath, replacementMap)
 			Expect(err).ToNot(HaveOccurred())
 
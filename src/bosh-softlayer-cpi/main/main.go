@@ -8,6 +8,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	bslcaction "bosh-softlayer-cpi/action"
+	bslcapi "bosh-softlayer-cpi/api"
 	bslcdisp "bosh-softlayer-cpi/api/dispatcher"
 	bslctrans "bosh-softlayer-cpi/api/transport"
 
@@ -19,6 +20,7 @@ const mainLogTag = "main"
 var (
 	configPathOpt = flag.String("configPath", "", "Path to configuration file")
 	cpiVersion    = flag.Bool("version", false, "The version of CPI release")
+	quotePathOpt  = flag.String("quote", "", "Path to a cloud config fragment to price and have SoftLayer verify without ordering anything")
 )
 
 func main() {
@@ -40,6 +42,15 @@ func main() {
 
 	dispatcher := buildDispatcher(config, logger, cmdRunner)
 
+	if *quotePathOpt != "" {
+		err = quoteOrders(*quotePathOpt, fs, dispatcher, os.Stdout)
+		if err != nil {
+			logger.Error(mainLogTag, "Quoting orders %s", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	cli := bslctrans.NewCLI(os.Stdin, os.Stdout, dispatcher, logger)
 
 	err = cli.ServeOnce()
@@ -50,7 +61,7 @@ func main() {
 }
 
 func basicDeps() (boshlog.Logger, boshsys.FileSystem, boshsys.CmdRunner) {
-	logger := boshlog.NewWriterLogger(boshlog.LevelDebug, os.Stderr, os.Stderr)
+	logger := bslcapi.NewRequestLogger(boshlog.NewWriterLogger(boshlog.LevelDebug, os.Stderr, os.Stderr))
 
 	fs := boshsys.NewOsFileSystem(logger)
 
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	bslcdisp "bosh-softlayer-cpi/api/dispatcher"
+)
+
+// quoteOrders prices the VM and disk types of a cloud config fragment through the
+// quote_orders action and writes its response to out. See docs/quote_orders.md.
+func quoteOrders(fragmentPath string, fs boshsys.FileSystem, dispatcher bslcdisp.Dispatcher, out io.Writer) error {
+	fragmentBytes, err := fs.ReadFile(fragmentPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading cloud config fragment %s", fragmentPath)
+	}
+
+	var fragment interface{}
+	err = yaml.Unmarshal(fragmentBytes, &fragment)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing cloud config fragment %s", fragmentPath)
+	}
+
+	reqBytes, err := json.Marshal(map[string]interface{}{
+		"method":    "quote_orders",
+		"arguments": []interface{}{stringKeys(fragment)},
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Building quote_orders request")
+	}
+
+	respBytes := dispatcher.Dispatch(reqBytes)
+
+	_, err = fmt.Fprintln(out, string(respBytes))
+	if err != nil {
+		return bosherr.WrapError(err, "Writing quote_orders response")
+	}
+
+	var resp bslcdisp.Response
+	err = json.Unmarshal(respBytes, &resp)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing quote_orders response")
+	}
+
+	if resp.Error != nil {
+		return bosherr.Error(resp.Error.Message)
+	}
+
+	return nil
+}
+
+// stringKeys turns the maps yaml decodes into ones encoding/json can marshal
+func stringKeys(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range typedValue {
+			result[fmt.Sprintf("%v", key)] = stringKeys(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typedValue))
+		for i, item := range typedValue {
+			result[i] = stringKeys(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
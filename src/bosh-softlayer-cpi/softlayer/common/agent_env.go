@@ -2,8 +2,12 @@ package common
 
 import (
 	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
 )
 
 type UserDataContentsType struct {
@@ -52,7 +56,76 @@ type DisksSpec struct {
 	Persistent PersistentSpec `json:"persistent"`
 }
 
-type PersistentSpec map[string]string
+// PersistentSpec maps the ids of the persistent disks to the device path they show up as,
+// or to their PersistentDiskSettings when the agent attaches them
+type PersistentSpec map[string]interface{}
+
+// DevicePath returns the device path of the disk, or an empty string if the agent attaches it
+func (s PersistentSpec) DevicePath(diskID string) string {
+	devicePath, _ := s[diskID].(string)
+	return devicePath
+}
+
+// The suffixes that name a partition after its device, such as /dev/sdb1, /dev/nvme0n1p1 or
+// /dev/mapper/3600a0980-part1
+var partitionSuffixPattern = regexp.MustCompile(`^(-part|p)?[0-9]*$`)
+
+// MountedDisk returns the id of the disk whose device holds the partition mounted at mountPoint,
+// and the suffix that names the partition after the device, or empty strings when it is none of
+// the disks
+func (s PersistentSpec) MountedDisk(mounts []Mount, mountPoint string) (string, string) {
+	for _, mount := range mounts {
+		if mount.MountPoint != mountPoint {
+			continue
+		}
+
+		for diskID := range s {
+			devicePath := s.DevicePath(diskID)
+			if devicePath == "" || !strings.HasPrefix(mount.PartitionPath, devicePath) {
+				continue
+			}
+
+			suffix := strings.TrimPrefix(mount.PartitionPath, devicePath)
+			if partitionSuffixPattern.MatchString(suffix) {
+				return diskID, suffix
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// PersistentDiskSettings is the disk hint the agent logs in to the iSCSI target of a disk with
+type PersistentDiskSettings struct {
+	ID            string        `json:"id"`
+	VolumeID      string        `json:"volume_id"`
+	Lun           string        `json:"lun"`
+	ISCSISettings ISCSISettings `json:"iscsi_settings"`
+}
+
+type ISCSISettings struct {
+	InitiatorName string `json:"initiator_name"`
+	Target        string `json:"target"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+}
+
+// NewPersistentDiskSettings returns the disk hint of volume for the host allowed to it with credential
+func NewPersistentDiskSettings(volume sldatatypes.SoftLayer_Network_Storage, credential AllowedHostCredential) PersistentDiskSettings {
+	diskID := strconv.Itoa(volume.Id)
+
+	return PersistentDiskSettings{
+		ID:       diskID,
+		VolumeID: diskID,
+		Lun:      volume.LunId,
+		ISCSISettings: ISCSISettings{
+			InitiatorName: credential.Iqn,
+			Target:        volume.ServiceResourceBackendIpAddress,
+			Username:      credential.Username,
+			Password:      credential.Password,
+		},
+	}
+}
 
 type EnvSpec map[string]interface{}
 
@@ -128,6 +201,14 @@ func NewAgentEnvForVM(agentID, vmCID string, networks Networks, disksSpec DisksS
 }
 
 func (ae AgentEnv) AttachPersistentDisk(diskID, path string) AgentEnv {
+	return ae.attachPersistentDisk(diskID, path)
+}
+
+func (ae AgentEnv) AttachPersistentDiskSettings(diskID string, settings PersistentDiskSettings) AgentEnv {
+	return ae.attachPersistentDisk(diskID, settings)
+}
+
+func (ae AgentEnv) attachPersistentDisk(diskID string, hint interface{}) AgentEnv {
 	spec := PersistentSpec{}
 
 	if ae.Disks.Persistent != nil {
@@ -136,7 +217,7 @@ func (ae AgentEnv) AttachPersistentDisk(diskID, path string) AgentEnv {
 		}
 	}
 
-	spec[diskID] = path
+	spec[diskID] = hint
 
 	ae.Disks.Persistent = spec
 
@@ -88,6 +88,68 @@ var _ = Describe("AgentEnv", func() {
 		})
 	})
 
+	Describe("AttachPersistentDiskSettings", func() {
+		var settings PersistentDiskSettings
+
+		BeforeEach(func() {
+			settings = PersistentDiskSettings{
+				ID:       "fake-disk-id",
+				VolumeID: "fake-disk-id",
+				Lun:      "fake-lun",
+				ISCSISettings: ISCSISettings{
+					InitiatorName: "fake-initiator-name",
+					Target:        "fake-target",
+					Username:      "fake-username",
+					Password:      "fake-password",
+				},
+			}
+		})
+
+		It("sets persistent disk settings for given disk id", func() {
+			agentEnv := AgentEnv{
+				Disks: DisksSpec{
+					Persistent: PersistentSpec{
+						"fake-other-disk-id": "fake-other-disk-path",
+					},
+				},
+			}
+
+			newAgentEnv := agentEnv.AttachPersistentDiskSettings("fake-disk-id", settings)
+
+			Expect(newAgentEnv.Disks.Persistent).To(Equal(PersistentSpec{
+				"fake-other-disk-id": "fake-other-disk-path",
+				"fake-disk-id":       settings,
+			}))
+			Expect(newAgentEnv.Disks.Persistent.DevicePath("fake-disk-id")).To(BeEmpty())
+			Expect(newAgentEnv.Disks.Persistent.DevicePath("fake-other-disk-id")).To(Equal("fake-other-disk-path"))
+
+			// keeps original agent env not modified
+			Expect(agentEnv.Disks.Persistent).To(Equal(PersistentSpec{
+				"fake-other-disk-id": "fake-other-disk-path",
+			}))
+		})
+
+		It("encodes the settings the way the agent reads them", func() {
+			newAgentEnv := AgentEnv{}.AttachPersistentDiskSettings("fake-disk-id", settings)
+
+			jsonBytes, err := json.Marshal(newAgentEnv.Disks.Persistent)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jsonBytes).To(MatchJSON(`{
+				"fake-disk-id": {
+					"id": "fake-disk-id",
+					"volume_id": "fake-disk-id",
+					"lun": "fake-lun",
+					"iscsi_settings": {
+						"initiator_name": "fake-initiator-name",
+						"target": "fake-target",
+						"username": "fake-username",
+						"password": "fake-password"
+					}
+				}
+			}`))
+		})
+	})
+
 	Describe("DetachPersistentDisk", func() {
 		It("unsets persistent disk path if previously set", func() {
 			agentEnv := AgentEnv{
@@ -161,6 +223,45 @@ var _ = Describe("AgentEnv", func() {
 		})
 	})
 
+	Describe("MountedDisk", func() {
+		var persistent PersistentSpec
+
+		BeforeEach(func() {
+			persistent = PersistentSpec{
+				"1234": "/dev/sda",
+				"5678": "/dev/sdab",
+				"9012": "/dev/mapper/3600a09803830304f3124457a4575725a",
+			}
+		})
+
+		It("returns the disk whose device holds the partition mounted at the mount point", func() {
+			mounts := []Mount{
+				{PartitionPath: "/dev/xvda1", MountPoint: "/boot"},
+				{PartitionPath: "/dev/sdab1", MountPoint: "/var/vcap/store"},
+			}
+
+			diskID, suffix := persistent.MountedDisk(mounts, "/var/vcap/store")
+			Expect(diskID).To(Equal("5678"))
+			Expect(suffix).To(Equal("1"))
+		})
+
+		It("returns the multipath disk and the suffix of its partition", func() {
+			mounts := []Mount{{PartitionPath: "/dev/mapper/3600a09803830304f3124457a4575725a-part1", MountPoint: "/var/vcap/store"}}
+
+			diskID, suffix := persistent.MountedDisk(mounts, "/var/vcap/store")
+			Expect(diskID).To(Equal("9012"))
+			Expect(suffix).To(Equal("-part1"))
+		})
+
+		It("returns nothing when none of the disks is mounted at the mount point", func() {
+			mounts := []Mount{{PartitionPath: "/dev/xvdc1", MountPoint: "/var/vcap/store"}}
+
+			diskID, suffix := persistent.MountedDisk(mounts, "/var/vcap/store")
+			Expect(diskID).To(BeEmpty())
+			Expect(suffix).To(BeEmpty())
+		})
+	})
+
 	Describe("MarshalJSON", func() {
 		It("returns JSON encoded agent env", func() {
 			agentEnv := AgentEnv{
@@ -0,0 +1,233 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+
+	"bosh-softlayer-cpi/api"
+)
+
+// NetworkCloudProperties are the cloud properties of a dynamic network
+type NetworkCloudProperties struct {
+	PrimaryNetworkComponent        *sldatatypes.PrimaryNetworkComponent        `json:"PrimaryNetworkComponent,omitempty"`
+	PrimaryBackendNetworkComponent *sldatatypes.PrimaryBackendNetworkComponent `json:"PrimaryBackendNetworkComponent,omitempty"`
+	PrivateNetworkOnlyFlag         *bool                                       `json:"PrivateNetworkOnlyFlag,omitempty"`
+}
+
+// DecodeCloudProperties decodes the cloud properties of the network strictly. It returns the
+// names of the properties it does not know.
+func (n Network) DecodeCloudProperties() (NetworkCloudProperties, []string, error) {
+	cloudProps := NetworkCloudProperties{}
+	if len(n.CloudProperties) == 0 {
+		return cloudProps, nil, nil
+	}
+
+	data, err := json.Marshal(n.CloudProperties)
+	if err != nil {
+		return NetworkCloudProperties{}, nil, err
+	}
+
+	problems, unknownProperties, err := decodeStrictly(data, &cloudProps, "")
+	if err != nil {
+		return NetworkCloudProperties{}, nil, err
+	}
+
+	if len(problems) > 0 {
+		return NetworkCloudProperties{}, unknownProperties, api.NewCloudPropertiesError(problems...)
+	}
+
+	return cloudProps, unknownProperties, nil
+}
+
+// CheckCloudProperties collects every problem in the VM cloud properties and in the cloud
+// properties of the dynamic networks into one api.CloudPropertiesError. It returns a warning for
+// every property it does not know.
+func CheckCloudProperties(cloudProps VMCloudProperties, networks Networks) ([]string, error) {
+	problems := append([]string{}, cloudProps.problems...)
+
+	warnings := []string{}
+	for _, name := range cloudProps.unknownProperties {
+		warnings = append(warnings, fmt.Sprintf("Unknown VM cloud property '%s' is ignored", name))
+	}
+
+	names := []string{}
+	for name, network := range networks {
+		if network.IsDynamic() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, unknownProperties, err := networks[name].DecodeCloudProperties()
+
+		if propsErr, ok := err.(api.CloudPropertiesError); ok {
+			for _, problem := range propsErr.Problems {
+				problems = append(problems, fmt.Sprintf("network '%s': %s", name, problem))
+			}
+		} else if err != nil {
+			problems = append(problems, fmt.Sprintf("network '%s': %s", name, err.Error()))
+		}
+
+		for _, property := range unknownProperties {
+			warnings = append(warnings, fmt.Sprintf("Unknown cloud property '%s' of network '%s' is ignored", property, name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return warnings, api.NewCloudPropertiesError(problems...)
+	}
+
+	return warnings, nil
+}
+
+// decodeStrictly unmarshals every property of the JSON object data into the field of target,
+// a pointer to a struct, that has its JSON name, matched case-insensitively like encoding/json.
+// Instead of stopping at the first value of the wrong type it returns a problem naming each
+// such property, along with the paths of the properties no field takes. Both are named
+// relative to path, the path of data itself.
+func decodeStrictly(data []byte, target interface{}, path string) ([]string, []string, error) {
+	var properties map[string]json.RawMessage
+	err := json.Unmarshal(data, &properties)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	targetValue := reflect.ValueOf(target).Elem()
+	fields := jsonFields(targetValue.Type())
+
+	problems := []string{}
+	unknownProperties := []string{}
+	for _, name := range sortedKeys(properties) {
+		index, found := fields[strings.ToLower(name)]
+		if !found {
+			unknownProperties = append(unknownProperties, propertyPath(path, name))
+			continue
+		}
+
+		field := targetValue.Field(index)
+		err := json.Unmarshal(properties[name], field.Addr().Interface())
+		if err != nil {
+			problems = append(problems, describeDecodeError(propertyPath(path, name), err))
+			continue
+		}
+
+		unknownProperties = append(unknownProperties, unknownNestedProperties(properties[name], field.Type(), propertyPath(path, name))...)
+	}
+
+	return problems, unknownProperties, nil
+}
+
+func describeDecodeError(name string, err error) string {
+	typeErr, ok := err.(*json.UnmarshalTypeError)
+	if !ok {
+		return fmt.Sprintf("'%s': %s", name, err.Error())
+	}
+
+	if typeErr.Field != "" {
+		name = name + "." + typeErr.Field
+	}
+
+	return fmt.Sprintf("'%s' must be %s, got %s", name, describeType(typeErr.Type), typeErr.Value)
+}
+
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+func unknownNestedProperties(data json.RawMessage, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	unknownProperties := []string{}
+	switch t.Kind() {
+	case reflect.Struct:
+		var properties map[string]json.RawMessage
+		if json.Unmarshal(data, &properties) != nil {
+			return nil
+		}
+
+		fields := jsonFields(t)
+		for _, name := range sortedKeys(properties) {
+			index, found := fields[strings.ToLower(name)]
+			if !found {
+				unknownProperties = append(unknownProperties, propertyPath(path, name))
+				continue
+			}
+
+			unknownProperties = append(unknownProperties, unknownNestedProperties(properties[name], t.Field(index).Type, propertyPath(path, name))...)
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return nil
+		}
+
+		for i, item := range items {
+			unknownProperties = append(unknownProperties, unknownNestedProperties(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+
+	return unknownProperties
+}
+
+// jsonFields maps the lower-cased JSON names of the exported fields of a struct type to their index
+func jsonFields(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[strings.ToLower(name)] = i
+	}
+
+	return fields
+}
+
+func propertyPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func sortedKeys(properties map[string]json.RawMessage) []string {
+	names := []string{}
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
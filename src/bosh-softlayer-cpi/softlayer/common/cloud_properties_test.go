@@ -0,0 +1,117 @@
+package common_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-softlayer-cpi/api"
+	. "bosh-softlayer-cpi/softlayer/common"
+)
+
+var _ = Describe("CloudProperties", func() {
+	Describe("Network.DecodeCloudProperties", func() {
+		It("decodes the network components and the private network flag", func() {
+			network := Network{
+				Type: "dynamic",
+				CloudProperties: map[string]interface{}{
+					"PrimaryNetworkComponent": map[string]interface{}{
+						"NetworkVlan": map[string]interface{}{"Id": float64(524954)},
+					},
+					"PrimaryBackendNetworkComponent": map[string]interface{}{
+						"NetworkVlan": map[string]interface{}{"PrimarySubnetId": json.Number("1100909")},
+					},
+					"PrivateNetworkOnlyFlag": true,
+				},
+			}
+
+			cloudProps, unknownProperties, err := network.DecodeCloudProperties()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unknownProperties).To(BeEmpty())
+			Expect(cloudProps.PrimaryNetworkComponent.NetworkVlan.Id).To(Equal(524954))
+			Expect(cloudProps.PrimaryBackendNetworkComponent.NetworkVlan.PrimarySubnetId).To(Equal(1100909))
+			Expect(*cloudProps.PrivateNetworkOnlyFlag).To(BeTrue())
+		})
+
+		It("names every property of the wrong type", func() {
+			network := Network{
+				Type: "dynamic",
+				CloudProperties: map[string]interface{}{
+					"PrimaryNetworkComponent": "fake-vlan",
+					"PrivateNetworkOnlyFlag":  "true",
+				},
+			}
+
+			_, _, err := network.DecodeCloudProperties()
+			Expect(err).To(HaveOccurred())
+			Expect(err.(api.CloudPropertiesError).Problems).To(Equal([]string{
+				"'PrimaryNetworkComponent' must be an object, got string",
+				"'PrivateNetworkOnlyFlag' must be a boolean, got string",
+			}))
+		})
+
+		It("returns the properties it does not know", func() {
+			network := Network{
+				Type: "dynamic",
+				CloudProperties: map[string]interface{}{
+					"PrimaryNetworkComponent": map[string]interface{}{
+						"NetworkVlans": map[string]interface{}{"Id": float64(524954)},
+					},
+					"PrivateNetworkOnly": true,
+				},
+			}
+
+			_, unknownProperties, err := network.DecodeCloudProperties()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unknownProperties).To(Equal([]string{"PrimaryNetworkComponent.NetworkVlans", "PrivateNetworkOnly"}))
+		})
+	})
+
+	Describe("CheckCloudProperties", func() {
+		var cloudProps VMCloudProperties
+
+		It("accepts valid cloud properties", func() {
+			err := json.Unmarshal([]byte(`{"StartCpus": 2, "datacenter": {"Name": "lon02"}, "sshKeys": [{"id": 1234}]}`), &cloudProps)
+			Expect(err).NotTo(HaveOccurred())
+
+			warnings, err := CheckCloudProperties(cloudProps, Networks{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("warns about the VM cloud properties it does not know", func() {
+			err := json.Unmarshal([]byte(`{"startCpu": 2, "sshKeys": [{"id": 1234, "name": "fake-key"}]}`), &cloudProps)
+			Expect(err).NotTo(HaveOccurred())
+
+			warnings, err := CheckCloudProperties(cloudProps, Networks{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(Equal([]string{
+				"Unknown VM cloud property 'sshKeys[0].name' is ignored",
+				"Unknown VM cloud property 'startCpu' is ignored",
+			}))
+		})
+
+		It("reports the problems of the VM and of the networks together", func() {
+			err := json.Unmarshal([]byte(`{"maxMemory": "8G", "localDiskFlag": 1}`), &cloudProps)
+			Expect(err).NotTo(HaveOccurred())
+
+			networks := Networks{
+				"fake-manual": Network{Type: "manual", CloudProperties: map[string]interface{}{"PrivateNetworkOnlyFlag": "ignored"}},
+				"fake-dynamic": Network{Type: "dynamic", CloudProperties: map[string]interface{}{
+					"PrivateNetworkOnlyFlag": "yes",
+					"Vlan":                   float64(1234),
+				}},
+			}
+
+			warnings, err := CheckCloudProperties(cloudProps, networks)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(api.CloudPropertiesError).Problems).To(Equal([]string{
+				"'localDiskFlag' must be a boolean, got number",
+				"'maxMemory' must be an integer, got string",
+				"network 'fake-dynamic': 'PrivateNetworkOnlyFlag' must be a boolean, got string",
+			}))
+			Expect(warnings).To(Equal([]string{"Unknown cloud property 'Vlan' of network 'fake-dynamic' is ignored"}))
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+// FailoverDatacenter is one entry of an ordered list of datacenters in the datacenter cloud property.
+// The virtual guest is ordered in the first one with capacity for it. VLANs are specific to a
+// datacenter, so the VLANs of the entry replace the ones of the dynamic network there.
+type FailoverDatacenter struct {
+	Name                           string                                      `json:"name"`
+	PrimaryNetworkComponent        *sldatatypes.PrimaryNetworkComponent        `json:"primaryNetworkComponent,omitempty"`
+	PrimaryBackendNetworkComponent *sldatatypes.PrimaryBackendNetworkComponent `json:"primaryBackendNetworkComponent,omitempty"`
+}
+
+// ApplyTo places the virtual guest template in the datacenter on its VLANs
+func (d FailoverDatacenter) ApplyTo(template *sldatatypes.SoftLayer_Virtual_Guest_Template) {
+	template.Datacenter = sldatatypes.Datacenter{Name: d.Name}
+	template.PrimaryNetworkComponent = d.PrimaryNetworkComponent
+	template.PrimaryBackendNetworkComponent = d.PrimaryBackendNetworkComponent
+}
+
+func isJSONList(data json.RawMessage) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(data)), "[")
+}
+
+// decodeFailoverDatacenters strictly decodes the list of datacenters given in the property name
+func decodeFailoverDatacenters(name string, data json.RawMessage) ([]FailoverDatacenter, []string, []string) {
+	var items []json.RawMessage
+	err := json.Unmarshal(data, &items)
+	if err != nil {
+		return nil, []string{describeDecodeError(name, err)}, nil
+	}
+
+	if len(items) == 0 {
+		return nil, []string{fmt.Sprintf("'%s' must list at least one datacenter", name)}, nil
+	}
+
+	datacenters := []FailoverDatacenter{}
+	problems := []string{}
+	unknownProperties := []string{}
+	for i, item := range items {
+		path := fmt.Sprintf("%s[%d]", name, i)
+
+		datacenter := FailoverDatacenter{}
+		itemProblems, itemUnknownProperties, err := decodeStrictly(item, &datacenter, path)
+		if err != nil {
+			problems = append(problems, describeDecodeError(path, err))
+			continue
+		}
+		problems = append(problems, itemProblems...)
+		unknownProperties = append(unknownProperties, itemUnknownProperties...)
+
+		if datacenter.Name == "" && len(itemProblems) == 0 {
+			problems = append(problems, fmt.Sprintf("'%s.name' must be set", path))
+		}
+
+		datacenters = append(datacenters, datacenter)
+	}
+
+	return datacenters, problems, unknownProperties
+}
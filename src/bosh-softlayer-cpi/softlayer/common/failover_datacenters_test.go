@@ -0,0 +1,80 @@
+package common_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-softlayer-cpi/api"
+	. "bosh-softlayer-cpi/softlayer/common"
+
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+var _ = Describe("FailoverDatacenter", func() {
+	var cloudProps VMCloudProperties
+
+	BeforeEach(func() {
+		cloudProps = VMCloudProperties{}
+	})
+
+	It("accepts a single datacenter", func() {
+		err := json.Unmarshal([]byte(`{"Datacenter": {"Name": "lon02"}}`), &cloudProps)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cloudProps.Datacenter.Name).To(Equal("lon02"))
+		Expect(cloudProps.Datacenters).To(BeEmpty())
+	})
+
+	It("accepts an ordered list of datacenters with their own VLANs", func() {
+		err := json.Unmarshal([]byte(`{"Datacenter": [
+			{"Name": "lon02", "PrimaryBackendNetworkComponent": {"NetworkVlan": {"Id": 524956}}},
+			{"Name": "ams01"}
+		]}`), &cloudProps)
+		Expect(err).NotTo(HaveOccurred())
+
+		warnings, err := CheckCloudProperties(cloudProps, Networks{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+
+		Expect(cloudProps.Datacenter.Name).To(Equal("lon02"))
+		Expect(cloudProps.Datacenters).To(HaveLen(2))
+		Expect(cloudProps.Datacenters[0].PrimaryBackendNetworkComponent.NetworkVlan.Id).To(Equal(524956))
+		Expect(cloudProps.Datacenters[1].Name).To(Equal("ams01"))
+	})
+
+	It("reports the problems of the listed datacenters", func() {
+		err := json.Unmarshal([]byte(`{"datacenter": [{"name": 3}, {"vlan": 1234}, "ams01"]}`), &cloudProps)
+		Expect(err).NotTo(HaveOccurred())
+
+		warnings, err := CheckCloudProperties(cloudProps, Networks{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(api.CloudPropertiesError).Problems).To(Equal([]string{
+			"'datacenter[0].name' must be a string, got number",
+			"'datacenter[1].name' must be set",
+			"'datacenter[2]' must be an object, got string",
+		}))
+		Expect(warnings).To(Equal([]string{"Unknown VM cloud property 'datacenter[1].vlan' is ignored"}))
+	})
+
+	Describe("ApplyTo", func() {
+		It("places the template in the datacenter on its VLANs", func() {
+			template := sldatatypes.SoftLayer_Virtual_Guest_Template{
+				Datacenter:              sldatatypes.Datacenter{Name: "lon02"},
+				PrimaryNetworkComponent: &sldatatypes.PrimaryNetworkComponent{NetworkVlan: sldatatypes.NetworkVlan{Id: 1234}},
+			}
+
+			datacenter := FailoverDatacenter{
+				Name: "ams01",
+				PrimaryBackendNetworkComponent: &sldatatypes.PrimaryBackendNetworkComponent{
+					NetworkVlan: sldatatypes.NetworkVlan{Id: 5678},
+				},
+			}
+			datacenter.ApplyTo(&template)
+
+			Expect(template.Datacenter.Name).To(Equal("ams01"))
+			Expect(template.PrimaryNetworkComponent).To(BeNil())
+			Expect(template.PrimaryBackendNetworkComponent.NetworkVlan.Id).To(Equal(5678))
+		})
+	})
+})
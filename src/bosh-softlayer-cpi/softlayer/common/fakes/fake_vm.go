@@ -10,13 +10,14 @@ import (
 )
 
 type FakeVM struct {
-	AttachDiskStub        func(disk.Disk) error
+	AttachDiskStub        func(disk.Disk) (interface{}, error)
 	attachDiskMutex       sync.RWMutex
 	attachDiskArgsForCall []struct {
 		arg1 disk.Disk
 	}
 	attachDiskReturns struct {
-		result1 error
+		result1 interface{}
+		result2 error
 	}
 	ConfigureNetworksStub        func(common.Networks) error
 	configureNetworksMutex       sync.RWMutex
@@ -42,6 +43,14 @@ type FakeVM struct {
 	detachDiskReturns struct {
 		result1 error
 	}
+	RescanDiskStub        func(disk.Disk) error
+	rescanDiskMutex       sync.RWMutex
+	rescanDiskArgsForCall []struct {
+		arg1 disk.Disk
+	}
+	rescanDiskReturns struct {
+		result1 error
+	}
 	DeleteStub        func(agentId string) error
 	deleteMutex       sync.RWMutex
 	deleteArgsForCall []struct {
@@ -80,6 +89,12 @@ type FakeVM struct {
 	getFullyQualifiedDomainNameReturns     struct {
 		result1 string
 	}
+	GetAllowedHostTypeStub        func() string
+	getAllowedHostTypeMutex       sync.RWMutex
+	getAllowedHostTypeArgsForCall []struct{}
+	getAllowedHostTypeReturns     struct {
+		result1 string
+	}
 	IDStub        func() int
 	iDMutex       sync.RWMutex
 	iDArgsForCall []struct{}
@@ -151,7 +166,7 @@ type FakeVM struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeVM) AttachDisk(arg1 disk.Disk) error {
+func (fake *FakeVM) AttachDisk(arg1 disk.Disk) (interface{}, error) {
 	fake.attachDiskMutex.Lock()
 	fake.attachDiskArgsForCall = append(fake.attachDiskArgsForCall, struct {
 		arg1 disk.Disk
@@ -161,7 +176,7 @@ func (fake *FakeVM) AttachDisk(arg1 disk.Disk) error {
 	if fake.AttachDiskStub != nil {
 		return fake.AttachDiskStub(arg1)
 	} else {
-		return fake.attachDiskReturns.result1
+		return fake.attachDiskReturns.result1, fake.attachDiskReturns.result2
 	}
 }
 
@@ -177,11 +192,12 @@ func (fake *FakeVM) AttachDiskArgsForCall(i int) disk.Disk {
 	return fake.attachDiskArgsForCall[i].arg1
 }
 
-func (fake *FakeVM) AttachDiskReturns(result1 error) {
+func (fake *FakeVM) AttachDiskReturns(result1 interface{}, result2 error) {
 	fake.AttachDiskStub = nil
 	fake.attachDiskReturns = struct {
-		result1 error
-	}{result1}
+		result1 interface{}
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeVM) ConfigureNetworks(arg1 common.Networks) error {
@@ -283,6 +299,39 @@ func (fake *FakeVM) DetachDiskReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeVM) RescanDisk(arg1 disk.Disk) error {
+	fake.rescanDiskMutex.Lock()
+	fake.rescanDiskArgsForCall = append(fake.rescanDiskArgsForCall, struct {
+		arg1 disk.Disk
+	}{arg1})
+	fake.recordInvocation("RescanDisk", []interface{}{arg1})
+	fake.rescanDiskMutex.Unlock()
+	if fake.RescanDiskStub != nil {
+		return fake.RescanDiskStub(arg1)
+	} else {
+		return fake.rescanDiskReturns.result1
+	}
+}
+
+func (fake *FakeVM) RescanDiskCallCount() int {
+	fake.rescanDiskMutex.RLock()
+	defer fake.rescanDiskMutex.RUnlock()
+	return len(fake.rescanDiskArgsForCall)
+}
+
+func (fake *FakeVM) RescanDiskArgsForCall(i int) disk.Disk {
+	fake.rescanDiskMutex.RLock()
+	defer fake.rescanDiskMutex.RUnlock()
+	return fake.rescanDiskArgsForCall[i].arg1
+}
+
+func (fake *FakeVM) RescanDiskReturns(result1 error) {
+	fake.RescanDiskStub = nil
+	fake.rescanDiskReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeVM) Delete(agentId string) error {
 	fake.deleteMutex.Lock()
 	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
@@ -441,6 +490,31 @@ func (fake *FakeVM) GetFullyQualifiedDomainNameReturns(result1 string) {
 	}{result1}
 }
 
+func (fake *FakeVM) GetAllowedHostType() string {
+	fake.getAllowedHostTypeMutex.Lock()
+	fake.getAllowedHostTypeArgsForCall = append(fake.getAllowedHostTypeArgsForCall, struct{}{})
+	fake.recordInvocation("GetAllowedHostType", []interface{}{})
+	fake.getAllowedHostTypeMutex.Unlock()
+	if fake.GetAllowedHostTypeStub != nil {
+		return fake.GetAllowedHostTypeStub()
+	} else {
+		return fake.getAllowedHostTypeReturns.result1
+	}
+}
+
+func (fake *FakeVM) GetAllowedHostTypeCallCount() int {
+	fake.getAllowedHostTypeMutex.RLock()
+	defer fake.getAllowedHostTypeMutex.RUnlock()
+	return len(fake.getAllowedHostTypeArgsForCall)
+}
+
+func (fake *FakeVM) GetAllowedHostTypeReturns(result1 string) {
+	fake.GetAllowedHostTypeStub = nil
+	fake.getAllowedHostTypeReturns = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeVM) ID() int {
 	fake.iDMutex.Lock()
 	fake.iDArgsForCall = append(fake.iDArgsForCall, struct{}{})
@@ -726,6 +800,8 @@ func (fake *FakeVM) Invocations() map[string][][]interface{} {
 	defer fake.configureNetworks2Mutex.RUnlock()
 	fake.detachDiskMutex.RLock()
 	defer fake.detachDiskMutex.RUnlock()
+	fake.rescanDiskMutex.RLock()
+	defer fake.rescanDiskMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
 	fake.getDataCenterIdMutex.RLock()
@@ -738,6 +814,8 @@ func (fake *FakeVM) Invocations() map[string][][]interface{} {
 	defer fake.getRootPasswordMutex.RUnlock()
 	fake.getFullyQualifiedDomainNameMutex.RLock()
 	defer fake.getFullyQualifiedDomainNameMutex.RUnlock()
+	fake.getAllowedHostTypeMutex.RLock()
+	defer fake.getAllowedHostTypeMutex.RUnlock()
 	fake.iDMutex.RLock()
 	defer fake.iDMutex.RUnlock()
 	fake.rebootMutex.RLock()
@@ -0,0 +1,84 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"bosh-softlayer-cpi/softlayer/common"
+	"bosh-softlayer-cpi/softlayer/common/helper"
+	"bosh-softlayer-cpi/softlayer/stemcell"
+)
+
+type FakeVMQuoter struct {
+	QuoteStub        func(stemcell.Stemcell, common.VMCloudProperties, common.Networks) (helper.OrderQuote, error)
+	quoteMutex       sync.RWMutex
+	quoteArgsForCall []struct {
+		arg1 stemcell.Stemcell
+		arg2 common.VMCloudProperties
+		arg3 common.Networks
+	}
+	quoteReturns struct {
+		result1 helper.OrderQuote
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeVMQuoter) Quote(arg1 stemcell.Stemcell, arg2 common.VMCloudProperties, arg3 common.Networks) (helper.OrderQuote, error) {
+	fake.quoteMutex.Lock()
+	fake.quoteArgsForCall = append(fake.quoteArgsForCall, struct {
+		arg1 stemcell.Stemcell
+		arg2 common.VMCloudProperties
+		arg3 common.Networks
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("Quote", []interface{}{arg1, arg2, arg3})
+	fake.quoteMutex.Unlock()
+	if fake.QuoteStub != nil {
+		return fake.QuoteStub(arg1, arg2, arg3)
+	} else {
+		return fake.quoteReturns.result1, fake.quoteReturns.result2
+	}
+}
+
+func (fake *FakeVMQuoter) QuoteCallCount() int {
+	fake.quoteMutex.RLock()
+	defer fake.quoteMutex.RUnlock()
+	return len(fake.quoteArgsForCall)
+}
+
+func (fake *FakeVMQuoter) QuoteArgsForCall(i int) (stemcell.Stemcell, common.VMCloudProperties, common.Networks) {
+	fake.quoteMutex.RLock()
+	defer fake.quoteMutex.RUnlock()
+	return fake.quoteArgsForCall[i].arg1, fake.quoteArgsForCall[i].arg2, fake.quoteArgsForCall[i].arg3
+}
+
+func (fake *FakeVMQuoter) QuoteReturns(result1 helper.OrderQuote, result2 error) {
+	fake.QuoteStub = nil
+	fake.quoteReturns = struct {
+		result1 helper.OrderQuote
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVMQuoter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.quoteMutex.RLock()
+	defer fake.quoteMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeVMQuoter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ common.VMQuoter = new(FakeVMQuoter)
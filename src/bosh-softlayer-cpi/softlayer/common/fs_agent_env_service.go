@@ -2,11 +2,8 @@ package common
 
 import (
 	"encoding/json"
-	"os"
-	"strconv"
 	"time"
 
-	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -20,6 +17,8 @@ type fsAgentEnvService struct {
 	vm                   VM
 	softlayerFileService SoftlayerFileService
 	settingsPath         string
+	retryCount           int
+	waitTime             time.Duration
 	logger               boshlog.Logger
 	logTag               string
 }
@@ -27,12 +26,15 @@ type fsAgentEnvService struct {
 func NewFSAgentEnvService(
 	vm VM,
 	softlayerFileService SoftlayerFileService,
+	options RuntimeOptions,
 	logger boshlog.Logger,
 ) AgentEnvService {
 	return &fsAgentEnvService{
 		vm:                   vm,
 		softlayerFileService: softlayerFileService,
 		settingsPath:         "/var/vcap/bosh/user_data.json",
+		retryCount:           options.UpdateAgentEnvRetryCount,
+		waitTime:             options.UpdateAgentEnvWaitTime,
 		logger:               logger,
 		logTag:               "FSAgentEnvService",
 	}
@@ -64,27 +66,18 @@ func (s *fsAgentEnvService) Update(agentEnv AgentEnv) error {
 		return bosherr.WrapError(err, "Marshalling agent env")
 	}
 
-	SL_CPI_WAIT_TIME_UPDATE_AGENT_ENV, err := strconv.Atoi(os.Getenv("SL_CPI_WAIT_TIME_UPDATE_AGENT_ENV"))
-	if err != nil || SL_CPI_WAIT_TIME_UPDATE_AGENT_ENV == 0 {
-		SL_CPI_WAIT_TIME_UPDATE_AGENT_ENV = 5
-	}
-	SL_CPI_RETRY_COUNT_UPDATE_AGENT_ENV, err := strconv.Atoi(os.Getenv("SL_CPI_RETRY_COUNT_UPDATE_AGENT_ENV"))
-	if err != nil || SL_CPI_RETRY_COUNT_UPDATE_AGENT_ENV == 0 {
-		SL_CPI_RETRY_COUNT_UPDATE_AGENT_ENV = 5
-	}
-
-	for i := 0; i < SL_CPI_RETRY_COUNT_UPDATE_AGENT_ENV; i++ {
+	for i := 0; i < s.retryCount; i++ {
 		s.logger.Debug(s.logTag, "Updating Agent Env: Making attempt #%d", i)
 		err = s.softlayerFileService.Upload(ROOT_USER_NAME, s.vm.GetRootPassword(), s.vm.GetPrimaryBackendIP(), s.settingsPath, jsonBytes)
 		if err == nil {
 			return nil
 		}
-		time.Sleep(time.Duration(SL_CPI_WAIT_TIME_UPDATE_AGENT_ENV) * time.Second)
+		time.Sleep(s.waitTime)
 	}
 
 	// Add this warning message due to bosh-softlayer-cpi issues #129, may remove this piece of code when we identify the real root cause
 	var longHostNameWarningMsg string
-	if slh.LengthOfHostName > 63 {
+	if len(s.vm.GetFullyQualifiedDomainName()) > 63 {
 		longHostNameWarningMsg = "Notice that the length of device hostname is greater than 63 characters, which might cause SSH service setup improperly by SoftLayer, please confirm with SoftLayer or consider to shorten the hostname"
 	}
 
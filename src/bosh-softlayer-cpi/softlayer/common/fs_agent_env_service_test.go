@@ -11,7 +11,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
-	"os"
+	"time"
 
 	. "bosh-softlayer-cpi/softlayer/common"
 )
@@ -27,7 +27,10 @@ var _ = Describe("SoftlayerAgentEnvService", func() {
 		fakeSoftlayerFileService = fakebslvm.NewFakeSoftlayerFileService()
 		fakevm = &fakebslvm.FakeVM{}
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		agentEnvService = NewFSAgentEnvService(fakevm, fakeSoftlayerFileService, logger)
+		runtimeOptions := DefaultRuntimeOptions()
+		runtimeOptions.UpdateAgentEnvRetryCount = 2
+		runtimeOptions.UpdateAgentEnvWaitTime = time.Millisecond
+		agentEnvService = NewFSAgentEnvService(fakevm, fakeSoftlayerFileService, runtimeOptions, logger)
 	})
 
 	Describe("Fetch", func() {
@@ -87,8 +90,6 @@ var _ = Describe("SoftlayerAgentEnvService", func() {
 			var err error
 			expectedAgentEnvBytes, err = json.Marshal(newAgentEnv)
 			Expect(err).ToNot(HaveOccurred())
-			os.Setenv("SL_CPI_RETRY_COUNT_UPDATE_AGENT_ENV", "2")
-			os.Setenv("SL_CPI_WAIT_TIME_UPDATE_AGENT_ENV", "1")
 		})
 
 		It("uploads file contents to the warden container", func() {
@@ -106,6 +107,11 @@ var _ = Describe("SoftlayerAgentEnvService", func() {
 				err := agentEnvService.Update(newAgentEnv)
 				Expect(err).To(HaveOccurred())
 			})
+
+			It("retries the upload as many times as configured", func() {
+				agentEnvService.Update(newAgentEnv)
+				Expect(len(fakeSoftlayerFileService.UploadInputs)).To(Equal(2))
+			})
 		})
 	})
 })
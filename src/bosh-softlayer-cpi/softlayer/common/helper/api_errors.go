@@ -0,0 +1,138 @@
+package helper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// APIErrorClass tells how a failed SoftLayer API request may be handled
+type APIErrorClass string
+
+const (
+	// APIErrorNotFound means the object the request names does not exist
+	APIErrorNotFound APIErrorClass = "not_found"
+	// APIErrorThrottled means SoftLayer rejected the request for exceeding the rate limit
+	APIErrorThrottled APIErrorClass = "throttled"
+	// APIErrorTransient means the request did not reach SoftLayer or got no answer
+	APIErrorTransient APIErrorClass = "transient"
+	// APIErrorConflict means a transaction running on the object blocks the request for now
+	APIErrorConflict APIErrorClass = "conflict"
+	// APIErrorPermanent means the request fails the same way however often it is sent
+	APIErrorPermanent APIErrorClass = "permanent"
+)
+
+// Retryable tells whether sending the request again later may succeed
+func (c APIErrorClass) Retryable() bool {
+	return c == APIErrorThrottled || c == APIErrorTransient || c == APIErrorConflict
+}
+
+// APIError is a SoftLayer API request that failed, along with its class
+type APIError struct {
+	Class      APIErrorClass
+	StatusCode int
+
+	cause error
+}
+
+func NewAPIError(class APIErrorClass, statusCode int, cause error) APIError {
+	return APIError{Class: class, StatusCode: statusCode, cause: cause}
+}
+
+// Error returns the message of the failure unchanged
+func (e APIError) Error() string  { return e.cause.Error() }
+func (e APIError) CanRetry() bool { return e.Class.Retryable() }
+
+// Status code softlayer-go reports when the request failed before SoftLayer answered it
+const noResponseStatusCode = 520
+
+var (
+	httpErrorCodePattern = regexp.MustCompile(`HTTP error code: '(\d+)'`)
+
+	notFoundMessages = []string{
+		"softlayer_exception_objectnotfound",
+		"unable to find object",
+	}
+	throttledMessages = []string{
+		"rate limit",
+		"too many requests",
+	}
+	conflictMessages = []string{
+		"please try again after",
+		"transaction is in progress",
+		"outstanding transaction",
+	}
+	transientMessages = []string{
+		"i/o timeout",
+		"connection refused",
+		"connection reset by peer",
+		"tls handshake timeout",
+		"unexpected eof",
+		"no such host",
+	}
+)
+
+// ClassifyAPIError classifies an error returned by a SoftLayer service, looking through the errors
+// wrapping it. Services turn most failed requests into a message naming the HTTP status code,
+// so the class of those is told from the message of the innermost cause.
+func ClassifyAPIError(err error) APIErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	cause := err
+	for {
+		if apiErr, ok := cause.(APIError); ok {
+			return apiErr.Class
+		}
+
+		complexErr, ok := cause.(bosherr.ComplexError)
+		if !ok {
+			break
+		}
+		cause = complexErr.Cause
+	}
+
+	statusCode := 0
+	if match := httpErrorCodePattern.FindStringSubmatch(cause.Error()); match != nil {
+		statusCode, _ = strconv.Atoi(match[1])
+	}
+
+	return ClassifyAPIResponse(statusCode, cause.Error())
+}
+
+// ClassifyAPIResponse classifies a failed request by its HTTP status code, 0 when unknown, and the
+// error message or response body
+func ClassifyAPIResponse(statusCode int, message string) APIErrorClass {
+	message = strings.ToLower(message)
+
+	switch {
+	case statusCode == 404 || containsAny(message, notFoundMessages):
+		return APIErrorNotFound
+	case statusCode == 429 || containsAny(message, throttledMessages):
+		return APIErrorThrottled
+	case statusCode == 409 || containsAny(message, conflictMessages):
+		return APIErrorConflict
+	case statusCode == noResponseStatusCode || statusCode == 502 || statusCode == 503 || statusCode == 504 || containsAny(message, transientMessages):
+		return APIErrorTransient
+	default:
+		return APIErrorPermanent
+	}
+}
+
+// IsAPIErrorRetryable tells whether the failed SoftLayer API request may succeed later
+func IsAPIErrorRetryable(err error) bool {
+	return ClassifyAPIError(err).Retryable()
+}
+
+func containsAny(message string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(message, substring) {
+			return true
+		}
+	}
+
+	return false
+}
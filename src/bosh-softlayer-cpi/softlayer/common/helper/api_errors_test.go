@@ -0,0 +1,55 @@
+package helper_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+var _ = Describe("APIErrors", func() {
+	Describe("ClassifyAPIError", func() {
+		It("tells the class from the HTTP error code in the message", func() {
+			Expect(slh.ClassifyAPIError(errors.New("softlayer-go: could not SoftLayer_Virtual_Guest#getObject, HTTP error code: '404'"))).To(Equal(slh.APIErrorNotFound))
+			Expect(slh.ClassifyAPIError(errors.New("softlayer-go: could not SoftLayer_Virtual_Guest#getObject, HTTP error code: '429'"))).To(Equal(slh.APIErrorThrottled))
+			Expect(slh.ClassifyAPIError(errors.New("softlayer-go: could not SoftLayer_Virtual_Guest#getObject, HTTP error code: '503'"))).To(Equal(slh.APIErrorTransient))
+			Expect(slh.ClassifyAPIError(errors.New("softlayer-go: could not SoftLayer_Virtual_Guest#getObject, HTTP error code: '401'"))).To(Equal(slh.APIErrorPermanent))
+		})
+
+		It("tells the class from the message SoftLayer returned", func() {
+			Expect(slh.ClassifyAPIError(errors.New("SoftLayer_Exception_ObjectNotFound: Unable to find object with id of '1234567'."))).To(Equal(slh.APIErrorNotFound))
+			Expect(slh.ClassifyAPIError(errors.New("Rate limit exceeded, please slow down"))).To(Equal(slh.APIErrorThrottled))
+			Expect(slh.ClassifyAPIError(errors.New("Unable to attach, please try again after Volume Provisioning is complete"))).To(Equal(slh.APIErrorConflict))
+			Expect(slh.ClassifyAPIError(errors.New("dial tcp 66.228.119.120:443: connection refused"))).To(Equal(slh.APIErrorTransient))
+			Expect(slh.ClassifyAPIError(errors.New("Invalid value provided for startCpus."))).To(Equal(slh.APIErrorPermanent))
+		})
+
+		It("looks through the errors wrapping it", func() {
+			err := bosherr.WrapError(slh.NewAPIError(slh.APIErrorConflict, 500, errors.New("fake-error")), "Deleting VirtualGuest")
+			Expect(slh.ClassifyAPIError(err)).To(Equal(slh.APIErrorConflict))
+		})
+
+		It("ignores the messages wrapping it", func() {
+			err := bosherr.WrapError(errors.New("fake-error"), "Waiting for the rate limit")
+			Expect(slh.ClassifyAPIError(err)).To(Equal(slh.APIErrorPermanent))
+		})
+
+		It("does not classify nil", func() {
+			Expect(slh.ClassifyAPIError(nil)).To(BeEmpty())
+			Expect(slh.IsAPIErrorRetryable(nil)).To(BeFalse())
+		})
+	})
+
+	Describe("APIError", func() {
+		It("keeps the message and tells whether it can be retried", func() {
+			err := slh.NewAPIError(slh.APIErrorThrottled, 429, errors.New("fake-error"))
+			Expect(err.Error()).To(Equal("fake-error"))
+			Expect(err.CanRetry()).To(BeTrue())
+			Expect(slh.NewAPIError(slh.APIErrorNotFound, 404, errors.New("fake-error")).CanRetry()).To(BeFalse())
+		})
+	})
+})
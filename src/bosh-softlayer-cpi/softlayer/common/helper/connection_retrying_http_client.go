@@ -0,0 +1,92 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+const connectionRetryingHttpClientLogTag = "ConnectionRetryingHttpClient"
+
+// Errors of a request that never reached SoftLayer, which softlayer-go retries on its own as well
+var connectionErrorMessages = []string{
+	"i/o timeout",
+	"connection refused",
+	"connection reset by peer",
+}
+
+// ConnectionRetryingHttpClient sits in front of the HTTP client of the SoftLayer services and sends
+// a request that could not reach SoftLayer again, making up to retryCount attempts waitTime apart.
+// It carries out the ApiRetryCount and ApiWaitTime runtime options, which softlayer-go only reads
+// from SL_API_RETRY_COUNT and SL_API_WAIT_TIME in the environment.
+type ConnectionRetryingHttpClient struct {
+	sl.HttpClient
+
+	retryCount int
+	waitTime   time.Duration
+	sleep      func(time.Duration)
+	logger     boshlog.Logger
+}
+
+func NewConnectionRetryingHttpClient(httpClient sl.HttpClient, retryCount int, waitTime time.Duration, logger boshlog.Logger) *ConnectionRetryingHttpClient {
+	return NewConnectionRetryingHttpClientWithSleep(httpClient, retryCount, waitTime, time.Sleep, logger)
+}
+
+func NewConnectionRetryingHttpClientWithSleep(httpClient sl.HttpClient, retryCount int, waitTime time.Duration, sleep func(time.Duration), logger boshlog.Logger) *ConnectionRetryingHttpClient {
+	return &ConnectionRetryingHttpClient{
+		HttpClient: httpClient,
+		retryCount: retryCount,
+		waitTime:   waitTime,
+		sleep:      sleep,
+		logger:     logger,
+	}
+}
+
+func (c *ConnectionRetryingHttpClient) DoRawHttpRequest(path string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequest(path, requestType, body)
+	})
+}
+
+func (c *ConnectionRetryingHttpClient) DoRawHttpRequestWithObjectMask(path string, masks []string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequestWithObjectMask(path, masks, requestType, body)
+	})
+}
+
+func (c *ConnectionRetryingHttpClient) DoRawHttpRequestWithObjectFilter(path string, filters string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequestWithObjectFilter(path, filters, requestType, body)
+	})
+}
+
+func (c *ConnectionRetryingHttpClient) DoRawHttpRequestWithObjectFilterAndObjectMask(path string, masks []string, filters string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequestWithObjectFilterAndObjectMask(path, masks, filters, requestType, body)
+	})
+}
+
+func (c *ConnectionRetryingHttpClient) do(path string, requestType string, requestBody *bytes.Buffer, request func(*bytes.Buffer) ([]byte, int, error)) ([]byte, int, error) {
+	// The HTTP client drains the request body, so every attempt sends a copy of it
+	var body []byte
+	if requestBody != nil {
+		body = append([]byte{}, requestBody.Bytes()...)
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, statusCode, err := request(bytes.NewBuffer(append([]byte{}, body...)))
+		if err == nil || attempt >= c.retryCount || !isConnectionError(err) {
+			return response, statusCode, err
+		}
+
+		c.logger.Warn(connectionRetryingHttpClientLogTag, fmt.Sprintf("%s %s could not reach SoftLayer, retrying in %s (attempt %d of %d): %s", requestType, path, c.waitTime, attempt, c.retryCount, err))
+		c.sleep(c.waitTime)
+	}
+}
+
+func isConnectionError(err error) bool {
+	return containsAny(err.Error(), connectionErrorMessages)
+}
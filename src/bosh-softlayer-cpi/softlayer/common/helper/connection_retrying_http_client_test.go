@@ -0,0 +1,61 @@
+package helper_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+var _ = Describe("ConnectionRetryingHttpClient", func() {
+	var (
+		fakeHttpClient *fakeslclient.FakeHttpClient
+		sleeps         []time.Duration
+		client         *slh.ConnectionRetryingHttpClient
+	)
+
+	BeforeEach(func() {
+		fakeHttpClient = fakeslclient.NewFakeHttpClient("fake-username", "fake-api-key")
+		sleeps = []time.Duration{}
+		client = slh.NewConnectionRetryingHttpClientWithSleep(fakeHttpClient, 3, 2*time.Second, func(d time.Duration) { sleeps = append(sleeps, d) }, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("sends a request that could not reach SoftLayer again up to the retry count, waiting in between", func() {
+		fakeHttpClient.DoRawHttpRequestInt = 520
+		fakeHttpClient.DoRawHttpRequestError = errors.New("dial tcp: connection refused")
+
+		_, _, err := client.DoRawHttpRequest("SoftLayer_Virtual_Guest/createObject.json", "POST", bytes.NewBufferString(`{"fake": "body"}`))
+		Expect(err).To(MatchError("dial tcp: connection refused"))
+		Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(3))
+		Expect(fakeHttpClient.DoRawHttpRequestRequestBody.String()).To(Equal(`{"fake": "body"}`))
+		Expect(sleeps).To(Equal([]time.Duration{2 * time.Second, 2 * time.Second}))
+	})
+
+	It("returns the response of a request that reached SoftLayer", func() {
+		fakeHttpClient.DoRawHttpRequestResponse = []byte(`{"error": "Invalid value provided for startCpus."}`)
+		fakeHttpClient.DoRawHttpRequestInt = 500
+
+		response, statusCode, err := client.DoRawHttpRequestWithObjectMask("SoftLayer_Virtual_Guest/1234567/getObject.json", []string{"id"}, "GET", new(bytes.Buffer))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusCode).To(Equal(500))
+		Expect(string(response)).To(Equal(`{"error": "Invalid value provided for startCpus."}`))
+		Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(1))
+		Expect(sleeps).To(BeEmpty())
+	})
+
+	It("does not retry other errors", func() {
+		fakeHttpClient.DoRawHttpRequestError = errors.New("fake-error")
+
+		_, _, err := client.DoRawHttpRequestWithObjectFilter("SoftLayer_Account/getVirtualGuests.json", "{}", "GET", new(bytes.Buffer))
+		Expect(err).To(MatchError("fake-error"))
+		Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(1))
+	})
+})
@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+type OrderQuoteItem struct {
+	Category    string  `json:"category"`
+	Description string  `json:"description"`
+	Hourly      float64 `json:"hourly"`
+	Monthly     float64 `json:"monthly"`
+}
+
+// OrderQuote is the itemized price SoftLayer returned for an order it
+// verified without placing it.
+type OrderQuote struct {
+	Items   []OrderQuoteItem `json:"items"`
+	Hourly  float64          `json:"hourly"`
+	Monthly float64          `json:"monthly"`
+}
+
+// VerifiedOrder is the SoftLayer_Container_Product_Order SoftLayer_Product_Order#verifyOrder returns,
+// with the fees of its prices, which the SoftLayer client has no fields for
+type VerifiedOrder struct {
+	Prices []VerifiedOrderPrice `json:"prices"`
+}
+
+type VerifiedOrderPrice struct {
+	Id                 int                  `json:"id"`
+	Item               *datatypes.Item      `json:"item,omitempty"`
+	Categories         []datatypes.Category `json:"categories,omitempty"`
+	HourlyRecurringFee string               `json:"hourlyRecurringFee,omitempty"`
+	RecurringFee       string               `json:"recurringFee,omitempty"`
+}
+
+func NewOrderQuote(verifiedOrder VerifiedOrder) (OrderQuote, error) {
+	quote := OrderQuote{Items: []OrderQuoteItem{}}
+
+	for _, price := range verifiedOrder.Prices {
+		item := OrderQuoteItem{}
+
+		if price.Item != nil {
+			item.Description = price.Item.Description
+		}
+		if len(price.Categories) > 0 {
+			item.Category = price.Categories[0].CategoryCode
+		}
+
+		var err error
+		item.Hourly, err = parseFee(price.HourlyRecurringFee)
+		if err != nil {
+			return OrderQuote{}, bosherr.WrapErrorf(err, "Parsing hourly fee of item price `%d`", price.Id)
+		}
+
+		item.Monthly, err = parseFee(price.RecurringFee)
+		if err != nil {
+			return OrderQuote{}, bosherr.WrapErrorf(err, "Parsing monthly fee of item price `%d`", price.Id)
+		}
+
+		quote.Items = append(quote.Items, item)
+		quote.Hourly += item.Hourly
+		quote.Monthly += item.Monthly
+	}
+
+	return quote, nil
+}
+
+// SoftLayer returns fees as decimal strings and leaves them out for free items.
+func parseFee(fee string) (float64, error) {
+	if strings.TrimSpace(fee) == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(fee, 64)
+}
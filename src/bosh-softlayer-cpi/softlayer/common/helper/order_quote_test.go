@@ -0,0 +1,52 @@
+package helper_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+var _ = Describe("OrderQuote", func() {
+	var verifiedOrder slh.VerifiedOrder
+
+	BeforeEach(func() {
+		verifiedOrder = slh.VerifiedOrder{
+			Prices: []slh.VerifiedOrderPrice{
+				{
+					Id:                 1640,
+					Item:               &datatypes.Item{Description: "2 x 2.0 GHz Cores"},
+					Categories:         []datatypes.Category{{CategoryCode: "guest_core"}},
+					HourlyRecurringFee: ".05",
+					RecurringFee:       "32.5",
+				},
+				{
+					Id:         905,
+					Item:       &datatypes.Item{Description: "Reboot / Remote Console"},
+					Categories: []datatypes.Category{{CategoryCode: "remote_management"}},
+				},
+			},
+		}
+	})
+
+	It("itemizes and sums the hourly and monthly fees", func() {
+		quote, err := slh.NewOrderQuote(verifiedOrder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(quote.Items).To(Equal([]slh.OrderQuoteItem{
+			{Category: "guest_core", Description: "2 x 2.0 GHz Cores", Hourly: 0.05, Monthly: 32.5},
+			{Category: "remote_management", Description: "Reboot / Remote Console"},
+		}))
+		Expect(quote.Hourly).To(BeNumerically("~", 0.05))
+		Expect(quote.Monthly).To(BeNumerically("~", 32.5))
+	})
+
+	It("returns an error when a fee is not a number", func() {
+		verifiedOrder.Prices[0].RecurringFee = "free"
+
+		_, err := slh.NewOrderQuote(verifiedOrder)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("1640"))
+	})
+})
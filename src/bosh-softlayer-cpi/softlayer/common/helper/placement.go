@@ -0,0 +1,231 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+// The placement group rule that puts every virtual guest of a group on a different host
+const spreadPlacementGroupRule = "SPREAD"
+
+const PLACEMENT_LOG_TAG = "Placement"
+
+// DedicatedHost is a SoftLayer_Virtual_DedicatedHost, which the SoftLayer client has no type for.
+// A virtual guest template refers to one by its id alone.
+type DedicatedHost struct {
+	Id         int                           `json:"id"`
+	Name       string                        `json:"name,omitempty"`
+	Datacenter *datatypes.SoftLayer_Location `json:"datacenter,omitempty"`
+}
+
+// PlacementGroup is a SoftLayer_Virtual_PlacementGroup, which the SoftLayer client has no type for
+type PlacementGroup struct {
+	Id              int    `json:"id,omitempty"`
+	Name            string `json:"name"`
+	BackendRouterId int    `json:"backendRouterId"`
+	RuleId          int    `json:"ruleId"`
+	GuestCount      int    `json:"guestCount,omitempty"`
+}
+
+type placementGroupRule struct {
+	Id      int    `json:"id"`
+	KeyName string `json:"keyName"`
+	Name    string `json:"name"`
+}
+
+// FindDedicatedHostByName looks up the dedicated host of the account with the name
+func FindDedicatedHostByName(softLayerClient sl.Client, name string) (DedicatedHost, error) {
+	filter, err := nameFilter("dedicatedHosts", name)
+	if err != nil {
+		return DedicatedHost{}, err
+	}
+
+	dedicatedHosts := []DedicatedHost{}
+	err = getAccountObjects(softLayerClient, "getDedicatedHosts", []string{"id", "name", "datacenter.name"}, filter, &dedicatedHosts)
+	if err != nil {
+		return DedicatedHost{}, bosherr.WrapErrorf(err, "Getting dedicated hosts named `%s`", name)
+	}
+
+	if len(dedicatedHosts) == 0 {
+		return DedicatedHost{}, bosherr.Errorf("Dedicated host `%s` does not exist", name)
+	}
+
+	return dedicatedHosts[0], nil
+}
+
+// FindPlacementGroupByName looks up the placement group of the account with the name. Of several
+// groups with the name, it returns the one with the lowest id, as CreatePlacementGroup keeps.
+func FindPlacementGroupByName(softLayerClient sl.Client, name string) (PlacementGroup, bool, error) {
+	filter, err := nameFilter("placementGroups", name)
+	if err != nil {
+		return PlacementGroup{}, false, err
+	}
+
+	placementGroups := []PlacementGroup{}
+	err = getAccountObjects(softLayerClient, "getPlacementGroups", []string{"id", "name", "backendRouterId", "ruleId", "guestCount"}, filter, &placementGroups)
+	if err != nil {
+		return PlacementGroup{}, false, bosherr.WrapErrorf(err, "Getting placement groups named `%s`", name)
+	}
+
+	if len(placementGroups) == 0 {
+		return PlacementGroup{}, false, nil
+	}
+
+	lowest := placementGroups[0]
+	for _, placementGroup := range placementGroups[1:] {
+		if placementGroup.Id < lowest.Id {
+			lowest = placementGroup
+		}
+	}
+
+	return lowest, true, nil
+}
+
+// CreatePlacementGroup creates a placement group that spreads its virtual guests over the hosts
+// behind the first backend router of the datacenter. When create_vm calls for other instances
+// created a group with the name at the same time, all of them keep the one with the lowest id,
+// and delete the group they created otherwise.
+func CreatePlacementGroup(softLayerClient sl.Client, name string, datacenter string, logger boshlog.Logger) (PlacementGroup, error) {
+	rules := []placementGroupRule{}
+	err := callSoftLayer(softLayerClient, "SoftLayer_Virtual_PlacementGroup_Rule/getAllObjects.json", nil, "GET", nil, &rules)
+	if err != nil {
+		return PlacementGroup{}, bosherr.WrapError(err, "Getting placement group rules")
+	}
+
+	ruleId := 0
+	for _, rule := range rules {
+		if rule.KeyName == spreadPlacementGroupRule {
+			ruleId = rule.Id
+			break
+		}
+	}
+	if ruleId == 0 {
+		return PlacementGroup{}, bosherr.Errorf("Placement group rule `%s` does not exist", spreadPlacementGroupRule)
+	}
+
+	routers := []datatypes.SoftLayer_Hardware{}
+	err = callSoftLayer(softLayerClient, "SoftLayer_Virtual_PlacementGroup/getAvailableRouters.json", []string{"id", "hostname", "datacenter.name"}, "GET", nil, &routers)
+	if err != nil {
+		return PlacementGroup{}, bosherr.WrapError(err, "Getting routers available to placement groups")
+	}
+
+	routerId := 0
+	for _, router := range routers {
+		if router.Datacenter != nil && router.Datacenter.Name == datacenter {
+			routerId = router.Id
+			break
+		}
+	}
+	if routerId == 0 {
+		return PlacementGroup{}, bosherr.Errorf("No router in datacenter `%s` is available to placement groups", datacenter)
+	}
+
+	placementGroup := PlacementGroup{}
+	err = callSoftLayer(softLayerClient, "SoftLayer_Virtual_PlacementGroup/createObject.json", nil, "POST", PlacementGroup{
+		Name:            name,
+		BackendRouterId: routerId,
+		RuleId:          ruleId,
+	}, &placementGroup)
+	if err != nil {
+		return PlacementGroup{}, bosherr.WrapErrorf(err, "Creating placement group `%s`", name)
+	}
+
+	kept, found, err := FindPlacementGroupByName(softLayerClient, name)
+	if err != nil {
+		logger.Warn(PLACEMENT_LOG_TAG, "Looking for other placement groups named `%s` after creating %d: %s", name, placementGroup.Id, err.Error())
+		return placementGroup, nil
+	}
+
+	if !found || kept.Id >= placementGroup.Id {
+		return placementGroup, nil
+	}
+
+	logger.Info(PLACEMENT_LOG_TAG, "Placement group %d named `%s` was created at the same time as %d, deleting %d", kept.Id, name, placementGroup.Id, placementGroup.Id)
+	deleted := false
+	err = callSoftLayer(softLayerClient, fmt.Sprintf("SoftLayer_Virtual_PlacementGroup/%d.json", placementGroup.Id), nil, "DELETE", nil, &deleted)
+	if err != nil || !deleted {
+		logger.Warn(PLACEMENT_LOG_TAG, "Deleting placement group %d: %v", placementGroup.Id, err)
+	}
+
+	return kept, nil
+}
+
+// getAccountObjects calls the SoftLayer_Account method getting the objects of the account that
+// match the filter, and unmarshals them into objects
+func getAccountObjects(softLayerClient sl.Client, method string, masks []string, filter string, objects interface{}) error {
+	path := fmt.Sprintf("SoftLayer_Account/%s.json", method)
+	response, errorCode, err := softLayerClient.GetHttpClient().DoRawHttpRequestWithObjectFilterAndObjectMask(path, masks, filter, "GET", new(bytes.Buffer))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Calling %s", path)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return bosherr.Errorf("Calling %s, HTTP error code: '%d'", path, errorCode)
+	}
+
+	err = json.Unmarshal(response, objects)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling response of %s", path)
+	}
+
+	return nil
+}
+
+// callSoftLayer calls the SoftLayer API at path, with the parameter when there is one, and
+// unmarshals the response into result
+func callSoftLayer(softLayerClient sl.Client, path string, masks []string, requestType string, parameter interface{}, result interface{}) error {
+	requestBody := new(bytes.Buffer)
+	if parameter != nil {
+		data, err := json.Marshal(map[string]interface{}{
+			"parameters": []interface{}{parameter},
+		})
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Marshalling parameters of %s", path)
+		}
+		requestBody = bytes.NewBuffer(data)
+	}
+
+	var response []byte
+	var errorCode int
+	var err error
+	if len(masks) > 0 {
+		response, errorCode, err = softLayerClient.GetHttpClient().DoRawHttpRequestWithObjectMask(path, masks, requestType, requestBody)
+	} else {
+		response, errorCode, err = softLayerClient.GetHttpClient().DoRawHttpRequest(path, requestType, requestBody)
+	}
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Calling %s", path)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return bosherr.Errorf("Calling %s, HTTP error code: '%d'", path, errorCode)
+	}
+
+	err = json.Unmarshal(response, result)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling response of %s", path)
+	}
+
+	return nil
+}
+
+// nameFilter returns the object filter on the name of the objects of the account property
+func nameFilter(property string, name string) (string, error) {
+	filter, err := json.Marshal(map[string]interface{}{
+		property: map[string]interface{}{
+			"name": map[string]string{"operation": name},
+		},
+	})
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Marshalling filter on %s named `%s`", property, name)
+	}
+
+	return string(filter), nil
+}
@@ -0,0 +1,127 @@
+package helper_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+
+	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+var _ = Describe("Placement", func() {
+	var (
+		fakeSoftLayerClient *fakeslclient.FakeSoftLayerClient
+		logger              boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		fakeSoftLayerClient = fakeslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	Describe("FindDedicatedHostByName", func() {
+		It("returns the dedicated host with the name", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, []string{"SoftLayer_Account_Service_getDedicatedHosts.json"}, 3)
+
+			dedicatedHost, err := slh.FindDedicatedHostByName(fakeSoftLayerClient, "fake-dedicated-host")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dedicatedHost.Id).To(Equal(11223))
+			Expect(dedicatedHost.Datacenter.Name).To(Equal("dal09"))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(Equal(`{"dedicatedHosts":{"name":{"operation":"fake-dedicated-host"}}}`))
+		})
+
+		It("escapes the name in the filter", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, []string{"SoftLayer_Account_Service_getDedicatedHosts.json"}, 3)
+
+			_, err := slh.FindDedicatedHostByName(fakeSoftLayerClient, `fake-"dedicated"-host`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(Equal(`{"dedicatedHosts":{"name":{"operation":"fake-\"dedicated\"-host"}}}`))
+		})
+
+		It("fails when no dedicated host has the name", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, []string{"SoftLayer_Account_Service_getDedicatedHosts_None.json"}, 3)
+
+			_, err := slh.FindDedicatedHostByName(fakeSoftLayerClient, "fake-dedicated-host")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Dedicated host `fake-dedicated-host` does not exist"))
+		})
+	})
+
+	Describe("FindPlacementGroupByName", func() {
+		It("returns the placement group with the name", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, []string{"SoftLayer_Account_Service_getPlacementGroups.json"}, 3)
+
+			placementGroup, found, err := slh.FindPlacementGroupByName(fakeSoftLayerClient, "fake-placement-group")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(placementGroup.Id).To(Equal(4455))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(Equal(`{"placementGroups":{"name":{"operation":"fake-placement-group"}}}`))
+		})
+
+		It("returns the placement group with the lowest id of those with the name", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, []string{"SoftLayer_Account_Service_getPlacementGroups_CreatedConcurrently.json"}, 3)
+
+			placementGroup, found, err := slh.FindPlacementGroupByName(fakeSoftLayerClient, "fake-director-fake-deployment-fake-job")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(placementGroup.Id).To(Equal(4450))
+		})
+
+		It("reports that nothing was found", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, []string{"SoftLayer_Account_Service_getPlacementGroups_None.json"}, 3)
+
+			_, found, err := slh.FindPlacementGroupByName(fakeSoftLayerClient, "fake-placement-group")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("CreatePlacementGroup", func() {
+		var fileNames []string
+
+		BeforeEach(func() {
+			fileNames = []string{
+				"SoftLayer_Virtual_PlacementGroup_Rule_Service_getAllObjects.json",
+				"SoftLayer_Virtual_PlacementGroup_Service_getAvailableRouters.json",
+				"SoftLayer_Virtual_PlacementGroup_Service_createObject.json",
+			}
+		})
+
+		It("creates a spread placement group behind a router of the datacenter", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, append(fileNames, "SoftLayer_Account_Service_getPlacementGroups_Created.json"), 3)
+
+			placementGroup, err := slh.CreatePlacementGroup(fakeSoftLayerClient, "fake-director-fake-deployment-fake-job", "dal09", logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(placementGroup.Id).To(Equal(4456))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Virtual_PlacementGroup/createObject.json"))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring(`"backendRouterId":1215735`))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring(`"ruleId":1`))
+		})
+
+		It("keeps the placement group created at the same time with a lower id and deletes its own", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, append(fileNames,
+				"SoftLayer_Account_Service_getPlacementGroups_CreatedConcurrently.json",
+				"SoftLayer_Virtual_PlacementGroup_Service_deleteObject_true.json",
+			), 3)
+
+			placementGroup, err := slh.CreatePlacementGroup(fakeSoftLayerClient, "fake-director-fake-deployment-fake-job", "dal09", logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(placementGroup.Id).To(Equal(4450))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestRequestType).To(Equal("DELETE"))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Virtual_PlacementGroup/4456.json"))
+		})
+
+		It("fails when no router of the datacenter is available", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, fileNames, 3)
+
+			_, err := slh.CreatePlacementGroup(fakeSoftLayerClient, "fake-director-fake-deployment-fake-job", "ams01", logger)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No router in datacenter `ams01`"))
+		})
+	})
+})
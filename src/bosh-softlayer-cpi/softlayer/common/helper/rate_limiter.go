@@ -0,0 +1,285 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+const rateLimiterLogTag = "RateLimiter"
+
+// RateLimit caps the SoftLayer API calls of every CPI process on the host. The token bucket
+// holding up to Burst calls and refilled at CallsPerSecond is kept in StateFile.
+type RateLimit struct {
+	CallsPerSecond float64
+	Burst          int
+	StateFile      string
+}
+
+func DefaultRateLimit() RateLimit {
+	return RateLimit{
+		CallsPerSecond: 10,
+		Burst:          10,
+		StateFile:      filepath.Join(os.TempDir(), "softlayer_cpi_api_rate_limit.json"),
+	}
+}
+
+// WithDefaults fills every unset field from DefaultRateLimit.
+func (r RateLimit) WithDefaults() RateLimit {
+	defaults := DefaultRateLimit()
+
+	if r.CallsPerSecond <= 0 {
+		r.CallsPerSecond = defaults.CallsPerSecond
+	}
+
+	if r.Burst <= 0 {
+		r.Burst = defaults.Burst
+	}
+
+	if r.StateFile == "" {
+		r.StateFile = defaults.StateFile
+	}
+
+	return r
+}
+
+// RateLimiter makes a SoftLayer API call wait for its turn
+type RateLimiter interface {
+	Wait(method string) error
+}
+
+type rateLimiterState struct {
+	Tokens    float64 `json:"tokens"`
+	UpdatedAt int64   `json:"updatedAt"`
+}
+
+// FileRateLimiter is a token bucket shared through a state file that every process taking a
+// token locks while it updates the bucket. It counts the calls of this process per API method.
+type FileRateLimiter struct {
+	limit RateLimit
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	callsLock sync.Mutex
+	calls     map[string]int
+
+	logger boshlog.Logger
+}
+
+func NewFileRateLimiter(limit RateLimit, logger boshlog.Logger) *FileRateLimiter {
+	return NewFileRateLimiterWithClock(limit, time.Now, time.Sleep, logger)
+}
+
+func NewFileRateLimiterWithClock(limit RateLimit, now func() time.Time, sleep func(time.Duration), logger boshlog.Logger) *FileRateLimiter {
+	return &FileRateLimiter{
+		limit:  limit.WithDefaults(),
+		now:    now,
+		sleep:  sleep,
+		calls:  map[string]int{},
+		logger: logger,
+	}
+}
+
+// Wait blocks until the bucket has a token for the call of the method and takes it
+func (l *FileRateLimiter) Wait(method string) error {
+	calls := l.count(method)
+
+	waited := time.Duration(0)
+	for {
+		delay, err := l.take()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Taking a token for %s from %s", method, l.limit.StateFile)
+		}
+
+		if delay == 0 {
+			break
+		}
+
+		waited += delay
+		l.sleep(delay)
+	}
+
+	if waited > 0 {
+		l.logger.Info(rateLimiterLogTag, fmt.Sprintf("Waited %s for the API rate limit before calling %s", waited, method))
+	}
+	l.logger.Debug(rateLimiterLogTag, fmt.Sprintf("Calling %s, call %d of this method", method, calls))
+
+	return nil
+}
+
+// CallCounts returns how often this process called each API method
+func (l *FileRateLimiter) CallCounts() map[string]int {
+	l.callsLock.Lock()
+	defer l.callsLock.Unlock()
+
+	calls := map[string]int{}
+	for method, count := range l.calls {
+		calls[method] = count
+	}
+
+	return calls
+}
+
+// LogCallCounts logs how often this process called each API method, one method a line
+func (l *FileRateLimiter) LogCallCounts() {
+	calls := l.CallCounts()
+
+	methods := []string{}
+	for method := range calls {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		l.logger.Debug(rateLimiterLogTag, fmt.Sprintf("Called %s %d times", method, calls[method]))
+	}
+}
+
+func (l *FileRateLimiter) count(method string) int {
+	l.callsLock.Lock()
+	defer l.callsLock.Unlock()
+
+	l.calls[method]++
+
+	return l.calls[method]
+}
+
+// take takes a token from the bucket in the state file, or returns how long to wait for one
+func (l *FileRateLimiter) take() (time.Duration, error) {
+	file, err := os.OpenFile(l.limit.StateFile, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Locking rate limit state file")
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return 0, err
+	}
+
+	now := l.now()
+	burst := float64(l.limit.Burst)
+
+	// A missing or unreadable state starts with a full bucket
+	state := rateLimiterState{Tokens: burst, UpdatedAt: now.UnixNano()}
+	if len(data) > 0 && json.Unmarshal(data, &state) != nil {
+		state = rateLimiterState{Tokens: burst, UpdatedAt: now.UnixNano()}
+	}
+
+	elapsed := now.Sub(time.Unix(0, state.UpdatedAt))
+	if elapsed > 0 {
+		state.Tokens = math.Min(burst, state.Tokens+elapsed.Seconds()*l.limit.CallsPerSecond)
+	}
+	state.UpdatedAt = now.UnixNano()
+
+	delay := time.Duration(0)
+	if state.Tokens >= 1 {
+		state.Tokens--
+	} else {
+		delay = time.Duration((1 - state.Tokens) / l.limit.CallsPerSecond * float64(time.Second))
+	}
+
+	data, err = json.Marshal(state)
+	if err != nil {
+		return 0, err
+	}
+
+	err = file.Truncate(0)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = file.WriteAt(data, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return delay, nil
+}
+
+// RateLimitedHttpClient sits in front of the HTTP client of the SoftLayer services and makes every
+// request wait for the rate limiter. A limiter that fails, for example on an unwritable state file,
+// lets the request through rather than failing it.
+type RateLimitedHttpClient struct {
+	sl.HttpClient
+
+	limiter RateLimiter
+	logger  boshlog.Logger
+}
+
+func NewRateLimitedHttpClient(httpClient sl.HttpClient, limiter RateLimiter, logger boshlog.Logger) *RateLimitedHttpClient {
+	return &RateLimitedHttpClient{
+		HttpClient: httpClient,
+		limiter:    limiter,
+		logger:     logger,
+	}
+}
+
+func (c *RateLimitedHttpClient) DoRawHttpRequest(path string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	c.wait(path, requestType)
+	return c.HttpClient.DoRawHttpRequest(path, requestType, requestBody)
+}
+
+func (c *RateLimitedHttpClient) DoRawHttpRequestWithObjectMask(path string, masks []string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	c.wait(path, requestType)
+	return c.HttpClient.DoRawHttpRequestWithObjectMask(path, masks, requestType, requestBody)
+}
+
+func (c *RateLimitedHttpClient) DoRawHttpRequestWithObjectFilter(path string, filters string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	c.wait(path, requestType)
+	return c.HttpClient.DoRawHttpRequestWithObjectFilter(path, filters, requestType, requestBody)
+}
+
+func (c *RateLimitedHttpClient) DoRawHttpRequestWithObjectFilterAndObjectMask(path string, masks []string, filters string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	c.wait(path, requestType)
+	return c.HttpClient.DoRawHttpRequestWithObjectFilterAndObjectMask(path, masks, filters, requestType, requestBody)
+}
+
+func (c *RateLimitedHttpClient) wait(path string, requestType string) {
+	err := c.limiter.Wait(APIMethod(path, requestType))
+	if err != nil {
+		c.logger.Warn(rateLimiterLogTag, fmt.Sprintf("Calling SoftLayer without the rate limit: %s", err.Error()))
+	}
+}
+
+// Methods the REST API calls on an object path without a method name
+var objectMethods = map[string]string{
+	"GET":    "getObject",
+	"POST":   "createObject",
+	"PUT":    "editObject",
+	"DELETE": "deleteObject",
+}
+
+// APIMethod names the SoftLayer API method a REST request calls, such as
+// SoftLayer_Virtual_Guest::getPowerState for SoftLayer_Virtual_Guest/1234567/getPowerState.json
+func APIMethod(path string, requestType string) string {
+	parts := strings.Split(strings.TrimSuffix(path, ".json"), "/")
+	service := parts[0]
+	method := parts[len(parts)-1]
+
+	if _, err := strconv.Atoi(method); err == nil || len(parts) == 1 {
+		method = objectMethods[requestType]
+	}
+
+	return fmt.Sprintf("%s::%s", service, method)
+}
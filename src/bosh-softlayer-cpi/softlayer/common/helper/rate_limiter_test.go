@@ -0,0 +1,149 @@
+package helper_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+type fakeRateLimiter struct {
+	methods []string
+	err     error
+}
+
+func (l *fakeRateLimiter) Wait(method string) error {
+	l.methods = append(l.methods, method)
+	return l.err
+}
+
+var _ = Describe("RateLimiter", func() {
+	var (
+		stateDir string
+		limit    slh.RateLimit
+		now      time.Time
+		sleeps   []time.Duration
+		logger   boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = ioutil.TempDir("", "rate-limiter")
+		Expect(err).NotTo(HaveOccurred())
+
+		limit = slh.RateLimit{CallsPerSecond: 2, Burst: 2, StateFile: filepath.Join(stateDir, "state.json")}
+		now = time.Unix(1500000000, 0)
+		sleeps = []time.Duration{}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(stateDir)
+	})
+
+	newLimiter := func() *slh.FileRateLimiter {
+		return slh.NewFileRateLimiterWithClock(limit, func() time.Time { return now }, func(d time.Duration) {
+			sleeps = append(sleeps, d)
+			now = now.Add(d)
+		}, logger)
+	}
+
+	Describe("FileRateLimiter", func() {
+		It("lets a burst through and then waits for the bucket to refill", func() {
+			limiter := newLimiter()
+
+			Expect(limiter.Wait("SoftLayer_Virtual_Guest::getObject")).To(Succeed())
+			Expect(limiter.Wait("SoftLayer_Virtual_Guest::getObject")).To(Succeed())
+			Expect(sleeps).To(BeEmpty())
+
+			Expect(limiter.Wait("SoftLayer_Virtual_Guest::getPowerState")).To(Succeed())
+			Expect(sleeps).To(Equal([]time.Duration{500 * time.Millisecond}))
+
+			Expect(limiter.CallCounts()).To(Equal(map[string]int{
+				"SoftLayer_Virtual_Guest::getObject":     2,
+				"SoftLayer_Virtual_Guest::getPowerState": 1,
+			}))
+		})
+
+		It("logs how often each method was called", func() {
+			outBuffer := bytes.NewBufferString("")
+			logger = boshlog.NewWriterLogger(boshlog.LevelDebug, outBuffer, outBuffer)
+			limiter := newLimiter()
+
+			Expect(limiter.Wait("SoftLayer_Virtual_Guest::getPowerState")).To(Succeed())
+			Expect(limiter.Wait("SoftLayer_Account::getVirtualGuests")).To(Succeed())
+			Expect(limiter.Wait("SoftLayer_Virtual_Guest::getPowerState")).To(Succeed())
+			outBuffer.Reset()
+
+			limiter.LogCallCounts()
+			Expect(outBuffer.String()).To(MatchRegexp(`(?s)Called SoftLayer_Account::getVirtualGuests 1 times.*Called SoftLayer_Virtual_Guest::getPowerState 2 times`))
+		})
+
+		It("shares the bucket with the other limiters using the state file", func() {
+			Expect(newLimiter().Wait("SoftLayer_Account::getVirtualGuests")).To(Succeed())
+			Expect(newLimiter().Wait("SoftLayer_Account::getVirtualGuests")).To(Succeed())
+			Expect(sleeps).To(BeEmpty())
+
+			Expect(newLimiter().Wait("SoftLayer_Account::getVirtualGuests")).To(Succeed())
+			Expect(sleeps).To(HaveLen(1))
+		})
+
+		It("starts over with a full bucket when the state file is unreadable", func() {
+			Expect(ioutil.WriteFile(limit.StateFile, []byte("fake-garbage"), 0600)).To(Succeed())
+
+			Expect(newLimiter().Wait("SoftLayer_Account::getVirtualGuests")).To(Succeed())
+			Expect(sleeps).To(BeEmpty())
+		})
+
+		It("fails when the state file cannot be opened", func() {
+			limit.StateFile = filepath.Join(stateDir, "missing", "state.json")
+
+			err := newLimiter().Wait("SoftLayer_Account::getVirtualGuests")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("RateLimitedHttpClient", func() {
+		It("waits for the limiter with the API method of the request", func() {
+			fakeHttpClient := fakeslclient.NewFakeHttpClient("fake-username", "fake-api-key")
+			limiter := &fakeRateLimiter{}
+			client := slh.NewRateLimitedHttpClient(fakeHttpClient, limiter, logger)
+
+			_, _, err := client.DoRawHttpRequest("SoftLayer_Virtual_Guest/1234567/getPowerState.json", "GET", new(bytes.Buffer))
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = client.DoRawHttpRequestWithObjectMask("SoftLayer_Virtual_Guest/1234567.json", []string{"id"}, "GET", new(bytes.Buffer))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(limiter.methods).To(Equal([]string{"SoftLayer_Virtual_Guest::getPowerState", "SoftLayer_Virtual_Guest::getObject"}))
+			Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(2))
+		})
+
+		It("sends the request when the limiter fails", func() {
+			fakeHttpClient := fakeslclient.NewFakeHttpClient("fake-username", "fake-api-key")
+			client := slh.NewRateLimitedHttpClient(fakeHttpClient, &fakeRateLimiter{err: errors.New("fake-error")}, logger)
+
+			_, _, err := client.DoRawHttpRequest("SoftLayer_Virtual_Guest/1234567.json", "DELETE", new(bytes.Buffer))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(1))
+		})
+	})
+
+	Describe("APIMethod", func() {
+		It("names the method the REST path calls", func() {
+			Expect(slh.APIMethod("SoftLayer_Virtual_Guest/1234567/getPowerState.json", "GET")).To(Equal("SoftLayer_Virtual_Guest::getPowerState"))
+			Expect(slh.APIMethod("SoftLayer_Virtual_Guest/createObject.json", "POST")).To(Equal("SoftLayer_Virtual_Guest::createObject"))
+			Expect(slh.APIMethod("SoftLayer_Virtual_Guest/1234567.json", "DELETE")).To(Equal("SoftLayer_Virtual_Guest::deleteObject"))
+		})
+	})
+})
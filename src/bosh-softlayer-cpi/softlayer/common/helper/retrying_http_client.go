@@ -0,0 +1,193 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+const retryingHttpClientLogTag = "RetryingHttpClient"
+
+// BackoffPolicy describes how often a SoftLayer API request that failed with a retryable error is
+// sent again. The delay before each retry doubles from Delay up to MaxDelay, and a random part of
+// up to half of it keeps concurrent CPI processes from retrying in lockstep.
+type BackoffPolicy struct {
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+func DefaultBackoffPolicy() BackoffPolicy {
+	return BackoffPolicy{
+		Attempts: 5,
+		Delay:    1 * time.Second,
+		MaxDelay: 30 * time.Second,
+	}
+}
+
+// WithDefaults fills every unset field from DefaultBackoffPolicy.
+func (p BackoffPolicy) WithDefaults() BackoffPolicy {
+	defaults := DefaultBackoffPolicy()
+
+	if p.Attempts <= 0 {
+		p.Attempts = defaults.Attempts
+	}
+
+	if p.Delay <= 0 {
+		p.Delay = defaults.Delay
+	}
+
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaults.MaxDelay
+	}
+
+	return p
+}
+
+// NextDelay returns the delay before the retry following the attempt, counted from 1, with jitter.
+func (p BackoffPolicy) NextDelay(attempt int) time.Duration {
+	delay := p.Delay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if delay < 2 {
+		return delay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+type backoffPolicyJSON struct {
+	Attempts int `json:"attempts"`
+	Delay    int `json:"delay"`
+	MaxDelay int `json:"maxDelay"`
+}
+
+// UnmarshalJSON reads delay and maxDelay as seconds, matching the other
+// timeouts in the CPI configuration.
+func (p *BackoffPolicy) UnmarshalJSON(data []byte) error {
+	var raw backoffPolicyJSON
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling backoff policy")
+	}
+
+	p.Attempts = raw.Attempts
+	p.Delay = time.Duration(raw.Delay) * time.Second
+	p.MaxDelay = time.Duration(raw.MaxDelay) * time.Second
+
+	return nil
+}
+
+func (p BackoffPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(backoffPolicyJSON{
+		Attempts: p.Attempts,
+		Delay:    int(p.Delay / time.Second),
+		MaxDelay: int(p.MaxDelay / time.Second),
+	})
+}
+
+// RetryingHttpClient sits in front of the HTTP client of the SoftLayer services. It classifies
+// every failed request and sends it again as the backoff policy allows when it may succeed later.
+// A request that changes something is only sent again when SoftLayer refused it, being throttled
+// or blocked by a running transaction, since one that got no answer may have been carried out.
+// The request fails with an APIError when it is not retried or runs out of attempts, also when
+// SoftLayer answered it with an HTTP error code alone.
+type RetryingHttpClient struct {
+	sl.HttpClient
+
+	policy BackoffPolicy
+	sleep  func(time.Duration)
+	logger boshlog.Logger
+}
+
+func NewRetryingHttpClient(httpClient sl.HttpClient, policy BackoffPolicy, logger boshlog.Logger) *RetryingHttpClient {
+	return NewRetryingHttpClientWithSleep(httpClient, policy, time.Sleep, logger)
+}
+
+func NewRetryingHttpClientWithSleep(httpClient sl.HttpClient, policy BackoffPolicy, sleep func(time.Duration), logger boshlog.Logger) *RetryingHttpClient {
+	return &RetryingHttpClient{
+		HttpClient: httpClient,
+		policy:     policy.WithDefaults(),
+		sleep:      sleep,
+		logger:     logger,
+	}
+}
+
+func (c *RetryingHttpClient) DoRawHttpRequest(path string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequest(path, requestType, body)
+	})
+}
+
+func (c *RetryingHttpClient) DoRawHttpRequestWithObjectMask(path string, masks []string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequestWithObjectMask(path, masks, requestType, body)
+	})
+}
+
+func (c *RetryingHttpClient) DoRawHttpRequestWithObjectFilter(path string, filters string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequestWithObjectFilter(path, filters, requestType, body)
+	})
+}
+
+func (c *RetryingHttpClient) DoRawHttpRequestWithObjectFilterAndObjectMask(path string, masks []string, filters string, requestType string, requestBody *bytes.Buffer) ([]byte, int, error) {
+	return c.do(path, requestType, requestBody, func(body *bytes.Buffer) ([]byte, int, error) {
+		return c.HttpClient.DoRawHttpRequestWithObjectFilterAndObjectMask(path, masks, filters, requestType, body)
+	})
+}
+
+func (c *RetryingHttpClient) do(path string, requestType string, requestBody *bytes.Buffer, request func(*bytes.Buffer) ([]byte, int, error)) ([]byte, int, error) {
+	// The HTTP client drains the request body, so every attempt sends a copy of it
+	var body []byte
+	if requestBody != nil {
+		body = append([]byte{}, requestBody.Bytes()...)
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, statusCode, err := request(bytes.NewBuffer(append([]byte{}, body...)))
+		if err == nil && statusCode < 400 {
+			return response, statusCode, nil
+		}
+
+		message := string(response)
+		if err != nil {
+			message = err.Error()
+		}
+		class := ClassifyAPIResponse(statusCode, message)
+
+		if attempt >= c.policy.Attempts || !c.mayRetry(requestType, class) {
+			// The services only report the status code of a failed request without an error,
+			// so the error carries the status code and body for the class to be told again
+			if err == nil {
+				err = fmt.Errorf("HTTP error code: '%d', response: %s", statusCode, message)
+			}
+			return response, statusCode, NewAPIError(class, statusCode, err)
+		}
+
+		delay := c.policy.NextDelay(attempt)
+		c.logger.Warn(retryingHttpClientLogTag, fmt.Sprintf("%s %s failed with a %s error, retrying in %s (attempt %d of %d): HTTP status code %d, %s", requestType, path, class, delay, attempt, c.policy.Attempts, statusCode, message))
+		c.sleep(delay)
+	}
+}
+
+func (c *RetryingHttpClient) mayRetry(requestType string, class APIErrorClass) bool {
+	if !class.Retryable() {
+		return false
+	}
+
+	return requestType == "GET" || class != APIErrorTransient
+}
@@ -0,0 +1,115 @@
+package helper_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+var _ = Describe("RetryingHttpClient", func() {
+	var (
+		fakeHttpClient *fakeslclient.FakeHttpClient
+		sleeps         []time.Duration
+		client         *slh.RetryingHttpClient
+	)
+
+	BeforeEach(func() {
+		fakeHttpClient = fakeslclient.NewFakeHttpClient("fake-username", "fake-api-key")
+		sleeps = []time.Duration{}
+		policy := slh.BackoffPolicy{Attempts: 3, Delay: 1 * time.Second, MaxDelay: 30 * time.Second}
+		client = slh.NewRetryingHttpClientWithSleep(fakeHttpClient, policy, func(d time.Duration) { sleeps = append(sleeps, d) }, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("sends a throttled request again with the same body", func() {
+		fakeHttpClient.DoRawHttpRequestResponses = [][]byte{[]byte(`{"error": "Rate limit exceeded"}`), []byte(`{"id": 1234567}`)}
+		fakeHttpClient.DoRawHttpRequestInts = []int{429, 200}
+
+		response, statusCode, err := client.DoRawHttpRequest("SoftLayer_Virtual_Guest/createObject.json", "POST", bytes.NewBufferString(`{"fake": "body"}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusCode).To(Equal(200))
+		Expect(string(response)).To(Equal(`{"id": 1234567}`))
+		Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(2))
+		Expect(fakeHttpClient.DoRawHttpRequestRequestBody.String()).To(Equal(`{"fake": "body"}`))
+		Expect(sleeps).To(HaveLen(1))
+	})
+
+	It("gives up after the attempts of the policy with a classified error", func() {
+		fakeHttpClient.DoRawHttpRequestInt = 520
+		fakeHttpClient.DoRawHttpRequestError = errors.New("dial tcp: i/o timeout")
+
+		_, _, err := client.DoRawHttpRequestWithObjectMask("SoftLayer_Virtual_Guest/1234567/getObject.json", []string{"id"}, "GET", new(bytes.Buffer))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("dial tcp: i/o timeout"))
+		Expect(err.(slh.APIError).Class).To(Equal(slh.APIErrorTransient))
+		Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(3))
+		Expect(sleeps).To(HaveLen(2))
+	})
+
+	It("does not send again a request changing something that got no answer", func() {
+		fakeHttpClient.DoRawHttpRequestInt = 520
+		fakeHttpClient.DoRawHttpRequestError = errors.New("dial tcp: i/o timeout")
+
+		_, _, err := client.DoRawHttpRequest("SoftLayer_Virtual_Guest/createObject.json", "POST", new(bytes.Buffer))
+		Expect(err).To(HaveOccurred())
+		Expect(slh.IsAPIErrorRetryable(err)).To(BeTrue())
+		Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(1))
+	})
+
+	It("does not retry permanent errors", func() {
+		fakeHttpClient.DoRawHttpRequestInt = 500
+		fakeHttpClient.DoRawHttpRequestError = errors.New("Invalid value provided for startCpus.")
+
+		_, _, err := client.DoRawHttpRequestWithObjectFilter("SoftLayer_Account/getVirtualGuests.json", "{}", "GET", new(bytes.Buffer))
+		Expect(err).To(HaveOccurred())
+		Expect(err.(slh.APIError).Class).To(Equal(slh.APIErrorPermanent))
+		Expect(fakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(1))
+		Expect(sleeps).To(BeEmpty())
+	})
+
+	It("fails a request answered with an HTTP error code alone with a classified error", func() {
+		fakeHttpClient.DoRawHttpRequestResponses = [][]byte{[]byte(`{"error": "Unable to attach, please try again after Volume Provisioning is complete"}`)}
+		fakeHttpClient.DoRawHttpRequestInts = []int{500}
+		client = slh.NewRetryingHttpClientWithSleep(fakeHttpClient, slh.BackoffPolicy{Attempts: 1}, func(time.Duration) {}, boshlog.NewLogger(boshlog.LevelNone))
+
+		_, statusCode, err := client.DoRawHttpRequest("SoftLayer_Network_Storage/1234567/allowAccessFromVirtualGuest.json", "POST", new(bytes.Buffer))
+		Expect(statusCode).To(Equal(500))
+		Expect(err).To(HaveOccurred())
+		Expect(err.(slh.APIError).Class).To(Equal(slh.APIErrorConflict))
+		Expect(err.(slh.APIError).StatusCode).To(Equal(500))
+
+		serviceErr := fmt.Errorf("softlayer-go: could not SoftLayer_Network_Storage#allowAccessFromVirtualGuest, error message '%s'", err.Error())
+		Expect(slh.IsAPIErrorRetryable(serviceErr)).To(BeTrue())
+	})
+
+	Describe("BackoffPolicy", func() {
+		It("doubles the delay up to the maximum and keeps at least half of it", func() {
+			policy := slh.BackoffPolicy{Attempts: 10, Delay: 1 * time.Second, MaxDelay: 5 * time.Second}
+
+			Expect(policy.NextDelay(1)).To(BeNumerically("~", 750*time.Millisecond, 250*time.Millisecond))
+			Expect(policy.NextDelay(2)).To(BeNumerically("~", 1500*time.Millisecond, 500*time.Millisecond))
+			Expect(policy.NextDelay(8)).To(BeNumerically("~", 3750*time.Millisecond, 1250*time.Millisecond))
+		})
+
+		It("reads delays in seconds and fills unset fields from the defaults", func() {
+			var policy slh.BackoffPolicy
+			err := json.Unmarshal([]byte(`{"attempts": 8, "delay": 2}`), &policy)
+			Expect(err).NotTo(HaveOccurred())
+
+			policy = policy.WithDefaults()
+			Expect(policy.Attempts).To(Equal(8))
+			Expect(policy.Delay).To(Equal(2 * time.Second))
+			Expect(policy.MaxDelay).To(Equal(slh.DefaultBackoffPolicy().MaxDelay))
+		})
+	})
+})
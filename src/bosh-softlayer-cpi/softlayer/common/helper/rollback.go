@@ -0,0 +1,84 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const ROLLBACK_LOG_TAG = "Rollback"
+
+type rollbackStep struct {
+	description string
+	undo        func() error
+}
+
+// Rollback records a compensating action for every create_vm step that has
+// completed so that a failure in a later step does not leave billed or
+// half-configured resources behind. Run undoes the steps in reverse order.
+type Rollback struct {
+	steps  []rollbackStep
+	keep   bool
+	logger boshlog.Logger
+}
+
+// NewRollback returns an empty Rollback. When keep is true Run only logs
+// what it would have undone, leaving failed VMs in place for debugging.
+func NewRollback(keep bool, logger boshlog.Logger) *Rollback {
+	return &Rollback{
+		keep:   keep,
+		logger: logger,
+	}
+}
+
+func (r *Rollback) Add(description string, undo func() error) {
+	r.steps = append(r.steps, rollbackStep{
+		description: description,
+		undo:        undo,
+	})
+}
+
+// Run undoes the recorded steps, the most recent first, and forgets them.
+// A failing compensating action is logged and does not stop the others.
+// It returns an error naming the steps that failed to roll back, or that
+// were kept, since the resources they name are left behind.
+func (r *Rollback) Run() error {
+	steps := r.steps
+	r.steps = nil
+
+	leftovers := []string{}
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+
+		if r.keep {
+			r.logger.Warn(ROLLBACK_LOG_TAG, "Keeping failed resources, skipping '%s'", step.description)
+			leftovers = append(leftovers, fmt.Sprintf("'%s' skipped to keep failed resources", step.description))
+			continue
+		}
+
+		r.logger.Info(ROLLBACK_LOG_TAG, "Rolling back: %s", step.description)
+		err := step.undo()
+		if err != nil {
+			r.logger.Error(ROLLBACK_LOG_TAG, "Rolling back '%s': %s", step.description, err.Error())
+			leftovers = append(leftovers, fmt.Sprintf("'%s' failed: %s", step.description, err.Error()))
+		}
+	}
+
+	if len(leftovers) == 0 {
+		return nil
+	}
+
+	return bosherr.Errorf("Left behind by rollback: %s", strings.Join(leftovers, "; "))
+}
+
+// Fail rolls back after err and returns err, along with what the rollback left behind
+func (r *Rollback) Fail(err error) error {
+	rollbackErr := r.Run()
+	if rollbackErr == nil {
+		return err
+	}
+
+	return bosherr.WrapError(err, rollbackErr.Error())
+}
@@ -0,0 +1,86 @@
+package helper_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+var _ = Describe("Rollback", func() {
+	var (
+		logger boshlog.Logger
+		undone []string
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		undone = []string{}
+	})
+
+	undo := func(name string, err error) func() error {
+		return func() error {
+			undone = append(undone, name)
+			return err
+		}
+	}
+
+	It("undoes the steps in reverse order", func() {
+		rollback := slh.NewRollback(false, logger)
+		rollback.Add("first", undo("first", nil))
+		rollback.Add("second", undo("second", nil))
+		rollback.Add("third", undo("third", nil))
+
+		Expect(rollback.Run()).To(Succeed())
+		Expect(undone).To(Equal([]string{"third", "second", "first"}))
+	})
+
+	It("keeps going when a step fails to roll back", func() {
+		rollback := slh.NewRollback(false, logger)
+		rollback.Add("first", undo("first", nil))
+		rollback.Add("second", undo("second", errors.New("fake-error")))
+
+		err := rollback.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Left behind by rollback: 'second' failed: fake-error"))
+		Expect(undone).To(Equal([]string{"second", "first"}))
+	})
+
+	It("undoes every step only once", func() {
+		rollback := slh.NewRollback(false, logger)
+		rollback.Add("first", undo("first", nil))
+
+		rollback.Run()
+		rollback.Run()
+		Expect(undone).To(Equal([]string{"first"}))
+	})
+
+	It("fails with the error that made it roll back, along with what it left behind", func() {
+		rollback := slh.NewRollback(false, logger)
+		rollback.Add("Cancelling VirtualGuest `1234567`", undo("first", errors.New("fake-cancel-error")))
+
+		err := rollback.Fail(errors.New("fake-create-error"))
+		Expect(err.Error()).To(Equal("Left behind by rollback: 'Cancelling VirtualGuest `1234567`' failed: fake-cancel-error: fake-create-error"))
+	})
+
+	It("fails with the error that made it roll back alone when everything was undone", func() {
+		rollback := slh.NewRollback(false, logger)
+		rollback.Add("first", undo("first", nil))
+
+		Expect(rollback.Fail(errors.New("fake-create-error"))).To(MatchError("fake-create-error"))
+	})
+
+	It("undoes nothing when failed resources are kept", func() {
+		rollback := slh.NewRollback(true, logger)
+		rollback.Add("first", undo("first", nil))
+
+		err := rollback.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("'first' skipped to keep failed resources"))
+		Expect(undone).To(BeEmpty())
+	})
+})
@@ -1,38 +1,49 @@
 package helper
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
-	"github.com/pivotal-golang/clock"
 
+	slcommon "github.com/maximilien/softlayer-go/common"
 	datatypes "github.com/maximilien/softlayer-go/data_types"
 	sl "github.com/maximilien/softlayer-go/softlayer"
 )
 
-var (
-	TIMEOUT                   time.Duration
-	POLLING_INTERVAL          time.Duration
-	LocalDiskFlagNotSet       bool
-	LengthOfHostName          int
-	NetworkInterface          string
-	LocalDNSConfigurationFile string
-)
-
 type SoftLayer_Hardware_Parameters struct {
 	Parameters []datatypes.SoftLayer_Hardware `json:"parameters"`
 }
 
-func AttachEphemeralDiskToVirtualGuest(softLayerClient sl.Client, virtualGuestId int, diskSize int, logger boshlog.Logger) error {
-	err := WaitForVirtualGuestLastCompleteTransaction(softLayerClient, virtualGuestId, "Service Setup")
+// agentVirtualGuest is a virtual guest found by its agent ID tag, with what tells whether it is
+// on its way out
+type agentVirtualGuest struct {
+	Id          int        `json:"id"`
+	Hostname    string     `json:"hostname"`
+	Domain      string     `json:"domain"`
+	CreateDate  *time.Time `json:"createDate"`
+	BillingItem *struct {
+		CancellationDate *time.Time `json:"cancellationDate"`
+	} `json:"billingItem"`
+	ActiveTransaction *struct {
+		TransactionStatus struct {
+			Name string `json:"name"`
+		} `json:"transactionStatus"`
+	} `json:"activeTransaction"`
+}
+
+func AttachEphemeralDiskToVirtualGuest(softLayerClient sl.Client, virtualGuestId int, diskSize int, policy WaitPolicy, logger boshlog.Logger) error {
+	err := WaitForVirtualGuestLastCompleteTransaction(softLayerClient, virtualGuestId, "Service Setup", policy)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` has Service Setup transaction complete", virtualGuestId)
 	}
 
-	err = WaitForVirtualGuestToHaveNoRunningTransactions(softLayerClient, virtualGuestId)
+	err = WaitForVirtualGuestToHaveNoRunningTransactions(softLayerClient, virtualGuestId, policy)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` to have no pending transactions", virtualGuestId)
 	}
@@ -53,23 +64,23 @@ func AttachEphemeralDiskToVirtualGuest(softLayerClient sl.Client, virtualGuestId
 		return nil
 	}
 
-	err = WaitForVirtualGuestToHaveRunningTransaction(softLayerClient, virtualGuestId, logger)
+	err = WaitForVirtualGuestToHaveRunningTransaction(softLayerClient, virtualGuestId, policy, logger)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` to launch transaction", virtualGuestId)
 	}
 
-	err = WaitForVirtualGuestToHaveNoRunningTransaction(softLayerClient, virtualGuestId, logger)
+	err = WaitForVirtualGuestToHaveNoRunningTransaction(softLayerClient, virtualGuestId, policy, logger)
 
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` no transcation in progress", virtualGuestId)
 	}
 
-	err = WaitForVirtualGuestUpgradeComplete(softLayerClient, virtualGuestId)
+	err = WaitForVirtualGuestUpgradeComplete(softLayerClient, virtualGuestId, policy)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` upgrade complete", virtualGuestId)
 	}
 
-	err = WaitForVirtualGuest(softLayerClient, virtualGuestId, "RUNNING")
+	err = WaitForVirtualGuest(softLayerClient, virtualGuestId, "RUNNING", policy)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d`", virtualGuestId)
 	}
@@ -85,129 +96,179 @@ func AttachEphemeralDiskToVirtualGuest(softLayerClient sl.Client, virtualGuestId
 	return nil
 }
 
-func WaitForVirtualGuestToHaveNoRunningTransactions(softLayerClient sl.Client, virtualGuestId int) error {
+func WaitForVirtualGuestToHaveNoRunningTransactions(softLayerClient sl.Client, virtualGuestId int, policy WaitPolicy) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < TIMEOUT {
+	done, err := policy.Wait(func() (bool, error) {
 		activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuestId)
 		if err != nil {
-			return bosherr.WrapError(err, "Getting active transaction from SoftLayer client")
+			return false, bosherr.WrapError(err, "Getting active transaction from SoftLayer client")
 		}
 
 		if len(activeTransactions) == 0 {
-			return nil
+			return true, nil
 		}
 
-		totalTime += POLLING_INTERVAL
-		time.Sleep(POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if done {
+		return nil
 	}
 
 	return bosherr.Errorf("Waiting for virtual guest with ID '%d' to have no active transactions", virtualGuestId)
 }
 
-func WaitForVirtualGuestToHaveRunningTransaction(softLayerClient sl.Client, virtualGuestId int, logger boshlog.Logger) error {
+// CancelVirtualGuest waits for the transactions running on a virtual guest to
+// finish and cancels it.
+func CancelVirtualGuest(softLayerClient sl.Client, virtualGuestId int, policy WaitPolicy) error {
+	err := WaitForVirtualGuestToHaveNoRunningTransactions(softLayerClient, virtualGuestId, policy)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` to have no pending transactions before cancelling it", virtualGuestId)
+	}
+
+	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
+	if err != nil {
+		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
+	}
+
+	deleted, err := virtualGuestService.DeleteObject(virtualGuestId)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Cancelling VirtualGuest `%d`", virtualGuestId)
+	}
+
+	if !deleted {
+		return bosherr.Errorf("SoftLayer did not accept the cancellation of VirtualGuest `%d`", virtualGuestId)
+	}
+
+	return nil
+}
+
+func WaitForVirtualGuestToHaveRunningTransaction(softLayerClient sl.Client, virtualGuestId int, policy WaitPolicy, logger boshlog.Logger) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < TIMEOUT {
+	done, err := policy.Wait(func() (bool, error) {
 		activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuestId)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Getting active transaction against virtual guest %d", virtualGuestId)
+			return false, bosherr.WrapErrorf(err, "Getting active transaction against virtual guest %d", virtualGuestId)
 		}
 
 		if len(activeTransactions) > 0 {
-			return nil
+			return true, nil
 		}
 
-		totalTime += POLLING_INTERVAL
-		time.Sleep(POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if done {
+		return nil
 	}
 
 	return bosherr.Errorf("Waiting for virtual guest with ID '%d' to have no active transactions", virtualGuestId)
 }
 
-func WaitForVirtualGuestToHaveNoRunningTransaction(softLayerClient sl.Client, virtualGuestId int, logger boshlog.Logger) error {
+func WaitForVirtualGuestToHaveNoRunningTransaction(softLayerClient sl.Client, virtualGuestId int, policy WaitPolicy, logger boshlog.Logger) error {
 
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < TIMEOUT {
+	done, err := policy.Wait(func() (bool, error) {
 		activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuestId)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Getting active transaction against virtual guest %d", virtualGuestId)
+			return false, bosherr.WrapErrorf(err, "Getting active transaction against virtual guest %d", virtualGuestId)
 		}
 
 		if len(activeTransactions) == 0 {
-			return nil
+			return true, nil
 		}
 
-		totalTime += POLLING_INTERVAL
-		time.Sleep(POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if done {
+		return nil
 	}
 
 	return bosherr.Errorf("Waiting for virtual guest with ID '%d' to have no active transactions", virtualGuestId)
 
 }
 
-func WaitForVirtualGuest(softLayerClient sl.Client, virtualGuestId int, targetState string) error {
+func WaitForVirtualGuest(softLayerClient sl.Client, virtualGuestId int, targetState string, policy WaitPolicy) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < TIMEOUT {
+	done, err := policy.Wait(func() (bool, error) {
 		vgPowerState, err := virtualGuestService.GetPowerState(virtualGuestId)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Getting Power State for virtual guest with ID '%d'", virtualGuestId)
+			return false, bosherr.WrapErrorf(err, "Getting Power State for virtual guest with ID '%d'", virtualGuestId)
 		}
 
 		if strings.Contains(vgPowerState.KeyName, targetState) {
-			return nil
+			return true, nil
 		}
 
-		totalTime += POLLING_INTERVAL
-		time.Sleep(POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if done {
+		return nil
 	}
 
 	return bosherr.Errorf("Waiting for virtual guest with ID '%d' to have be in state '%s'", virtualGuestId, targetState)
 }
 
-func WaitForVirtualGuestLastCompleteTransaction(softLayerClient sl.Client, virtualGuestId int, targetTransaction string) error {
+func WaitForVirtualGuestLastCompleteTransaction(softLayerClient sl.Client, virtualGuestId int, targetTransaction string, policy WaitPolicy) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < TIMEOUT {
+	done, err := policy.Wait(func() (bool, error) {
 		lastTransaction, err := virtualGuestService.GetLastTransaction(virtualGuestId)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Getting Last Complete Transaction for virtual guest with ID '%d'", virtualGuestId)
+			return false, bosherr.WrapErrorf(err, "Getting Last Complete Transaction for virtual guest with ID '%d'", virtualGuestId)
 		}
 
 		if strings.Contains(lastTransaction.TransactionGroup.Name, targetTransaction) && strings.Contains(lastTransaction.TransactionStatus.FriendlyName, "Complete") {
-			return nil
+			return true, nil
 		}
 
-		totalTime += POLLING_INTERVAL
-		time.Sleep(POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if done {
+		return nil
 	}
 
 	return bosherr.Errorf("Waiting for virtual guest with ID '%d' to have last transaction '%s'", virtualGuestId, targetTransaction)
 }
 
-func WaitForVirtualGuestIsNotPingable(softLayerClient sl.Client, virtualGuestId int, logger boshlog.Logger) error {
+func WaitForVirtualGuestIsNotPingable(softLayerClient sl.Client, virtualGuestId int, policy WaitPolicy, logger boshlog.Logger) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
@@ -227,9 +288,7 @@ func WaitForVirtualGuestIsNotPingable(softLayerClient sl.Client, virtualGuestId
 			}
 		})
 
-	timeService := clock.NewClock()
-	timeoutRetryStrategy := boshretry.NewTimeoutRetryStrategy(TIMEOUT, POLLING_INTERVAL, checkPingableRetryable, timeService, logger)
-	err = timeoutRetryStrategy.Try()
+	err = policy.RetryStrategy(checkPingableRetryable, logger).Try()
 	if err != nil {
 		return bosherr.Errorf("Waiting for virtual guest with ID '%d' is not pingable", virtualGuestId)
 	}
@@ -237,7 +296,7 @@ func WaitForVirtualGuestIsNotPingable(softLayerClient sl.Client, virtualGuestId
 	return nil
 }
 
-func WaitForVirtualGuestIsPingable(softLayerClient sl.Client, virtualGuestId int, logger boshlog.Logger) error {
+func WaitForVirtualGuestIsPingable(softLayerClient sl.Client, virtualGuestId int, policy WaitPolicy, logger boshlog.Logger) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
@@ -257,44 +316,47 @@ func WaitForVirtualGuestIsPingable(softLayerClient sl.Client, virtualGuestId int
 			}
 		})
 
-	timeService := clock.NewClock()
-	timeoutRetryStrategy := boshretry.NewTimeoutRetryStrategy(TIMEOUT, POLLING_INTERVAL, checkPingableRetryable, timeService, logger)
-	err = timeoutRetryStrategy.Try()
+	err = policy.RetryStrategy(checkPingableRetryable, logger).Try()
 	if err != nil {
 		return bosherr.Errorf("Waiting for virtual guest with ID '%d' is not pingable", virtualGuestId)
 	}
 	return nil
 }
 
-func WaitForVirtualGuestUpgradeComplete(softLayerClient sl.Client, virtualGuestId int) error {
+func WaitForVirtualGuestUpgradeComplete(softLayerClient sl.Client, virtualGuestId int, policy WaitPolicy) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < TIMEOUT {
+	done, err := policy.Wait(func() (bool, error) {
 		lastTransaction, err := virtualGuestService.GetLastTransaction(virtualGuestId)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Getting Last Complete Transaction for virtual guest with ID '%d'", virtualGuestId)
+			return false, bosherr.WrapErrorf(err, "Getting Last Complete Transaction for virtual guest with ID '%d'", virtualGuestId)
 		}
 
 		if strings.Contains(lastTransaction.TransactionGroup.Name, "Cloud Migrate") && strings.Contains(lastTransaction.TransactionStatus.FriendlyName, "Complete") {
-			return nil
+			return true, nil
 		}
 
 		if strings.Contains(lastTransaction.TransactionGroup.Name, "Cloud Instance Upgrade") && strings.Contains(lastTransaction.TransactionStatus.FriendlyName, "Complete") {
-			return nil
+			return true, nil
 		}
 
-		totalTime += POLLING_INTERVAL
-		time.Sleep(POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if done {
+		return nil
 	}
 
 	return bosherr.Errorf("Waiting for virtual guest with ID '%d' to update complete", virtualGuestId)
 }
 
-func WaitForVirtualGuestToTargetState(softLayerClient sl.Client, virtualGuestId int, targetState string, logger boshlog.Logger) error {
+func WaitForVirtualGuestToTargetState(softLayerClient sl.Client, virtualGuestId int, targetState string, policy WaitPolicy, logger boshlog.Logger) error {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
@@ -313,9 +375,7 @@ func WaitForVirtualGuestToTargetState(softLayerClient sl.Client, virtualGuestId
 			}
 		})
 
-	timeService := clock.NewClock()
-	timeoutRetryStrategy := boshretry.NewTimeoutRetryStrategy(TIMEOUT, POLLING_INTERVAL, getTargetStateRetryable, timeService, logger)
-	err = timeoutRetryStrategy.Try()
+	err = policy.RetryStrategy(getTargetStateRetryable, logger).Try()
 	if err != nil {
 		return bosherr.Errorf("Waiting for virtual guest with ID '%d' to have be in state '%s'", virtualGuestId, targetState)
 	}
@@ -323,6 +383,145 @@ func WaitForVirtualGuestToTargetState(softLayerClient sl.Client, virtualGuestId
 	return nil
 }
 
+// AgentIDTag is the tag put on a virtual guest ordered by create_vm so that
+// a retried create_vm for the same agent adopts it instead of ordering another.
+func AgentIDTag(agentID string) string {
+	return "agent_id:" + agentID
+}
+
+// AgentIDTagReferences returns the tag references to put in the order template of a virtual guest,
+// so that it is tagged with AgentIDTag from the moment it exists
+func AgentIDTagReferences(agentID string) []TagReference {
+	return []TagReference{{Tag: Tag{Name: AgentIDTag(agentID)}}}
+}
+
+// SetVirtualGuestTags puts tags on a virtual guest. SoftLayer replaces all tags of the virtual guest,
+// so the tags it has are kept, except those with the key, the part before ':', of one of tags.
+func SetVirtualGuestTags(softLayerClient sl.Client, virtualGuestId int, tags []string) error {
+	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
+	if err != nil {
+		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
+	}
+
+	tagReferences, err := virtualGuestService.GetTagReferences(virtualGuestId)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Getting tags of VirtualGuest `%d`", virtualGuestId)
+	}
+
+	existing := []string{}
+	for _, tagReference := range tagReferences {
+		existing = append(existing, tagReference.Tag.Name)
+	}
+
+	success, err := virtualGuestService.SetTags(virtualGuestId, MergeTags(existing, tags))
+	if err != nil || !success {
+		return bosherr.WrapErrorf(err, "Setting tags on VirtualGuest `%d`", virtualGuestId)
+	}
+
+	return nil
+}
+
+// MergeTags returns tags along with the existing tags whose key none of tags has. The key of a tag
+// is the part before ':', or the whole tag.
+func MergeTags(existing []string, tags []string) []string {
+	keys := map[string]bool{}
+	merged := []string{}
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		keys[tagKey(tag)] = true
+		merged = append(merged, tag)
+	}
+
+	for _, tag := range existing {
+		if tag == "" || keys[tagKey(tag)] {
+			continue
+		}
+		merged = append(merged, tag)
+	}
+
+	return merged
+}
+
+func tagKey(tag string) string {
+	return strings.SplitN(tag, ":", 2)[0]
+}
+
+// FindVirtualGuestByAgentID looks up the virtual guest tagged with AgentIDTag. Virtual guests being
+// cancelled or reclaimed are passed over, and of the others the newest is returned.
+func FindVirtualGuestByAgentID(softLayerClient sl.Client, agentID string) (datatypes.SoftLayer_Virtual_Guest, bool, error) {
+	masks := []string{
+		"id",
+		"hostname",
+		"domain",
+		"createDate",
+		"billingItem.cancellationDate",
+		"activeTransaction.transactionStatus.name",
+	}
+	filter := fmt.Sprintf(`{"virtualGuests":{"tagReferences":{"tag":{"name":{"operation":"%s"}}}}}`, AgentIDTag(agentID))
+
+	response, errorCode, err := softLayerClient.GetHttpClient().DoRawHttpRequestWithObjectFilterAndObjectMask("SoftLayer_Account/getVirtualGuests.json", masks, filter, "GET", new(bytes.Buffer))
+	if err != nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, false, bosherr.WrapErrorf(err, "Getting VirtualGuests tagged with agent ID `%s`", agentID)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return datatypes.SoftLayer_Virtual_Guest{}, false, bosherr.Errorf("Getting VirtualGuests tagged with agent ID `%s`, HTTP error code: '%d'", agentID, errorCode)
+	}
+
+	virtualGuests := []agentVirtualGuest{}
+	err = json.Unmarshal(response, &virtualGuests)
+	if err != nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, false, bosherr.WrapErrorf(err, "Unmarshalling VirtualGuests tagged with agent ID `%s`", agentID)
+	}
+
+	var newest *agentVirtualGuest
+	for i := range virtualGuests {
+		virtualGuest := &virtualGuests[i]
+		if virtualGuest.isGoing() {
+			continue
+		}
+
+		if newest == nil || virtualGuest.isNewerThan(*newest) {
+			newest = virtualGuest
+		}
+	}
+
+	if newest == nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, false, nil
+	}
+
+	return datatypes.SoftLayer_Virtual_Guest{
+		Id:         newest.Id,
+		Hostname:   newest.Hostname,
+		Domain:     newest.Domain,
+		CreateDate: newest.CreateDate,
+	}, true, nil
+}
+
+// isGoing tells whether the virtual guest has been cancelled or is being reclaimed
+func (g agentVirtualGuest) isGoing() bool {
+	if g.BillingItem != nil && g.BillingItem.CancellationDate != nil {
+		return true
+	}
+
+	if g.ActiveTransaction != nil {
+		status := strings.ToUpper(g.ActiveTransaction.TransactionStatus.Name)
+		return strings.Contains(status, "RECLAIM") || strings.Contains(status, "CANCEL")
+	}
+
+	return false
+}
+
+func (g agentVirtualGuest) isNewerThan(other agentVirtualGuest) bool {
+	if g.CreateDate != nil && other.CreateDate != nil && !g.CreateDate.Equal(*other.CreateDate) {
+		return g.CreateDate.After(*other.CreateDate)
+	}
+
+	return g.Id > other.Id
+}
+
 func GetObjectDetailsOnVirtualGuest(softLayerClient sl.Client, virtualGuestId int) (datatypes.SoftLayer_Virtual_Guest, error) {
 	virtualGuestService, err := softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
@@ -346,3 +545,29 @@ func GetObjectDetailsOnHardware(softLayerClient sl.Client, hardwareId int) (data
 	}
 	return hardware, nil
 }
+
+func IsObjectNotFoundError(err error) bool {
+	return ClassifyAPIError(err) == APIErrorNotFound
+}
+
+// Messages SoftLayer rejects an order with when a datacenter cannot take it right now, or does not
+// offer what it asks for
+var capacityErrorMessages = []string{
+	"insufficient capacity",
+	"insufficientcapacity",
+	"insufficient resources",
+	"out of stock",
+	"unable to place",
+	"not available in",
+	"no available",
+}
+
+// IsCapacityError tells whether an order failed because the datacenter has no capacity for it or
+// does not offer it, so that it may succeed in another datacenter
+func IsCapacityError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return containsAny(strings.ToLower(err.Error()), capacityErrorMessages)
+}
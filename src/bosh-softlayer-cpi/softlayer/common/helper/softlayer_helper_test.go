@@ -11,8 +11,6 @@ import (
 
 	testhelpers "bosh-softlayer-cpi/test_helpers"
 
-	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
-	fakesutil "bosh-softlayer-cpi/util/fakes"
 	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
 
 	slh "bosh-softlayer-cpi/softlayer/common/helper"
@@ -21,18 +19,14 @@ import (
 var _ = Describe("SoftLayerVirtualGuest", func() {
 	var (
 		fakeSoftLayerClient *fakeslclient.FakeSoftLayerClient
-		sshClient           *fakesutil.FakeSshClient
-		agentEnvService     *fakescommon.FakeAgentEnvService
 		logger              boshlog.Logger
+		waitPolicy          slh.WaitPolicy
 	)
 
 	BeforeEach(func() {
 		fakeSoftLayerClient = fakeslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
-		sshClient = &fakesutil.FakeSshClient{}
-		agentEnvService = &fakescommon.FakeAgentEnvService{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		slh.TIMEOUT = 10 * time.Millisecond
-		slh.POLLING_INTERVAL = 1 * time.Millisecond
+		waitPolicy = slh.NewWaitPolicy(10*time.Millisecond, 1*time.Millisecond)
 	})
 
 	Describe("WaitForVirtualGuestLastCompleteTransaction", func() {
@@ -48,7 +42,7 @@ var _ = Describe("SoftLayerVirtualGuest", func() {
 			})
 
 			It("returns nil", func() {
-				err := slh.WaitForVirtualGuestLastCompleteTransaction(fakeSoftLayerClient, 1234567, "Service Setup")
+				err := slh.WaitForVirtualGuestLastCompleteTransaction(fakeSoftLayerClient, 1234567, "Service Setup", waitPolicy)
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
@@ -59,7 +53,7 @@ var _ = Describe("SoftLayerVirtualGuest", func() {
 			})
 
 			It("returns error", func() {
-				err := slh.WaitForVirtualGuestLastCompleteTransaction(fakeSoftLayerClient, 1234567, "Service Setup")
+				err := slh.WaitForVirtualGuestLastCompleteTransaction(fakeSoftLayerClient, 1234567, "Service Setup", waitPolicy)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -84,7 +78,7 @@ var _ = Describe("SoftLayerVirtualGuest", func() {
 			})
 
 			It("returns error", func() {
-				err := slh.WaitForVirtualGuestLastCompleteTransaction(fakeSoftLayerClient, 1234567, "Service Setup")
+				err := slh.WaitForVirtualGuestLastCompleteTransaction(fakeSoftLayerClient, 1234567, "Service Setup", waitPolicy)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -109,7 +103,7 @@ var _ = Describe("SoftLayerVirtualGuest", func() {
 			})
 
 			It("returns nil", func() {
-				err := slh.AttachEphemeralDiskToVirtualGuest(fakeSoftLayerClient, 12345, 25, logger)
+				err := slh.AttachEphemeralDiskToVirtualGuest(fakeSoftLayerClient, 12345, 25, waitPolicy, logger)
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
@@ -132,9 +126,108 @@ var _ = Describe("SoftLayerVirtualGuest", func() {
 			})
 
 			It("returns error", func() {
-				err := slh.AttachEphemeralDiskToVirtualGuest(fakeSoftLayerClient, 12345, 25, logger)
+				err := slh.AttachEphemeralDiskToVirtualGuest(fakeSoftLayerClient, 12345, 25, waitPolicy, logger)
 				Expect(err).To(HaveOccurred())
 			})
 		})
 	})
+
+	Describe("FindVirtualGuestByAgentID", func() {
+		Context("when a virtual guest is tagged with the agent ID", func() {
+			BeforeEach(func() {
+				fileNames := []string{
+					"SoftLayer_Account_Service_getVirtualGuests.json",
+				}
+				testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, fileNames, 3)
+			})
+
+			It("returns the newest tagged virtual guest", func() {
+				virtualGuest, found, err := slh.FindVirtualGuestByAgentID(fakeSoftLayerClient, "fake-agent-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(virtualGuest.Id).To(Equal(5820228))
+				Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(ContainSubstring(slh.AgentIDTag("fake-agent-id")))
+			})
+		})
+
+		Context("when virtual guests tagged with the agent ID are being cancelled or reclaimed", func() {
+			BeforeEach(func() {
+				fileNames := []string{
+					"SoftLayer_Account_Service_getVirtualGuests_Cancelling.json",
+				}
+				testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, fileNames, 3)
+			})
+
+			It("returns the tagged virtual guest still active", func() {
+				virtualGuest, found, err := slh.FindVirtualGuestByAgentID(fakeSoftLayerClient, "fake-agent-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(virtualGuest.Id).To(Equal(5820228))
+			})
+		})
+
+		Context("when no virtual guest is tagged with the agent ID", func() {
+			BeforeEach(func() {
+				fileNames := []string{
+					"SoftLayer_Account_Service_getVirtualGuests_None.json",
+				}
+				testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, fileNames, 3)
+			})
+
+			It("reports that nothing was found", func() {
+				_, found, err := slh.FindVirtualGuestByAgentID(fakeSoftLayerClient, "fake-agent-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("SetVirtualGuestTags", func() {
+		BeforeEach(func() {
+			fileNames := []string{
+				"SoftLayer_Virtual_Guest_Service_getTagReferences.json",
+				"SoftLayer_Virtual_Guest_Service_setTags.json",
+			}
+			testhelpers.SetTestFixturesForFakeSoftLayerClientbyLevels(fakeSoftLayerClient, fileNames, 3)
+		})
+
+		It("replaces the tags with the same keys on the virtual guest and keeps its other tags", func() {
+			err := slh.SetVirtualGuestTags(fakeSoftLayerClient, 1234567, []string{"agent_id:fake-agent-id"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Virtual_Guest/1234567/setTags.json"))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring("agent_id:fake-agent-id, director_uuid:fake-director-uuid"))
+			Expect(fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestRequestBody.String()).NotTo(ContainSubstring("fake-old-agent-id"))
+		})
+	})
+
+	Describe("MergeTags", func() {
+		It("keeps the existing tags with other keys", func() {
+			merged := slh.MergeTags(
+				[]string{"agent_id:fake-agent-id", "director_uuid:fake-old-uuid", "fake-plain-tag"},
+				[]string{"director_uuid:fake-uuid", "job:fake-job", ""},
+			)
+			Expect(merged).To(Equal([]string{"director_uuid:fake-uuid", "job:fake-job", "agent_id:fake-agent-id", "fake-plain-tag"}))
+		})
+	})
+
+	Describe("IsCapacityError", func() {
+		It("recognizes a datacenter out of capacity", func() {
+			err := errors.New("Calling SoftLayer_Virtual_Guest#createObject, HTTP error code: '500', error: 'There is insufficient capacity to complete the request.'")
+			Expect(slh.IsCapacityError(err)).To(BeTrue())
+		})
+
+		It("does not retry other order errors elsewhere", func() {
+			err := errors.New("Calling SoftLayer_Virtual_Guest#createObject, HTTP error code: '500', error: 'Invalid value provided for startCpus.'")
+			Expect(slh.IsCapacityError(err)).To(BeFalse())
+			Expect(slh.IsCapacityError(nil)).To(BeFalse())
+		})
+
+		It("recognizes what the datacenter does not offer", func() {
+			err := errors.New("Calling SoftLayer_Virtual_Guest#createObject, HTTP error code: '500', error: 'The flavor B1_1X2X25 is not available in datacenter lon02.'")
+			Expect(slh.IsCapacityError(err)).To(BeTrue())
+
+			err = errors.New("Calling SoftLayer_Virtual_Guest#createObject, HTTP error code: '500', error: 'There are no available hosts in the selected datacenter.'")
+			Expect(slh.IsCapacityError(err)).To(BeTrue())
+		})
+	})
 })
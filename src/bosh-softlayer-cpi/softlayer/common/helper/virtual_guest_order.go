@@ -0,0 +1,69 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+// VirtualGuestTemplate is the template of a virtual guest order with the properties the
+// SoftLayer client has no field for
+type VirtualGuestTemplate struct {
+	datatypes.SoftLayer_Virtual_Guest_Template
+
+	DedicatedHost    *DedicatedHost `json:"dedicatedHost,omitempty"`
+	PlacementGroupId int            `json:"placementGroupId,omitempty"`
+	TagReferences    []TagReference `json:"tagReferences,omitempty"`
+}
+
+type TagReference struct {
+	Tag Tag `json:"tag"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// CreateVirtualGuest orders a virtual guest from the template. The error of a rejected order
+// carries the reason SoftLayer gives, such as a datacenter out of capacity.
+func CreateVirtualGuest(softLayerClient sl.Client, template VirtualGuestTemplate) (datatypes.SoftLayer_Virtual_Guest, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"parameters": []interface{}{template},
+	})
+	if err != nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, bosherr.WrapError(err, "Marshalling VirtualGuest template")
+	}
+
+	response, errorCode, err := softLayerClient.GetHttpClient().DoRawHttpRequest("SoftLayer_Virtual_Guest.json", "POST", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, bosherr.WrapError(err, "Calling SoftLayer_Virtual_Guest#createObject")
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		apiError := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(response, &apiError) == nil && apiError.Error != "" {
+			return datatypes.SoftLayer_Virtual_Guest{}, bosherr.Errorf("Calling SoftLayer_Virtual_Guest#createObject, HTTP error code: '%d', error: '%s'", errorCode, apiError.Error)
+		}
+
+		return datatypes.SoftLayer_Virtual_Guest{}, bosherr.Errorf("Calling SoftLayer_Virtual_Guest#createObject, HTTP error code: '%d'", errorCode)
+	}
+
+	err = softLayerClient.GetHttpClient().CheckForHttpResponseErrors(response)
+	if err != nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, bosherr.WrapError(err, "Calling SoftLayer_Virtual_Guest#createObject")
+	}
+
+	virtualGuest := datatypes.SoftLayer_Virtual_Guest{}
+	err = json.Unmarshal(response, &virtualGuest)
+	if err != nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, bosherr.WrapError(err, "Unmarshalling ordered VirtualGuest")
+	}
+
+	return virtualGuest, nil
+}
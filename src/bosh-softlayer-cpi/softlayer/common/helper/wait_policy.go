@@ -0,0 +1,184 @@
+package helper
+
+import (
+	"encoding/json"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+	"github.com/pivotal-golang/clock"
+)
+
+// WaitPolicy describes how long to poll SoftLayer for a long-running
+// operation and how often. Backoff multiplies the interval after every
+// attempt, up to MaxInterval when set; values below 1 keep the interval
+// constant.
+type WaitPolicy struct {
+	Timeout     time.Duration
+	Interval    time.Duration
+	Backoff     float64
+	MaxInterval time.Duration
+}
+
+// WaitPolicies holds one WaitPolicy per kind of operation so that, for
+// example, an OS reload can wait for hours while a disk attach gives up
+// after minutes.
+type WaitPolicies struct {
+	Create               WaitPolicy `json:"create"`
+	OSReload             WaitPolicy `json:"osReload"`
+	EphemeralDiskUpgrade WaitPolicy `json:"ephemeralDiskUpgrade"`
+	DiskAttach           WaitPolicy `json:"diskAttach"`
+	DiskResize           WaitPolicy `json:"diskResize"`
+	StemcellLookup       WaitPolicy `json:"stemcellLookup"`
+	Delete               WaitPolicy `json:"delete"`
+}
+
+func NewWaitPolicy(timeout time.Duration, interval time.Duration) WaitPolicy {
+	return WaitPolicy{
+		Timeout:  timeout,
+		Interval: interval,
+		Backoff:  1,
+	}
+}
+
+// NewAgingWaitPolicy returns a WaitPolicy that polls less often the longer
+// the operation runs, so that long transactions don't eat up the API rate
+// limit.
+func NewAgingWaitPolicy(timeout time.Duration, interval time.Duration, maxInterval time.Duration) WaitPolicy {
+	return WaitPolicy{
+		Timeout:     timeout,
+		Interval:    interval,
+		Backoff:     1.2,
+		MaxInterval: maxInterval,
+	}
+}
+
+func DefaultWaitPolicies() WaitPolicies {
+	return WaitPolicies{
+		Create:               NewAgingWaitPolicy(120*time.Minute, 5*time.Second, 60*time.Second),
+		OSReload:             NewAgingWaitPolicy(4*time.Hour, 10*time.Second, 120*time.Second),
+		EphemeralDiskUpgrade: NewAgingWaitPolicy(120*time.Minute, 5*time.Second, 60*time.Second),
+		DiskAttach:           NewWaitPolicy(60*time.Minute, 10*time.Second),
+		DiskResize:           NewAgingWaitPolicy(120*time.Minute, 10*time.Second, 60*time.Second),
+		StemcellLookup:       NewWaitPolicy(30*time.Second, 5*time.Second),
+		Delete:               NewAgingWaitPolicy(60*time.Minute, 10*time.Second, 60*time.Second),
+	}
+}
+
+// WithDefaults fills every unset field from DefaultWaitPolicies.
+func (p WaitPolicies) WithDefaults() WaitPolicies {
+	defaults := DefaultWaitPolicies()
+
+	return WaitPolicies{
+		Create:               p.Create.WithDefaults(defaults.Create),
+		OSReload:             p.OSReload.WithDefaults(defaults.OSReload),
+		EphemeralDiskUpgrade: p.EphemeralDiskUpgrade.WithDefaults(defaults.EphemeralDiskUpgrade),
+		DiskAttach:           p.DiskAttach.WithDefaults(defaults.DiskAttach),
+		DiskResize:           p.DiskResize.WithDefaults(defaults.DiskResize),
+		StemcellLookup:       p.StemcellLookup.WithDefaults(defaults.StemcellLookup),
+		Delete:               p.Delete.WithDefaults(defaults.Delete),
+	}
+}
+
+func (p WaitPolicy) WithDefaults(defaults WaitPolicy) WaitPolicy {
+	if p.Timeout <= 0 {
+		p.Timeout = defaults.Timeout
+	}
+
+	if p.Interval <= 0 {
+		p.Interval = defaults.Interval
+	}
+
+	if p.Backoff <= 0 {
+		p.Backoff = defaults.Backoff
+	}
+
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = defaults.MaxInterval
+	}
+
+	return p
+}
+
+// NextInterval returns the interval to sleep after an attempt that slept
+// for interval.
+func (p WaitPolicy) NextInterval(interval time.Duration) time.Duration {
+	if p.Backoff <= 1 {
+		return interval
+	}
+
+	next := time.Duration(float64(interval) * p.Backoff)
+	if p.MaxInterval > 0 && next > p.MaxInterval {
+		return p.MaxInterval
+	}
+
+	return next
+}
+
+// Wait calls check until it reports done, returns an error or the policy
+// times out. It returns false and no error on timeout.
+func (p WaitPolicy) Wait(check func() (bool, error)) (bool, error) {
+	interval := p.Interval
+	if interval <= 0 {
+		return false, bosherr.Error("Wait policy must have a positive polling interval")
+	}
+
+	totalTime := time.Duration(0)
+	for totalTime < p.Timeout {
+		done, err := check()
+		if err != nil {
+			return false, err
+		}
+
+		if done {
+			return true, nil
+		}
+
+		totalTime += interval
+		time.Sleep(interval)
+		interval = p.NextInterval(interval)
+	}
+
+	return false, nil
+}
+
+// RetryStrategy wraps retryable in a bosh timeout retry strategy bounded by
+// the policy. The bosh strategy uses a fixed delay, so Backoff is ignored.
+func (p WaitPolicy) RetryStrategy(retryable boshretry.Retryable, logger boshlog.Logger) boshretry.RetryStrategy {
+	return boshretry.NewTimeoutRetryStrategy(p.Timeout, p.Interval, retryable, clock.NewClock(), logger)
+}
+
+type waitPolicyJSON struct {
+	Timeout            int     `json:"timeout"`
+	PollingInterval    int     `json:"pollingInterval"`
+	Backoff            float64 `json:"backoff"`
+	MaxPollingInterval int     `json:"maxPollingInterval,omitempty"`
+}
+
+// UnmarshalJSON reads timeout and pollingInterval as seconds, matching the
+// other timeouts in the CPI configuration.
+func (p *WaitPolicy) UnmarshalJSON(data []byte) error {
+	var raw waitPolicyJSON
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling wait policy")
+	}
+
+	p.Timeout = time.Duration(raw.Timeout) * time.Second
+	p.Interval = time.Duration(raw.PollingInterval) * time.Second
+	p.Backoff = raw.Backoff
+	p.MaxInterval = time.Duration(raw.MaxPollingInterval) * time.Second
+
+	return nil
+}
+
+func (p WaitPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(waitPolicyJSON{
+		Timeout:            int(p.Timeout / time.Second),
+		PollingInterval:    int(p.Interval / time.Second),
+		Backoff:            p.Backoff,
+		MaxPollingInterval: int(p.MaxInterval / time.Second),
+	})
+}
@@ -0,0 +1,110 @@
+package helper_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+var _ = Describe("WaitPolicy", func() {
+	var (
+		waitPolicy slh.WaitPolicy
+	)
+
+	BeforeEach(func() {
+		waitPolicy = slh.NewWaitPolicy(10*time.Millisecond, 1*time.Millisecond)
+	})
+
+	Describe("Wait", func() {
+		It("returns true when the check is done", func() {
+			attempts := 0
+			done, err := waitPolicy.Wait(func() (bool, error) {
+				attempts++
+				return attempts == 3, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeTrue())
+			Expect(attempts).To(Equal(3))
+		})
+
+		It("returns the error of the check", func() {
+			done, err := waitPolicy.Wait(func() (bool, error) {
+				return false, errors.New("fake-error")
+			})
+			Expect(err).To(MatchError("fake-error"))
+			Expect(done).To(BeFalse())
+		})
+
+		It("returns false when the policy times out", func() {
+			attempts := 0
+			done, err := waitPolicy.Wait(func() (bool, error) {
+				attempts++
+				return false, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(attempts).To(Equal(10))
+		})
+
+		It("makes fewer attempts when backing off", func() {
+			waitPolicy.Backoff = 2
+
+			attempts := 0
+			done, err := waitPolicy.Wait(func() (bool, error) {
+				attempts++
+				return false, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+			Expect(attempts).To(Equal(4))
+		})
+
+		It("does not back off beyond the maximum interval", func() {
+			policy := slh.NewAgingWaitPolicy(time.Hour, 10*time.Second, 15*time.Second)
+
+			Expect(policy.NextInterval(10 * time.Second)).To(Equal(12 * time.Second))
+			Expect(policy.NextInterval(14 * time.Second)).To(Equal(15 * time.Second))
+			Expect(policy.NextInterval(15 * time.Second)).To(Equal(15 * time.Second))
+		})
+
+		It("returns error when the interval is not positive", func() {
+			_, err := slh.WaitPolicy{Timeout: time.Second}.Wait(func() (bool, error) {
+				return true, nil
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("WaitPolicies", func() {
+		It("fills unset policies from the defaults", func() {
+			waitPolicies := slh.WaitPolicies{DiskAttach: slh.WaitPolicy{Timeout: 5 * time.Minute}}.WithDefaults()
+
+			defaults := slh.DefaultWaitPolicies()
+			Expect(waitPolicies.Create).To(Equal(defaults.Create))
+			Expect(waitPolicies.OSReload).To(Equal(defaults.OSReload))
+			Expect(waitPolicies.DiskAttach.Timeout).To(Equal(5 * time.Minute))
+			Expect(waitPolicies.DiskAttach.Interval).To(Equal(defaults.DiskAttach.Interval))
+			Expect(waitPolicies.DiskAttach.Backoff).To(Equal(defaults.DiskAttach.Backoff))
+		})
+
+		It("keeps the max polling interval of a policy without backoff", func() {
+			waitPolicies := slh.WaitPolicies{Create: slh.WaitPolicy{MaxInterval: 20 * time.Second}}.WithDefaults()
+
+			Expect(waitPolicies.Create.MaxInterval).To(Equal(20 * time.Second))
+			Expect(waitPolicies.Create.Backoff).To(Equal(slh.DefaultWaitPolicies().Create.Backoff))
+		})
+
+		It("reads timeouts and polling intervals as seconds", func() {
+			var waitPolicies slh.WaitPolicies
+			err := json.Unmarshal([]byte(`{"osReload":{"timeout":7200,"pollingInterval":30,"backoff":1.5}}`), &waitPolicies)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(waitPolicies.OSReload).To(Equal(slh.WaitPolicy{Timeout: 2 * time.Hour, Interval: 30 * time.Second, Backoff: 1.5}))
+			Expect(waitPolicies.Create).To(Equal(slh.WaitPolicy{}))
+		})
+	})
+})
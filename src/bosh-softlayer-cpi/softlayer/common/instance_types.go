@@ -0,0 +1,184 @@
+package common
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+const (
+	// Largest second disk, in GB, SoftLayer attaches to a virtual guest on local or on SAN storage
+	MaxLocalEphemeralDiskSize = 300
+	MaxSanEphemeralDiskSize   = 2000
+)
+
+var NetworkSpeeds = []int{10, 100, 1000}
+
+// InstanceType is a named flavor of virtual guest defined in the CPI config.
+// The instance_type cloud property picks one; properties set explicitly in the
+// cloud properties win over it. Datacenters overrides any of its properties for
+// virtual guests ordered in the datacenter with that name.
+type InstanceType struct {
+	StartCpus         int   `json:"startCpus,omitempty"`
+	MaxMemory         int   `json:"maxMemory,omitempty"`
+	LocalDiskFlag     *bool `json:"localDiskFlag,omitempty"`
+	MaxNetworkSpeed   int   `json:"maxNetworkSpeed,omitempty"`
+	EphemeralDiskSize int   `json:"ephemeralDiskSize,omitempty"`
+	HourlyBillingFlag *bool `json:"hourlyBillingFlag,omitempty"`
+
+	Datacenters map[string]InstanceType `json:"datacenters,omitempty"`
+}
+
+type InstanceTypes map[string]InstanceType
+
+// Resolve returns the named instance type with the overrides of the datacenter applied
+func (t InstanceTypes) Resolve(name string, datacenter string) (InstanceType, error) {
+	instanceType, found := t[name]
+	if !found {
+		return InstanceType{}, bosherr.Errorf("Unknown instance type '%s'", name)
+	}
+
+	if override, found := instanceType.Datacenters[datacenter]; found {
+		instanceType = instanceType.overriddenBy(override)
+	}
+	instanceType.Datacenters = nil
+
+	return instanceType, nil
+}
+
+// Overrides tells whether the named instance type overrides any of its properties in the datacenter
+func (t InstanceTypes) Overrides(name string, datacenter string) bool {
+	_, found := t[name].Datacenters[datacenter]
+	return found
+}
+
+func (t InstanceTypes) Validate() error {
+	for name, instanceType := range t {
+		err := instanceType.validate()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Validating instance type '%s'", name)
+		}
+
+		for datacenter, override := range instanceType.Datacenters {
+			if len(override.Datacenters) > 0 {
+				return bosherr.Errorf("Validating instance type '%s': the override for datacenter '%s' must not have datacenters of its own", name, datacenter)
+			}
+
+			err = instanceType.overriddenBy(override).validate()
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Validating instance type '%s' in datacenter '%s'", name, datacenter)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t InstanceType) overriddenBy(override InstanceType) InstanceType {
+	if override.StartCpus != 0 {
+		t.StartCpus = override.StartCpus
+	}
+	if override.MaxMemory != 0 {
+		t.MaxMemory = override.MaxMemory
+	}
+	if override.LocalDiskFlag != nil {
+		t.LocalDiskFlag = override.LocalDiskFlag
+	}
+	if override.MaxNetworkSpeed != 0 {
+		t.MaxNetworkSpeed = override.MaxNetworkSpeed
+	}
+	if override.EphemeralDiskSize != 0 {
+		t.EphemeralDiskSize = override.EphemeralDiskSize
+	}
+	if override.HourlyBillingFlag != nil {
+		t.HourlyBillingFlag = override.HourlyBillingFlag
+	}
+
+	return t
+}
+
+func (t InstanceType) validate() error {
+	if t.StartCpus < 0 || t.MaxMemory < 0 || t.EphemeralDiskSize < 0 {
+		return bosherr.Error("startCpus, maxMemory and ephemeralDiskSize must not be negative")
+	}
+
+	if t.MaxNetworkSpeed != 0 && !isNetworkSpeed(t.MaxNetworkSpeed) {
+		return bosherr.Errorf("maxNetworkSpeed must be one of %v", NetworkSpeeds)
+	}
+
+	localDisk := t.LocalDiskFlag == nil || *t.LocalDiskFlag
+	return validateEphemeralDiskSize(t.EphemeralDiskSize, localDisk)
+}
+
+// ApplyInstanceType fills the properties the cloud properties leave out from the instance type
+func (p *VMCloudProperties) ApplyInstanceType(instanceType InstanceType) {
+	if !p.isSet("startCpus", p.StartCpus != 0) && instanceType.StartCpus != 0 {
+		p.StartCpus = instanceType.StartCpus
+	}
+
+	if !p.isSet("maxMemory", p.MaxMemory != 0) && instanceType.MaxMemory != 0 {
+		p.MaxMemory = instanceType.MaxMemory
+	}
+
+	if !p.isSet("localDiskFlag", false) && instanceType.LocalDiskFlag != nil {
+		p.LocalDiskFlag = *instanceType.LocalDiskFlag
+	}
+
+	if len(p.NetworkComponents) == 0 && instanceType.MaxNetworkSpeed != 0 {
+		p.NetworkComponents = []sldatatypes.NetworkComponents{{MaxSpeed: instanceType.MaxNetworkSpeed}}
+	}
+
+	if !p.isSet("ephemeralDiskSize", p.EphemeralDiskSize != 0) && instanceType.EphemeralDiskSize != 0 {
+		p.EphemeralDiskSize = instanceType.EphemeralDiskSize
+	}
+
+	if !p.isSet("hourlyBillingFlag", p.HourlyBillingFlag) && instanceType.HourlyBillingFlag != nil {
+		p.HourlyBillingFlag = *instanceType.HourlyBillingFlag
+	}
+}
+
+// Validate rejects cloud properties SoftLayer cannot order a virtual guest with
+func (p VMCloudProperties) Validate() error {
+	if p.StartCpus <= 0 {
+		return bosherr.Errorf("startCpus must be positive, got %d", p.StartCpus)
+	}
+
+	if p.MaxMemory <= 0 {
+		return bosherr.Errorf("maxMemory must be positive, got %d", p.MaxMemory)
+	}
+
+	for _, networkComponent := range p.NetworkComponents {
+		if networkComponent.MaxSpeed != 0 && !isNetworkSpeed(networkComponent.MaxSpeed) {
+			return bosherr.Errorf("networkComponents maxSpeed must be one of %v, got %d", NetworkSpeeds, networkComponent.MaxSpeed)
+		}
+	}
+
+	err := p.validatePlacement()
+	if err != nil {
+		return err
+	}
+
+	return validateEphemeralDiskSize(p.EphemeralDiskSize, p.LocalDiskFlag)
+}
+
+func validateEphemeralDiskSize(size int, localDisk bool) error {
+	if localDisk && size > MaxLocalEphemeralDiskSize {
+		return bosherr.Errorf("ephemeralDiskSize %d GB is over the %d GB limit of local disks", size, MaxLocalEphemeralDiskSize)
+	}
+
+	if !localDisk && size > MaxSanEphemeralDiskSize {
+		return bosherr.Errorf("ephemeralDiskSize %d GB is over the %d GB limit of SAN disks", size, MaxSanEphemeralDiskSize)
+	}
+
+	return nil
+}
+
+func isNetworkSpeed(speed int) bool {
+	for _, networkSpeed := range NetworkSpeeds {
+		if speed == networkSpeed {
+			return true
+		}
+	}
+
+	return false
+}
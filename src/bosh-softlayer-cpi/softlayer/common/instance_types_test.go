@@ -0,0 +1,137 @@
+package common_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+var _ = Describe("InstanceTypes", func() {
+	var (
+		trueFlag      = true
+		falseFlag     = false
+		instanceTypes InstanceTypes
+	)
+
+	BeforeEach(func() {
+		instanceTypes = InstanceTypes{
+			"small": InstanceType{
+				StartCpus:         2,
+				MaxMemory:         4096,
+				LocalDiskFlag:     &trueFlag,
+				MaxNetworkSpeed:   100,
+				EphemeralDiskSize: 100,
+				HourlyBillingFlag: &trueFlag,
+				Datacenters: map[string]InstanceType{
+					"lon02": InstanceType{MaxMemory: 8192, LocalDiskFlag: &falseFlag},
+				},
+			},
+		}
+	})
+
+	Describe("Resolve", func() {
+		It("returns the instance type", func() {
+			instanceType, err := instanceTypes.Resolve("small", "dal09")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instanceType.MaxMemory).To(Equal(4096))
+			Expect(*instanceType.LocalDiskFlag).To(BeTrue())
+			Expect(instanceType.Datacenters).To(BeNil())
+		})
+
+		It("applies the overrides of the datacenter", func() {
+			instanceType, err := instanceTypes.Resolve("small", "lon02")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instanceType.StartCpus).To(Equal(2))
+			Expect(instanceType.MaxMemory).To(Equal(8192))
+			Expect(*instanceType.LocalDiskFlag).To(BeFalse())
+		})
+
+		It("returns an error for an unknown instance type", func() {
+			_, err := instanceTypes.Resolve("huge", "lon02")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown instance type 'huge'"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts valid instance types", func() {
+			Expect(instanceTypes.Validate()).To(Succeed())
+		})
+
+		It("rejects a network speed SoftLayer does not offer", func() {
+			instanceTypes["fast"] = InstanceType{MaxNetworkSpeed: 10000}
+			Expect(instanceTypes.Validate()).NotTo(Succeed())
+		})
+
+		It("rejects a local ephemeral disk over the local disk limit", func() {
+			instanceTypes["big"] = InstanceType{EphemeralDiskSize: 500}
+			Expect(instanceTypes.Validate()).NotTo(Succeed())
+		})
+
+		It("rejects a datacenter override that makes the instance type impossible", func() {
+			instanceTypes["small"].Datacenters["dal09"] = InstanceType{EphemeralDiskSize: 500, LocalDiskFlag: &trueFlag}
+			Expect(instanceTypes.Validate()).NotTo(Succeed())
+		})
+	})
+})
+
+var _ = Describe("VMCloudProperties", func() {
+	var (
+		trueFlag     = true
+		instanceType InstanceType
+	)
+
+	BeforeEach(func() {
+		instanceType = InstanceType{
+			StartCpus:         2,
+			MaxMemory:         4096,
+			MaxNetworkSpeed:   100,
+			EphemeralDiskSize: 100,
+			HourlyBillingFlag: &trueFlag,
+		}
+	})
+
+	Describe("ApplyInstanceType", func() {
+		It("fills the properties the cloud properties leave out", func() {
+			cloudProps := VMCloudProperties{}
+			err := json.Unmarshal([]byte(`{"instance_type": "small", "maxMemory": 2048}`), &cloudProps)
+			Expect(err).NotTo(HaveOccurred())
+
+			cloudProps.ApplyInstanceType(instanceType)
+			Expect(cloudProps.StartCpus).To(Equal(2))
+			Expect(cloudProps.MaxMemory).To(Equal(2048))
+			Expect(cloudProps.NetworkComponents).To(Equal([]sldatatypes.NetworkComponents{{MaxSpeed: 100}}))
+			Expect(cloudProps.EphemeralDiskSize).To(Equal(100))
+			Expect(cloudProps.HourlyBillingFlag).To(BeTrue())
+		})
+
+		It("keeps flags set to false explicitly", func() {
+			cloudProps := VMCloudProperties{}
+			err := json.Unmarshal([]byte(`{"HourlyBillingFlag": false}`), &cloudProps)
+			Expect(err).NotTo(HaveOccurred())
+
+			cloudProps.ApplyInstanceType(instanceType)
+			Expect(cloudProps.HourlyBillingFlag).To(BeFalse())
+		})
+	})
+
+	Describe("Validate", func() {
+		It("rejects a local ephemeral disk over the local disk limit", func() {
+			cloudProps := VMCloudProperties{StartCpus: 2, MaxMemory: 4096, LocalDiskFlag: true, EphemeralDiskSize: 500}
+			Expect(cloudProps.Validate()).NotTo(Succeed())
+
+			cloudProps.LocalDiskFlag = false
+			Expect(cloudProps.Validate()).To(Succeed())
+		})
+
+		It("rejects missing CPUs or memory", func() {
+			Expect(VMCloudProperties{MaxMemory: 4096}.Validate()).NotTo(Succeed())
+			Expect(VMCloudProperties{StartCpus: 2}.Validate()).NotTo(Succeed())
+		})
+	})
+})
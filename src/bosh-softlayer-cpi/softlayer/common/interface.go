@@ -1,6 +1,11 @@
 package common
 
 import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	sldatatypes "github.com/maximilien/softlayer-go/data_types"
 
 	bslcdisk "bosh-softlayer-cpi/softlayer/disk"
@@ -9,6 +14,13 @@ import (
 
 type Environment map[string]interface{}
 
+// BoshGroup returns the group the director puts the VM in, named after its director, deployment and instance group
+func (e Environment) BoshGroup() string {
+	bosh, _ := e["bosh"].(map[string]interface{})
+	group, _ := bosh["group"].(string)
+	return group
+}
+
 type Mount struct {
 	PartitionPath string
 	MountPoint    string
@@ -17,11 +29,64 @@ type Mount struct {
 type FeatureOptions struct {
 	DisableOsReload                  bool   `json:"disableOsReload"`
 	EnablePool                       bool   `json:"enablePool"`
+	KeepFailedVMs                    bool   `json:"keepFailedVms"`
 	ApiEndpoint                      string `json:"apiEndpoint"`
 	ApiWaitTime                      int    `json:"apiWaitTime"`
 	ApiRetryCount                    int    `json:"apiRetryCount"`
 	CreateISCSIVolumeTimeout         int    `json:"createIscsiVolumeTimeout"`
 	CreateISCSIVolumePollingInterval int    `json:"createIscsiVolumePollingInterval"`
+	UpdateAgentEnvWaitTime           int    `json:"updateAgentEnvWaitTime"`
+	UpdateAgentEnvRetryCount         int    `json:"updateAgentEnvRetryCount"`
+
+	NetworkInterface          string            `json:"networkInterface,omitempty"`
+	LocalDNSConfigurationFile string            `json:"localDnsConfigurationFile,omitempty"`
+	WaitPolicies              slh.WaitPolicies  `json:"waitPolicies,omitempty"`
+	ApiBackoff                slh.BackoffPolicy `json:"apiBackoff,omitempty"`
+	ApiRateLimit              float64           `json:"apiRateLimit,omitempty"`
+	ApiRateLimitBurst         int               `json:"apiRateLimitBurst,omitempty"`
+	ApiRateLimitStateFile     string            `json:"apiRateLimitStateFile,omitempty"`
+	PoolLeaseDuration         int               `json:"poolLeaseDuration,omitempty"`
+	DiskAttachMode            string            `json:"diskAttachMode,omitempty"`
+}
+
+const (
+	DefaultNetworkInterface          = "eth0"
+	DefaultLocalDNSConfigurationFile = "/etc/hosts"
+
+	DefaultPoolLeaseDuration = 10 * time.Minute
+
+	// The agent logs in to the iSCSI targets of a disk given in its disk hint
+	DiskAttachModeAgent = "agent"
+	// The CPI logs in to the iSCSI targets of a disk over SSH as root
+	DiskAttachModeSSH = "ssh"
+)
+
+// WithDefaults fills the local network interface, the local DNS configuration file, the disk attach mode and every unset wait policy
+func (o FeatureOptions) WithDefaults() FeatureOptions {
+	if o.NetworkInterface == "" {
+		o.NetworkInterface = DefaultNetworkInterface
+	}
+
+	if o.DiskAttachMode == "" {
+		o.DiskAttachMode = DiskAttachModeSSH
+	}
+
+	if o.LocalDNSConfigurationFile == "" {
+		o.LocalDNSConfigurationFile = DefaultLocalDNSConfigurationFile
+	}
+
+	o.WaitPolicies = o.WaitPolicies.WithDefaults()
+
+	return o
+}
+
+// PoolLease returns how long a VM ordered from the pool stays leased without being renewed, given in seconds by PoolLeaseDuration
+func (o FeatureOptions) PoolLease() time.Duration {
+	if o.PoolLeaseDuration <= 0 {
+		return DefaultPoolLeaseDuration
+	}
+
+	return time.Duration(o.PoolLeaseDuration) * time.Second
 }
 
 type VMCloudProperties struct {
@@ -55,6 +120,77 @@ type VMCloudProperties struct {
 	BaremetalNetbootImage string `json:"bm_netboot_image,omitempty"`
 
 	DisableOsReload bool `json:"disableOsReload,omitempty"`
+
+	InstanceType string `json:"instance_type,omitempty"`
+
+	DedicatedHostId      int    `json:"dedicatedHostId,omitempty"`
+	DedicatedHostName    string `json:"dedicatedHostName,omitempty"`
+	PlacementGroupId     int    `json:"placementGroupId,omitempty"`
+	PlacementGroupName   string `json:"placementGroupName,omitempty"`
+	CreatePlacementGroup bool   `json:"createPlacementGroup,omitempty"`
+
+	// Datacenters to try in order when the datacenter cloud property lists several. Datacenter is the first.
+	Datacenters []FailoverDatacenter `json:"-"`
+
+	// lower-cased names of the properties the cloud properties were unmarshaled from
+	setProperties map[string]bool
+	// properties of the wrong type and properties no field takes, reported by CheckCloudProperties
+	problems          []string
+	unknownProperties []string
+}
+
+// LocalDiskFlag defaults to true when it is not specified in the cloud properties.
+// This default can be removed once LocalDiskFlag is set in place in all deployment manifests
+func (p *VMCloudProperties) UnmarshalJSON(data []byte) error {
+	type vmCloudProperties VMCloudProperties
+
+	var properties map[string]json.RawMessage
+	err := json.Unmarshal(data, &properties)
+	if err != nil {
+		return err
+	}
+
+	setProperties := map[string]bool{}
+	var datacenters []FailoverDatacenter
+	problems := []string{}
+	unknownProperties := []string{}
+	for name, value := range properties {
+		setProperties[strings.ToLower(name)] = true
+
+		if strings.EqualFold(name, "datacenter") && isJSONList(value) {
+			datacenters, problems, unknownProperties = decodeFailoverDatacenters(name, value)
+			delete(properties, name)
+		}
+	}
+
+	data, err = json.Marshal(properties)
+	if err != nil {
+		return err
+	}
+
+	props := vmCloudProperties{LocalDiskFlag: true}
+	fieldProblems, fieldUnknownProperties, err := decodeStrictly(data, &props, "")
+	if err != nil {
+		return err
+	}
+
+	*p = VMCloudProperties(props)
+	if len(datacenters) > 0 {
+		p.Datacenter = sldatatypes.Datacenter{Name: datacenters[0].Name}
+		p.Datacenters = datacenters
+	}
+
+	p.setProperties = setProperties
+	p.problems = append(problems, fieldProblems...)
+	p.unknownProperties = append(unknownProperties, fieldUnknownProperties...)
+
+	return nil
+}
+
+// isSet tells whether a property was given in the cloud properties. Cloud properties
+// built in code rather than unmarshaled only know whether the property is non-zero.
+func (p VMCloudProperties) isSet(name string, nonZero bool) bool {
+	return nonZero || p.setProperties[strings.ToLower(name)]
 }
 
 type AllowedHostCredential struct {
@@ -92,11 +228,13 @@ const (
 	SOFTLAYER_VM_LOG_TAG         = "SoftLayerVM"
 	ROOT_USER_NAME               = "root"
 	SOFTLAYER_VM_CREATOR_LOG_TAG = "SoftLayerVMCreator"
+	SOFTLAYER_VM_QUOTER_LOG_TAG  = "SoftLayerVMQuoter"
 )
 
 //go:generate counterfeiter -o fakes/fake_vm.go . VM
 type VM interface {
-	AttachDisk(bslcdisk.Disk) error
+	// AttachDisk returns the disk hint, the device path the disk shows up as or its iSCSI settings
+	AttachDisk(bslcdisk.Disk) (interface{}, error)
 
 	ConfigureNetworks(Networks) error
 
@@ -104,6 +242,9 @@ type VM interface {
 	ConfigureNetworks2(Networks) error
 
 	DetachDisk(bslcdisk.Disk) error
+
+	// RescanDisk has the VM pick up the new size of an attached disk
+	RescanDisk(bslcdisk.Disk) error
 	Delete(agentId string) error
 
 	GetDataCenterId() int
@@ -111,6 +252,7 @@ type VM interface {
 	GetPrimaryBackendIP() string
 	GetRootPassword() string
 	GetFullyQualifiedDomainName() string
+	GetAllowedHostType() string
 
 	ID() int
 
@@ -141,3 +283,8 @@ type VMDeleter interface {
 type VMFinder interface {
 	Find(int) (VM, bool, error)
 }
+
+//go:generate counterfeiter -o fakes/fake_vm_quoter.go . VMQuoter
+type VMQuoter interface {
+	Quote(bslcstem.Stemcell, VMCloudProperties, Networks) (slh.OrderQuote, error)
+}
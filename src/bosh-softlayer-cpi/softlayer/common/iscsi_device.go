@@ -0,0 +1,198 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-softlayer-cpi/util"
+)
+
+// IscsiDeviceFinder finds the device an iSCSI volume shows up as on a host by the IQN of its
+// target and its LUN, so that volumes logged in at the same time cannot be taken for each other
+type IscsiDeviceFinder interface {
+	Find(targets []string, lun string, hasMultiPath bool) (string, error)
+}
+
+type iscsiDeviceFinder struct {
+	sshClient util.SshClient
+	user      string
+	password  string
+	ip        string
+	logger    boshlog.Logger
+	logTag    string
+}
+
+func NewIscsiDeviceFinder(sshClient util.SshClient, user string, password string, ip string, logger boshlog.Logger) IscsiDeviceFinder {
+	return &iscsiDeviceFinder{
+		sshClient: sshClient,
+		user:      user,
+		password:  password,
+		ip:        ip,
+		logger:    logger,
+		logTag:    "iscsiDeviceFinder",
+	}
+}
+
+// IscsiTargets returns the IQNs of the targets listed in the output of
+// `iscsiadm -m discovery -t sendtargets`, such as
+//
+//	10.1.222.67:3260,1031 iqn.1992-08.com.netapp:lon0201
+func IscsiTargets(discovery string) []string {
+	targets := []string{}
+	for _, line := range strings.Split(discovery, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "iqn.") {
+			continue
+		}
+
+		if !containsString(targets, fields[1]) {
+			targets = append(targets, fields[1])
+		}
+	}
+
+	return targets
+}
+
+// Find returns the path of the device of the volume with lun on one of targets, or "" while the
+// volume has not shown up on the host yet. The device is looked up in /dev/disk/by-path and, on
+// hosts without it, in the session data of iscsiadm. With multipath, the path is the one of the
+// multipath device holding it.
+func (f *iscsiDeviceFinder) Find(targets []string, lun string, hasMultiPath bool) (string, error) {
+	if len(targets) == 0 {
+		return "", bosherr.Error("No iSCSI targets to find the device in")
+	}
+	if lun == "" {
+		return "", bosherr.Error("No LUN to find the device of")
+	}
+
+	device, err := f.findByPath(targets, lun)
+	if err != nil {
+		return "", err
+	}
+
+	if device == "" {
+		device, err = f.findBySession(targets, lun)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if device == "" || !hasMultiPath {
+		return device, nil
+	}
+
+	return f.findMultiPathHolder(device)
+}
+
+func (f *iscsiDeviceFinder) findByPath(targets []string, lun string) (string, error) {
+	patterns := []string{}
+	for _, target := range targets {
+		patterns = append(patterns, fmt.Sprintf("/dev/disk/by-path/ip-*-iscsi-%s-lun-%s", target, lun))
+	}
+
+	command := fmt.Sprintf("for p in %s; do [ -e \"$p\" ] && readlink -f \"$p\"; done; true", strings.Join(patterns, " "))
+	output, err := f.sshClient.ExecCommand(f.user, f.password, f.ip, command)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Resolving iSCSI devices in /dev/disk/by-path")
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "/dev/") {
+			f.logger.Debug(f.logTag, "Found device %s of LUN %s in /dev/disk/by-path", line, lun)
+			return line, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (f *iscsiDeviceFinder) findBySession(targets []string, lun string) (string, error) {
+	output, err := f.sshClient.ExecCommand(f.user, f.password, f.ip, "iscsiadm -m session -P 3 2>/dev/null; true")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Listing iSCSI sessions")
+	}
+
+	device := IscsiSessionDevice(output, targets, lun)
+	if device != "" {
+		f.logger.Debug(f.logTag, "Found device %s of LUN %s in iSCSI sessions", device, lun)
+	}
+
+	return device, nil
+}
+
+func (f *iscsiDeviceFinder) findMultiPathHolder(device string) (string, error) {
+	name := strings.TrimPrefix(device, "/dev/")
+	command := fmt.Sprintf("cat /sys/block/%s/holders/*/dm/name 2>/dev/null; true", name)
+	output, err := f.sshClient.ExecCommand(f.user, f.password, f.ip, command)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Finding multipath device holding %s", device)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			f.logger.Debug(f.logTag, "Found multipath device %s holding %s", line, device)
+			return "/dev/mapper/" + line, nil
+		}
+	}
+
+	return "", nil
+}
+
+// RescanIscsiDevice has a host pick up the new size of the iSCSI devices it is logged in to and,
+// when devicePath is a multipath device, of the multipath device too
+func RescanIscsiDevice(sshClient util.SshClient, user string, password string, ip string, devicePath string) error {
+	_, err := sshClient.ExecCommand(user, password, ip, "iscsiadm -m session --rescan")
+	if err != nil {
+		return bosherr.WrapError(err, "Rescanning iSCSI sessions")
+	}
+
+	if !strings.HasPrefix(devicePath, "/dev/mapper/") {
+		return nil
+	}
+
+	name := strings.TrimPrefix(devicePath, "/dev/mapper/")
+	_, err = sshClient.ExecCommand(user, password, ip, fmt.Sprintf("multipathd -k'resize map %s'", name))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resizing multipath device %s", devicePath)
+	}
+
+	return nil
+}
+
+// IscsiSessionDevice returns the path of the disk attached for lun on one of targets in the output
+// of `iscsiadm -m session -P 3`, or "" when there is none
+func IscsiSessionDevice(sessions string, targets []string, lun string) string {
+	var target, currentLun string
+	for _, line := range strings.Split(sessions, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Target: "):
+			target = strings.Fields(line)[1]
+			currentLun = ""
+		case strings.Contains(line, "Lun: "):
+			currentLun = strings.TrimSpace(line[strings.Index(line, "Lun: ")+len("Lun: "):])
+		case strings.HasPrefix(line, "Attached scsi disk "):
+			fields := strings.Fields(line)
+			if len(fields) > 3 && containsString(targets, target) && currentLun == lun {
+				return "/dev/" + fields[3]
+			}
+		}
+	}
+
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,134 @@
+package common_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	fakesutil "bosh-softlayer-cpi/util/fakes"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+)
+
+var _ = Describe("IscsiDeviceFinder", func() {
+	const sessions = `Target: iqn.1992-08.com.netapp:lon0201 (non-flash)
+	Current Portal: 10.1.222.67:3260,1031
+	Persistent Portal: 10.1.222.67:3260,1031
+		Host Number: 3	State: running
+		scsi3 Channel 00 Id 0 Lun: 0
+			Attached scsi disk sdb		State: running
+		scsi3 Channel 00 Id 0 Lun: 1
+			Attached scsi disk sdc		State: running
+Target: iqn.1992-08.com.netapp:lon0202 (non-flash)
+	Current Portal: 10.1.222.68:3260,1032
+		Host Number: 4	State: running
+		scsi4 Channel 00 Id 0 Lun: 1
+			Attached scsi disk sdd		State: running
+`
+
+	var (
+		logger       boshlog.Logger
+		sshClient    *fakesutil.FakeSshClient
+		deviceFinder IscsiDeviceFinder
+		outputs      []string
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		sshClient = &fakesutil.FakeSshClient{}
+		deviceFinder = NewIscsiDeviceFinder(sshClient, "root", "fake-password", "10.0.0.1", logger)
+		sshClient.ExecCommandStub = func(_, _, _, _ string) (string, error) {
+			return outputs[sshClient.ExecCommandCallCount()-1], nil
+		}
+	})
+
+	Describe("IscsiTargets", func() {
+		It("returns each target discovered once", func() {
+			discovery := `10.1.222.67:3260,1031 iqn.1992-08.com.netapp:lon0201
+10.1.222.52:3260,1031 iqn.1992-08.com.netapp:lon0201
+10.1.222.68:3260,1032 iqn.1992-08.com.netapp:lon0202
+`
+			Expect(IscsiTargets(discovery)).To(Equal([]string{"iqn.1992-08.com.netapp:lon0201", "iqn.1992-08.com.netapp:lon0202"}))
+		})
+
+		It("ignores lines that list no target", func() {
+			Expect(IscsiTargets("iscsiadm: No portals found\n")).To(BeEmpty())
+		})
+	})
+
+	Describe("IscsiSessionDevice", func() {
+		It("returns the disk attached for the LUN on the target", func() {
+			Expect(IscsiSessionDevice(sessions, []string{"iqn.1992-08.com.netapp:lon0201"}, "1")).To(Equal("/dev/sdc"))
+			Expect(IscsiSessionDevice(sessions, []string{"iqn.1992-08.com.netapp:lon0202"}, "1")).To(Equal("/dev/sdd"))
+		})
+
+		It("returns nothing when the LUN is on none of the targets", func() {
+			Expect(IscsiSessionDevice(sessions, []string{"iqn.1992-08.com.netapp:lon0202"}, "0")).To(Equal(""))
+		})
+	})
+
+	Describe("Find", func() {
+		targets := []string{"iqn.1992-08.com.netapp:lon0201"}
+
+		It("resolves the device in /dev/disk/by-path", func() {
+			outputs = []string{"/dev/sdc\n"}
+
+			device, err := deviceFinder.Find(targets, "1", false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(device).To(Equal("/dev/sdc"))
+
+			_, _, _, command := sshClient.ExecCommandArgsForCall(0)
+			Expect(command).To(ContainSubstring("/dev/disk/by-path/ip-*-iscsi-iqn.1992-08.com.netapp:lon0201-lun-1"))
+		})
+
+		It("falls back to the iSCSI sessions", func() {
+			outputs = []string{"", sessions}
+
+			device, err := deviceFinder.Find(targets, "1", false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(device).To(Equal("/dev/sdc"))
+		})
+
+		It("returns the multipath device holding the device", func() {
+			outputs = []string{"/dev/sdc\n", "3600a09803830304f3124457a4575725a\n"}
+
+			device, err := deviceFinder.Find(targets, "1", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(device).To(Equal("/dev/mapper/3600a09803830304f3124457a4575725a"))
+		})
+
+		It("returns nothing until multipath holds the device", func() {
+			outputs = []string{"/dev/sdc\n", ""}
+
+			device, err := deviceFinder.Find(targets, "1", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(device).To(Equal(""))
+		})
+
+		It("returns nothing while the device has not shown up", func() {
+			outputs = []string{"", ""}
+
+			device, err := deviceFinder.Find(targets, "1", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(device).To(Equal(""))
+			Expect(sshClient.ExecCommandCallCount()).To(Equal(2))
+		})
+
+		It("reports an error without a LUN", func() {
+			_, err := deviceFinder.Find(targets, "", false)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("reports an error when the host cannot be reached", func() {
+			sshClient.ExecCommandStub = nil
+			sshClient.ExecCommandReturns("", errors.New("fake-error"))
+
+			_, err := deviceFinder.Find(targets, "1", false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-error"))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package common
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// validatePlacement rejects dedicated host and placement group properties SoftLayer cannot order together
+func (p VMCloudProperties) validatePlacement() error {
+	if p.DedicatedHostId < 0 || p.PlacementGroupId < 0 {
+		return bosherr.Error("dedicatedHostId and placementGroupId must not be negative")
+	}
+
+	if p.DedicatedHostId != 0 && p.DedicatedHostName != "" {
+		return bosherr.Error("Only one of dedicatedHostId and dedicatedHostName may be set")
+	}
+
+	if p.PlacementGroupId != 0 && (p.PlacementGroupName != "" || p.CreatePlacementGroup) {
+		return bosherr.Error("placementGroupId cannot be combined with placementGroupName or createPlacementGroup")
+	}
+
+	dedicatedHost := p.DedicatedHostId != 0 || p.DedicatedHostName != ""
+	placementGroup := p.PlacementGroupId != 0 || p.PlacementGroupName != "" || p.CreatePlacementGroup
+
+	if dedicatedHost && placementGroup {
+		return bosherr.Error("Virtual guests on a dedicated host cannot be in a placement group")
+	}
+
+	if dedicatedHost && p.DedicatedAccountHostOnlyFlag {
+		return bosherr.Error("dedicatedAccountHostOnlyFlag cannot be combined with a dedicated host")
+	}
+
+	if (dedicatedHost || placementGroup) && len(p.Datacenters) > 1 {
+		return bosherr.Error("Dedicated hosts and placement groups belong to one datacenter and cannot be combined with a list of datacenters")
+	}
+
+	return nil
+}
+
+// PlacementGroupNameFor returns the name of the placement group the virtual guest joins. Without
+// placementGroupName, createPlacementGroup creates one per director, deployment and instance group.
+func (p VMCloudProperties) PlacementGroupNameFor(env Environment) (string, error) {
+	if p.PlacementGroupName != "" || !p.CreatePlacementGroup {
+		return p.PlacementGroupName, nil
+	}
+
+	group := env.BoshGroup()
+	if group == "" {
+		return "", bosherr.Error("createPlacementGroup without placementGroupName needs the bosh group of the VM in its env")
+	}
+
+	return group, nil
+}
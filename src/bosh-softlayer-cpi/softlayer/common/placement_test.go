@@ -0,0 +1,117 @@
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+
+	fakestemcell "bosh-softlayer-cpi/softlayer/stemcell/fakes"
+)
+
+var _ = Describe("Placement", func() {
+	var cloudProps VMCloudProperties
+
+	BeforeEach(func() {
+		cloudProps = VMCloudProperties{
+			StartCpus:         2,
+			MaxMemory:         2048,
+			EphemeralDiskSize: 25,
+			LocalDiskFlag:     true,
+		}
+	})
+
+	Describe("Validate", func() {
+		It("accepts a dedicated host", func() {
+			cloudProps.DedicatedHostName = "fake-dedicated-host"
+			Expect(cloudProps.Validate()).To(Succeed())
+		})
+
+		It("accepts a placement group created on demand", func() {
+			cloudProps.CreatePlacementGroup = true
+			Expect(cloudProps.Validate()).To(Succeed())
+		})
+
+		It("rejects both the ID and the name of a dedicated host", func() {
+			cloudProps.DedicatedHostId = 11223
+			cloudProps.DedicatedHostName = "fake-dedicated-host"
+			Expect(cloudProps.Validate()).To(MatchError(ContainSubstring("Only one of dedicatedHostId and dedicatedHostName")))
+		})
+
+		It("rejects creating a placement group given by ID", func() {
+			cloudProps.PlacementGroupId = 4455
+			cloudProps.CreatePlacementGroup = true
+			Expect(cloudProps.Validate()).To(MatchError(ContainSubstring("placementGroupId cannot be combined")))
+		})
+
+		It("rejects a placement group on a dedicated host", func() {
+			cloudProps.DedicatedHostId = 11223
+			cloudProps.PlacementGroupName = "fake-placement-group"
+			Expect(cloudProps.Validate()).To(MatchError(ContainSubstring("cannot be in a placement group")))
+		})
+
+		It("rejects a dedicated host along with dedicatedAccountHostOnlyFlag", func() {
+			cloudProps.DedicatedHostId = 11223
+			cloudProps.DedicatedAccountHostOnlyFlag = true
+			Expect(cloudProps.Validate()).To(MatchError(ContainSubstring("dedicatedAccountHostOnlyFlag")))
+		})
+
+		It("rejects a placement group along with failover datacenters", func() {
+			cloudProps.PlacementGroupName = "fake-placement-group"
+			cloudProps.Datacenters = []FailoverDatacenter{{Name: "lon02"}, {Name: "ams01"}}
+			Expect(cloudProps.Validate()).To(MatchError(ContainSubstring("cannot be combined with a list of datacenters")))
+		})
+
+		It("rejects negative IDs", func() {
+			cloudProps.PlacementGroupId = -1
+			Expect(cloudProps.Validate()).To(MatchError(ContainSubstring("must not be negative")))
+		})
+	})
+
+	Describe("PlacementGroupNameFor", func() {
+		env := Environment{"bosh": map[string]interface{}{"group": "fake-director-fake-deployment-fake-job"}}
+
+		It("returns the placementGroupName", func() {
+			cloudProps.PlacementGroupName = "fake-placement-group"
+			cloudProps.CreatePlacementGroup = true
+
+			name, err := cloudProps.PlacementGroupNameFor(env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("fake-placement-group"))
+		})
+
+		It("names a placement group created on demand after the bosh group", func() {
+			cloudProps.CreatePlacementGroup = true
+
+			name, err := cloudProps.PlacementGroupNameFor(env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("fake-director-fake-deployment-fake-job"))
+		})
+
+		It("fails when the env has no bosh group", func() {
+			cloudProps.CreatePlacementGroup = true
+
+			_, err := cloudProps.PlacementGroupNameFor(Environment{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CreateVirtualGuestTemplate", func() {
+		It("orders the virtual guest on the dedicated host in the placement group", func() {
+			cloudProps.DedicatedHostId = 11223
+			cloudProps.PlacementGroupId = 4455
+
+			template, err := CreateVirtualGuestTemplate(&fakestemcell.FakeStemcell{}, cloudProps, Networks{}, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.DedicatedHost.Id).To(Equal(11223))
+			Expect(template.PlacementGroupId).To(Equal(4455))
+		})
+
+		It("leaves both out by default", func() {
+			template, err := CreateVirtualGuestTemplate(&fakestemcell.FakeStemcell{}, cloudProps, Networks{}, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.DedicatedHost).To(BeNil())
+			Expect(template.PlacementGroupId).To(BeZero())
+		})
+	})
+})
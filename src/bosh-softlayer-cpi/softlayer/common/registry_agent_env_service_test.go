@@ -30,7 +30,6 @@ var _ = Describe("RegistryAgentEnvService", func() {
 			var (
 				ts           *httptest.Server
 				settingsJSON string
-				agentEnv     AgentEnv
 			)
 
 			BeforeEach(func() {
@@ -53,7 +52,6 @@ var _ = Describe("RegistryAgentEnvService", func() {
 
 			It("fetches settings from the registry", func() {
 				settingsJSON = `{"settings": "{\"agent_id\":\"my-agent-id\"}"}`
-				agentEnv = AgentEnv{AgentID: "fake-agent-id"}
 
 				agentEnv, err := agentEnvService.Fetch()
 				Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,178 @@
+package common
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+const (
+	DefaultApiEndpoint              = "api.softlayer.com"
+	DefaultApiWaitTime              = 1 * time.Second
+	DefaultApiRetryCount            = 1
+	DefaultUpdateAgentEnvWaitTime   = 5 * time.Second
+	DefaultUpdateAgentEnvRetryCount = 5
+)
+
+var ApiEndpoints = []string{"api.softlayer.com", "api.service.softlayer.com", "66.228.119.120", "10.0.80.88"}
+
+// RuntimeOptions are the settings of the SoftLayer client, the agent env
+// services and the disk creator. They are built once from FeatureOptions
+// and handed to each of them through its constructor.
+type RuntimeOptions struct {
+	ApiEndpoint   string
+	ApiWaitTime   time.Duration
+	ApiRetryCount int
+	ApiBackoff    slh.BackoffPolicy
+	ApiRateLimit  slh.RateLimit
+
+	CreateISCSIVolume slh.WaitPolicy
+
+	UpdateAgentEnvWaitTime   time.Duration
+	UpdateAgentEnvRetryCount int
+}
+
+func DefaultRuntimeOptions() RuntimeOptions {
+	return RuntimeOptions{
+		ApiEndpoint:   DefaultApiEndpoint,
+		ApiWaitTime:   DefaultApiWaitTime,
+		ApiRetryCount: DefaultApiRetryCount,
+		ApiBackoff:    slh.DefaultBackoffPolicy(),
+		ApiRateLimit:  slh.DefaultRateLimit(),
+
+		CreateISCSIVolume: slh.NewWaitPolicy(600*time.Second, 10*time.Second),
+
+		UpdateAgentEnvWaitTime:   DefaultUpdateAgentEnvWaitTime,
+		UpdateAgentEnvRetryCount: DefaultUpdateAgentEnvRetryCount,
+	}
+}
+
+// RuntimeOptions converts the feature options to RuntimeOptions, taking
+// every unset value from DefaultRuntimeOptions. Times are given in seconds.
+func (o FeatureOptions) RuntimeOptions() RuntimeOptions {
+	options := DefaultRuntimeOptions()
+
+	if o.ApiEndpoint != "" {
+		options.ApiEndpoint = o.ApiEndpoint
+	}
+
+	if o.ApiWaitTime != 0 {
+		options.ApiWaitTime = time.Duration(o.ApiWaitTime) * time.Second
+	}
+
+	if o.ApiRetryCount != 0 {
+		options.ApiRetryCount = o.ApiRetryCount
+	}
+
+	options.ApiBackoff = o.ApiBackoff.WithDefaults()
+
+	options.ApiRateLimit = slh.RateLimit{
+		CallsPerSecond: o.ApiRateLimit,
+		Burst:          o.ApiRateLimitBurst,
+		StateFile:      o.ApiRateLimitStateFile,
+	}.WithDefaults()
+
+	if o.CreateISCSIVolumeTimeout != 0 {
+		options.CreateISCSIVolume.Timeout = time.Duration(o.CreateISCSIVolumeTimeout) * time.Second
+	}
+
+	if o.CreateISCSIVolumePollingInterval != 0 {
+		options.CreateISCSIVolume.Interval = time.Duration(o.CreateISCSIVolumePollingInterval) * time.Second
+	}
+
+	if o.UpdateAgentEnvWaitTime != 0 {
+		options.UpdateAgentEnvWaitTime = time.Duration(o.UpdateAgentEnvWaitTime) * time.Second
+	}
+
+	if o.UpdateAgentEnvRetryCount != 0 {
+		options.UpdateAgentEnvRetryCount = o.UpdateAgentEnvRetryCount
+	}
+
+	return options
+}
+
+func (o RuntimeOptions) Validate() error {
+	if !o.isKnownApiEndpoint() && !o.isLoopbackApiEndpoint() {
+		return bosherr.Errorf("Unknown ApiEndpoint '%s', must be one of %v or an http:// URL on the loopback interface", o.ApiEndpoint, ApiEndpoints)
+	}
+
+	if o.ApiWaitTime < 0 {
+		return bosherr.Error("ApiWaitTime must not be negative")
+	}
+
+	if o.ApiRetryCount < 1 {
+		return bosherr.Error("ApiRetryCount must be positive")
+	}
+
+	if o.ApiBackoff.Attempts < 1 {
+		return bosherr.Error("ApiBackoff attempts must be positive")
+	}
+
+	if o.ApiBackoff.Delay > o.ApiBackoff.MaxDelay {
+		return bosherr.Error("ApiBackoff delay must not exceed maxDelay")
+	}
+
+	if o.ApiRateLimit.CallsPerSecond <= 0 || o.ApiRateLimit.Burst < 1 {
+		return bosherr.Error("ApiRateLimit and ApiRateLimitBurst must be positive")
+	}
+
+	if o.CreateISCSIVolume.Timeout <= 0 {
+		return bosherr.Error("CreateISCSIVolumeTimeout must be positive")
+	}
+
+	if o.CreateISCSIVolume.Interval <= 0 {
+		return bosherr.Error("CreateISCSIVolumePollingInterval must be positive")
+	}
+
+	if o.UpdateAgentEnvWaitTime < 0 {
+		return bosherr.Error("UpdateAgentEnvWaitTime must not be negative")
+	}
+
+	if o.UpdateAgentEnvRetryCount < 1 {
+		return bosherr.Error("UpdateAgentEnvRetryCount must be positive")
+	}
+
+	return nil
+}
+
+// ApiUrl returns the SoftLayer REST API root on ApiEndpoint, without the scheme
+func (o RuntimeOptions) ApiUrl() string {
+	return fmt.Sprintf("%s/rest/v3", strings.TrimPrefix(o.ApiEndpoint, "http://"))
+}
+
+// ApiUsesHttps tells whether the API is called over HTTPS, which it is unless ApiEndpoint is an
+// http:// URL, such as the one of a SoftLayer API simulator
+func (o RuntimeOptions) ApiUsesHttps() bool {
+	return !strings.HasPrefix(o.ApiEndpoint, "http://")
+}
+
+func (o RuntimeOptions) isKnownApiEndpoint() bool {
+	for _, endpoint := range ApiEndpoints {
+		if endpoint == o.ApiEndpoint {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLoopbackApiEndpoint tells whether ApiEndpoint is an http:// URL on the loopback interface,
+// such as the one of a SoftLayer API simulator. Plain HTTP is not allowed to other hosts.
+func (o RuntimeOptions) isLoopbackApiEndpoint() bool {
+	endpoint, err := url.Parse(o.ApiEndpoint)
+	if err != nil || endpoint.Scheme != "http" {
+		return false
+	}
+
+	switch endpoint.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+
+	return false
+}
@@ -8,15 +8,18 @@ import (
 
 type SoftLayerAgentEnvServiceFactory struct {
 	registryOptions RegistryOptions
+	runtimeOptions  RuntimeOptions
 	logger          boshlog.Logger
 }
 
 func NewSoftLayerAgentEnvServiceFactory(
 	registryOptions RegistryOptions,
+	runtimeOptions RuntimeOptions,
 	logger boshlog.Logger,
 ) SoftLayerAgentEnvServiceFactory {
 	return SoftLayerAgentEnvServiceFactory{
 		registryOptions: registryOptions,
+		runtimeOptions:  runtimeOptions,
 		logger:          logger,
 	}
 }
@@ -35,5 +38,5 @@ func (f SoftLayerAgentEnvServiceFactory) New(
 		)
 		return NewRegistryAgentEnvService(endpoint, strconv.Itoa(vm.ID()), f.logger)
 	}
-	return NewFSAgentEnvService(vm, softlayerFileService, f.logger)
+	return NewFSAgentEnvService(vm, softlayerFileService, f.runtimeOptions, f.logger)
 }
@@ -0,0 +1,32 @@
+package common
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	slclient "github.com/maximilien/softlayer-go/client"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+// NewSoftLayerClient creates a SoftLayer client talking to the endpoint of
+// options and retrying requests that could not reach SoftLayer ApiRetryCount
+// times, ApiWaitTime apart, on top of the retries softlayer-go makes itself.
+// Requests failing with a retryable error are sent again following the
+// ApiBackoff policy of options, and every request, retries included, waits
+// for the ApiRateLimit shared by the CPI processes on the host.
+func NewSoftLayerClient(username string, apiKey string, options RuntimeOptions, logger boshlog.Logger) sl.Client {
+	return NewSoftLayerClientWithRateLimiter(username, apiKey, options, slh.NewFileRateLimiter(options.ApiRateLimit, logger), logger)
+}
+
+// NewSoftLayerClientWithRateLimiter creates the client of NewSoftLayerClient
+// with requests waiting for rateLimiter instead
+func NewSoftLayerClientWithRateLimiter(username string, apiKey string, options RuntimeOptions, rateLimiter slh.RateLimiter, logger boshlog.Logger) sl.Client {
+	httpClient := slclient.NewHttpClient(username, apiKey, options.ApiUrl(), slclient.TEMPLATE_ROOT_PATH, options.ApiUsesHttps())
+
+	client := slclient.NewSoftLayerClient(username, apiKey)
+	rateLimitedHttpClient := slh.NewRateLimitedHttpClient(httpClient, rateLimiter, logger)
+	connectionRetryingHttpClient := slh.NewConnectionRetryingHttpClient(rateLimitedHttpClient, options.ApiRetryCount, options.ApiWaitTime, logger)
+	client.HttpClient = slh.NewRetryingHttpClient(connectionRetryingHttpClient, options.ApiBackoff, logger)
+
+	return client
+}
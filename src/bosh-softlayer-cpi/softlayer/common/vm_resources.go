@@ -0,0 +1,72 @@
+package common
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Sizes a virtual guest can be ordered with: CPUs, memory in MB and ephemeral disk in GB
+var (
+	OrderableCpus                    = []int{1, 2, 4, 8, 12, 16, 32, 48, 56}
+	OrderableMemory                  = []int{1024, 2048, 4096, 6144, 8192, 12288, 16384, 32768, 49152, 65536, 131072, 247808}
+	OrderableLocalEphemeralDiskSizes = []int{25, 100, 150, 200, 300}
+	OrderableSanEphemeralDiskSizes   = []int{25, 100, 125, 150, 175, 200, 250, 300, 350, 400, 500, 750, 1000, 1500, 2000}
+)
+
+// VMResources are the resources the director asks for in the vm_resources of an instance group.
+// RAM and EphemeralDiskSize are in MB.
+type VMResources struct {
+	CPU               int `json:"cpu"`
+	RAM               int `json:"ram"`
+	EphemeralDiskSize int `json:"ephemeral_disk_size"`
+}
+
+// CloudProperties returns the smallest virtual guest SoftLayer can order with at least the resources.
+// The ephemeral disk is local unless it is larger than any local disk.
+func (r VMResources) CloudProperties() (VMCloudProperties, error) {
+	if r.CPU < 0 || r.RAM < 0 || r.EphemeralDiskSize < 0 {
+		return VMCloudProperties{}, bosherr.Error("cpu, ram and ephemeral_disk_size must not be negative")
+	}
+
+	cpus, found := roundUpToOrderable(r.CPU, OrderableCpus)
+	if !found {
+		return VMCloudProperties{}, bosherr.Errorf("No virtual guest has %d CPUs, the most is %d", r.CPU, cpus)
+	}
+
+	memory, found := roundUpToOrderable(r.RAM, OrderableMemory)
+	if !found {
+		return VMCloudProperties{}, bosherr.Errorf("No virtual guest has %d MB of memory, the most is %d MB", r.RAM, memory)
+	}
+
+	diskSize := (r.EphemeralDiskSize + 1023) / 1024
+	localDisk := true
+
+	ephemeralDiskSize, found := roundUpToOrderable(diskSize, OrderableLocalEphemeralDiskSizes)
+	if !found {
+		localDisk = false
+		ephemeralDiskSize, found = roundUpToOrderable(diskSize, OrderableSanEphemeralDiskSizes)
+		if !found {
+			return VMCloudProperties{}, bosherr.Errorf("No virtual guest has a %d GB ephemeral disk, the most is %d GB", diskSize, ephemeralDiskSize)
+		}
+	}
+
+	cloudProps := VMCloudProperties{
+		StartCpus:         cpus,
+		MaxMemory:         memory,
+		EphemeralDiskSize: ephemeralDiskSize,
+		LocalDiskFlag:     localDisk,
+	}
+
+	return cloudProps, cloudProps.Validate()
+}
+
+// roundUpToOrderable returns the smallest of the ascending sizes that is at least size,
+// or the largest one and false when there is none
+func roundUpToOrderable(size int, sizes []int) (int, bool) {
+	for _, orderable := range sizes {
+		if size <= orderable {
+			return orderable, true
+		}
+	}
+
+	return sizes[len(sizes)-1], false
+}
@@ -0,0 +1,39 @@
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+)
+
+var _ = Describe("VMResources", func() {
+	Describe("CloudProperties", func() {
+		It("keeps sizes SoftLayer can order as they are", func() {
+			cloudProps, err := VMResources{CPU: 8, RAM: 16384, EphemeralDiskSize: 100 * 1024}.CloudProperties()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloudProps.StartCpus).To(Equal(8))
+			Expect(cloudProps.MaxMemory).To(Equal(16384))
+			Expect(cloudProps.EphemeralDiskSize).To(Equal(100))
+			Expect(cloudProps.LocalDiskFlag).To(BeTrue())
+		})
+
+		It("rounds a partial GB of ephemeral disk up", func() {
+			cloudProps, err := VMResources{CPU: 1, RAM: 1024, EphemeralDiskSize: 300*1024 + 1}.CloudProperties()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloudProps.EphemeralDiskSize).To(Equal(350))
+			Expect(cloudProps.LocalDiskFlag).To(BeFalse())
+		})
+
+		It("rejects more memory than any virtual guest has", func() {
+			_, err := VMResources{CPU: 1, RAM: 300000, EphemeralDiskSize: 1024}.CloudProperties()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("the most is 247808 MB"))
+		})
+
+		It("rejects an ephemeral disk larger than any SAN disk", func() {
+			_, err := VMResources{CPU: 1, RAM: 1024, EphemeralDiskSize: 3000 * 1024}.CloudProperties()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
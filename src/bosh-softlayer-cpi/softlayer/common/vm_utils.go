@@ -5,11 +5,12 @@ import (
 	"net"
 	"net/url"
 	"os"
-	"reflect"
+	"strings"
 	"time"
 
 	sldatatypes "github.com/maximilien/softlayer-go/data_types"
 
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -39,24 +40,25 @@ func TimeStampForTime(now time.Time) string {
 	return now.Format("20060102-030405-") + fmt.Sprintf("%03d", int(now.UnixNano()/1e6-now.Unix()*1e3))
 }
 
-func CreateVirtualGuestTemplate(stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, userData string) (sldatatypes.SoftLayer_Virtual_Guest_Template, error) {
-	for _, network := range networks {
+func CreateVirtualGuestTemplate(stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, userData string) (slh.VirtualGuestTemplate, error) {
+	for name, network := range networks {
 		switch network.Type {
 		case "dynamic":
-			networkComponent, exist := network.CloudProperties["PrimaryNetworkComponent"]
-			if exist {
-				configureNetwork(networkComponent.(map[string]interface{}), &cloudProps, true)
+			networkCloudProps, _, err := network.DecodeCloudProperties()
+			if err != nil {
+				return slh.VirtualGuestTemplate{}, bosherr.WrapErrorf(err, "Decoding cloud properties of network '%s'", name)
+			}
+
+			if networkCloudProps.PrimaryNetworkComponent != nil {
+				cloudProps.PrimaryNetworkComponent = *networkCloudProps.PrimaryNetworkComponent
 			}
 
-			networkComponent, exist = network.CloudProperties["PrimaryBackendNetworkComponent"]
-			if exist {
-				configureNetwork(networkComponent.(map[string]interface{}), &cloudProps, false)
+			if networkCloudProps.PrimaryBackendNetworkComponent != nil {
+				cloudProps.PrimaryBackendNetworkComponent = *networkCloudProps.PrimaryBackendNetworkComponent
 			}
 
-			privateNetworkOnlyFlag, exist := network.CloudProperties["PrivateNetworkOnlyFlag"]
-			if exist {
-				privateOnly := privateNetworkOnlyFlag.(bool)
-				cloudProps.PrivateNetworkOnlyFlag = privateOnly
+			if networkCloudProps.PrivateNetworkOnlyFlag != nil {
+				cloudProps.PrivateNetworkOnlyFlag = *networkCloudProps.PrivateNetworkOnlyFlag
 			}
 		default:
 			continue
@@ -96,7 +98,16 @@ func CreateVirtualGuestTemplate(stemcell bslcstem.Stemcell, cloudProps VMCloudPr
 		},
 	}
 
-	return virtualGuestTemplate, nil
+	template := slh.VirtualGuestTemplate{
+		SoftLayer_Virtual_Guest_Template: virtualGuestTemplate,
+		PlacementGroupId:                 cloudProps.PlacementGroupId,
+	}
+
+	if cloudProps.DedicatedHostId != 0 {
+		template.DedicatedHost = &slh.DedicatedHost{Id: cloudProps.DedicatedHostId}
+	}
+
+	return template, nil
 }
 
 func CreateAgentUserData(agentID string, cloudProps VMCloudProperties, networks Networks, env Environment, agentOptions AgentOptions) AgentEnv {
@@ -188,6 +199,35 @@ func UpdateEtcHostsOfBoshInit(path string, record string) (err error) {
 	return nil
 }
 
+// RemoveEtcHostsEntry removes the lines written by UpdateEtcHostsOfBoshInit for record
+func RemoveEtcHostsEntry(path string, record string) error {
+	logger := boshlog.NewWriterLogger(boshlog.LevelError, os.Stderr, os.Stderr)
+	fs := boshsys.NewOsFileSystem(logger)
+
+	if !fs.FileExists(path) {
+		return nil
+	}
+
+	contents, err := fs.ReadFileString(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading file %s", path)
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(contents, "\n") {
+		if strings.TrimSpace(line) != record {
+			lines = append(lines, line)
+		}
+	}
+
+	err = fs.WriteFileString(path, strings.Join(lines, "\n"))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing '%s' from file %s", record, path)
+	}
+
+	return nil
+}
+
 func UpdateDeviceName(vmID int, virtualGuestService sl.SoftLayer_Virtual_Guest_Service, cloudProps VMCloudProperties) (err error) {
 	deviceName := sldatatypes.SoftLayer_Virtual_Guest{
 		Hostname: cloudProps.VmNamePrefix,
@@ -229,31 +269,3 @@ func GetLocalIPAddressOfGivenInterface(networkInterface string) (string, error)
 const ETC_HOSTS_TEMPLATE = `127.0.0.1 localhost
 {{.}}
 `
-
-// private methods
-func configureNetwork(networkComponent map[string]interface{}, cloudProps *VMCloudProperties, primaryNetwork bool) {
-	networkVlan := sldatatypes.NetworkVlan{}
-	networkComponentNetworkVlan, exist := networkComponent["NetworkVlan"]
-	if exist {
-		networkVlanInfo := networkComponentNetworkVlan.(map[string]interface{})
-		configureNetworkVlan(networkVlanInfo, &networkVlan, "Id")
-		configureNetworkVlan(networkVlanInfo, &networkVlan, "PrimarySubnetId")
-
-		if primaryNetwork {
-			cloudProps.PrimaryNetworkComponent = sldatatypes.PrimaryNetworkComponent{
-				NetworkVlan: networkVlan,
-			}
-		} else {
-			cloudProps.PrimaryBackendNetworkComponent = sldatatypes.PrimaryBackendNetworkComponent{
-				NetworkVlan: networkVlan,
-			}
-		}
-	}
-}
-
-func configureNetworkVlan(networkVlanInfo map[string]interface{}, networkVlan *sldatatypes.NetworkVlan, fieldName string) {
-	fieldValue, exist := networkVlanInfo[fieldName]
-	if exist {
-		reflect.ValueOf(networkVlan).Elem().FieldByName(fieldName).SetInt(int64(fieldValue.(float64)))
-	}
-}
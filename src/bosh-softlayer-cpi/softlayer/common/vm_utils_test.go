@@ -14,6 +14,7 @@ import (
 	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
 	"github.com/maximilien/softlayer-go/softlayer"
 
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -41,9 +42,8 @@ var _ = Describe("VM Utils", func() {
 
 	Describe("#CreateAgentMetadata", func() {
 		var (
-			agentID, agentName string
+			agentID            string
 			networks           Networks
-			disks              DisksSpec
 			env                Environment
 			agentOptions       AgentOptions
 			cloudProps         VMCloudProperties
@@ -52,13 +52,11 @@ var _ = Describe("VM Utils", func() {
 
 		BeforeEach(func() {
 			agentID = "fake-agentID"
-			agentName = "fake-agentName"
 			cloudProps = VMCloudProperties{
 				BoshIp:            "fake-powerdns",
 				EphemeralDiskSize: 100,
 			}
 			networks = Networks{}
-			disks = DisksSpec{}
 			env = Environment{}
 			agentOptions = AgentOptions{}
 
@@ -128,20 +126,16 @@ var _ = Describe("VM Utils", func() {
 
 	Describe("#CreateVirtualGuestTemplate", func() {
 		var (
-			agentID      string
 			stemcell     bslcstem.SoftLayerStemcell
 			cloudProps   VMCloudProperties
 			networks     Networks
-			env          Environment
-			agentOptions AgentOptions
 			expectedVgt  sldatatypes.SoftLayer_Virtual_Guest_Template
 		)
 
 		// Setting in network
 		Context("when PrimaryNetworkComponent_Id, PrimaryBackendNetworkComponent_PSId exist in network settings", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -191,8 +185,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -257,14 +249,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryBackendNetworkComponent_Id_PSId, PrivateNetworkOnlyFlag exist in network settings", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -311,8 +302,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -378,14 +367,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryNetworkComponent_Id_PSId, PrimaryBackendNetworkComponent_Id exist in network settings", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -436,8 +424,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -503,14 +489,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryNetworkComponent_PSId exists in network settings, PrivateNetworkOnlyFlag exists in cloudProps", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -556,8 +541,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -622,7 +605,7 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
@@ -630,8 +613,7 @@ var _ = Describe("VM Utils", func() {
 		// Setting in cloudProps
 		Context("when PrimaryNetworkComponent_Id, PrimaryBackendNetworkComponent_Id_PSId exist in cloudProps", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -660,8 +642,6 @@ var _ = Describe("VM Utils", func() {
 				}
 
 				networks = Networks{}
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -727,14 +707,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryNetworkComponent_Id_PSId, PrivateNetworkOnlyFlag exist in cloudProps", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -761,8 +740,6 @@ var _ = Describe("VM Utils", func() {
 				}
 
 				networks = Networks{}
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -828,14 +805,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryBackendNetworkComponent_PSId, PrivateNetworkOnlyFlag exist in cloudProps", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -862,8 +838,6 @@ var _ = Describe("VM Utils", func() {
 				}
 
 				networks = Networks{}
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -928,14 +902,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryNetworkComponent_PSId, PrimaryBackendNetworkComponent_Id exist in cloudProps", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -964,8 +937,6 @@ var _ = Describe("VM Utils", func() {
 				}
 
 				networks = Networks{}
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -1030,7 +1001,7 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
@@ -1038,8 +1009,7 @@ var _ = Describe("VM Utils", func() {
 		// Setting in both network and cloudProps
 		Context("when PrimaryNetworkComponent and PrimaryBackendNetworkComponent contain Id and PSId in cloudProps but only Id in network settings ", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -1094,8 +1064,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -1160,14 +1128,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryNetworkComponent and PrimaryBackendNetworkComponent contain Id and PSId in network settings but only Id in cloudProps ", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -1223,8 +1190,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -1291,14 +1256,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryNetworkComponent contains PSId in cloudProps but Id in network settings and PrimaryBackendNetworkComponent contains PSId in network settings but Id in cloudProps", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -1353,8 +1317,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -1419,14 +1381,13 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 
 		Context("when PrimaryNetworkComponent and PrimaryBackendNetworkComponent contains Id and PSId in both network settings and cloudProps", func() {
 			BeforeEach(func() {
-				agentID = "fake-agentID"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 				cloudProps = VMCloudProperties{
 					StartCpus: 4,
 					MaxMemory: 2048,
@@ -1482,8 +1443,6 @@ var _ = Describe("VM Utils", func() {
 					},
 				}
 
-				env = Environment{}
-				agentOptions = AgentOptions{}
 
 				expectedVgt = sldatatypes.SoftLayer_Virtual_Guest_Template{
 					Hostname:  "bosh-20150810-081217-541",
@@ -1550,21 +1509,16 @@ var _ = Describe("VM Utils", func() {
 
 				//Since VGT.Hostname use timestamp we need to fix it here
 				expectedVgt.Hostname = vgt.Hostname
-				Expect(vgt).To(Equal(expectedVgt))
+				Expect(vgt.SoftLayer_Virtual_Guest_Template).To(Equal(expectedVgt))
 			})
 		})
 	})
 
 	Describe("#UpdateDeviceName", func() {
 		var (
-			cloudProps VMCloudProperties
-			slvgs      softlayer.SoftLayer_Virtual_Guest_Service
+			slvgs softlayer.SoftLayer_Virtual_Guest_Service
 		)
 		BeforeEach(func() {
-			cloudProps = VMCloudProperties{
-				VmNamePrefix: "fake-hostname",
-				Domain:       "fake-domain",
-			}
 			slvgs, _ = softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 
 			fileNames := []string{
@@ -0,0 +1,77 @@
+package disk
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Endurance tiers in IOPS per GB and the STORAGE_TIER_LEVEL attribute SoftLayer lists their prices under
+var enduranceTierLevels = map[float64]int{
+	0.25: 100,
+	2:    200,
+	4:    300,
+	10:   1000,
+}
+
+// Endurance tiers in IOPS per GB by the storage tier level SoftLayer reports on endurance volumes
+var enduranceStorageTierLevels = map[string]float64{
+	"LOW_INTENSITY_TIER": 0.25,
+	"READHEAVY_TIER":     2,
+	"WRITEHEAVY_TIER":    4,
+	"10_IOPS_PER_GB":     10,
+}
+
+// The largest endurance volume in GB SoftLayer provisions at 10 IOPS per GB
+const MAX_TIER_10_DISK_SIZE = 4000
+
+// Snapshot space sizes in GB SoftLayer sells with endurance storage
+var snapshotSpaceSizes = []int{5, 10, 20, 40, 80, 100, 250, 500, 1000, 2000, 4000, 8000, 12000}
+
+// Validate checks that the properties describe a volume of size in MB SoftLayer can provision, so
+// that create_disk fails before ordering rather than with an opaque order error
+func (p DiskCloudProperties) Validate(size int) error {
+	switch p.StorageType {
+	case "", STORAGE_TYPE_PERFORMANCE:
+		if p.Tier != 0 {
+			return bosherr.Errorf("Tier '%g' can only be set for '%s' storage", p.Tier, STORAGE_TYPE_ENDURANCE)
+		}
+		if p.SnapshotSpace != 0 {
+			return bosherr.Errorf("Snapshot space can only be set for '%s' storage", STORAGE_TYPE_ENDURANCE)
+		}
+		return nil
+	case STORAGE_TYPE_ENDURANCE:
+		return p.validateEndurance(size)
+	default:
+		return bosherr.Errorf("Unknown storage type '%s', must be '%s' or '%s'", p.StorageType, STORAGE_TYPE_PERFORMANCE, STORAGE_TYPE_ENDURANCE)
+	}
+}
+
+func (p DiskCloudProperties) validateEndurance(size int) error {
+	if p.Iops != 0 {
+		return bosherr.Errorf("IOPS cannot be set for '%s' storage, they follow from the tier", STORAGE_TYPE_ENDURANCE)
+	}
+
+	if _, ok := enduranceTierLevels[p.Tier]; !ok {
+		return bosherr.Errorf("Tier '%g' of '%s' storage must be one of 0.25, 2, 4 and 10 IOPS per GB", p.Tier, STORAGE_TYPE_ENDURANCE)
+	}
+
+	diskSize := SoftLayerDiskSize(size)
+	if p.Tier == 10 && diskSize > MAX_TIER_10_DISK_SIZE {
+		return bosherr.Errorf("Disk size '%d' GB exceeds '%d' GB, the largest at tier 10", diskSize, MAX_TIER_10_DISK_SIZE)
+	}
+
+	if p.SnapshotSpace != 0 && !containsInt(snapshotSpaceSizes, p.SnapshotSpace) {
+		return bosherr.Errorf("Snapshot space '%d' GB must be one of %v", p.SnapshotSpace, snapshotSpaceSizes)
+	}
+
+	return nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
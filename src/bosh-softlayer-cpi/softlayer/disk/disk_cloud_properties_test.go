@@ -0,0 +1,51 @@
+package disk_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/disk"
+)
+
+var _ = Describe("DiskCloudProperties", func() {
+	Describe("Validate", func() {
+		It("accepts performance storage by default", func() {
+			Expect(DiskCloudProperties{Iops: 1000}.Validate(20 * 1024)).To(Succeed())
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_PERFORMANCE}.Validate(20 * 1024)).To(Succeed())
+		})
+
+		It("rejects endurance properties on performance storage", func() {
+			Expect(DiskCloudProperties{Tier: 2}.Validate(20 * 1024)).ToNot(Succeed())
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_PERFORMANCE, SnapshotSpace: 10}.Validate(20 * 1024)).ToNot(Succeed())
+		})
+
+		It("rejects unknown storage types", func() {
+			Expect(DiskCloudProperties{StorageType: "fake-type"}.Validate(20 * 1024)).ToNot(Succeed())
+		})
+
+		It("accepts each endurance tier", func() {
+			for _, tier := range []float64{0.25, 2, 4, 10} {
+				Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: tier, SnapshotSpace: 20}.Validate(20 * 1024)).To(Succeed())
+			}
+		})
+
+		It("rejects endurance storage without a valid tier", func() {
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE}.Validate(20 * 1024)).ToNot(Succeed())
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 3}.Validate(20 * 1024)).ToNot(Succeed())
+		})
+
+		It("rejects IOPS on endurance storage", func() {
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 2, Iops: 1000}.Validate(20 * 1024)).ToNot(Succeed())
+		})
+
+		It("rejects disks larger than 4000 GB at tier 10", func() {
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 10}.Validate(4000 * 1024)).To(Succeed())
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 10}.Validate(8000 * 1024)).ToNot(Succeed())
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 4}.Validate(8000 * 1024)).To(Succeed())
+		})
+
+		It("rejects snapshot space SoftLayer does not sell", func() {
+			Expect(DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 2, SnapshotSpace: 15}.Validate(20 * 1024)).ToNot(Succeed())
+		})
+	})
+})
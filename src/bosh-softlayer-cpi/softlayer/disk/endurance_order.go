@@ -0,0 +1,122 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+// enduranceStorageOrder is the SoftLayer_Container_Product_Order_Network_Storage_AsAService
+// container, which the SoftLayer client has no type for
+type enduranceStorageOrder struct {
+	ComplexType      string           `json:"complexType"`
+	Location         string           `json:"location"`
+	PackageId        int              `json:"packageId"`
+	Prices           []orderItemPrice `json:"prices"`
+	Quantity         int              `json:"quantity"`
+	VolumeSize       int              `json:"volumeSize"`
+	OsFormatType     osFormatType     `json:"osFormatType"`
+	UseHourlyPricing bool             `json:"useHourlyPricing,omitempty"`
+}
+
+// itemPriceLookup is an object filter on the prices of the storage package and what it looks for
+type itemPriceLookup struct {
+	description string
+	filters     string
+}
+
+type osFormatType struct {
+	Id      int    `json:"id"`
+	KeyName string `json:"keyName"`
+}
+
+// buildEnduranceOrder looks up the prices of the storage service, the block storage, the storage
+// space of size in GB at the tier and the snapshot space in the Storage-as-a-Service package
+func buildEnduranceOrder(client sl.Client, size int, cloudProps DiskCloudProperties, location string) (enduranceStorageOrder, error) {
+	productPackageService, err := client.GetSoftLayer_Product_Package_Service()
+	if err != nil {
+		return enduranceStorageOrder{}, bosherr.WrapError(err, "Cannot get product package service.")
+	}
+
+	tierLevel := enduranceTierLevels[cloudProps.Tier]
+	tierRestriction := fmt.Sprintf(`"capacityRestrictionType":{"operation":"STORAGE_TIER_LEVEL"},"capacityRestrictionMinimum":{"operation":"<= %d"},"capacityRestrictionMaximum":{"operation":">= %d"}`, tierLevel, tierLevel)
+
+	lookups := []itemPriceLookup{
+		{"storage service", `{"itemPrices":{"categories":{"categoryCode":{"operation":"storage_as_a_service"}}}}`},
+		{"block storage", `{"itemPrices":{"categories":{"categoryCode":{"operation":"storage_block"}}}}`},
+		{fmt.Sprintf("tier '%g'", cloudProps.Tier), fmt.Sprintf(`{"itemPrices":{"attributes":{"value":{"operation":%d}},"categories":{"categoryCode":{"operation":"storage_tier_level"}}}}`, tierLevel)},
+	}
+	if cloudProps.SnapshotSpace > 0 {
+		lookups = append(lookups, itemPriceLookup{fmt.Sprintf("'%d' GB of snapshot space", cloudProps.SnapshotSpace), fmt.Sprintf(`{"itemPrices":{"item":{"capacity":{"operation":%d}},"categories":{"categoryCode":{"operation":"storage_snapshot_space"}},%s}}`, cloudProps.SnapshotSpace, tierRestriction)})
+	}
+
+	prices := []orderItemPrice{}
+	for _, lookup := range lookups {
+		itemPrices, err := productPackageService.GetItemPrices(STORAGE_AS_A_SERVICE_PACKAGE_ID, lookup.filters)
+		if err != nil {
+			return enduranceStorageOrder{}, bosherr.WrapErrorf(err, "Finding price of %s", lookup.description)
+		}
+
+		priceId := standardItemPriceId(itemPrices)
+		if priceId == 0 {
+			return enduranceStorageOrder{}, bosherr.Errorf("No standard price of %s of endurance storage", lookup.description)
+		}
+
+		prices = append(prices, orderItemPrice{Id: priceId})
+	}
+
+	spacePriceId, err := findEnduranceSpacePriceId(client, size, cloudProps.Tier)
+	if err != nil {
+		return enduranceStorageOrder{}, bosherr.WrapErrorf(err, "Finding price of '%d' GB of storage space", size)
+	}
+	prices = append(prices, orderItemPrice{Id: spacePriceId})
+
+	return enduranceStorageOrder{
+		ComplexType:      "SoftLayer_Container_Product_Order_Network_Storage_AsAService",
+		Location:         location,
+		PackageId:        STORAGE_AS_A_SERVICE_PACKAGE_ID,
+		Prices:           prices,
+		Quantity:         1,
+		VolumeSize:       size,
+		OsFormatType:     osFormatType{Id: 12, KeyName: "LINUX"},
+		UseHourlyPricing: cloudProps.UseHourlyPricing,
+	}, nil
+}
+
+// standardItemPriceId returns the id of the first price not specific to a location group, or 0
+func standardItemPriceId(itemPrices []datatypes.SoftLayer_Product_Item_Price) int {
+	for _, itemPrice := range itemPrices {
+		if itemPrice.LocationGroupId == 0 {
+			return itemPrice.Id
+		}
+	}
+
+	return 0
+}
+
+// postStorageOrder posts the storage order of the kind, performance or endurance, to the
+// SoftLayer_Product_Order method, placeOrder or verifyOrder, and returns the response
+func postStorageOrder(client sl.Client, method string, kind string, order interface{}) ([]byte, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"parameters": []interface{}{order},
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Marshalling %s storage order", kind)
+	}
+
+	response, errorCode, err := client.GetHttpClient().DoRawHttpRequest(fmt.Sprintf("SoftLayer_Product_Order/%s.json", method), "POST", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Calling %s with %s storage order", method, kind)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return nil, bosherr.Errorf("Calling %s with %s storage order, HTTP error code: '%d'", method, kind, errorCode)
+	}
+
+	return response, nil
+}
@@ -18,6 +18,25 @@ type FakeDiskFinder struct {
 		result2 bool
 		result3 error
 	}
+	FindAllowedByHostStub        func(hostType string, hostID int) ([]disk.Disk, error)
+	findAllowedByHostMutex       sync.RWMutex
+	findAllowedByHostArgsForCall []struct {
+		hostType string
+		hostID   int
+	}
+	findAllowedByHostReturns struct {
+		result1 []disk.Disk
+		result2 error
+	}
+	FindAllowedHostsStub        func(id int) ([]disk.DiskHost, error)
+	findAllowedHostsMutex       sync.RWMutex
+	findAllowedHostsArgsForCall []struct {
+		id int
+	}
+	findAllowedHostsReturns struct {
+		result1 []disk.DiskHost
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -57,11 +76,84 @@ func (fake *FakeDiskFinder) FindReturns(result1 disk.Disk, result2 bool, result3
 	}{result1, result2, result3}
 }
 
+func (fake *FakeDiskFinder) FindAllowedByHost(hostType string, hostID int) ([]disk.Disk, error) {
+	fake.findAllowedByHostMutex.Lock()
+	fake.findAllowedByHostArgsForCall = append(fake.findAllowedByHostArgsForCall, struct {
+		hostType string
+		hostID   int
+	}{hostType, hostID})
+	fake.recordInvocation("FindAllowedByHost", []interface{}{hostType, hostID})
+	fake.findAllowedByHostMutex.Unlock()
+	if fake.FindAllowedByHostStub != nil {
+		return fake.FindAllowedByHostStub(hostType, hostID)
+	} else {
+		return fake.findAllowedByHostReturns.result1, fake.findAllowedByHostReturns.result2
+	}
+}
+
+func (fake *FakeDiskFinder) FindAllowedByHostCallCount() int {
+	fake.findAllowedByHostMutex.RLock()
+	defer fake.findAllowedByHostMutex.RUnlock()
+	return len(fake.findAllowedByHostArgsForCall)
+}
+
+func (fake *FakeDiskFinder) FindAllowedByHostArgsForCall(i int) (string, int) {
+	fake.findAllowedByHostMutex.RLock()
+	defer fake.findAllowedByHostMutex.RUnlock()
+	return fake.findAllowedByHostArgsForCall[i].hostType, fake.findAllowedByHostArgsForCall[i].hostID
+}
+
+func (fake *FakeDiskFinder) FindAllowedByHostReturns(result1 []disk.Disk, result2 error) {
+	fake.FindAllowedByHostStub = nil
+	fake.findAllowedByHostReturns = struct {
+		result1 []disk.Disk
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskFinder) FindAllowedHosts(id int) ([]disk.DiskHost, error) {
+	fake.findAllowedHostsMutex.Lock()
+	fake.findAllowedHostsArgsForCall = append(fake.findAllowedHostsArgsForCall, struct {
+		id int
+	}{id})
+	fake.recordInvocation("FindAllowedHosts", []interface{}{id})
+	fake.findAllowedHostsMutex.Unlock()
+	if fake.FindAllowedHostsStub != nil {
+		return fake.FindAllowedHostsStub(id)
+	} else {
+		return fake.findAllowedHostsReturns.result1, fake.findAllowedHostsReturns.result2
+	}
+}
+
+func (fake *FakeDiskFinder) FindAllowedHostsCallCount() int {
+	fake.findAllowedHostsMutex.RLock()
+	defer fake.findAllowedHostsMutex.RUnlock()
+	return len(fake.findAllowedHostsArgsForCall)
+}
+
+func (fake *FakeDiskFinder) FindAllowedHostsArgsForCall(i int) int {
+	fake.findAllowedHostsMutex.RLock()
+	defer fake.findAllowedHostsMutex.RUnlock()
+	return fake.findAllowedHostsArgsForCall[i].id
+}
+
+func (fake *FakeDiskFinder) FindAllowedHostsReturns(result1 []disk.DiskHost, result2 error) {
+	fake.FindAllowedHostsStub = nil
+	fake.findAllowedHostsReturns = struct {
+		result1 []disk.DiskHost
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeDiskFinder) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.findMutex.RLock()
 	defer fake.findMutex.RUnlock()
+	fake.findAllowedByHostMutex.RLock()
+	defer fake.findAllowedByHostMutex.RUnlock()
+	fake.findAllowedHostsMutex.RLock()
+	defer fake.findAllowedHostsMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -0,0 +1,83 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"bosh-softlayer-cpi/softlayer/common/helper"
+	"bosh-softlayer-cpi/softlayer/disk"
+)
+
+type FakeDiskQuoter struct {
+	QuoteStub        func(size int, cloudProp disk.DiskCloudProperties, location string) (helper.OrderQuote, error)
+	quoteMutex       sync.RWMutex
+	quoteArgsForCall []struct {
+		size      int
+		cloudProp disk.DiskCloudProperties
+		location  string
+	}
+	quoteReturns struct {
+		result1 helper.OrderQuote
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDiskQuoter) Quote(size int, cloudProp disk.DiskCloudProperties, location string) (helper.OrderQuote, error) {
+	fake.quoteMutex.Lock()
+	fake.quoteArgsForCall = append(fake.quoteArgsForCall, struct {
+		size      int
+		cloudProp disk.DiskCloudProperties
+		location  string
+	}{size, cloudProp, location})
+	fake.recordInvocation("Quote", []interface{}{size, cloudProp, location})
+	fake.quoteMutex.Unlock()
+	if fake.QuoteStub != nil {
+		return fake.QuoteStub(size, cloudProp, location)
+	} else {
+		return fake.quoteReturns.result1, fake.quoteReturns.result2
+	}
+}
+
+func (fake *FakeDiskQuoter) QuoteCallCount() int {
+	fake.quoteMutex.RLock()
+	defer fake.quoteMutex.RUnlock()
+	return len(fake.quoteArgsForCall)
+}
+
+func (fake *FakeDiskQuoter) QuoteArgsForCall(i int) (int, disk.DiskCloudProperties, string) {
+	fake.quoteMutex.RLock()
+	defer fake.quoteMutex.RUnlock()
+	return fake.quoteArgsForCall[i].size, fake.quoteArgsForCall[i].cloudProp, fake.quoteArgsForCall[i].location
+}
+
+func (fake *FakeDiskQuoter) QuoteReturns(result1 helper.OrderQuote, result2 error) {
+	fake.QuoteStub = nil
+	fake.quoteReturns = struct {
+		result1 helper.OrderQuote
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskQuoter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.quoteMutex.RLock()
+	defer fake.quoteMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeDiskQuoter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ disk.DiskQuoter = new(FakeDiskQuoter)
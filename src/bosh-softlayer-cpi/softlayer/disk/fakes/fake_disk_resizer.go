@@ -0,0 +1,78 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"bosh-softlayer-cpi/softlayer/disk"
+)
+
+type FakeDiskResizer struct {
+	ResizeStub        func(id int, size int) error
+	resizeMutex       sync.RWMutex
+	resizeArgsForCall []struct {
+		id   int
+		size int
+	}
+	resizeReturns struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDiskResizer) Resize(id int, size int) error {
+	fake.resizeMutex.Lock()
+	fake.resizeArgsForCall = append(fake.resizeArgsForCall, struct {
+		id   int
+		size int
+	}{id, size})
+	fake.recordInvocation("Resize", []interface{}{id, size})
+	fake.resizeMutex.Unlock()
+	if fake.ResizeStub != nil {
+		return fake.ResizeStub(id, size)
+	} else {
+		return fake.resizeReturns.result1
+	}
+}
+
+func (fake *FakeDiskResizer) ResizeCallCount() int {
+	fake.resizeMutex.RLock()
+	defer fake.resizeMutex.RUnlock()
+	return len(fake.resizeArgsForCall)
+}
+
+func (fake *FakeDiskResizer) ResizeArgsForCall(i int) (int, int) {
+	fake.resizeMutex.RLock()
+	defer fake.resizeMutex.RUnlock()
+	return fake.resizeArgsForCall[i].id, fake.resizeArgsForCall[i].size
+}
+
+func (fake *FakeDiskResizer) ResizeReturns(result1 error) {
+	fake.ResizeStub = nil
+	fake.resizeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDiskResizer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.resizeMutex.RLock()
+	defer fake.resizeMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeDiskResizer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ disk.DiskResizer = new(FakeDiskResizer)
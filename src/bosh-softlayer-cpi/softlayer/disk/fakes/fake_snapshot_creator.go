@@ -0,0 +1,80 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"bosh-softlayer-cpi/softlayer/disk"
+)
+
+type FakeSnapshotCreator struct {
+	CreateStub        func(diskID int, metadata disk.SnapshotMetadata) (disk.Snapshot, error)
+	createMutex       sync.RWMutex
+	createArgsForCall []struct {
+		diskID   int
+		metadata disk.SnapshotMetadata
+	}
+	createReturns struct {
+		result1 disk.Snapshot
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSnapshotCreator) Create(diskID int, metadata disk.SnapshotMetadata) (disk.Snapshot, error) {
+	fake.createMutex.Lock()
+	fake.createArgsForCall = append(fake.createArgsForCall, struct {
+		diskID   int
+		metadata disk.SnapshotMetadata
+	}{diskID, metadata})
+	fake.recordInvocation("Create", []interface{}{diskID, metadata})
+	fake.createMutex.Unlock()
+	if fake.CreateStub != nil {
+		return fake.CreateStub(diskID, metadata)
+	} else {
+		return fake.createReturns.result1, fake.createReturns.result2
+	}
+}
+
+func (fake *FakeSnapshotCreator) CreateCallCount() int {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return len(fake.createArgsForCall)
+}
+
+func (fake *FakeSnapshotCreator) CreateArgsForCall(i int) (int, disk.SnapshotMetadata) {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return fake.createArgsForCall[i].diskID, fake.createArgsForCall[i].metadata
+}
+
+func (fake *FakeSnapshotCreator) CreateReturns(result1 disk.Snapshot, result2 error) {
+	fake.CreateStub = nil
+	fake.createReturns = struct {
+		result1 disk.Snapshot
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSnapshotCreator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return fake.invocations
+}
+
+func (fake *FakeSnapshotCreator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ disk.SnapshotCreator = new(FakeSnapshotCreator)
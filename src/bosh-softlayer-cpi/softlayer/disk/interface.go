@@ -1,18 +1,61 @@
 package disk
 
+import (
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
 type DiskCloudProperties struct {
 	Iops             int  `json:"iops,omitempty"`
 	UseHourlyPricing bool `json:"useHourlyPricing,omitempty"`
+
+	// StorageType is STORAGE_TYPE_PERFORMANCE, the default, or STORAGE_TYPE_ENDURANCE
+	StorageType string `json:"storage_type,omitempty"`
+	// Tier is the IOPS per GB of endurance storage, one of 0.25, 2, 4 and 10
+	Tier float64 `json:"tier,omitempty"`
+	// SnapshotSpace is the snapshot space in GB ordered with endurance storage
+	SnapshotSpace int `json:"snapshot_space,omitempty"`
 }
 
+const (
+	STORAGE_TYPE_PERFORMANCE = "performance"
+	STORAGE_TYPE_ENDURANCE   = "endurance"
+)
+
+type SnapshotMetadata map[string]interface{}
+
+// Relational properties of SoftLayer_Network_Storage which list the hosts allowed to access a volume
+const (
+	ALLOWED_VIRTUAL_GUESTS = "allowedVirtualGuests"
+	ALLOWED_HARDWARE       = "allowedHardware"
+)
+
 //go:generate counterfeiter -o fakes/fake_disk_creator.go . DiskCreator
 type DiskCreator interface {
 	Create(size int, cloudProp DiskCloudProperties, datacenter_id int) (Disk, error)
 }
 
+//go:generate counterfeiter -o fakes/fake_disk_quoter.go . DiskQuoter
+type DiskQuoter interface {
+	Quote(size int, cloudProp DiskCloudProperties, location string) (slhelper.OrderQuote, error)
+}
+
+// DiskHost is a virtual guest or hardware allowed to access a disk. Its type is one of the relational
+// properties the hosts are listed under.
+type DiskHost struct {
+	Type string
+	ID   int
+}
+
+//go:generate counterfeiter -o fakes/fake_disk_resizer.go . DiskResizer
+type DiskResizer interface {
+	Resize(id int, size int) error
+}
+
 //go:generate counterfeiter -o fakes/fake_disk_finder.go . DiskFinder
 type DiskFinder interface {
 	Find(id int) (Disk, bool, error)
+	FindAllowedByHost(hostType string, hostID int) ([]Disk, error)
+	FindAllowedHosts(id int) ([]DiskHost, error)
 }
 
 //go:generate counterfeiter -o fakes/fake_disk.go . Disk
@@ -20,3 +63,19 @@ type Disk interface {
 	ID() int
 	Delete() error
 }
+
+//go:generate counterfeiter -o fakes/fake_snapshot_creator.go . SnapshotCreator
+type SnapshotCreator interface {
+	Create(diskID int, metadata SnapshotMetadata) (Snapshot, error)
+}
+
+//go:generate counterfeiter -o fakes/fake_snapshot_finder.go . SnapshotFinder
+type SnapshotFinder interface {
+	Find(id int) (Snapshot, bool, error)
+}
+
+//go:generate counterfeiter -o fakes/fake_snapshot.go . Snapshot
+type Snapshot interface {
+	ID() int
+	Delete() error
+}
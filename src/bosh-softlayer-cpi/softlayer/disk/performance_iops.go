@@ -0,0 +1,110 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	slservices "github.com/maximilien/softlayer-go/services"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+
+	"bosh-softlayer-cpi/api"
+)
+
+// findPerformanceIops returns the IOPS, in ascending order, SoftLayer offers performance storage of
+// size in GB with in the datacenter. These are the IOPS with a standard price or a price of one of
+// the price groups of the datacenter.
+func findPerformanceIops(client sl.Client, size int, datacenterId int) ([]int, error) {
+	priceGroups, err := findPriceGroups(client, datacenterId)
+	if err != nil {
+		return nil, err
+	}
+
+	productPackageService, err := client.GetSoftLayer_Product_Package_Service()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Cannot get product package service.")
+	}
+
+	filters := fmt.Sprintf(`{"itemPrices":{"attributes":{"value":{"operation":%d}},"categories":{"categoryCode":{"operation":"performance_storage_iops"}}}}`, size)
+	itemPrices, err := productPackageService.GetItemPrices(slservices.NETWORK_PERFORMANCE_STORAGE_PACKAGE_ID, filters)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding IOPS prices of '%d' GB of performance storage", size)
+	}
+
+	offered := []int{}
+	for _, itemPrice := range itemPrices {
+		if itemPrice.LocationGroupId != 0 && !containsInt(priceGroups, itemPrice.LocationGroupId) {
+			continue
+		}
+		if itemPrice.Item == nil {
+			continue
+		}
+
+		iops, err := strconv.Atoi(itemPrice.Item.Capacity)
+		if err != nil || containsInt(offered, iops) {
+			continue
+		}
+
+		offered = append(offered, iops)
+	}
+
+	sort.Ints(offered)
+
+	return offered, nil
+}
+
+func findPriceGroups(client sl.Client, datacenterId int) ([]int, error) {
+	response, errorCode, err := client.GetHttpClient().DoRawHttpRequest(fmt.Sprintf("SoftLayer_Location_Datacenter/%d/getPriceGroups.json", datacenterId), "GET", new(bytes.Buffer))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Getting price groups of datacenter '%d'", datacenterId)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return nil, bosherr.Errorf("Getting price groups of datacenter '%d', HTTP error code: '%d'", datacenterId, errorCode)
+	}
+
+	groups := []struct {
+		Id int `json:"id"`
+	}{}
+	err = json.Unmarshal(response, &groups)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling price groups")
+	}
+
+	ids := []int{}
+	for _, group := range groups {
+		ids = append(ids, group.Id)
+	}
+
+	return ids, nil
+}
+
+// selectPerformanceIops picks the IOPS to order performance storage of size in GB with out of the
+// offered IOPS. Without iops it is the median of the offered IOPS. IOPS within the offered range
+// are rounded up to the next offered IOPS, and IOPS out of it are rejected.
+func selectPerformanceIops(offered []int, iops int, size int) (int, error) {
+	if len(offered) == 0 {
+		return 0, bosherr.Errorf("SoftLayer offers no IOPS for '%d' GB of performance storage in the datacenter", size)
+	}
+
+	if iops == 0 {
+		return offered[len(offered)/2], nil
+	}
+
+	min, max := offered[0], offered[len(offered)-1]
+	if iops < min || iops > max {
+		return 0, api.NewCloudPropertiesError(fmt.Sprintf("IOPS '%d' is out of the range '%d' to '%d' SoftLayer offers for '%d' GB of performance storage", iops, min, max, size))
+	}
+
+	for _, value := range offered {
+		if value >= iops {
+			return value, nil
+		}
+	}
+
+	return max, nil
+}
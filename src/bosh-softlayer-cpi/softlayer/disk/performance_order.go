@@ -0,0 +1,59 @@
+package disk
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	slservices "github.com/maximilien/softlayer-go/services"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+// buildPerformanceOrder looks up the prices of the storage space of size in GB, the IOPS and the
+// block storage in the performance storage package
+func buildPerformanceOrder(client sl.Client, size int, iops int, location string, useHourlyPricing bool) (datatypes.SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi, error) {
+	productPackageService, err := client.GetSoftLayer_Product_Package_Service()
+	if err != nil {
+		return datatypes.SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi{}, bosherr.WrapError(err, "Cannot get product package service.")
+	}
+
+	lookups := []itemPriceLookup{
+		{fmt.Sprintf("'%d' GB of storage space", size), fmt.Sprintf(`{"itemPrices":{"item":{"keyName":{"operation":"%d_GB_PERFORMANCE_STORAGE_SPACE"}}}}`, size)},
+		{fmt.Sprintf("'%d' IOPS", iops), fmt.Sprintf(`{"itemPrices":{"item":{"capacity":{"operation":%d}},"attributes":{"value":{"operation":%d}},"categories":{"categoryCode":{"operation":"performance_storage_iops"}}}}`, iops, size)},
+	}
+
+	prices := []datatypes.SoftLayer_Product_Item_Price{}
+	for _, lookup := range lookups {
+		itemPrices, err := productPackageService.GetItemPrices(slservices.NETWORK_PERFORMANCE_STORAGE_PACKAGE_ID, lookup.filters)
+		if err != nil {
+			return datatypes.SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi{}, bosherr.WrapErrorf(err, "Finding price of %s", lookup.description)
+		}
+
+		priceId := standardItemPriceId(itemPrices)
+		if priceId == 0 {
+			return datatypes.SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi{}, bosherr.Errorf("No standard price of %s of performance storage", lookup.description)
+		}
+
+		prices = append(prices, datatypes.SoftLayer_Product_Item_Price{Id: priceId})
+	}
+
+	items, err := productPackageService.GetItems(slservices.NETWORK_PERFORMANCE_STORAGE_PACKAGE_ID, `{"items":{"categories":{"categoryCode":{"operation":"performance_storage_iscsi"}}}}`)
+	if err != nil {
+		return datatypes.SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi{}, bosherr.WrapError(err, "Finding price of block storage")
+	}
+
+	if len(items) == 0 || len(items[0].Prices) == 0 {
+		return datatypes.SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi{}, bosherr.Error("No price of block storage of performance storage")
+	}
+	prices = append(prices, datatypes.SoftLayer_Product_Item_Price{Id: items[0].Prices[0].Id})
+
+	return datatypes.SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi{
+		ComplexType:      "SoftLayer_Container_Product_Order_Network_PerformanceStorage_Iscsi",
+		Location:         location,
+		OsFormatType:     datatypes.SoftLayer_Network_Storage_Iscsi_OS_Type{Id: 12, KeyName: "LINUX"},
+		Prices:           prices,
+		PackageId:        slservices.NETWORK_PERFORMANCE_STORAGE_PACKAGE_ID,
+		Quantity:         1,
+		UseHourlyPricing: useHourlyPricing,
+	}, nil
+}
@@ -1,23 +1,33 @@
 package disk
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strconv"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
 	sl "github.com/maximilien/softlayer-go/softlayer"
+
+	"bosh-softlayer-cpi/api"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
 )
 
 const SOFTLAYER_DISK_CREATOR_LOG_TAG = "SoftLayerDiskCreator"
 
 type SoftLayerCreator struct {
 	softLayerClient sl.Client
+	createPolicy    slhelper.WaitPolicy
 	logger          boshlog.Logger
 }
 
-func NewSoftLayerDiskCreator(client sl.Client, logger boshlog.Logger) SoftLayerCreator {
+func NewSoftLayerDiskCreator(client sl.Client, createPolicy slhelper.WaitPolicy, logger boshlog.Logger) SoftLayerCreator {
 	return SoftLayerCreator{
 		softLayerClient: client,
+		createPolicy:    createPolicy,
 		logger:          logger,
 	}
 }
@@ -25,20 +35,145 @@ func NewSoftLayerDiskCreator(client sl.Client, logger boshlog.Logger) SoftLayerC
 func (c SoftLayerCreator) Create(size int, cloudProps DiskCloudProperties, datacenter_id int) (Disk, error) {
 	c.logger.Debug(SOFTLAYER_DISK_CREATOR_LOG_TAG, "Creating disk of size '%d'", size)
 
-	storageService, err := c.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	err := cloudProps.Validate(size)
+	if err != nil {
+		return SoftLayerDisk{}, api.NewCloudPropertiesError(err.Error())
+	}
+
+	if cloudProps.StorageType == STORAGE_TYPE_ENDURANCE {
+		return c.createEnduranceDisk(size, cloudProps, datacenter_id)
+	}
+
+	diskSize := SoftLayerDiskSize(size)
+
+	offered, err := findPerformanceIops(c.softLayerClient, diskSize, datacenter_id)
+	if err != nil {
+		return SoftLayerDisk{}, err
+	}
+
+	iops, err := selectPerformanceIops(offered, cloudProps.Iops, diskSize)
+	if err != nil {
+		return SoftLayerDisk{}, err
+	}
+
+	if iops != cloudProps.Iops {
+		c.logger.Info(SOFTLAYER_DISK_CREATOR_LOG_TAG, "Ordering '%d' GB of performance storage with '%d' IOPS instead of '%d' IOPS", diskSize, iops, cloudProps.Iops)
+	}
+
+	order, err := buildPerformanceOrder(c.softLayerClient, diskSize, iops, strconv.Itoa(datacenter_id), cloudProps.UseHourlyPricing)
 	if err != nil {
-		return SoftLayerDisk{}, bosherr.WrapError(err, "Create SoftLayer Network Storage Service error.")
+		return SoftLayerDisk{}, bosherr.WrapError(err, "Building performance storage order")
 	}
 
-	disk, err := storageService.CreateNetworkStorage(c.getSoftLayerDiskSize(size), cloudProps.Iops, strconv.Itoa(datacenter_id), cloudProps.UseHourlyPricing)
+	id, err := c.placeOrder(STORAGE_TYPE_PERFORMANCE, order)
 	if err != nil {
-		return SoftLayerDisk{}, bosherr.WrapError(err, "Create SoftLayer iSCSI disk error.")
+		return SoftLayerDisk{}, err
 	}
 
-	return NewSoftLayerDisk(disk.Id, c.softLayerClient, c.logger), nil
+	c.setNotes(id, fmt.Sprintf("%d GB performance storage with %d IOPS", diskSize, iops))
+
+	return NewSoftLayerDisk(id, c.softLayerClient, c.logger), nil
+}
+
+func (c SoftLayerCreator) createEnduranceDisk(size int, cloudProps DiskCloudProperties, datacenter_id int) (Disk, error) {
+	diskSize := SoftLayerDiskSize(size)
+	c.logger.Info(SOFTLAYER_DISK_CREATOR_LOG_TAG, "Ordering '%d' GB of endurance storage at tier '%g' with '%d' GB of snapshot space", diskSize, cloudProps.Tier, cloudProps.SnapshotSpace)
+
+	order, err := buildEnduranceOrder(c.softLayerClient, diskSize, cloudProps, strconv.Itoa(datacenter_id))
+	if err != nil {
+		return SoftLayerDisk{}, bosherr.WrapError(err, "Building endurance storage order")
+	}
+
+	id, err := c.placeOrder(STORAGE_TYPE_ENDURANCE, order)
+	if err != nil {
+		return SoftLayerDisk{}, err
+	}
+
+	c.setNotes(id, fmt.Sprintf("%d GB endurance storage at %g IOPS per GB with %d GB snapshot space", diskSize, cloudProps.Tier, cloudProps.SnapshotSpace))
+
+	return NewSoftLayerDisk(id, c.softLayerClient, c.logger), nil
+}
+
+// placeOrder places the storage order of the kind, performance or endurance, and returns the id of
+// the iSCSI volume once it shows up
+func (c SoftLayerCreator) placeOrder(kind string, order interface{}) (int, error) {
+	response, err := postStorageOrder(c.softLayerClient, "placeOrder", kind, order)
+	if err != nil {
+		return 0, err
+	}
+
+	receipt := datatypes.SoftLayer_Container_Product_Order_Receipt{}
+	err = json.Unmarshal(response, &receipt)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Unmarshalling %s storage order receipt", kind)
+	}
+
+	return c.waitForOrderedVolume(receipt.OrderId)
+}
+
+// waitForOrderedVolume waits until the iSCSI volume of the order with orderId shows up in the account
+func (c SoftLayerCreator) waitForOrderedVolume(orderId int) (int, error) {
+	accountService, err := c.softLayerClient.GetSoftLayer_Account_Service()
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Cannot get account service.")
+	}
+
+	filter := fmt.Sprintf(`{"iscsiNetworkStorage":{"billingItem":{"orderItem":{"order":{"id":{"operation":%d}}}}}}`, orderId)
+
+	var id int
+	found, err := c.createPolicy.Wait(func() (bool, error) {
+		volumes, err := accountService.GetIscsiNetworkStorageWithFilter(filter)
+		if err != nil {
+			if slhelper.IsAPIErrorRetryable(err) {
+				return false, nil
+			}
+			return false, bosherr.WrapErrorf(err, "Finding iSCSI volume of order '%d'", orderId)
+		}
+
+		if len(volumes) != 1 {
+			return false, nil
+		}
+
+		id = volumes[0].Id
+		return true, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if !found {
+		return 0, bosherr.Errorf("Waiting for iSCSI volume of order '%d' TIME OUT!", orderId)
+	}
+
+	c.logger.Info(SOFTLAYER_DISK_CREATOR_LOG_TAG, "Found iSCSI volume '%d' of order '%d'", id, orderId)
+
+	return id, nil
+}
+
+// setNotes records how the volume with id was ordered in its notes for operators to audit. The
+// volume is provisioned and billed by then, so failing to set them only logs a warning.
+func (c SoftLayerCreator) setNotes(id int, notes string) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"parameters": []map[string]string{{"notes": notes}},
+	})
+	if err != nil {
+		c.logger.Warn(SOFTLAYER_DISK_CREATOR_LOG_TAG, "Marshalling notes of disk '%d': %s", id, err)
+		return
+	}
+
+	_, errorCode, err := c.softLayerClient.GetHttpClient().DoRawHttpRequest(fmt.Sprintf("SoftLayer_Network_Storage/%d/editObject.json", id), "POST", bytes.NewBuffer(requestBody))
+	if err != nil {
+		c.logger.Warn(SOFTLAYER_DISK_CREATOR_LOG_TAG, "Setting notes of disk '%d': %s", id, err)
+		return
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		c.logger.Warn(SOFTLAYER_DISK_CREATOR_LOG_TAG, "Setting notes of disk '%d', HTTP error code: '%d'", id, errorCode)
+	}
 }
 
-func (c SoftLayerCreator) getSoftLayerDiskSize(size int) int {
+// SoftLayerDiskSize rounds a size in MB up to the smallest orderable iSCSI volume size in GB
+func SoftLayerDiskSize(size int) int {
 	// Sizes and IOPS ranges: http://knowledgelayer.softlayer.com/learning/performance-storage-concepts
 	sizeArray := []int{20, 40, 80, 100, 250, 500, 1000, 2000, 4000, 8000, 12000}
 
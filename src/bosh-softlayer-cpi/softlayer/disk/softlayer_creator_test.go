@@ -1,8 +1,13 @@
 package disk_test
 
 import (
+	"time"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	"bosh-softlayer-cpi/api"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+
 	testhelpers "bosh-softlayer-cpi/test_helpers"
 
 	fakeclient "github.com/maximilien/softlayer-go/client/fakes"
@@ -23,7 +28,7 @@ var _ = Describe("SoftLayerCreator", func() {
 	BeforeEach(func() {
 		fc = fakeclient.NewFakeSoftLayerClient("fake-user", "fake-key")
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		creator = NewSoftLayerDiskCreator(fc, logger)
+		creator = NewSoftLayerDiskCreator(fc, slhelper.NewWaitPolicy(2*time.Second, time.Second), logger)
 	})
 
 	Describe("Create", func() {
@@ -34,11 +39,14 @@ var _ = Describe("SoftLayerCreator", func() {
 		Context("Creates disk successfully with cloud properties", func() {
 			BeforeEach(func() {
 				fileNames := []string{
-					"SoftLayer_Product_Order_Service_getItems.json",
+					"SoftLayer_Location_Datacenter_Service_getPriceGroups.json",
+					"SoftLayer_Product_Order_Service_getIopsItemPrices_20GB.json",
 					"SoftLayer_Product_Order_Service_getItemPrices.json",
 					"SoftLayer_Product_Order_Service_getItemPricesBySizeAndIops.json",
+					"SoftLayer_Product_Order_Service_getItems.json",
 					"SoftLayer_Product_Order_Service_placeOrder.json",
 					"SoftLayer_Account_Service_getIscsiVolume.json",
+					"SoftLayer_Network_Storage_Service_editObject.json",
 				}
 				cloudProps = DiskCloudProperties{
 					Iops:             1000,
@@ -54,16 +62,27 @@ var _ = Describe("SoftLayerCreator", func() {
 				expectedDisk := NewSoftLayerDisk(1234, fc, logger)
 				Expect(disk).To(Equal(expectedDisk))
 			})
+
+			It("records the size and IOPS in the notes of the disk", func() {
+				_, err := creator.Create(20, cloudProps, 123)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Network_Storage/1234/editObject.json"))
+				Expect(fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring(`"notes":"20 GB performance storage with 1000 IOPS"`))
+			})
 		})
 
 		Context("Creates disk successfully without cloud properties", func() {
 			BeforeEach(func() {
 				fileNames := []string{
-					"SoftLayer_Product_Order_Service_getItems.json",
+					"SoftLayer_Location_Datacenter_Service_getPriceGroups.json",
+					"SoftLayer_Product_Order_Service_getIopsItemPrices_20GB.json",
 					"SoftLayer_Product_Order_Service_getItemPrices.json",
 					"SoftLayer_Product_Order_Service_getIopsItemPrices.json",
+					"SoftLayer_Product_Order_Service_getItems.json",
 					"SoftLayer_Product_Order_Service_placeOrder.json",
 					"SoftLayer_Account_Service_getIscsiVolume.json",
+					"SoftLayer_Network_Storage_Service_editObject.json",
 				}
 				cloudProps = DiskCloudProperties{}
 				testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, fileNames)
@@ -76,6 +95,106 @@ var _ = Describe("SoftLayerCreator", func() {
 				expectedDisk := NewSoftLayerDisk(1234, fc, logger)
 				Expect(disk).To(Equal(expectedDisk))
 			})
+
+			It("orders the median of the IOPS offered in the datacenter", func() {
+				_, err := creator.Create(20, cloudProps, 123)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring(`"notes":"20 GB performance storage with 500 IOPS"`))
+			})
+		})
+
+		Context("Selects IOPS", func() {
+			BeforeEach(func() {
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+					"SoftLayer_Location_Datacenter_Service_getPriceGroups.json",
+					"SoftLayer_Product_Order_Service_getIopsItemPrices_20GB.json",
+				})
+			})
+
+			It("rounds IOPS up to the next IOPS offered", func() {
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+					"SoftLayer_Product_Order_Service_getItemPrices.json",
+					"SoftLayer_Product_Order_Service_getItemPricesBySizeAndIops.json",
+					"SoftLayer_Product_Order_Service_getItems.json",
+					"SoftLayer_Product_Order_Service_placeOrder.json",
+					"SoftLayer_Account_Service_getIscsiVolume.json",
+					"SoftLayer_Network_Storage_Service_editObject.json",
+				})
+
+				_, err := creator.Create(20, DiskCloudProperties{Iops: 700}, 123)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring(`"notes":"20 GB performance storage with 800 IOPS"`))
+			})
+
+			It("rejects IOPS out of the range offered with a cloud error", func() {
+				_, err := creator.Create(20, DiskCloudProperties{Iops: 2000}, 123)
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(api.CloudPropertiesError{}))
+				Expect(err.Error()).To(ContainSubstring("IOPS '2000' is out of the range '100' to '1000'"))
+				Expect(fc.FakeHttpClient.DoRawHttpRequestResponsesIndex).To(Equal(2))
+			})
+
+			It("looks up the IOPS prices of the rounded size", func() {
+				_, err := creator.Create(30*1024, DiskCloudProperties{Iops: 2000}, 123)
+				Expect(err).To(HaveOccurred())
+				Expect(fc.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(ContainSubstring(`"attributes":{"value":{"operation":40}}`))
+			})
+		})
+
+		Context("Creates endurance disk", func() {
+			BeforeEach(func() {
+				cloudProps = DiskCloudProperties{
+					StorageType:   STORAGE_TYPE_ENDURANCE,
+					Tier:          2,
+					SnapshotSpace: 10,
+				}
+			})
+
+			It("places an endurance storage order, records it in the notes and returns the ordered disk", func() {
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceService.json",
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceBlock.json",
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceTier.json",
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceSnapshot.json",
+					"SoftLayer_Product_Order_Service_getItems_EnduranceSpace.json",
+					"SoftLayer_Product_Order_placeOrder.json",
+					"SoftLayer_Account_Service_getIscsiVolume.json",
+					"SoftLayer_Network_Storage_Service_editObject.json",
+				})
+
+				disk, err := creator.Create(20, cloudProps, 123)
+				Expect(err).ToNot(HaveOccurred())
+
+				expectedDisk := NewSoftLayerDisk(1234, fc, logger)
+				Expect(disk).To(Equal(expectedDisk))
+
+				Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Network_Storage/1234/editObject.json"))
+				Expect(fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring(`"notes":"20 GB endurance storage at 2 IOPS per GB with 10 GB snapshot space"`))
+			})
+
+			It("reports error without ordering when the tier is not offered", func() {
+				cloudProps.Tier = 3
+
+				_, err := creator.Create(20, cloudProps, 123)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Tier '3'"))
+				Expect(fc.FakeHttpClient.DoRawHttpRequestResponsesIndex).To(Equal(0))
+			})
+
+			It("reports error when the storage space has no standard price", func() {
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceService.json",
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceBlock.json",
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceTier.json",
+					"SoftLayer_Product_Order_Service_getItemPrices_EnduranceSnapshot.json",
+					"SoftLayer_Network_Storage_Service_getAllowedHardware_None.json",
+				})
+
+				_, err := creator.Create(20, cloudProps, 123)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("No standard price of '20' GB of storage space at tier '2'"))
+			})
 		})
 
 		Context("Failed to create disk", func() {
@@ -1,15 +1,26 @@
 package disk
 
 import (
-	"strings"
+	"bytes"
+	"encoding/json"
+	"fmt"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	slcommon "github.com/maximilien/softlayer-go/common"
 	slc "github.com/maximilien/softlayer-go/softlayer"
+
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
 )
 
 const SOFTLAYER_DISK_FINDER_LOG_TAG = "SoftLayerDiskFinder"
 
+// allowedHostMethods names the SoftLayer_Network_Storage method listing the hosts of each type
+var allowedHostMethods = map[string]string{
+	ALLOWED_VIRTUAL_GUESTS: "AllowedVirtualGuests",
+	ALLOWED_HARDWARE:       "AllowedHardware",
+}
+
 type SoftLayerFinder struct {
 	softLayerClient slc.Client
 	logger          boshlog.Logger
@@ -29,7 +40,7 @@ func (f SoftLayerFinder) Find(id int) (Disk, bool, error) {
 
 	disk, err := service.GetNetworkStorage(id)
 	if err != nil {
-		if !strings.Contains(err.Error(), "HTTP error code") {
+		if !slhelper.IsObjectNotFoundError(err) {
 			return nil, false, bosherr.WrapErrorf(err, "Failed to find iSCSI volume with id: %d", id)
 		}
 	}
@@ -42,3 +53,57 @@ func (f SoftLayerFinder) Find(id int) (Disk, bool, error) {
 
 	return result, true, nil
 }
+
+func (f SoftLayerFinder) FindAllowedByHost(hostType string, hostID int) ([]Disk, error) {
+	f.logger.Debug(SOFTLAYER_DISK_FINDER_LOG_TAG, "Finding disks allowed by %s '%d'", hostType, hostID)
+
+	accountService, err := f.softLayerClient.GetSoftLayer_Account_Service()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Cannot get account service.")
+	}
+
+	filter := fmt.Sprintf(`{"iscsiNetworkStorage":{"%s":{"id":{"operation":"%d"}}}}`, hostType, hostID)
+	volumes, err := accountService.GetIscsiNetworkStorageWithFilter(filter)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Failed to list iSCSI volumes allowed by %s %d", hostType, hostID)
+	}
+
+	disks := []Disk{}
+	for _, volume := range volumes {
+		disks = append(disks, NewSoftLayerDisk(volume.Id, f.softLayerClient, f.logger))
+	}
+
+	return disks, nil
+}
+
+// FindAllowedHosts lists the virtual guests and hardware allowed to access the disk with id
+func (f SoftLayerFinder) FindAllowedHosts(id int) ([]DiskHost, error) {
+	f.logger.Debug(SOFTLAYER_DISK_FINDER_LOG_TAG, "Finding hosts allowed to access disk '%d'", id)
+
+	hosts := []DiskHost{}
+	for _, hostType := range []string{ALLOWED_VIRTUAL_GUESTS, ALLOWED_HARDWARE} {
+		path := fmt.Sprintf("SoftLayer_Network_Storage/%d/get%s.json", id, allowedHostMethods[hostType])
+		response, errorCode, err := f.softLayerClient.GetHttpClient().DoRawHttpRequest(path, "GET", new(bytes.Buffer))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Failed to list %s of iSCSI volume with id: %d", hostType, id)
+		}
+
+		if slcommon.IsHttpErrorCode(errorCode) {
+			return nil, bosherr.Errorf("Failed to list %s of iSCSI volume with id: %d, HTTP error code: '%d'", hostType, id, errorCode)
+		}
+
+		allowed := []struct {
+			Id int `json:"id"`
+		}{}
+		err = json.Unmarshal(response, &allowed)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling %s of iSCSI volume with id: %d", hostType, id)
+		}
+
+		for _, host := range allowed {
+			hosts = append(hosts, DiskHost{Type: hostType, ID: host.Id})
+		}
+	}
+
+	return hosts, nil
+}
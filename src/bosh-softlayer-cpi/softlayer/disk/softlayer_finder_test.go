@@ -1,6 +1,8 @@
 package disk_test
 
 import (
+	"errors"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	testhelpers "bosh-softlayer-cpi/test_helpers"
@@ -46,5 +48,88 @@ var _ = Describe("SoftLayerFinder", func() {
 			Expect(found).To(BeFalse())
 			Expect(disk).To(BeNil())
 		})
+
+		It("returns found as false when SoftLayer returns 404", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_getEmptyIscsiVolume.json")
+			fc.FakeHttpClient.DoRawHttpRequestInt = 404
+
+			disk, found, err := finder.Find(1234)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(disk).To(BeNil())
+		})
+
+		It("returns an error when SoftLayer fails with another error", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_getEmptyIscsiVolume.json")
+			fc.FakeHttpClient.DoRawHttpRequestInt = 500
+
+			_, found, err := finder.Find(1234)
+			Expect(err).To(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("FindAllowedByHost", func() {
+		It("returns the disks which the virtual guest is allowed to access", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Account_Service_getIscsiVolume.json")
+
+			disks, err := finder.FindAllowedByHost(ALLOWED_VIRTUAL_GUESTS, 5678)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]Disk{NewSoftLayerDisk(1234, fc, logger)}))
+
+			Expect(fc.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(ContainSubstring(`"allowedVirtualGuests":{"id":{"operation":"5678"}}`))
+		})
+
+		It("filters on allowed hardware when the host is a baremetal server", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Account_Service_getIscsiVolume.json")
+
+			_, err := finder.FindAllowedByHost(ALLOWED_HARDWARE, 5678)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fc.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(ContainSubstring(`"allowedHardware":{"id":{"operation":"5678"}}`))
+		})
+
+		It("returns an error when listing the iSCSI volumes fails", func() {
+			fc.FakeHttpClient.DoRawHttpRequestError = errors.New("fake-error")
+
+			_, err := finder.FindAllowedByHost(ALLOWED_VIRTUAL_GUESTS, 5678)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("FindAllowedHosts", func() {
+		It("returns the virtual guests and hardware allowed to access the disk", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getAllowedVirtualGuests.json",
+				"SoftLayer_Network_Storage_Service_getAllowedHardware.json",
+			})
+
+			hosts, err := finder.FindAllowedHosts(1234)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hosts).To(Equal([]DiskHost{
+				{Type: ALLOWED_VIRTUAL_GUESTS, ID: 1234567},
+				{Type: ALLOWED_HARDWARE, ID: 7654321},
+			}))
+
+			Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Network_Storage/1234/getAllowedHardware.json"))
+		})
+
+		It("returns no hosts for a detached disk", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getAllowedVirtualGuests_None.json",
+				"SoftLayer_Network_Storage_Service_getAllowedHardware_None.json",
+			})
+
+			hosts, err := finder.FindAllowedHosts(1234)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hosts).To(BeEmpty())
+		})
+
+		It("returns an error when listing the hosts fails", func() {
+			fc.FakeHttpClient.DoRawHttpRequestError = errors.New("fake-error")
+
+			_, err := finder.FindAllowedHosts(1234)
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
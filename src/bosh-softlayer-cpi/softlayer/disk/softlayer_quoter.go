@@ -0,0 +1,91 @@
+package disk
+
+import (
+	"encoding/json"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+
+	"bosh-softlayer-cpi/api"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+const SOFTLAYER_DISK_QUOTER_LOG_TAG = "SoftLayerDiskQuoter"
+
+type SoftLayerQuoter struct {
+	softLayerClient sl.Client
+	logger          boshlog.Logger
+}
+
+func NewSoftLayerDiskQuoter(client sl.Client, logger boshlog.Logger) SoftLayerQuoter {
+	return SoftLayerQuoter{
+		softLayerClient: client,
+		logger:          logger,
+	}
+}
+
+// Quote has SoftLayer verify the iSCSI volume order create_disk would place
+// in the given location, with the IOPS create_disk would select, and returns
+// its price. Nothing is provisioned.
+func (q SoftLayerQuoter) Quote(size int, cloudProps DiskCloudProperties, location string) (slhelper.OrderQuote, error) {
+	q.logger.Debug(SOFTLAYER_DISK_QUOTER_LOG_TAG, "Verifying order of disk of size '%d' in location '%s'", size, location)
+
+	err := cloudProps.Validate(size)
+	if err != nil {
+		return slhelper.OrderQuote{}, api.NewCloudPropertiesError(err.Error())
+	}
+
+	if cloudProps.StorageType == STORAGE_TYPE_ENDURANCE {
+		return q.quoteEnduranceDisk(size, cloudProps, location)
+	}
+
+	datacenterId, err := strconv.Atoi(location)
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapErrorf(err, "Datacenter '%s' is not a SoftLayer datacenter id", location)
+	}
+
+	diskSize := SoftLayerDiskSize(size)
+
+	offered, err := findPerformanceIops(q.softLayerClient, diskSize, datacenterId)
+	if err != nil {
+		return slhelper.OrderQuote{}, err
+	}
+
+	iops, err := selectPerformanceIops(offered, cloudProps.Iops, diskSize)
+	if err != nil {
+		return slhelper.OrderQuote{}, err
+	}
+
+	order, err := buildPerformanceOrder(q.softLayerClient, diskSize, iops, location, cloudProps.UseHourlyPricing)
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapError(err, "Building performance storage order")
+	}
+
+	return q.verifyOrder(STORAGE_TYPE_PERFORMANCE, order)
+}
+
+func (q SoftLayerQuoter) quoteEnduranceDisk(size int, cloudProps DiskCloudProperties, location string) (slhelper.OrderQuote, error) {
+	order, err := buildEnduranceOrder(q.softLayerClient, SoftLayerDiskSize(size), cloudProps, location)
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapError(err, "Building endurance storage order")
+	}
+
+	return q.verifyOrder(STORAGE_TYPE_ENDURANCE, order)
+}
+
+func (q SoftLayerQuoter) verifyOrder(kind string, order interface{}) (slhelper.OrderQuote, error) {
+	response, err := postStorageOrder(q.softLayerClient, "verifyOrder", kind, order)
+	if err != nil {
+		return slhelper.OrderQuote{}, err
+	}
+
+	verifiedOrder := slhelper.VerifiedOrder{}
+	err = json.Unmarshal(response, &verifiedOrder)
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapErrorf(err, "Unmarshalling verified %s storage order", kind)
+	}
+
+	return slhelper.NewOrderQuote(verifiedOrder)
+}
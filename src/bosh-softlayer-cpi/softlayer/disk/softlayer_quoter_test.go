@@ -0,0 +1,102 @@
+package disk_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+
+	fakeclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/disk"
+)
+
+var _ = Describe("SoftLayerQuoter", func() {
+	var (
+		fc     *fakeclient.FakeSoftLayerClient
+		logger boshlog.Logger
+		quoter SoftLayerQuoter
+	)
+
+	BeforeEach(func() {
+		fc = fakeclient.NewFakeSoftLayerClient("fake-user", "fake-key")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		quoter = NewSoftLayerDiskQuoter(fc, logger)
+	})
+
+	Describe("Quote", func() {
+		BeforeEach(func() {
+			fileNames := []string{
+				"SoftLayer_Location_Datacenter_Service_getPriceGroups.json",
+				"SoftLayer_Product_Order_Service_getIopsItemPrices_20GB.json",
+				"SoftLayer_Product_Order_Service_getItemPrices.json",
+				"SoftLayer_Product_Order_Service_getItemPricesBySizeAndIops.json",
+				"SoftLayer_Product_Order_Service_getItems.json",
+				"SoftLayer_Product_Order_Service_verifyOrder_iscsi.json",
+			}
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, fileNames)
+		})
+
+		It("returns the itemized price without placing the order", func() {
+			quote, err := quoter.Quote(20, DiskCloudProperties{Iops: 1000, UseHourlyPricing: true}, "123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(quote.Items).To(HaveLen(3))
+			Expect(quote.Hourly).To(BeNumerically("~", 0.09))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Product_Order/verifyOrder.json"))
+		})
+
+		It("rejects IOPS SoftLayer does not offer for the size", func() {
+			_, err := quoter.Quote(20, DiskCloudProperties{Iops: 6000}, "123")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("IOPS '6000' is out of the range '100' to '1000'"))
+		})
+	})
+
+	Describe("Quote endurance storage", func() {
+		It("returns the itemized price of endurance storage", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Product_Order_Service_getItemPrices_EnduranceService.json",
+				"SoftLayer_Product_Order_Service_getItemPrices_EnduranceBlock.json",
+				"SoftLayer_Product_Order_Service_getItemPrices_EnduranceTier.json",
+				"SoftLayer_Product_Order_Service_getItemPrices_EnduranceSnapshot.json",
+				"SoftLayer_Product_Order_Service_getItems_EnduranceSpace.json",
+				"SoftLayer_Product_Order_Service_verifyOrder_endurance.json",
+			})
+
+			quote, err := quoter.Quote(20, DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 2, SnapshotSpace: 10}, "123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(quote.Items).To(HaveLen(5))
+			Expect(quote.Monthly).To(BeNumerically("~", 14.5))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Product_Order/verifyOrder.json"))
+
+			order := fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()
+			Expect(order).To(ContainSubstring(`"complexType":"SoftLayer_Container_Product_Order_Network_Storage_AsAService"`))
+			Expect(order).To(ContainSubstring(`"packageId":759`))
+			Expect(order).To(ContainSubstring(`"volumeSize":20`))
+			Expect(order).To(ContainSubstring(`"location":"123"`))
+			Expect(order).To(ContainSubstring(`"prices":[{"id":45058},{"id":45098},{"id":45088},{"id":46160},{"id":45318}]`))
+		})
+
+		It("orders no snapshot space unless asked to", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Product_Order_Service_getItemPrices_EnduranceService.json",
+				"SoftLayer_Product_Order_Service_getItemPrices_EnduranceBlock.json",
+				"SoftLayer_Product_Order_Service_getItemPrices_EnduranceTier.json",
+				"SoftLayer_Product_Order_Service_getItems_EnduranceSpace.json",
+				"SoftLayer_Product_Order_Service_verifyOrder_endurance.json",
+			})
+
+			_, err := quoter.Quote(20, DiskCloudProperties{StorageType: STORAGE_TYPE_ENDURANCE, Tier: 2}, "123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(ContainSubstring(`"prices":[{"id":45058},{"id":45098},{"id":45088},{"id":45318}]`))
+		})
+
+		It("rejects invalid cloud properties", func() {
+			_, err := quoter.Quote(20, DiskCloudProperties{StorageType: "fake-type"}, "123")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown storage type 'fake-type'"))
+		})
+	})
+})
@@ -0,0 +1,266 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+
+	"bosh-softlayer-cpi/api"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+const SOFTLAYER_DISK_RESIZER_LOG_TAG = "SoftLayerDiskResizer"
+
+// volumeUpgradeOrder is the SoftLayer_Container_Product_Order_Network_Storage_AsAService_Upgrade
+// container, which the SoftLayer client has no type for
+type volumeUpgradeOrder struct {
+	ComplexType string              `json:"complexType"`
+	PackageId   int                 `json:"packageId"`
+	Prices      []orderItemPrice    `json:"prices"`
+	Volume      volumeUpgradeVolume `json:"volume"`
+	VolumeSize  int                 `json:"volumeSize"`
+	Iops        int                 `json:"iops,omitempty"`
+}
+
+type orderItemPrice struct {
+	Id int `json:"id"`
+}
+
+type volumeUpgradeVolume struct {
+	Id int `json:"id"`
+}
+
+// resizableVolume is the iSCSI volume to upgrade along with its storage type, the tier of endurance
+// storage and the IOPS of performance storage, which the SoftLayer client has no fields for
+type resizableVolume struct {
+	Id               int    `json:"id"`
+	CapacityGb       int    `json:"capacityGb"`
+	UpgradableFlag   bool   `json:"upgradableFlag"`
+	ProvisionedIops  string `json:"provisionedIops"`
+	StorageTierLevel string `json:"storageTierLevel"`
+	StorageType      struct {
+		KeyName string `json:"keyName"`
+	} `json:"storageType"`
+}
+
+func (v resizableVolume) isEndurance() bool {
+	return strings.HasPrefix(v.StorageType.KeyName, "ENDURANCE")
+}
+
+type SoftLayerResizer struct {
+	softLayerClient sl.Client
+	resizePolicy    slhelper.WaitPolicy
+	logger          boshlog.Logger
+}
+
+func NewSoftLayerDiskResizer(client sl.Client, resizePolicy slhelper.WaitPolicy, logger boshlog.Logger) SoftLayerResizer {
+	return SoftLayerResizer{
+		softLayerClient: client,
+		resizePolicy:    resizePolicy,
+		logger:          logger,
+	}
+}
+
+// Resize upgrades the capacity of the iSCSI volume with id in place to size in MB, rounded up like
+// on create, and waits for the upgrade transaction to finish. SoftLayer cannot shrink a volume, nor
+// upgrade one it does not flag as upgradable, such as a volume ordered from the legacy performance
+// storage package. Those fail with api.NotSupportedError and the director copies the disk instead.
+func (r SoftLayerResizer) Resize(id int, size int) error {
+	newSize := SoftLayerDiskSize(size)
+	r.logger.Debug(SOFTLAYER_DISK_RESIZER_LOG_TAG, "Resizing disk '%d' to '%d' GB", id, newSize)
+
+	storageService, err := r.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	if err != nil {
+		return bosherr.WrapError(err, "Cannot get network storage service.")
+	}
+
+	volume, err := r.getVolume(id)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Cannot get iSCSI volume with id: %d", id)
+	}
+
+	switch {
+	case newSize < volume.CapacityGb:
+		r.logger.Info(SOFTLAYER_DISK_RESIZER_LOG_TAG, "Not shrinking disk '%d' from '%d' GB to '%d' GB", id, volume.CapacityGb, newSize)
+		return api.NotSupportedError{}
+	case newSize == volume.CapacityGb:
+		r.logger.Info(SOFTLAYER_DISK_RESIZER_LOG_TAG, "Disk '%d' already has '%d' GB", id, newSize)
+		return nil
+	}
+
+	if !volume.UpgradableFlag {
+		r.logger.Info(SOFTLAYER_DISK_RESIZER_LOG_TAG, "Disk '%d' cannot be upgraded in place", id)
+		return api.NotSupportedError{}
+	}
+
+	order, err := r.buildUpgradeOrder(volume, newSize)
+	if err != nil {
+		return err
+	}
+
+	err = r.placeUpgradeOrder(order)
+	if err != nil {
+		return err
+	}
+
+	return r.waitForUpgrade(storageService, id, newSize)
+}
+
+func (r SoftLayerResizer) getVolume(id int) (resizableVolume, error) {
+	masks := []string{
+		"id",
+		"capacityGb",
+		"upgradableFlag",
+		"provisionedIops",
+		"storageTierLevel",
+		"storageType.keyName",
+	}
+
+	response, errorCode, err := r.softLayerClient.GetHttpClient().DoRawHttpRequestWithObjectMask(fmt.Sprintf("SoftLayer_Network_Storage/%d/getObject.json", id), masks, "GET", new(bytes.Buffer))
+	if err != nil {
+		return resizableVolume{}, err
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return resizableVolume{}, bosherr.Errorf("HTTP error code: '%d'", errorCode)
+	}
+
+	volume := resizableVolume{}
+	err = json.Unmarshal(response, &volume)
+	if err != nil {
+		return resizableVolume{}, bosherr.WrapError(err, "Unmarshalling iSCSI volume")
+	}
+
+	return volume, nil
+}
+
+// buildUpgradeOrder prices size in GB of storage space for the volume in the Storage-as-a-Service
+// package, at the tier of an endurance volume or with the IOPS of a performance volume
+func (r SoftLayerResizer) buildUpgradeOrder(volume resizableVolume, size int) (volumeUpgradeOrder, error) {
+	order := volumeUpgradeOrder{
+		ComplexType: "SoftLayer_Container_Product_Order_Network_Storage_AsAService_Upgrade",
+		PackageId:   STORAGE_AS_A_SERVICE_PACKAGE_ID,
+		Volume:      volumeUpgradeVolume{Id: volume.Id},
+		VolumeSize:  size,
+	}
+
+	if volume.isEndurance() {
+		tier, found := enduranceStorageTierLevels[volume.StorageTierLevel]
+		if !found {
+			return volumeUpgradeOrder{}, bosherr.Errorf("Unknown tier level '%s' of endurance iSCSI volume with id: %d", volume.StorageTierLevel, volume.Id)
+		}
+
+		priceId, err := findEnduranceSpacePriceId(r.softLayerClient, size, tier)
+		if err != nil {
+			return volumeUpgradeOrder{}, bosherr.WrapErrorf(err, "Finding price of '%d' GB of storage space", size)
+		}
+		order.Prices = []orderItemPrice{{Id: priceId}}
+
+		return order, nil
+	}
+
+	iops, err := strconv.Atoi(volume.ProvisionedIops)
+	if err != nil {
+		return volumeUpgradeOrder{}, bosherr.WrapErrorf(err, "Reading IOPS '%s' of iSCSI volume with id: %d", volume.ProvisionedIops, volume.Id)
+	}
+
+	spacePriceId, err := findPerformanceSpacePriceId(r.softLayerClient, size)
+	if err != nil {
+		return volumeUpgradeOrder{}, bosherr.WrapErrorf(err, "Finding price of '%d' GB of storage space", size)
+	}
+
+	iopsPriceId, err := findPerformanceIopsPriceId(r.softLayerClient, iops, size)
+	if err != nil {
+		return volumeUpgradeOrder{}, bosherr.WrapErrorf(err, "Finding price of '%d' IOPS", iops)
+	}
+
+	order.Prices = []orderItemPrice{{Id: spacePriceId}, {Id: iopsPriceId}}
+	order.Iops = iops
+
+	return order, nil
+}
+
+func (r SoftLayerResizer) placeUpgradeOrder(order volumeUpgradeOrder) error {
+	id := order.Volume.Id
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"parameters": []volumeUpgradeOrder{order},
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling upgrade order")
+	}
+
+	response, errorCode, err := r.softLayerClient.GetHttpClient().DoRawHttpRequest("SoftLayer_Product_Order/placeOrder.json", "POST", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Placing upgrade order of iSCSI volume with id: %d", id)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return bosherr.Errorf("Placing upgrade order of iSCSI volume with id: %d, HTTP error code: '%d'", id, errorCode)
+	}
+
+	receipt := datatypes.SoftLayer_Container_Product_Order_Receipt{}
+	err = json.Unmarshal(response, &receipt)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling upgrade order receipt")
+	}
+
+	r.logger.Info(SOFTLAYER_DISK_RESIZER_LOG_TAG, "Placed order '%d' to upgrade disk '%d' to '%d' GB", receipt.OrderId, id, order.VolumeSize)
+
+	return nil
+}
+
+// waitForUpgrade waits until the volume has no active transaction and reports its new capacity
+func (r SoftLayerResizer) waitForUpgrade(storageService sl.SoftLayer_Network_Storage_Service, id int, size int) error {
+	upgraded, err := r.resizePolicy.Wait(func() (bool, error) {
+		response, errorCode, err := r.softLayerClient.GetHttpClient().DoRawHttpRequest(fmt.Sprintf("SoftLayer_Network_Storage/%d/getActiveTransactions.json", id), "GET", new(bytes.Buffer))
+		if err != nil {
+			if slhelper.IsAPIErrorRetryable(err) {
+				return false, nil
+			}
+			return false, bosherr.WrapErrorf(err, "Getting active transactions of iSCSI volume with id: %d", id)
+		}
+
+		if slcommon.IsHttpErrorCode(errorCode) {
+			return false, bosherr.Errorf("Getting active transactions of iSCSI volume with id: %d, HTTP error code: '%d'", id, errorCode)
+		}
+
+		transactions := []datatypes.SoftLayer_Provisioning_Version1_Transaction{}
+		err = json.Unmarshal(response, &transactions)
+		if err != nil {
+			return false, bosherr.WrapError(err, "Unmarshalling active transactions of iSCSI volume")
+		}
+
+		if len(transactions) > 0 {
+			r.logger.Debug(SOFTLAYER_DISK_RESIZER_LOG_TAG, "Disk '%d' has '%d' active transactions", id, len(transactions))
+			return false, nil
+		}
+
+		volume, err := storageService.GetNetworkStorage(id)
+		if err != nil {
+			if slhelper.IsAPIErrorRetryable(err) {
+				return false, nil
+			}
+			return false, bosherr.WrapErrorf(err, "Cannot get iSCSI volume with id: %d", id)
+		}
+
+		return volume.CapacityGb >= size, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !upgraded {
+		return bosherr.Errorf("Waiting for upgrade of iSCSI volume with id: %d to '%d' GB TIME OUT!", id, size)
+	}
+
+	return nil
+}
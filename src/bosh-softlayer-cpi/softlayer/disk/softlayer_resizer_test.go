@@ -0,0 +1,141 @@
+package disk_test
+
+import (
+	"errors"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-softlayer-cpi/api"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+	fakeclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/disk"
+)
+
+var _ = Describe("SoftLayerResizer", func() {
+	var (
+		fc      *fakeclient.FakeSoftLayerClient
+		logger  boshlog.Logger
+		resizer SoftLayerResizer
+	)
+
+	BeforeEach(func() {
+		fc = fakeclient.NewFakeSoftLayerClient("fake-user", "fake-key")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		resizer = NewSoftLayerDiskResizer(fc, slhelper.NewWaitPolicy(time.Second, time.Millisecond), logger)
+	})
+
+	Describe("Resize", func() {
+		It("places an upgrade order and waits until the volume has the new size", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume.json",
+				"SoftLayer_Product_Order_Service_getItems_PerformanceSpace.json",
+				"SoftLayer_Product_Order_Service_getItems_PerformanceIops.json",
+				"SoftLayer_Product_Order_placeOrder.json",
+				"SoftLayer_Virtual_Guest_Service_getActiveTransactions.json",
+				"SoftLayer_Virtual_Guest_Service_getActiveTransactions_None.json",
+				"SoftLayer_Network_Storage_Service_getIscsiVolume_Upgraded.json",
+			})
+
+			err := resizer.Resize(1234, 30*1024)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fc.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskPath).To(Equal("SoftLayer_Product_Package/759/getItems.json"))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(ContainSubstring("performance_storage_iops"))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Network_Storage/1234/getActiveTransactions.json"))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestResponsesIndex).To(Equal(7))
+		})
+
+		It("orders the storage space and the IOPS of the volume in the storage package", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume.json",
+				"SoftLayer_Product_Order_Service_getItems_PerformanceSpace.json",
+				"SoftLayer_Product_Order_Service_getItems_PerformanceIops.json",
+				"SoftLayer_Product_Order_placeOrder.json",
+			})
+			fc.FakeHttpClient.DoRawHttpRequestInts = []int{200, 200, 200, 500}
+
+			err := resizer.Resize(1234, 30*1024)
+			Expect(err).To(HaveOccurred())
+			Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Product_Order/placeOrder.json"))
+			order := fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()
+			Expect(order).To(ContainSubstring(`"packageId":759`))
+			Expect(order).To(ContainSubstring(`"prices":[{"id":190233},{"id":190113}]`))
+			Expect(order).To(ContainSubstring(`"volumeSize":40`))
+			Expect(order).To(ContainSubstring(`"iops":1000`))
+		})
+
+		It("rejects upgrading a volume SoftLayer does not flag as upgradable as not supported", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume_Upgraded.json",
+			})
+
+			err := resizer.Resize(1234, 80*1024)
+			Expect(err).To(Equal(api.NotSupportedError{}))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestResponsesIndex).To(Equal(1))
+		})
+
+		It("prices the storage space of an endurance volume at its tier in the storage package", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume_Endurance.json",
+				"SoftLayer_Product_Order_Service_getItems_EnduranceSpace.json",
+				"SoftLayer_Product_Order_placeOrder.json",
+				"SoftLayer_Virtual_Guest_Service_getActiveTransactions_None.json",
+				"SoftLayer_Network_Storage_Service_getIscsiVolume_Upgraded.json",
+			})
+
+			err := resizer.Resize(1234, 30*1024)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fc.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskPath).To(Equal("SoftLayer_Product_Package/759/getItems.json"))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestWithObjectFilterAndObjectMaskFilters).To(ContainSubstring("performance_storage_space"))
+		})
+
+		It("does nothing when the volume already has the size", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume.json",
+			})
+
+			err := resizer.Resize(1234, 20*1024)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fc.FakeHttpClient.DoRawHttpRequestResponsesIndex).To(Equal(1))
+		})
+
+		It("rejects shrinking the volume as not supported", func() {
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume_Upgraded.json",
+			})
+
+			err := resizer.Resize(1234, 20*1024)
+			Expect(err).To(Equal(api.NotSupportedError{}))
+		})
+
+		It("reports error when SoftLayer fails", func() {
+			fc.FakeHttpClient.DoRawHttpRequestError = errors.New("fake-error")
+
+			err := resizer.Resize(1234, 40*1024)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-error"))
+		})
+
+		It("times out when the upgrade transaction does not finish", func() {
+			fileNames := []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume.json",
+				"SoftLayer_Product_Order_Service_getItems_PerformanceSpace.json",
+				"SoftLayer_Product_Order_Service_getItems_PerformanceIops.json",
+				"SoftLayer_Product_Order_placeOrder.json",
+			}
+			for i := 0; i < 50; i++ {
+				fileNames = append(fileNames, "SoftLayer_Virtual_Guest_Service_getActiveTransactions.json")
+			}
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fc, fileNames)
+			resizer = NewSoftLayerDiskResizer(fc, slhelper.NewWaitPolicy(5*time.Millisecond, time.Millisecond), logger)
+
+			err := resizer.Resize(1234, 40*1024)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
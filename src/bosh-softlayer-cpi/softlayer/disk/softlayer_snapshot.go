@@ -0,0 +1,46 @@
+package disk
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	slc "github.com/maximilien/softlayer-go/softlayer"
+)
+
+const SOFTLAYER_SNAPSHOT_LOG_TAG = "SoftLayerSnapshot"
+
+type SoftLayerSnapshot struct {
+	id              int
+	softLayerClient slc.Client
+	logger          boshlog.Logger
+}
+
+func NewSoftLayerSnapshot(id int, client slc.Client, logger boshlog.Logger) SoftLayerSnapshot {
+	return SoftLayerSnapshot{
+		id:              id,
+		softLayerClient: client,
+		logger:          logger,
+	}
+}
+
+func (s SoftLayerSnapshot) ID() int { return s.id }
+
+func (s SoftLayerSnapshot) Delete() error {
+	s.logger.Debug(SOFTLAYER_SNAPSHOT_LOG_TAG, "Deleting snapshot '%d'", s.id)
+
+	service, err := s.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	if err != nil {
+		return bosherr.WrapError(err, "Cannot get network storage service.")
+	}
+
+	deleted, err := service.DeleteObject(s.id)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to delete snapshot with id: %d", s.id)
+	}
+
+	if !deleted {
+		return bosherr.Errorf("Failed to delete snapshot with id: %d", s.id)
+	}
+
+	return nil
+}
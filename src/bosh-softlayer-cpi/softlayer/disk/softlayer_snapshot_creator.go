@@ -0,0 +1,80 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	slcommon "github.com/maximilien/softlayer-go/common"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+const SOFTLAYER_SNAPSHOT_CREATOR_LOG_TAG = "SoftLayerSnapshotCreator"
+
+type SoftLayerSnapshotCreator struct {
+	softLayerClient sl.Client
+	logger          boshlog.Logger
+}
+
+func NewSoftLayerSnapshotCreator(client sl.Client, logger boshlog.Logger) SoftLayerSnapshotCreator {
+	return SoftLayerSnapshotCreator{
+		softLayerClient: client,
+		logger:          logger,
+	}
+}
+
+func (c SoftLayerSnapshotCreator) Create(diskID int, metadata SnapshotMetadata) (Snapshot, error) {
+	c.logger.Debug(SOFTLAYER_SNAPSHOT_CREATOR_LOG_TAG, "Creating snapshot of disk '%d'", diskID)
+
+	parameters := map[string]interface{}{
+		"parameters": []string{SnapshotNotesFromMetadata(metadata)},
+	}
+
+	requestBody, err := json.Marshal(parameters)
+	if err != nil {
+		return SoftLayerSnapshot{}, bosherr.WrapError(err, "Marshalling snapshot parameters")
+	}
+
+	response, errorCode, err := c.softLayerClient.GetHttpClient().DoRawHttpRequest(fmt.Sprintf("SoftLayer_Network_Storage/%d/createSnapshot.json", diskID), "POST", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return SoftLayerSnapshot{}, bosherr.WrapErrorf(err, "Creating snapshot of iSCSI volume with id: %d", diskID)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return SoftLayerSnapshot{}, bosherr.Errorf("Creating snapshot of iSCSI volume with id: %d, HTTP error code: '%d'", diskID, errorCode)
+	}
+
+	snapshot := datatypes.SoftLayer_Network_Storage{}
+	err = json.Unmarshal(response, &snapshot)
+	if err != nil {
+		return SoftLayerSnapshot{}, bosherr.WrapError(err, "Unmarshalling snapshot of iSCSI volume")
+	}
+
+	if snapshot.Id == 0 {
+		return SoftLayerSnapshot{}, bosherr.Errorf("SoftLayer did not return a snapshot for iSCSI volume with id: %d", diskID)
+	}
+
+	return NewSoftLayerSnapshot(snapshot.Id, c.softLayerClient, c.logger), nil
+}
+
+// SnapshotNotesFromMetadata flattens director metadata into sorted 'key:value' pairs
+func SnapshotNotesFromMetadata(metadata SnapshotMetadata) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s:%v", key, metadata[key]))
+	}
+
+	return strings.Join(pairs, ",")
+}
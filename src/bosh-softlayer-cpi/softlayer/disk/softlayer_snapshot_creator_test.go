@@ -0,0 +1,89 @@
+package disk_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+	fakeclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/disk"
+)
+
+var _ = Describe("SoftLayerSnapshotCreator", func() {
+	var (
+		fc       *fakeclient.FakeSoftLayerClient
+		logger   boshlog.Logger
+		creator  SoftLayerSnapshotCreator
+		metadata SnapshotMetadata
+	)
+
+	BeforeEach(func() {
+		fc = fakeclient.NewFakeSoftLayerClient("fake-user", "fake-key")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		creator = NewSoftLayerSnapshotCreator(fc, logger)
+		metadata = SnapshotMetadata{
+			"deployment": "fake-deployment",
+			"job":        "fake-job",
+			"index":      0,
+		}
+	})
+
+	Describe("Create", func() {
+		It("creates snapshot successfully and returns unique snapshot id", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_createSnapshot.json")
+
+			snapshot, err := creator.Create(1234, metadata)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot).To(Equal(NewSoftLayerSnapshot(5678, fc, logger)))
+
+			Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Network_Storage/1234/createSnapshot.json"))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestRequestType).To(Equal("POST"))
+		})
+
+		It("passes the director metadata as snapshot notes", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_createSnapshot.json")
+
+			_, err := creator.Create(1234, metadata)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fc.FakeHttpClient.DoRawHttpRequestRequestBody.String()).To(Equal(`{"parameters":["deployment:fake-deployment,index:0,job:fake-job"]}`))
+		})
+
+		It("reports error when SoftLayer API call fails", func() {
+			fc.FakeHttpClient.DoRawHttpRequestError = errors.New("fake-error")
+
+			_, err := creator.Create(1234, metadata)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-error"))
+		})
+
+		It("reports error when SoftLayer returns HTTP error code", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_createSnapshot.json")
+			fc.FakeHttpClient.DoRawHttpRequestInt = 500
+
+			_, err := creator.Create(1234, metadata)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("reports error when SoftLayer returns no snapshot", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_getEmptyIscsiVolume.json")
+
+			_, err := creator.Create(1234, metadata)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("SnapshotNotesFromMetadata", func() {
+		It("returns sorted key:value pairs", func() {
+			Expect(SnapshotNotesFromMetadata(metadata)).To(Equal("deployment:fake-deployment,index:0,job:fake-job"))
+		})
+
+		It("returns empty notes for empty metadata", func() {
+			Expect(SnapshotNotesFromMetadata(SnapshotMetadata{})).To(Equal(""))
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package disk
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	slc "github.com/maximilien/softlayer-go/softlayer"
+
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+const SOFTLAYER_SNAPSHOT_FINDER_LOG_TAG = "SoftLayerSnapshotFinder"
+
+// The nasType SoftLayer gives the snapshots of iSCSI volumes
+const SNAPSHOT_NAS_TYPE = "SNAPSHOT"
+
+type SoftLayerSnapshotFinder struct {
+	softLayerClient slc.Client
+	logger          boshlog.Logger
+}
+
+func NewSoftLayerSnapshotFinder(client slc.Client, logger boshlog.Logger) SoftLayerSnapshotFinder {
+	return SoftLayerSnapshotFinder{softLayerClient: client, logger: logger}
+}
+
+func (f SoftLayerSnapshotFinder) Find(id int) (Snapshot, bool, error) {
+	f.logger.Debug(SOFTLAYER_SNAPSHOT_FINDER_LOG_TAG, "Finding snapshot '%d'", id)
+
+	service, err := f.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Cannot get network storage service.")
+	}
+
+	snapshot, err := service.GetNetworkStorage(id)
+	if err != nil {
+		if !slhelper.IsObjectNotFoundError(err) {
+			return nil, false, bosherr.WrapErrorf(err, "Failed to find snapshot with id: %d", id)
+		}
+	}
+
+	// The id may be that of a volume, which delete_snapshot must not delete
+	if snapshot.Id == 0 || snapshot.NasType != SNAPSHOT_NAS_TYPE {
+		return nil, false, nil
+	}
+
+	return NewSoftLayerSnapshot(id, f.softLayerClient, f.logger), true, nil
+}
@@ -0,0 +1,56 @@
+package disk_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+	fakeclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/disk"
+)
+
+var _ = Describe("SoftLayerSnapshotFinder", func() {
+	var (
+		fc     *fakeclient.FakeSoftLayerClient
+		logger boshlog.Logger
+		finder SoftLayerSnapshotFinder
+	)
+
+	BeforeEach(func() {
+		fc = fakeclient.NewFakeSoftLayerClient("fake-user", "fake-key")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		finder = NewSoftLayerSnapshotFinder(fc, logger)
+	})
+
+	Describe("Find", func() {
+		It("returns snapshot and found as true when found the snapshot successfully", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_getSnapshot.json")
+
+			snapshot, found, err := finder.Find(5678)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(snapshot).To(Equal(NewSoftLayerSnapshot(5678, fc, logger)))
+		})
+
+		It("returns found as false when the id is that of a volume", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_getIscsiVolume.json")
+
+			snapshot, found, err := finder.Find(1234)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(snapshot).To(BeNil())
+		})
+
+		It("returns found as false when failed to find the snapshot", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_getEmptyIscsiVolume.json")
+
+			snapshot, found, err := finder.Find(5678)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(snapshot).To(BeNil())
+		})
+	})
+})
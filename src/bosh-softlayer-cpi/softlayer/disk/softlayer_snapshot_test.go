@@ -0,0 +1,43 @@
+package disk_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+	fakeclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	. "bosh-softlayer-cpi/softlayer/disk"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SoftLayerSnapshot", func() {
+	var (
+		fc       *fakeclient.FakeSoftLayerClient
+		snapshot SoftLayerSnapshot
+	)
+
+	BeforeEach(func() {
+		fc = fakeclient.NewFakeSoftLayerClient("fake-user", "fake-key")
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		snapshot = NewSoftLayerSnapshot(5678, fc, logger)
+	})
+
+	Describe("Delete", func() {
+		It("deletes a snapshot successfully", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_deleteObject_true.json")
+
+			err := snapshot.Delete()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fc.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Network_Storage/5678.json"))
+			Expect(fc.FakeHttpClient.DoRawHttpRequestRequestType).To(Equal("DELETE"))
+		})
+
+		It("reports error when SoftLayer does not delete the snapshot", func() {
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(fc, "SoftLayer_Network_Storage_Service_deleteObject_false.json")
+
+			err := snapshot.Delete()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
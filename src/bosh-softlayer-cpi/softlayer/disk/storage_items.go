@@ -0,0 +1,167 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+// The Storage-as-a-Service package, which sells endurance storage and the upgrades of iSCSI volumes
+const STORAGE_AS_A_SERVICE_PACKAGE_ID = 759
+
+// storageItem is a SoftLayer_Product_Item of the Storage-as-a-Service package along with the range
+// of capacities it is sold for, which the SoftLayer client has no type for
+type storageItem struct {
+	Id              int                `json:"id"`
+	KeyName         string             `json:"keyName"`
+	CapacityMinimum string             `json:"capacityMinimum"`
+	CapacityMaximum string             `json:"capacityMaximum"`
+	Prices          []storageItemPrice `json:"prices"`
+}
+
+type storageItemPrice struct {
+	Id                         int    `json:"id"`
+	LocationGroupId            int    `json:"locationGroupId"`
+	CapacityRestrictionMinimum string `json:"capacityRestrictionMinimum"`
+	CapacityRestrictionMaximum string `json:"capacityRestrictionMaximum"`
+}
+
+// findStorageItems returns the items of the Storage-as-a-Service package in the category
+func findStorageItems(client sl.Client, categoryCode string) ([]storageItem, error) {
+	masks := []string{
+		"id",
+		"keyName",
+		"capacityMinimum",
+		"capacityMaximum",
+		"prices.id",
+		"prices.locationGroupId",
+		"prices.capacityRestrictionMinimum",
+		"prices.capacityRestrictionMaximum",
+	}
+	filters := fmt.Sprintf(`{"items":{"itemCategory":{"categoryCode":{"operation":"%s"}}}}`, categoryCode)
+
+	response, errorCode, err := client.GetHttpClient().DoRawHttpRequestWithObjectFilterAndObjectMask(fmt.Sprintf("SoftLayer_Product_Package/%d/getItems.json", STORAGE_AS_A_SERVICE_PACKAGE_ID), masks, filters, "GET", new(bytes.Buffer))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Getting '%s' items of the storage package", categoryCode)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		return nil, bosherr.Errorf("Getting '%s' items of the storage package, HTTP error code: '%d'", categoryCode, errorCode)
+	}
+
+	items := []storageItem{}
+	err = json.Unmarshal(response, &items)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling items of the storage package")
+	}
+
+	return items, nil
+}
+
+// sells tells whether the item is sold for capacity
+func (i storageItem) sells(capacity int) bool {
+	return inCapacityRange(capacity, i.CapacityMinimum, i.CapacityMaximum)
+}
+
+// standardPriceId returns the id of the first price of the item not specific to a location group
+// that applies to volumes of size in GB, or 0. Prices without a capacity restriction apply to all.
+func (i storageItem) standardPriceId(size int) int {
+	for _, price := range i.Prices {
+		if price.LocationGroupId != 0 {
+			continue
+		}
+
+		if price.CapacityRestrictionMinimum != "" && !inCapacityRange(size, price.CapacityRestrictionMinimum, price.CapacityRestrictionMaximum) {
+			continue
+		}
+
+		return price.Id
+	}
+
+	return 0
+}
+
+func inCapacityRange(capacity int, minimum string, maximum string) bool {
+	min, err := strconv.Atoi(minimum)
+	if err != nil {
+		return false
+	}
+
+	max, err := strconv.Atoi(maximum)
+	if err != nil {
+		return false
+	}
+
+	return min <= capacity && capacity <= max
+}
+
+// findEnduranceSpacePriceId returns the standard price of size in GB of endurance storage space at
+// tier. The storage package sells it as one item per tier covering a range of sizes.
+func findEnduranceSpacePriceId(client sl.Client, size int, tier float64) (int, error) {
+	keyName := fmt.Sprintf("STORAGE_SPACE_FOR_%s_IOPS_PER_GB", strings.Replace(strconv.FormatFloat(tier, 'f', -1, 64), ".", "_", 1))
+
+	items, err := findStorageItems(client, "performance_storage_space")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		if item.KeyName != keyName || !item.sells(size) {
+			continue
+		}
+
+		if priceId := item.standardPriceId(size); priceId != 0 {
+			return priceId, nil
+		}
+	}
+
+	return 0, bosherr.Errorf("No standard price of '%d' GB of storage space at tier '%g'", size, tier)
+}
+
+// findPerformanceSpacePriceId returns the standard price of size in GB of performance storage
+// space. The storage package sells it as items covering ranges of sizes.
+func findPerformanceSpacePriceId(client sl.Client, size int) (int, error) {
+	items, err := findStorageItems(client, "performance_storage_space")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		if item.KeyName != fmt.Sprintf("%s_%s_GBS", item.CapacityMinimum, item.CapacityMaximum) || !item.sells(size) {
+			continue
+		}
+
+		if priceId := item.standardPriceId(size); priceId != 0 {
+			return priceId, nil
+		}
+	}
+
+	return 0, bosherr.Errorf("No standard price of '%d' GB of performance storage space", size)
+}
+
+// findPerformanceIopsPriceId returns the standard price of iops for size in GB of performance
+// storage space
+func findPerformanceIopsPriceId(client sl.Client, iops int, size int) (int, error) {
+	items, err := findStorageItems(client, "performance_storage_iops")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		if !item.sells(iops) {
+			continue
+		}
+
+		if priceId := item.standardPriceId(size); priceId != 0 {
+			return priceId, nil
+		}
+	}
+
+	return 0, bosherr.Errorf("No standard price of '%d' IOPS for '%d' GB of performance storage space", iops, size)
+}
@@ -5,11 +5,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
-	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -38,13 +36,13 @@ type softLayerHardware struct {
 
 	agentEnvService AgentEnvService
 
+	waitPolicies   slh.WaitPolicies
+	diskAttachMode string
+
 	logger boshlog.Logger
 }
 
-func NewSoftLayerHardware(hardware datatypes.SoftLayer_Hardware, softLayerClient sl.Client, baremetalClient bmscl.BmpClient, sshClient util.SshClient, logger boshlog.Logger) VM {
-	slh.TIMEOUT = 60 * time.Minute
-	slh.POLLING_INTERVAL = 10 * time.Second
-
+func NewSoftLayerHardware(hardware datatypes.SoftLayer_Hardware, softLayerClient sl.Client, baremetalClient bmscl.BmpClient, sshClient util.SshClient, waitPolicies slh.WaitPolicies, diskAttachMode string, logger boshlog.Logger) VM {
 	return &softLayerHardware{
 		id: hardware.Id,
 
@@ -54,6 +52,9 @@ func NewSoftLayerHardware(hardware datatypes.SoftLayer_Hardware, softLayerClient
 		baremetalClient: baremetalClient,
 		sshClient:       sshClient,
 
+		waitPolicies:   waitPolicies,
+		diskAttachMode: diskAttachMode,
+
 		logger: logger,
 	}
 }
@@ -86,6 +87,10 @@ func (vm *softLayerHardware) GetFullyQualifiedDomainName() string {
 	return vm.hardware.FullyQualifiedDomainName
 }
 
+func (vm *softLayerHardware) GetAllowedHostType() string {
+	return bslcdisk.ALLOWED_HARDWARE
+}
+
 func (vm *softLayerHardware) SetAgentEnvService(agentEnvService AgentEnvService) error {
 	if agentEnvService != nil {
 		vm.agentEnvService = agentEnvService
@@ -163,68 +168,67 @@ func (vm *softLayerHardware) ConfigureNetworks2(networks Networks) error {
 	return api.NotSupportedError{}
 }
 
-func (vm *softLayerHardware) AttachDisk(disk bslcdisk.Disk) error {
+func (vm *softLayerHardware) AttachDisk(disk bslcdisk.Disk) (interface{}, error) {
 	volume, err := vm.fetchIscsiVolume(disk.ID())
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d`", disk.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d`", disk.ID()))
 	}
 
 	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
 	if err != nil {
-		return bosherr.WrapError(err, "Cannot get network storage service.")
+		return nil, bosherr.WrapError(err, "Cannot get network storage service.")
 	}
 
 	allowed, err := networkStorageService.HasAllowedHardware(disk.ID(), vm.ID())
 
-	totalTime := time.Duration(0)
 	if err == nil && allowed == false {
-		for totalTime < slh.TIMEOUT {
+		granted, err := vm.waitPolicies.DiskAttach.Wait(func() (bool, error) {
 			allowable, err := networkStorageService.AttachNetworkStorageToHardware(vm.hardware, disk.ID())
 			if err != nil {
-				if !strings.Contains(err.Error(), "HTTP error code") {
-					return bosherr.WrapError(err, fmt.Sprintf("Granting volume access to virtual guest %d", vm.ID()))
-				}
-			} else {
-				if allowable {
-					break
+				if !slh.IsAPIErrorRetryable(err) {
+					return false, bosherr.WrapError(err, fmt.Sprintf("Granting volume access to virtual guest %d", vm.ID()))
 				}
+
+				return false, nil
 			}
 
-			totalTime += slh.POLLING_INTERVAL
-			time.Sleep(slh.POLLING_INTERVAL)
+			return allowable, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !granted {
+			return nil, bosherr.Error("Waiting for grantting access to hardware TIME OUT!")
 		}
 	}
-	if totalTime >= slh.TIMEOUT {
-		return bosherr.Error("Waiting for grantting access to hardware TIME OUT!")
+
+	if vm.diskAttachMode == DiskAttachModeAgent {
+		return vm.attachDiskThroughAgent(volume)
 	}
 
 	hasMultiPath, err := vm.hasMulitPathToolBasedOnShellScript()
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to get multipath information from hardware `%d`", vm.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to get multipath information from hardware `%d`", vm.ID()))
 	}
 
-	deviceName, err := vm.waitForVolumeAttached(volume, hasMultiPath)
+	devicePath, err := vm.waitForVolumeAttached(volume, hasMultiPath)
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to attach volume `%d` to hardware `%d`", disk.ID(), vm.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to attach volume `%d` to hardware `%d`", disk.ID(), vm.ID()))
 	}
 	oldAgentEnv, err := vm.agentEnvService.Fetch()
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to unmarshal userdata from hardware with id: %d.", vm.ID())
+		return nil, bosherr.WrapErrorf(err, "Failed to unmarshal userdata from hardware with id: %d.", vm.ID())
 	}
 
-	var newAgentEnv AgentEnv
-	if hasMultiPath {
-		newAgentEnv = oldAgentEnv.AttachPersistentDisk(strconv.Itoa(disk.ID()), "/dev/mapper/"+deviceName)
-	} else {
-		newAgentEnv = oldAgentEnv.AttachPersistentDisk(strconv.Itoa(disk.ID()), "/dev/"+deviceName)
-	}
+	newAgentEnv := oldAgentEnv.AttachPersistentDisk(strconv.Itoa(disk.ID()), devicePath)
 
 	err = vm.agentEnvService.Update(newAgentEnv)
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on hardware with id: `%d`", vm.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on hardware with id: `%d`", vm.ID()))
 	}
 
-	return nil
+	return devicePath, nil
 }
 
 func (vm *softLayerHardware) DetachDisk(disk bslcdisk.Disk) error {
@@ -233,59 +237,69 @@ func (vm *softLayerHardware) DetachDisk(disk bslcdisk.Disk) error {
 		return bosherr.WrapError(err, fmt.Sprintf("failed in disk `%d`", disk.ID()))
 	}
 
+	if vm.diskAttachMode == DiskAttachModeAgent {
+		// Logging out of the iSCSI target of the disk is left to the agent
+		_, err = vm.revokeVolumeAccess(disk)
+		return err
+	}
+
 	hasMultiPath, err := vm.hasMulitPathToolBasedOnShellScript()
 	if err != nil {
 		return bosherr.WrapError(err, fmt.Sprintf("Failed to get multipath information from hardware `%d`", vm.ID()))
 	}
 
-	err = vm.detachVolumeBasedOnShellScript(hasMultiPath)
+	// Detaching unmounts /var/vcap/store, so the disk mounted there is mounted again once found anew
+	storeDiskID, storePartitionSuffix, err := vm.findStoreDisk()
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to detach volume with id %d from hardware with id: %d.", volume.Id, vm.ID())
+		return bosherr.WrapError(err, "Finding the disk mounted at /var/vcap/store")
 	}
 
-	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	err = vm.detachVolumeBasedOnShellScript(hasMultiPath)
 	if err != nil {
-		return bosherr.WrapError(err, "Cannot get network storage service.")
+		return bosherr.WrapErrorf(err, "Failed to detach volume with id %d from hardware with id: %d.", volume.Id, vm.ID())
 	}
 
-	allowed, err := networkStorageService.HasAllowedHardware(disk.ID(), vm.ID())
-	if err == nil && allowed == true {
-		err = networkStorageService.DetachNetworkStorageFromHardware(vm.hardware, disk.ID())
-	}
+	newAgentEnv, err := vm.revokeVolumeAccess(disk)
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to revoke access of disk `%d` from hardware `%d`", disk.ID(), vm.ID()))
+		return err
 	}
 
-	oldAgentEnv, err := vm.agentEnvService.Fetch()
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to unmarshal userdata from hardware with id: %d.", vm.ID())
-	}
+	// Detaching logs out of every iSCSI session, so the volumes left are logged in to again, and
+	// their devices found anew, since a device without multipath may come back under another name
+	for key := range newAgentEnv.Disks.Persistent {
+		devicePath := newAgentEnv.Disks.Persistent.DevicePath(key)
+		leftDiskId, err := strconv.Atoi(key)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to transfer disk id %s from string to int", key))
+		}
+		vm.logger.Debug(SOFTLAYER_HARDWARE_LOG_TAG, "Left Disk Id %d", leftDiskId)
+		vm.logger.Debug(SOFTLAYER_HARDWARE_LOG_TAG, "Left Disk device path %s", devicePath)
+		volume, err := vm.fetchIscsiVolume(leftDiskId)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d` and hardware `%d`", disk.ID(), vm.ID()))
+		}
 
-	newAgentEnv := oldAgentEnv.DetachPersistentDisk(strconv.Itoa(disk.ID()))
-	err = vm.agentEnvService.Update(newAgentEnv)
-	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on hardware with id: `%d`", vm.ID()))
-	}
+		discovery, err := vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to reattach volume `%s` to hardware `%d`", key, vm.ID()))
+		}
 
-	if len(newAgentEnv.Disks.Persistent) == 1 {
-		for key, devicePath := range newAgentEnv.Disks.Persistent {
-			leftDiskId, err := strconv.Atoi(key)
-			if err != nil {
-				return bosherr.WrapError(err, fmt.Sprintf("Failed to transfer disk id %s from string to int", key))
-			}
-			vm.logger.Debug(SOFTLAYER_HARDWARE_LOG_TAG, "Left Disk Id %d", leftDiskId)
-			vm.logger.Debug(SOFTLAYER_HARDWARE_LOG_TAG, "Left Disk device path %s", devicePath)
-			volume, err := vm.fetchIscsiVolume(leftDiskId)
-			if err != nil {
-				return bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d` and hardware `%d`", disk.ID(), vm.ID()))
-			}
+		newDevicePath, err := vm.findVolumeDevice(volume, discovery, hasMultiPath)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to reattach volume `%s` to hardware `%d`", key, vm.ID()))
+		}
 
-			_, err = vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
+		if newDevicePath != devicePath {
+			vm.logger.Info(SOFTLAYER_HARDWARE_LOG_TAG, fmt.Sprintf("Device of disk %s moved from %s to %s", key, devicePath, newDevicePath))
+			newAgentEnv = newAgentEnv.AttachPersistentDisk(key, newDevicePath)
+			err = vm.UpdateAgentEnv(newAgentEnv)
 			if err != nil {
-				return bosherr.WrapError(err, fmt.Sprintf("Failed to reattach volume `%s` to hardware `%d`", key, vm.ID()))
+				return bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on hardware with id: `%d`", vm.ID()))
 			}
+		}
 
-			command := fmt.Sprintf("sleep 5; mount %s-part1 /var/vcap/store", devicePath)
+		if key == storeDiskID {
+			command := fmt.Sprintf("sleep 5; mount %s%s /var/vcap/store", newDevicePath, storePartitionSuffix)
 			_, err = vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
 			if err != nil {
 				return bosherr.WrapError(err, "mount /var/vcap/store")
@@ -296,6 +310,26 @@ func (vm *softLayerHardware) DetachDisk(disk bslcdisk.Disk) error {
 	return nil
 }
 
+func (vm *softLayerHardware) RescanDisk(disk bslcdisk.Disk) error {
+	if vm.diskAttachMode == DiskAttachModeAgent {
+		vm.logger.Warn(SOFTLAYER_HARDWARE_LOG_TAG, "Disk %d is attached by the agent, which picks up its new size when it logs in to it again", disk.ID())
+		return nil
+	}
+
+	agentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to unmarshal userdata from hardware with id: %d.", vm.ID())
+	}
+
+	devicePath := agentEnv.Disks.Persistent.DevicePath(strconv.Itoa(disk.ID()))
+	err = RescanIscsiDevice(vm.sshClient, ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), devicePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to rescan disk `%d` on hardware `%d`", disk.ID(), vm.ID())
+	}
+
+	return nil
+}
+
 func (vm *softLayerHardware) UpdateAgentEnv(agentEnv AgentEnv) error {
 	return vm.agentEnvService.Update(agentEnv)
 }
@@ -305,16 +339,63 @@ func (vm *softLayerHardware) DeleteAgentEnv() error {
 }
 
 // Private methods
-func (vm *softLayerHardware) waitForVolumeAttached(volume datatypes.SoftLayer_Network_Storage, hasMultiPath bool) (string, error) {
 
-	oldDisks, err := vm.getIscsiDeviceNamesBasedOnShellScript(hasMultiPath)
+// attachDiskThroughAgent puts the iSCSI target of volume and the CHAP credentials of the hardware
+// into the agent env as the disk hint, so that the agent logs in to the target itself
+func (vm *softLayerHardware) attachDiskThroughAgent(volume datatypes.SoftLayer_Network_Storage) (interface{}, error) {
+	credential, err := vm.getAllowedHostCredential()
+	if err != nil {
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to get iscsi host auth from hardware `%d`", vm.ID()))
+	}
+
+	diskSettings := NewPersistentDiskSettings(volume, credential)
+
+	oldAgentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Failed to unmarshal userdata from hardware with id: %d.", vm.ID())
+	}
+
+	newAgentEnv := oldAgentEnv.AttachPersistentDiskSettings(diskSettings.ID, diskSettings)
+
+	err = vm.agentEnvService.Update(newAgentEnv)
 	if err != nil {
-		return "", bosherr.WrapError(err, fmt.Sprintf("Failed to get devices names from hardware `%d`", vm.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on hardware with id: `%d`", vm.ID()))
 	}
-	if len(oldDisks) > 2 {
-		return "", bosherr.Error(fmt.Sprintf("Too manay persistent disks attached to hardware `%d`", vm.ID()))
+
+	return diskSettings, nil
+}
+
+// revokeVolumeAccess revokes the access of the hardware to disk and drops the disk from the agent
+// env, returning the agent env with the disks left
+func (vm *softLayerHardware) revokeVolumeAccess(disk bslcdisk.Disk) (AgentEnv, error) {
+	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapError(err, "Cannot get network storage service.")
 	}
 
+	allowed, err := networkStorageService.HasAllowedHardware(disk.ID(), vm.ID())
+	if err == nil && allowed == true {
+		err = networkStorageService.DetachNetworkStorageFromHardware(vm.hardware, disk.ID())
+	}
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapError(err, fmt.Sprintf("Failed to revoke access of disk `%d` from hardware `%d`", disk.ID(), vm.ID()))
+	}
+
+	oldAgentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapErrorf(err, "Failed to unmarshal userdata from hardware with id: %d.", vm.ID())
+	}
+
+	newAgentEnv := oldAgentEnv.DetachPersistentDisk(strconv.Itoa(disk.ID()))
+	err = vm.agentEnvService.Update(newAgentEnv)
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on hardware with id: `%d`", vm.ID()))
+	}
+
+	return newAgentEnv, nil
+}
+
+func (vm *softLayerHardware) waitForVolumeAttached(volume datatypes.SoftLayer_Network_Storage, hasMultiPath bool) (string, error) {
 	credential, err := vm.getAllowedHostCredential()
 	if err != nil {
 		return "", bosherr.WrapError(err, fmt.Sprintf("Failed to get iscsi host auth from hardware `%d`", vm.ID()))
@@ -340,45 +421,40 @@ func (vm *softLayerHardware) waitForVolumeAttached(volume datatypes.SoftLayer_Ne
 		return "", bosherr.WrapError(err, fmt.Sprintf("Failed to restart open iscsi from hardware `%d`", vm.ID()))
 	}
 
-	_, err = vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
+	discovery, err := vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Failed to attach volume with id %d to hardware with id: %d.", volume.Id, vm.ID())
 	}
 
-	var deviceName string
-	totalTime := time.Duration(0)
-	for totalTime < slh.TIMEOUT {
-		newDisks, err := vm.getIscsiDeviceNamesBasedOnShellScript(hasMultiPath)
-		if err != nil {
-			return "", bosherr.WrapError(err, fmt.Sprintf("Failed to get devices names from hardware `%d`", vm.ID()))
-		}
+	return vm.findVolumeDevice(volume, discovery, hasMultiPath)
+}
 
-		if len(oldDisks) == 0 {
-			if len(newDisks) > 0 {
-				deviceName = newDisks[0]
-				return deviceName, nil
-			}
-		}
+// findVolumeDevice waits for the device of volume, logged in to the targets in discovery, to show up
+func (vm *softLayerHardware) findVolumeDevice(volume datatypes.SoftLayer_Network_Storage, discovery string, hasMultiPath bool) (string, error) {
+	targets := IscsiTargets(discovery)
+	if len(targets) == 0 {
+		return "", bosherr.Errorf("No iSCSI targets discovered at `%s` for volume `%d`", volume.ServiceResourceBackendIpAddress, volume.Id)
+	}
+	vm.logger.Info(SOFTLAYER_HARDWARE_LOG_TAG, fmt.Sprintf("Volume %d is LUN %s on iSCSI targets %v", volume.Id, volume.LunId, targets))
 
-		var included bool
-		for _, newDisk := range newDisks {
-			for _, oldDisk := range oldDisks {
-				if strings.EqualFold(newDisk, oldDisk) {
-					included = true
-				}
-			}
-			if !included {
-				deviceName = newDisk
-			}
-			included = false
-		}
+	deviceFinder := NewIscsiDeviceFinder(vm.sshClient, ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), vm.logger)
 
-		if len(deviceName) > 0 {
-			return deviceName, nil
+	var devicePath string
+	found, err := vm.waitPolicies.DiskAttach.Wait(func() (bool, error) {
+		var err error
+		devicePath, err = deviceFinder.Find(targets, volume.LunId, hasMultiPath)
+		if err != nil {
+			return false, bosherr.WrapError(err, fmt.Sprintf("Failed to find device of volume `%d` on hardware `%d`", volume.Id, vm.ID()))
 		}
 
-		totalTime += slh.POLLING_INTERVAL
-		time.Sleep(slh.POLLING_INTERVAL)
+		return len(devicePath) > 0, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if found {
+		return devicePath, nil
 	}
 
 	return "", bosherr.Errorf("Failed to attach disk '%d' to hardware '%d'", volume.Id, vm.ID())
@@ -398,46 +474,6 @@ func (vm *softLayerHardware) hasMulitPathToolBasedOnShellScript() (bool, error)
 	return false, nil
 }
 
-func (vm *softLayerHardware) getIscsiDeviceNamesBasedOnShellScript(hasMultiPath bool) ([]string, error) {
-	devices := []string{}
-
-	command1 := fmt.Sprintf("dmsetup ls")
-	command2 := fmt.Sprintf("cat /proc/partitions")
-
-	if hasMultiPath {
-		result, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command1)
-		if err != nil {
-			return devices, err
-		}
-		if strings.Contains(result, "No devices found") {
-			return devices, nil
-		}
-		vm.logger.Info(SOFTLAYER_HARDWARE_LOG_TAG, fmt.Sprintf("Devices on hardware %d: %s", vm.ID(), result))
-		lines := strings.Split(strings.Trim(result, "\n"), "\n")
-		for i := 0; i < len(lines); i++ {
-			if match, _ := regexp.MatchString("-part1", lines[i]); !match {
-				devices = append(devices, strings.Fields(lines[i])[0])
-			}
-		}
-	} else {
-		result, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command2)
-		if err != nil {
-			return devices, err
-		}
-
-		vm.logger.Info(SOFTLAYER_HARDWARE_LOG_TAG, fmt.Sprintf("Devices on hardware %d: %s", vm.ID(), result))
-		lines := strings.Split(strings.Trim(result, "\n"), "\n")
-		for i := 0; i < len(lines); i++ {
-			if match, _ := regexp.MatchString("sd[a-z]$", lines[i]); match {
-				vals := strings.Fields(lines[i])
-				devices = append(devices, vals[len(vals)-1])
-			}
-		}
-	}
-
-	return devices, nil
-}
-
 func (vm *softLayerHardware) fetchIscsiVolume(volumeId int) (datatypes.SoftLayer_Network_Storage, error) {
 	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
 	if err != nil {
@@ -504,20 +540,20 @@ func (vm *softLayerHardware) restartOpenIscsiBasedOnShellScript() (bool, error)
 	return true, nil
 }
 
-func (vm *softLayerHardware) discoveryOpenIscsiTargetsBasedOnShellScript(volume datatypes.SoftLayer_Network_Storage) (bool, error) {
+func (vm *softLayerHardware) discoveryOpenIscsiTargetsBasedOnShellScript(volume datatypes.SoftLayer_Network_Storage) (string, error) {
 	command := fmt.Sprintf("sleep 5; iscsiadm -m discovery -t sendtargets -p %s", volume.ServiceResourceBackendIpAddress)
-	_, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
+	discovery, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
 	if err != nil {
-		return false, bosherr.WrapError(err, "discoverying open iscsi targets")
+		return "", bosherr.WrapError(err, "discoverying open iscsi targets")
 	}
 
 	command = "sleep 5; echo `iscsiadm -m node -l`"
 	_, err = vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
 	if err != nil {
-		return false, bosherr.WrapError(err, "login iscsi targets")
+		return "", bosherr.WrapError(err, "login iscsi targets")
 	}
 
-	return true, nil
+	return discovery, nil
 }
 
 func (vm *softLayerHardware) writeOpenIscsiInitiatornameBasedOnShellScript(credential AllowedHostCredential) (bool, error) {
@@ -628,6 +664,23 @@ func (vm *softLayerHardware) detachVolumeBasedOnShellScript(hasMultiPath bool) e
 	return nil
 }
 
+// findStoreDisk returns the id of the persistent disk mounted at /var/vcap/store and the suffix
+// naming the mounted partition after its device, or empty strings when no persistent disk is
+func (vm *softLayerHardware) findStoreDisk() (string, string, error) {
+	agentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return "", "", bosherr.WrapErrorf(err, "Failed to unmarshal userdata from hardware with id: %d.", vm.ID())
+	}
+
+	mounts, err := vm.searchMounts()
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Searching mounts")
+	}
+
+	diskID, partitionSuffix := agentEnv.Disks.Persistent.MountedDisk(mounts, "/var/vcap/store")
+	return diskID, partitionSuffix, nil
+}
+
 func (vm *softLayerHardware) isMountPoint(path string) (bool, error) {
 	mounts, err := vm.searchMounts()
 	if err != nil {
@@ -680,30 +733,36 @@ func (vm *softLayerHardware) provisionBaremetal(server_id string, stemcell strin
 	}
 
 	task_id := createBaremetalResponse.Data.TaskId
-	slh.TIMEOUT = 10 * time.Minute
-	totalTime := time.Duration(0)
-	for totalTime < slh.TIMEOUT {
+	var serverID int
+	completed, err := vm.waitPolicies.OSReload.Wait(func() (bool, error) {
 		taskOutput, err := vm.baremetalClient.TaskJsonOutput(task_id, "task")
 		if err != nil {
-			return 0, bosherr.WrapErrorf(err, "Failed to get state with task_id: %d", task_id)
+			return false, bosherr.WrapErrorf(err, "Failed to get state with task_id: %d", task_id)
 		}
 
 		info := taskOutput.Data["info"].(map[string]interface{})
 		switch info["status"].(string) {
 		case "failed":
-			return 0, bosherr.Errorf("Failed to install the stemcell: %v", taskOutput)
+			return false, bosherr.Errorf("Failed to install the stemcell: %v", taskOutput)
 
 		case "completed":
 			serverOutput, err := vm.baremetalClient.TaskJsonOutput(task_id, "server")
 			if err != nil {
-				return 0, bosherr.WrapErrorf(err, "Failed to get server_id with task_id: %d", task_id)
+				return false, bosherr.WrapErrorf(err, "Failed to get server_id with task_id: %d", task_id)
 			}
 			info = serverOutput.Data["info"].(map[string]interface{})
-			return int(info["id"].(float64)), nil
+			serverID = int(info["id"].(float64))
+			return true, nil
 		default:
-			totalTime += slh.POLLING_INTERVAL
-			time.Sleep(slh.POLLING_INTERVAL)
+			return false, nil
 		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if completed {
+		return serverID, nil
 	}
 
 	return 0, bosherr.Error("Provisioning baremetal timeout")
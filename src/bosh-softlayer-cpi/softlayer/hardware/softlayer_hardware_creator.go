@@ -2,13 +2,12 @@ package hardware
 
 import (
 	"fmt"
-	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	. "bosh-softlayer-cpi/softlayer/common"
-	slh "bosh-softlayer-cpi/softlayer/common/helper"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
 	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
 	bmslc "github.com/cloudfoundry-community/bosh-softlayer-tools/clients"
 	sl "github.com/maximilien/softlayer-go/softlayer"
@@ -19,32 +18,42 @@ type baremetalCreator struct {
 	bmsClient              bmslc.BmpClient
 	agentEnvServiceFactory AgentEnvServiceFactory
 
-	agentOptions AgentOptions
-	logger       boshlog.Logger
-	vmFinder     VMFinder
+	agentOptions   AgentOptions
+	featureOptions FeatureOptions
+	logger         boshlog.Logger
+	vmFinder       VMFinder
 }
 
-func NewBaremetalCreator(vmFinder VMFinder, softLayerClient sl.Client, bmsClient bmslc.BmpClient, agentOptions AgentOptions, logger boshlog.Logger) VMCreator {
-	slh.TIMEOUT = 15 * time.Minute
-	slh.POLLING_INTERVAL = 5 * time.Second
-
+func NewBaremetalCreator(vmFinder VMFinder, softLayerClient sl.Client, bmsClient bmslc.BmpClient, agentOptions AgentOptions, featureOptions FeatureOptions, logger boshlog.Logger) VMCreator {
 	return &baremetalCreator{
 		vmFinder:        vmFinder,
 		softLayerClient: softLayerClient,
 		bmsClient:       bmsClient,
 		agentOptions:    agentOptions,
+		featureOptions:  featureOptions,
 		logger:          logger,
 	}
 }
 
 func (c *baremetalCreator) Create(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+	rollback := slhelper.NewRollback(c.featureOptions.KeepFailedVMs, c.logger)
+
+	vm, err := c.create(agentID, stemcell, cloudProps, networks, env, rollback)
+	if err != nil {
+		return nil, rollback.Fail(err)
+	}
+
+	return vm, nil
+}
+
+func (c *baremetalCreator) create(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	for _, network := range networks {
 		switch network.Type {
 		case "dynamic":
 			if len(network.IP) == 0 {
-				return c.createByBaremetal(agentID, stemcell, cloudProps, networks, env)
+				return c.createByBaremetal(agentID, stemcell, cloudProps, networks, env, rollback)
 			} else {
-				return c.createByOSReload(agentID, stemcell, cloudProps, networks, env)
+				return c.createByOSReload(agentID, stemcell, cloudProps, networks, env, rollback)
 			}
 		case "manual":
 			return nil, bosherr.Error("Manual networking is not currently supported")
@@ -60,7 +69,7 @@ func (c *baremetalCreator) Create(agentID string, stemcell bslcstem.Stemcell, cl
 
 func (c *baremetalCreator) GetAgentOptions() AgentOptions { return c.agentOptions }
 
-func (c *baremetalCreator) createByBaremetal(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+func (c *baremetalCreator) createByBaremetal(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	hardwareId, err := c.provisionBaremetal(cloudProps.VmNamePrefix, cloudProps.BaremetalStemcell, cloudProps.BaremetalNetbootImage)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Create baremetal error")
@@ -70,14 +79,17 @@ func (c *baremetalCreator) createByBaremetal(agentID string, stemcell bslcstem.S
 	if err != nil || !found {
 		return nil, bosherr.WrapErrorf(err, "Cannot find hardware with id: %d.", hardwareId)
 	}
+	rollback.Add(fmt.Sprintf("Releasing baremetal `%d`", hardwareId), func() error {
+		return hardware.Delete(agentID)
+	})
 
 	var boshIP string
 	if cloudProps.BoshIp != "" {
 		boshIP = cloudProps.BoshIp
 	} else {
-		boshIP, err = GetLocalIPAddressOfGivenInterface(slh.NetworkInterface)
+		boshIP, err = GetLocalIPAddressOfGivenInterface(c.featureOptions.NetworkInterface)
 		if err != nil {
-			return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", slh.NetworkInterface))
+			return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", c.featureOptions.NetworkInterface))
 		}
 	}
 
@@ -99,6 +111,7 @@ func (c *baremetalCreator) createByBaremetal(agentID string, stemcell bslcstem.S
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Updating VM's agent env")
 	}
+	rollback.Add(fmt.Sprintf("Deleting agent env of baremetal `%d`", hardware.ID()), hardware.DeleteAgentEnv)
 
 	if len(c.agentOptions.VcapPassword) > 0 {
 		err = hardware.SetVcapPassword(c.agentOptions.VcapPassword)
@@ -110,7 +123,7 @@ func (c *baremetalCreator) createByBaremetal(agentID string, stemcell bslcstem.S
 	return hardware, nil
 }
 
-func (c *baremetalCreator) createByOSReload(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+func (c *baremetalCreator) createByOSReload(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	if len(cloudProps.BaremetalStemcell) == 0 {
 		return nil, bosherr.Error("No stemcell provided to do os_reload.")
 	}
@@ -147,9 +160,9 @@ func (c *baremetalCreator) createByOSReload(agentID string, stemcell bslcstem.St
 	if cloudProps.BoshIp != "" {
 		boshIP = cloudProps.BoshIp
 	} else {
-		boshIP, err = GetLocalIPAddressOfGivenInterface(slh.NetworkInterface)
+		boshIP, err = GetLocalIPAddressOfGivenInterface(c.featureOptions.NetworkInterface)
 		if err != nil {
-			return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", slh.NetworkInterface))
+			return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", c.featureOptions.NetworkInterface))
 		}
 	}
 
@@ -174,6 +187,7 @@ func (c *baremetalCreator) createByOSReload(agentID string, stemcell bslcstem.St
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Updating VM's agent env")
 	}
+	rollback.Add(fmt.Sprintf("Deleting agent env of baremetal `%d`", vm.ID()), vm.DeleteAgentEnv)
 
 	if len(c.agentOptions.VcapPassword) > 0 {
 		err = vm.SetVcapPassword(c.agentOptions.VcapPassword)
@@ -198,31 +212,36 @@ func (c *baremetalCreator) provisionBaremetal(server_name string, stemcell strin
 	}
 
 	task_id := createBaremetalResponse.Data.TaskId
-	slh.TIMEOUT = 120 * time.Minute
-	totalTime := time.Duration(0)
-	for totalTime < slh.TIMEOUT {
-
+	var serverID int
+	completed, err := c.featureOptions.WaitPolicies.Create.Wait(func() (bool, error) {
 		taskOutput, err := c.bmsClient.TaskJsonOutput(task_id, "task")
 		if err != nil {
-			return 0, bosherr.WrapErrorf(err, "Failed to get state with task_id: %d", task_id)
+			return false, bosherr.WrapErrorf(err, "Failed to get state with task_id: %d", task_id)
 		}
 
 		info := taskOutput.Data["info"].(map[string]interface{})
 		switch info["status"].(string) {
 		case "failed":
-			return 0, bosherr.Errorf("Failed to install the stemcell: %v", taskOutput)
+			return false, bosherr.Errorf("Failed to install the stemcell: %v", taskOutput)
 
 		case "completed":
 			serverOutput, err := c.bmsClient.TaskJsonOutput(task_id, "server")
 			if err != nil {
-				return 0, bosherr.WrapErrorf(err, "Failed to get server_id with task_id: %d", task_id)
+				return false, bosherr.WrapErrorf(err, "Failed to get server_id with task_id: %d", task_id)
 			}
 			info = serverOutput.Data["info"].(map[string]interface{})
-			return int(info["id"].(float64)), nil
+			serverID = int(info["id"].(float64))
+			return true, nil
 		default:
-			totalTime += slh.POLLING_INTERVAL
-			time.Sleep(slh.POLLING_INTERVAL)
+			return false, nil
 		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if completed {
+		return serverID, nil
 	}
 
 	return 0, bosherr.Error("Provisioning baremetal timeout")
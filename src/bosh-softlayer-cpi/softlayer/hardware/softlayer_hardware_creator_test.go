@@ -3,6 +3,7 @@ package hardware_test
 import (
 	. "bosh-softlayer-cpi/softlayer/hardware"
 	"encoding/json"
+	"errors"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"runtime"
@@ -34,6 +35,7 @@ var _ = Describe("SoftLayer_Hardware_Creator", func() {
 		agentOptions    bslcommon.AgentOptions
 		logger          boshlog.Logger
 		creator         bslcommon.VMCreator
+		waitPolicies    slh.WaitPolicies
 	)
 
 	BeforeEach(func() {
@@ -44,15 +46,30 @@ var _ = Describe("SoftLayer_Hardware_Creator", func() {
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		fakeVmFinder = &fakescommon.FakeVMFinder{}
 
+		waitPolicy := slh.NewWaitPolicy(2*time.Second, 1*time.Second)
+		waitPolicies = slh.WaitPolicies{
+			Create:         waitPolicy,
+			OSReload:       waitPolicy,
+			StemcellLookup: waitPolicy,
+			Delete:         waitPolicy,
+		}
+
+		featureOptions := bslcommon.FeatureOptions{WaitPolicies: waitPolicies}
+		switch runtime.GOOS {
+		case "darwin":
+			featureOptions.NetworkInterface = "en0"
+		default:
+			featureOptions.NetworkInterface = "eth0"
+		}
+
 		creator = NewBaremetalCreator(
 			fakeVmFinder,
 			softLayerClient,
 			baremetalClient,
 			agentOptions,
+			featureOptions,
 			logger,
 		)
-		slh.TIMEOUT = 2 * time.Second
-		slh.POLLING_INTERVAL = 1 * time.Second
 	})
 
 	Describe("#Create", func() {
@@ -68,7 +85,7 @@ var _ = Describe("SoftLayer_Hardware_Creator", func() {
 		Context("valid arguments", func() {
 			BeforeEach(func() {
 				agentID = "fake-agent-id"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, waitPolicies, logger)
 
 				env = bslcommon.Environment{}
 			})
@@ -170,6 +187,41 @@ var _ = Describe("SoftLayer_Hardware_Creator", func() {
 						Expect(vm.ID()).To(Equal(1234567))
 					})
 
+					It("releases the baremetal server when updating the agent env fails", func() {
+						cloudProps = bslcommon.VMCloudProperties{
+							Domain:                "fake-domain.com",
+							BoshIp:                "10.0.0.1",
+							VmNamePrefix:          "bosh-test",
+							Baremetal:             true,
+							BaremetalStemcell:     "fake-stemcell",
+							BaremetalNetbootImage: "fake-netboot-image",
+						}
+
+						baremetalClient.ProvisioningBaremetalResponse = bmsclients.CreateBaremetalsResponse{
+							Status: 200,
+							Data: bmsclients.TaskInfo{
+								TaskId: 1234567,
+							},
+						}
+						taskJson := bmsclients.TaskJsonResponse{}
+						err := json.Unmarshal([]byte(`{"status": 200, "data": {"info": {"status": "completed"}}}`), &taskJson)
+						Expect(err).ToNot(HaveOccurred())
+						serverJson := bmsclients.TaskJsonResponse{}
+						err = json.Unmarshal([]byte(`{"status": 200, "data": {"info": {"id": 1234567}}}`), &serverJson)
+						Expect(err).ToNot(HaveOccurred())
+						baremetalClient.TaskJsonResponses = []bmsclients.TaskJsonResponse{taskJson, serverJson}
+
+						fakeVm = &fakescommon.FakeVM{}
+						fakeVm.IDReturns(1234567)
+						fakeVm.UpdateAgentEnvReturns(errors.New("fake-update-agent-env-error"))
+						fakeVmFinder.FindReturns(fakeVm, true, nil)
+
+						_, err = creator.Create(agentID, stemcell, cloudProps, networks, env)
+						Expect(err).To(HaveOccurred())
+						Expect(fakeVm.DeleteCallCount()).To(Equal(1))
+						Expect(fakeVm.DeleteArgsForCall(0)).To(Equal(agentID))
+					})
+
 					It("returns a new SoftLayerVM without bosh ip", func() {
 						cloudProps = bslcommon.VMCloudProperties{
 							StartCpus: 4,
@@ -243,14 +295,6 @@ var _ = Describe("SoftLayer_Hardware_Creator", func() {
 						fakeVm = &fakescommon.FakeVM{}
 						fakeVm.IDReturns(1234567)
 						fakeVmFinder.FindReturns(fakeVm, true, nil)
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
 						vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(vm.ID()).To(Equal(1234567))
@@ -327,14 +371,6 @@ var _ = Describe("SoftLayer_Hardware_Creator", func() {
 						fakeVm = &fakescommon.FakeVM{}
 						fakeVm.IDReturns(1234567)
 						fakeVmFinder.FindReturns(fakeVm, true, nil)
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
 						vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(vm.ID()).To(Equal(1234567))
@@ -347,7 +383,7 @@ var _ = Describe("SoftLayer_Hardware_Creator", func() {
 			Context("missing correct VMProperties", func() {
 				BeforeEach(func() {
 					agentID = "fake-agent-id"
-					stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+					stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, waitPolicies, logger)
 					networks = bslcommon.Networks{}
 					env = bslcommon.Environment{}
 
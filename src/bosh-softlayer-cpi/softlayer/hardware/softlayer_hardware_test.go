@@ -37,6 +37,8 @@ var _ = Describe("SoftLayerHardware", func() {
 		logger              boshlog.Logger
 		vm                  bslcommon.VM
 		stemcell            *fakestemcell.FakeStemcell
+		hw                  datatypes.SoftLayer_Hardware
+		waitPolicies        slh.WaitPolicies
 	)
 
 	BeforeEach(func() {
@@ -46,7 +48,7 @@ var _ = Describe("SoftLayerHardware", func() {
 		agentEnvService = &fakevm.FakeAgentEnvService{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
-		hw := datatypes.SoftLayer_Hardware{
+		hw = datatypes.SoftLayer_Hardware{
 			BareMetalInstanceFlag: 1,
 			Domain:                "fake-domain.com",
 			Hostname:              "fake-hostname",
@@ -66,15 +68,19 @@ var _ = Describe("SoftLayerHardware", func() {
 			},
 		}
 
-		vm = hardware.NewSoftLayerHardware(hw, fakeSoftLayerClient, fakeBaremetalClient, sshClient, logger)
+		waitPolicy := slh.NewWaitPolicy(2*time.Second, 1*time.Second)
+		waitPolicies = slh.WaitPolicies{
+			OSReload:   waitPolicy,
+			DiskAttach: waitPolicy,
+			Delete:     waitPolicy,
+		}
+
+		vm = hardware.NewSoftLayerHardware(hw, fakeSoftLayerClient, fakeBaremetalClient, sshClient, waitPolicies, bslcommon.DiskAttachModeSSH, logger)
 		vm.SetAgentEnvService(agentEnvService)
 	})
 
 	Describe("Delete", func() {
 		It("deletes the VM successfully", func() {
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
 			expectedCmdResults := []string{
 				"",
 			}
@@ -94,9 +100,6 @@ var _ = Describe("SoftLayerHardware", func() {
 
 	Describe("Reboot", func() {
 		It("returns unsupport error", func() {
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
 			err := vm.Reboot()
 			Expect(err).To(HaveOccurred())
 		})
@@ -171,41 +174,22 @@ var _ = Describe("SoftLayerHardware", func() {
 			disk bsldisk.Disk
 		)
 
-		const expectedDmSetupLs1 = `
-36090a0c8600058baa8283574c302c0fc-part1	(252:1)
-36090a0c8600058baa8283574c302c0fc	(252:0)
-`
-		const expectedDmSetupLs2 = `
-36090a0c8600058baa8283574c302c0fc-part1	(252:1)
-36090a0c8600058baa8283574c302c0fc	(252:0)
-36090a0c8600058baa8283574c302c0fd-part1	(252:1)
-36090a0c8600058baa8283574c302c0fd	(252:0)
-`
-		const expectedPartitions1 = `major minor  #blocks  name
-
-   7        0     131072 loop0
- 202       16    2097152 xvdb
- 202       17    2096451 xvdb1
- 202        0   26214400 xvda
- 202        1     248832 xvda1
- 202        2   25963520 xvda2
- 202       32  314572800 xvdc
- 202       33  314568733 xvdc1
+		const expectedSendTargets = `10.1.222.67:3260,1031 iqn.1992-08.com.netapp:lon0201
+10.1.222.52:3260,1031 iqn.1992-08.com.netapp:lon0201
 `
-		const expectedPartitions2 = `major minor  #blocks  name
-
-   7        0     131072 loop0
- 202       16    2097152 xvdb
- 202       17    2096451 xvdb1
- 202        0   26214400 xvda
- 202        1     248832 xvda1
- 202        2   25963520 xvda2
- 202       32  314572800 xvdc
- 202       33  314568733 xvdc1
- 252        0  314572800 dm-0
- 252        1  314572799 dm-1
-   8       16  314572800 sdb
-   8       17  314572799 sdb1
+		const expectedSessions = `iSCSI Transport Class version 2.0-870
+version 2.0-873
+Target: iqn.1992-08.com.netapp:lon0201 (non-flash)
+	Current Portal: 10.1.222.67:3260,1031
+	Persistent Portal: 10.1.222.67:3260,1031
+		************************
+		Attached SCSI devices:
+		************************
+		Host Number: 3	State: running
+		scsi3 Channel 00 Id 0 Lun: 0
+			Attached scsi disk sdb		State: running
+		scsi3 Channel 00 Id 0 Lun: 1
+			Attached scsi disk sdc		State: running
 `
 
 		BeforeEach(func() {
@@ -221,78 +205,127 @@ var _ = Describe("SoftLayerHardware", func() {
 			testhelpers.SetTestFixturesForFakeSoftLayerClient(fakeSoftLayerClient, fileNames)
 		})
 
-		It("attaches the iSCSI volume successfully (multipath-tool installed)", func() {
+		It("attaches the iSCSI volume by the IQN and LUN of its target (multipath-tool installed)", func() {
 			expectedCmdResults := []string{
 				"/sbin/multipath",
-				"No devices found",
 				"",
 				"",
 				"",
+				expectedSendTargets,
 				"",
-				"",
-				expectedDmSetupLs1,
+				"/dev/sdc\n",
+				"3600a09803830304f3124457a4575725a\n",
 			}
 			sshClient.ExecCommandStub = func(_, _, _, _ string) (string, error) {
 				return expectedCmdResults[sshClient.ExecCommandCallCount()-1], nil
 			}
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
-			err := vm.AttachDisk(disk)
+			devicePath, err := vm.AttachDisk(disk)
 			Expect(err).ToNot(HaveOccurred())
+			Expect(devicePath).To(Equal("/dev/mapper/3600a09803830304f3124457a4575725a"))
+
+			_, _, _, command := sshClient.ExecCommandArgsForCall(6)
+			Expect(command).To(ContainSubstring("/dev/disk/by-path/ip-*-iscsi-iqn.1992-08.com.netapp:lon0201-lun-1"))
+			_, _, _, command = sshClient.ExecCommandArgsForCall(7)
+			Expect(command).To(ContainSubstring("/sys/block/sdc/holders/"))
 		})
 
-		It("attaches the iSCSI volume successfully (multipath-tool not installed)", func() {
+		It("attaches the iSCSI volume by the IQN and LUN of its target (multipath-tool not installed)", func() {
 			expectedCmdResults := []string{
 				"",
-				expectedPartitions1,
 				"",
 				"",
 				"",
+				expectedSendTargets,
 				"",
-				"",
-				expectedPartitions2,
+				"/dev/sdc\n",
 			}
 			sshClient.ExecCommandStub = func(_, _, _, _ string) (string, error) {
 				return expectedCmdResults[sshClient.ExecCommandCallCount()-1], nil
 			}
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
-			err := vm.AttachDisk(disk)
+			devicePath, err := vm.AttachDisk(disk)
 			Expect(err).ToNot(HaveOccurred())
+			Expect(devicePath).To(Equal("/dev/sdc"))
 		})
 
-		It("attaches second iSCSI volume successfully (multipath-tool installed)", func() {
+		It("finds the device in the iSCSI sessions when there is no /dev/disk/by-path", func() {
 			expectedCmdResults := []string{
-				"/sbin/multipath",
-				expectedDmSetupLs1,
 				"",
 				"",
 				"",
 				"",
+				expectedSendTargets,
+				"",
 				"",
-				expectedDmSetupLs2,
+				expectedSessions,
 			}
-
 			sshClient.ExecCommandStub = func(_, _, _, _ string) (string, error) {
 				return expectedCmdResults[sshClient.ExecCommandCallCount()-1], nil
 			}
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
-			err := vm.AttachDisk(disk)
+			devicePath, err := vm.AttachDisk(disk)
 			Expect(err).ToNot(HaveOccurred())
+			Expect(devicePath).To(Equal("/dev/sdc"))
+		})
+
+		It("reports error when no iSCSI target is discovered for the volume", func() {
+			sshClient.ExecCommandReturns("", nil)
+			_, err := vm.AttachDisk(disk)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No iSCSI targets discovered"))
 		})
 
 		It("reports error when failed to attach the iSCSI volume", func() {
 
 			sshClient.ExecCommandReturns("fake-result", errors.New("fake-error"))
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
+			_, err := vm.AttachDisk(disk)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 
-			err := vm.AttachDisk(disk)
+	Describe("#RescanDisk", func() {
+		var (
+			disk *fakedisk.FakeDisk
+		)
+
+		BeforeEach(func() {
+			disk = &fakedisk.FakeDisk{}
+			disk.IDReturns(1234)
+		})
+
+		It("rescans the iSCSI sessions and resizes the multipath device of the disk", func() {
+			agentEnvService.FetchReturns(bslcommon.AgentEnv{
+				Disks: bslcommon.DisksSpec{
+					Persistent: bslcommon.PersistentSpec{"1234": "/dev/mapper/3600a09803830304f3124457a4575725a"},
+				},
+			}, nil)
+
+			err := vm.RescanDisk(disk)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sshClient.ExecCommandCallCount()).To(Equal(2))
+			_, _, _, command := sshClient.ExecCommandArgsForCall(0)
+			Expect(command).To(Equal("iscsiadm -m session --rescan"))
+			_, _, _, command = sshClient.ExecCommandArgsForCall(1)
+			Expect(command).To(Equal("multipathd -k'resize map 3600a09803830304f3124457a4575725a'"))
+		})
+
+		It("only rescans the iSCSI sessions without multipath", func() {
+			agentEnvService.FetchReturns(bslcommon.AgentEnv{
+				Disks: bslcommon.DisksSpec{
+					Persistent: bslcommon.PersistentSpec{"1234": "/dev/sdc"},
+				},
+			}, nil)
+
+			err := vm.RescanDisk(disk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sshClient.ExecCommandCallCount()).To(Equal(1))
+		})
+
+		It("reports error when failed to rescan", func() {
+			sshClient.ExecCommandReturns("", errors.New("fake-error"))
+
+			err := vm.RescanDisk(disk)
 			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-error"))
 		})
 	})
 
@@ -351,6 +384,7 @@ iscsiadm: No records found
 			expectedCmdResults := []string{
 				"",
 				expectMountPoints,
+				expectMountPoints,
 				"",
 				expectStopOpenIscsi,
 				"",
@@ -360,9 +394,6 @@ iscsiadm: No records found
 			sshClient.ExecCommandStub = func(_, _, _, _ string) (string, error) {
 				return expectedCmdResults[sshClient.ExecCommandCallCount()-1], nil
 			}
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
 			err := vm.DetachDisk(disk)
 			Expect(err).ToNot(HaveOccurred())
 		})
@@ -370,6 +401,7 @@ iscsiadm: No records found
 		It("detaches iSCSI volume successfully with multipath-tools installed (one volume attached)", func() {
 			expectedCmdResults := []string{
 				expectMultipathInstalled,
+				expectMountPoints,
 				"",
 				expectMountPoints,
 				expectStopOpenIscsi,
@@ -381,20 +413,87 @@ iscsiadm: No records found
 			sshClient.ExecCommandStub = func(_, _, _, _ string) (string, error) {
 				return expectedCmdResults[sshClient.ExecCommandCallCount()-1], nil
 			}
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
 			err := vm.DetachDisk(disk)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
 		It("reports error when failed to detach iSCSI volume", func() {
 			sshClient.ExecCommandReturns("fake-result", errors.New("fake-error"))
-			slh.TIMEOUT = 2 * time.Second
-			slh.POLLING_INTERVAL = 1 * time.Second
-
 			err := vm.DetachDisk(disk)
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Context("when the agent attaches disks", func() {
+		var (
+			disk         *fakedisk.FakeDisk
+			diskSettings bslcommon.PersistentDiskSettings
+		)
+
+		BeforeEach(func() {
+			vm = hardware.NewSoftLayerHardware(hw, fakeSoftLayerClient, fakeBaremetalClient, sshClient, waitPolicies, bslcommon.DiskAttachModeAgent, logger)
+			vm.SetAgentEnvService(agentEnvService)
+
+			disk = &fakedisk.FakeDisk{}
+			disk.IDReturns(1234)
+
+			diskSettings = bslcommon.PersistentDiskSettings{
+				ID:       "1234",
+				VolumeID: "1234",
+				Lun:      "1",
+				ISCSISettings: bslcommon.ISCSISettings{
+					InitiatorName: "fake-iqn",
+					Target:        "fake-ip",
+					Username:      "fake-username",
+					Password:      "fake-password",
+				},
+			}
+		})
+
+		It("grants access and hands the iSCSI settings to the agent without SSH", func() {
+			fileNames := []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume.json",
+				"SoftLayer_Network_Storage_Service_getAllowedVirtualGuests_None.json",
+				"SoftLayer_Network_Storage_Service_allowAccessFromVirtualGuest.json",
+				"SoftLayer_Virtual_Guest_Service_getAllowedHost.json",
+				"SoftLayer_Network_Storage_Allowed_Host_Service_getCredential.json",
+			}
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fakeSoftLayerClient, fileNames)
+
+			diskHint, err := vm.AttachDisk(disk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskHint).To(Equal(diskSettings))
+			Expect(sshClient.ExecCommandCallCount()).To(Equal(0))
+
+			Expect(agentEnvService.UpdateCallCount()).To(Equal(1))
+			Expect(agentEnvService.UpdateArgsForCall(0).Disks.Persistent).To(Equal(bslcommon.PersistentSpec{"1234": diskSettings}))
+		})
+
+		It("revokes access and drops the disk from the agent env without SSH", func() {
+			fileNames := []string{
+				"SoftLayer_Network_Storage_Service_getIscsiVolume.json",
+				"SoftLayer_Network_Storage_Service_getAllowedVirtualGuests.json",
+				"SoftLayer_Network_Storage_Service_removeAccessFromVirtualGuest.json",
+			}
+			testhelpers.SetTestFixturesForFakeSoftLayerClient(fakeSoftLayerClient, fileNames)
+			agentEnvService.FetchReturns(bslcommon.AgentEnv{
+				Disks: bslcommon.DisksSpec{
+					Persistent: bslcommon.PersistentSpec{"1234": diskSettings, "5678": diskSettings},
+				},
+			}, nil)
+
+			err := vm.DetachDisk(disk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sshClient.ExecCommandCallCount()).To(Equal(0))
+
+			Expect(agentEnvService.UpdateCallCount()).To(Equal(1))
+			Expect(agentEnvService.UpdateArgsForCall(0).Disks.Persistent).To(Equal(bslcommon.PersistentSpec{"5678": diskSettings}))
+		})
+
+		It("leaves rescanning the disk to the agent", func() {
+			err := vm.RescanDisk(disk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sshClient.ExecCommandCallCount()).To(Equal(0))
+		})
+	})
 })
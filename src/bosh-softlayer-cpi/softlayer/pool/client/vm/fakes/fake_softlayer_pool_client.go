@@ -81,6 +81,15 @@ type FakeSoftLayerPoolClient struct {
 		result1 *vm.OrderVMByFilterOK
 		result2 error
 	}
+	RenewVMLeaseStub        func(params *vm.RenewVMLeaseParams) (*vm.RenewVMLeaseOK, error)
+	renewVMLeaseMutex       sync.RWMutex
+	renewVMLeaseArgsForCall []struct {
+		params *vm.RenewVMLeaseParams
+	}
+	renewVMLeaseReturns struct {
+		result1 *vm.RenewVMLeaseOK
+		result2 error
+	}
 	UpdateVMStub        func(params *vm.UpdateVMParams) (*vm.UpdateVMOK, error)
 	updateVMMutex       sync.RWMutex
 	updateVMArgsForCall []struct {
@@ -380,6 +389,40 @@ func (fake *FakeSoftLayerPoolClient) OrderVMByFilterReturns(result1 *vm.OrderVMB
 	}{result1, result2}
 }
 
+func (fake *FakeSoftLayerPoolClient) RenewVMLease(params *vm.RenewVMLeaseParams) (*vm.RenewVMLeaseOK, error) {
+	fake.renewVMLeaseMutex.Lock()
+	fake.renewVMLeaseArgsForCall = append(fake.renewVMLeaseArgsForCall, struct {
+		params *vm.RenewVMLeaseParams
+	}{params})
+	fake.recordInvocation("RenewVMLease", []interface{}{params})
+	fake.renewVMLeaseMutex.Unlock()
+	if fake.RenewVMLeaseStub != nil {
+		return fake.RenewVMLeaseStub(params)
+	} else {
+		return fake.renewVMLeaseReturns.result1, fake.renewVMLeaseReturns.result2
+	}
+}
+
+func (fake *FakeSoftLayerPoolClient) RenewVMLeaseCallCount() int {
+	fake.renewVMLeaseMutex.RLock()
+	defer fake.renewVMLeaseMutex.RUnlock()
+	return len(fake.renewVMLeaseArgsForCall)
+}
+
+func (fake *FakeSoftLayerPoolClient) RenewVMLeaseArgsForCall(i int) *vm.RenewVMLeaseParams {
+	fake.renewVMLeaseMutex.RLock()
+	defer fake.renewVMLeaseMutex.RUnlock()
+	return fake.renewVMLeaseArgsForCall[i].params
+}
+
+func (fake *FakeSoftLayerPoolClient) RenewVMLeaseReturns(result1 *vm.RenewVMLeaseOK, result2 error) {
+	fake.RenewVMLeaseStub = nil
+	fake.renewVMLeaseReturns = struct {
+		result1 *vm.RenewVMLeaseOK
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeSoftLayerPoolClient) UpdateVM(params *vm.UpdateVMParams) (*vm.UpdateVMOK, error) {
 	fake.updateVMMutex.Lock()
 	fake.updateVMArgsForCall = append(fake.updateVMArgsForCall, struct {
@@ -395,6 +438,8 @@ func (fake *FakeSoftLayerPoolClient) UpdateVM(params *vm.UpdateVMParams) (*vm.Up
 }
 
 func (fake *FakeSoftLayerPoolClient) UpdateVMCallCount() int {
+	fake.renewVMLeaseMutex.RLock()
+	defer fake.renewVMLeaseMutex.RUnlock()
 	fake.updateVMMutex.RLock()
 	defer fake.updateVMMutex.RUnlock()
 	return len(fake.updateVMArgsForCall)
@@ -14,6 +14,7 @@ type SoftLayerPoolClient interface {
 	GetVMByCid(params *GetVMByCidParams) (*GetVMByCidOK, error)
 	ListVM(params *ListVMParams) (*ListVMOK, error)
 	OrderVMByFilter(params *OrderVMByFilterParams) (*OrderVMByFilterOK, error)
+	RenewVMLease(params *RenewVMLeaseParams) (*RenewVMLeaseOK, error)
 	UpdateVM(params *UpdateVMParams) (*UpdateVMOK, error)
 	UpdateVMWithState(params *UpdateVMWithStateParams) (*UpdateVMWithStateOK, error)
 	SetTransport(transport runtime.ClientTransport)
@@ -0,0 +1,138 @@
+package vm
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
+	cr "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/swag"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"bosh-softlayer-cpi/softlayer/pool/models"
+)
+
+// NewRenewVMLeaseParams creates a new RenewVMLeaseParams object
+// with the default values initialized.
+func NewRenewVMLeaseParams() *RenewVMLeaseParams {
+	var ()
+	return &RenewVMLeaseParams{
+
+		timeout: cr.DefaultTimeout,
+	}
+}
+
+// NewRenewVMLeaseParamsWithTimeout creates a new RenewVMLeaseParams object
+// with the default values initialized, and the ability to set a timeout on a request
+func NewRenewVMLeaseParamsWithTimeout(timeout time.Duration) *RenewVMLeaseParams {
+	var ()
+	return &RenewVMLeaseParams{
+
+		timeout: timeout,
+	}
+}
+
+// NewRenewVMLeaseParamsWithContext creates a new RenewVMLeaseParams object
+// with the default values initialized, and the ability to set a context for a request
+func NewRenewVMLeaseParamsWithContext(ctx context.Context) *RenewVMLeaseParams {
+	var ()
+	return &RenewVMLeaseParams{
+
+		Context: ctx,
+	}
+}
+
+/*RenewVMLeaseParams contains all the parameters to send to the API endpoint
+for the renew Vm lease operation typically these are written to a http.Request
+*/
+type RenewVMLeaseParams struct {
+
+	/*Body
+	  Holder and duration of the renewed lease
+
+	*/
+	Body *models.Lease
+	/*Cid
+	  ID of vm whose lease needs to be renewed
+
+	*/
+	Cid int32
+
+	timeout time.Duration
+	Context context.Context
+}
+
+// WithTimeout adds the timeout to the renew Vm lease params
+func (o *RenewVMLeaseParams) WithTimeout(timeout time.Duration) *RenewVMLeaseParams {
+	o.SetTimeout(timeout)
+	return o
+}
+
+// SetTimeout adds the timeout to the renew Vm lease params
+func (o *RenewVMLeaseParams) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// WithContext adds the context to the renew Vm lease params
+func (o *RenewVMLeaseParams) WithContext(ctx context.Context) *RenewVMLeaseParams {
+	o.SetContext(ctx)
+	return o
+}
+
+// SetContext adds the context to the renew Vm lease params
+func (o *RenewVMLeaseParams) SetContext(ctx context.Context) {
+	o.Context = ctx
+}
+
+// WithBody adds the body to the renew Vm lease params
+func (o *RenewVMLeaseParams) WithBody(body *models.Lease) *RenewVMLeaseParams {
+	o.SetBody(body)
+	return o
+}
+
+// SetBody adds the body to the renew Vm lease params
+func (o *RenewVMLeaseParams) SetBody(body *models.Lease) {
+	o.Body = body
+}
+
+// WithCid adds the cid to the renew Vm lease params
+func (o *RenewVMLeaseParams) WithCid(cid int32) *RenewVMLeaseParams {
+	o.SetCid(cid)
+	return o
+}
+
+// SetCid adds the cid to the renew Vm lease params
+func (o *RenewVMLeaseParams) SetCid(cid int32) {
+	o.Cid = cid
+}
+
+// WriteToRequest writes these params to a swagger request
+func (o *RenewVMLeaseParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+
+	r.SetTimeout(o.timeout)
+	var res []error
+
+	if o.Body == nil {
+		o.Body = new(models.Lease)
+	}
+
+	if err := r.SetBodyParam(o.Body); err != nil {
+		return err
+	}
+
+	// path param cid
+	if err := r.SetPathParam("cid", swag.FormatInt32(o.Cid)); err != nil {
+		return err
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package vm
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/runtime"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"bosh-softlayer-cpi/softlayer/pool/models"
+)
+
+// RenewVMLeaseReader is a Reader for the RenewVMLease structure.
+type RenewVMLeaseReader struct {
+	formats strfmt.Registry
+}
+
+// ReadResponse reads a server response into the received o.
+func (o *RenewVMLeaseReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	switch response.Code() {
+
+	case 200:
+		result := NewRenewVMLeaseOK()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return result, nil
+
+	case 404:
+		result := NewRenewVMLeaseNotFound()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+
+	case 409:
+		result := NewRenewVMLeaseConflict()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+
+	default:
+		result := NewRenewVMLeaseDefault(response.Code())
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
+	}
+}
+
+// NewRenewVMLeaseOK creates a RenewVMLeaseOK with default headers values
+func NewRenewVMLeaseOK() *RenewVMLeaseOK {
+	return &RenewVMLeaseOK{}
+}
+
+// WithPayload adds the payload to the renew Vm lease o k response
+func (o *RenewVMLeaseOK) WithPayload(payload *models.VMResponse) *RenewVMLeaseOK {
+	o.Payload = payload
+	return o
+}
+
+/*RenewVMLeaseOK handles this case with default header values.
+
+lease renewed successfully
+*/
+type RenewVMLeaseOK struct {
+	Payload *models.VMResponse
+}
+
+func (o *RenewVMLeaseOK) Error() string {
+	return fmt.Sprintf("[PUT /vms/{cid}/lease][%d] renewVmLeaseOK  %+v", 200, o.Payload)
+}
+
+func (o *RenewVMLeaseOK) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.VMResponse)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewRenewVMLeaseNotFound creates a RenewVMLeaseNotFound with default headers values
+func NewRenewVMLeaseNotFound() *RenewVMLeaseNotFound {
+	return &RenewVMLeaseNotFound{}
+}
+
+/*RenewVMLeaseNotFound handles this case with default header values.
+
+vm not found
+*/
+type RenewVMLeaseNotFound struct {
+}
+
+func (o *RenewVMLeaseNotFound) Error() string {
+	return fmt.Sprintf("[PUT /vms/{cid}/lease][%d] renewVmLeaseNotFound ", 404)
+}
+
+func (o *RenewVMLeaseNotFound) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	return nil
+}
+
+// NewRenewVMLeaseConflict creates a RenewVMLeaseConflict with default headers values
+func NewRenewVMLeaseConflict() *RenewVMLeaseConflict {
+	return &RenewVMLeaseConflict{}
+}
+
+/*RenewVMLeaseConflict handles this case with default header values.
+
+vm is not leased to the holder
+*/
+type RenewVMLeaseConflict struct {
+	Payload *models.Error
+}
+
+func (o *RenewVMLeaseConflict) Error() string {
+	return fmt.Sprintf("[PUT /vms/{cid}/lease][%d] renewVmLeaseConflict  %+v", 409, o.Payload)
+}
+
+func (o *RenewVMLeaseConflict) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.Error)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewRenewVMLeaseDefault creates a RenewVMLeaseDefault with default headers values
+func NewRenewVMLeaseDefault(code int) *RenewVMLeaseDefault {
+	return &RenewVMLeaseDefault{
+		_statusCode: code,
+	}
+}
+
+/*RenewVMLeaseDefault handles this case with default header values.
+
+unexpected error
+*/
+type RenewVMLeaseDefault struct {
+	_statusCode int
+
+	Payload *models.Error
+}
+
+// Code gets the status code for the renew Vm lease default response
+func (o *RenewVMLeaseDefault) Code() int {
+	return o._statusCode
+}
+
+func (o *RenewVMLeaseDefault) Error() string {
+	return fmt.Sprintf("[PUT /vms/{cid}/lease][%d] renewVmLease default  %+v", o._statusCode, o.Payload)
+}
+
+func (o *RenewVMLeaseDefault) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.Error)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
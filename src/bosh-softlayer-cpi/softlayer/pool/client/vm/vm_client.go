@@ -242,6 +242,35 @@ func (a *Client) OrderVMByFilter(params *OrderVMByFilterParams) (*OrderVMByFilte
 
 }
 
+/*
+RenewVMLease renews the lease an order took on a vm
+
+Only the holder of the lease can renew it, and only while the vm is provisioning
+*/
+func (a *Client) RenewVMLease(params *RenewVMLeaseParams) (*RenewVMLeaseOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewRenewVMLeaseParams()
+	}
+
+	result, err := a.transport.Submit(&runtime.ClientOperation{
+		ID:                 "renewVmLease",
+		Method:             "PUT",
+		PathPattern:        "/vms/{cid}/lease",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http"},
+		Params:             params,
+		Reader:             &RenewVMLeaseReader{formats: a.formats},
+		Context:            params.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RenewVMLeaseOK), nil
+
+}
+
 /*
 UpdateVM updates an existing vm
 */
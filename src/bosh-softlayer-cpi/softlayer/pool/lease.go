@@ -0,0 +1,160 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	operations "bosh-softlayer-cpi/softlayer/pool/client/vm"
+	"bosh-softlayer-cpi/softlayer/pool/models"
+)
+
+const SOFTLAYER_POOL_LEASE_LOG_TAG = "SoftLayerPoolLease"
+
+// poolLease is the lease an order took on a vm of the pool. While kept, it is renewed every third
+// of its duration, so that it expires soon after the CPI holding it stops.
+type poolLease struct {
+	client   operations.SoftLayerPoolClient
+	cid      int
+	holder   string
+	duration time.Duration
+	logger   boshlog.Logger
+
+	stop chan struct{}
+	done chan struct{}
+
+	lock sync.Mutex
+	lost error
+}
+
+func newPoolLease(client operations.SoftLayerPoolClient, cid int, holder string, duration time.Duration, logger boshlog.Logger) *poolLease {
+	return &poolLease{
+		client:   client,
+		cid:      cid,
+		holder:   holder,
+		duration: duration,
+		logger:   logger,
+	}
+}
+
+// keep renews the lease in the background until release is called
+func (l *poolLease) keep() {
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+
+		ticker := time.NewTicker(l.duration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				if !l.renew() {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// release stops renewing the lease and returns an error when the lease was lost while kept
+func (l *poolLease) release() error {
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+		l.stop = nil
+	}
+
+	return l.lostError()
+}
+
+func (l *poolLease) lostError() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.lost
+}
+
+// renew renews the lease once, and tells whether to go on renewing it. A lease the pool has for
+// another holder is lost. A pool server without the lease endpoint answers not found, and keeps no
+// leases to renew. Any other failure is retried on the next tick, before the lease expires.
+func (l *poolLease) renew() bool {
+	lease := &models.Lease{
+		Holder:   l.holder,
+		Duration: int32(l.duration / time.Second),
+	}
+	_, err := l.client.RenewVMLease(operations.NewRenewVMLeaseParams().WithCid(int32(l.cid)).WithBody(lease))
+	if err == nil {
+		l.logger.Debug(SOFTLAYER_POOL_LEASE_LOG_TAG, fmt.Sprintf("Renewed the lease of %s on vm %d for %s", l.holder, l.cid, l.duration))
+		return true
+	}
+
+	switch err.(type) {
+	case *operations.RenewVMLeaseNotFound:
+		l.logger.Info(SOFTLAYER_POOL_LEASE_LOG_TAG, fmt.Sprintf("Pool keeps no lease of %s on vm %d to renew, leases are not supported", l.holder, l.cid))
+		return false
+	case *operations.RenewVMLeaseConflict:
+		l.lock.Lock()
+		l.lost = bosherr.WrapErrorf(err, "Lease of %s on vm %d in pool was lost", l.holder, l.cid)
+		l.lock.Unlock()
+		return false
+	default:
+		l.logger.Warn(SOFTLAYER_POOL_LEASE_LOG_TAG, fmt.Sprintf("Renewing the lease of %s on vm %d: %s", l.holder, l.cid, err.Error()))
+		return true
+	}
+}
+
+// leaseExpired tells whether vm is provisioning under a lease that ran out before now. A vm the
+// pool keeps no lease for, such as one ordered from a pool server without lease support, may still
+// be OS reloaded by the CPI that ordered it, so it never expires.
+func leaseExpired(vm *models.VM, now time.Time) bool {
+	if vm.State != models.StateProvisioning || vm.Lease == nil || time.Time(vm.Lease.Expiry).IsZero() {
+		return false
+	}
+
+	return time.Time(vm.Lease.Expiry).Before(now)
+}
+
+// reclaimExpiredLeases frees the vms of the pool whose leases expired, such as the vms held by a
+// CPI that stopped in the middle of an OS reload. Every order OS reloads the vm it gets, so a
+// reclaimed vm is put back as it is.
+func reclaimExpiredLeases(client operations.SoftLayerPoolClient, now time.Time, logger boshlog.Logger) ([]int, error) {
+	resp, err := client.FindVmsByStates(operations.NewFindVmsByStatesParams().WithStates([]string{string(models.StateProvisioning)}))
+	if err != nil {
+		if _, ok := err.(*operations.FindVmsByStatesNotFound); ok {
+			return nil, nil
+		}
+		return nil, bosherr.WrapError(err, "Finding provisioning vms in pool")
+	}
+
+	reclaimed := []int{}
+	for _, vm := range resp.Payload.Vms {
+		if !leaseExpired(vm, now) {
+			continue
+		}
+
+		logger.Info(SOFTLAYER_POOL_LEASE_LOG_TAG, fmt.Sprintf("Reclaiming vm %d, whose lease of %s expired %s", vm.Cid, vm.Lease.Holder, vm.Lease.Expiry.String()))
+
+		free := models.VMState{
+			State: models.StateFree,
+		}
+		_, err := client.UpdateVMWithState(operations.NewUpdateVMWithStateParams().WithBody(&free).WithCid(vm.Cid))
+		if err != nil {
+			if _, ok := err.(*operations.UpdateVMWithStateNotFound); ok {
+				continue
+			}
+			return reclaimed, bosherr.WrapErrorf(err, "Updating state of vm %d in pool to free", vm.Cid)
+		}
+
+		reclaimed = append(reclaimed, int(vm.Cid))
+	}
+
+	return reclaimed, nil
+}
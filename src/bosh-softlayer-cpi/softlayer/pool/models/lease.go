@@ -0,0 +1,34 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+)
+
+// Lease Lease an order takes on the vm it hands out, which the pool reclaims once it expires
+// swagger:model Lease
+type Lease struct {
+
+	// Seconds the lease lasts from when it is taken or renewed
+	Duration int32 `json:"duration,omitempty"`
+
+	// expiry
+	Expiry strfmt.DateTime `json:"expiry,omitempty"`
+
+	// holder
+	Holder string `json:"holder,omitempty"`
+}
+
+// Validate validates this lease
+func (m *Lease) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
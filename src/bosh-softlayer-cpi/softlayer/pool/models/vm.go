@@ -32,6 +32,9 @@ type VM struct {
 	// ip
 	IP strfmt.IPv4 `json:"ip,omitempty"`
 
+	// lease
+	Lease *Lease `json:"lease,omitempty"`
+
 	// memory mb
 	MemoryMb int32 `json:"memory_mb,omitempty"`
 
@@ -52,6 +55,11 @@ type VM struct {
 func (m *VM) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateLease(formats); err != nil {
+		// prop
+		res = append(res, err)
+	}
+
 	if err := m.validateState(formats); err != nil {
 		// prop
 		res = append(res, err)
@@ -63,6 +71,22 @@ func (m *VM) Validate(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *VM) validateLease(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Lease) { // not required
+		return nil
+	}
+
+	if m.Lease != nil {
+
+		if err := m.Lease.Validate(formats); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *VM) validateState(formats strfmt.Registry) error {
 
 	if swag.IsZero(m.State) { // not required
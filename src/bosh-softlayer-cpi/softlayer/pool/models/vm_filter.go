@@ -23,6 +23,9 @@ type VMFilter struct {
 	// ip
 	IP strfmt.IPv4 `json:"ip,omitempty"`
 
+	// lease
+	Lease *Lease `json:"lease,omitempty"`
+
 	// memory mb
 	MemoryMb int32 `json:"memory_mb,omitempty"`
 
@@ -40,6 +43,11 @@ type VMFilter struct {
 func (m *VMFilter) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateLease(formats); err != nil {
+		// prop
+		res = append(res, err)
+	}
+
 	if err := m.validateState(formats); err != nil {
 		// prop
 		res = append(res, err)
@@ -51,6 +59,22 @@ func (m *VMFilter) Validate(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *VMFilter) validateLease(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Lease) { // not required
+		return nil
+	}
+
+	if m.Lease != nil {
+
+		if err := m.Lease.Validate(formats); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *VMFilter) validateState(formats strfmt.Registry) error {
 
 	if swag.IsZero(m.State) { // not required
@@ -40,9 +40,6 @@ type softLayerPoolCreator struct {
 }
 
 func NewSoftLayerPoolCreator(vmFinder VMFinder, softLayerVmPoolClient operations.SoftLayerPoolClient, softLayerClient sl.Client, agentOptions AgentOptions, featureOptions FeatureOptions, registryOptions RegistryOptions, logger boshlog.Logger) VMCreator {
-	slhelper.TIMEOUT = 120 * time.Minute
-	slhelper.POLLING_INTERVAL = 5 * time.Second
-
 	return &softLayerPoolCreator{
 		softLayerVmPoolClient: softLayerVmPoolClient,
 		softLayerClient:       softLayerClient,
@@ -55,13 +52,24 @@ func NewSoftLayerPoolCreator(vmFinder VMFinder, softLayerVmPoolClient operations
 }
 
 func (c *softLayerPoolCreator) Create(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+	rollback := slhelper.NewRollback(c.featureOptions.KeepFailedVMs, c.logger)
+
+	vm, err := c.create(agentID, stemcell, cloudProps, networks, env, rollback)
+	if err != nil {
+		return nil, rollback.Fail(err)
+	}
+
+	return vm, nil
+}
+
+func (c *softLayerPoolCreator) create(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	for _, network := range networks {
 		switch network.Type {
 		case "dynamic":
 			if len(network.IP) == 0 {
-				return c.createFromVMPool(agentID, stemcell, cloudProps, networks, env)
+				return c.createFromVMPool(agentID, stemcell, cloudProps, networks, env, rollback)
 			} else {
-				return c.createByOSReload(agentID, stemcell, cloudProps, networks, env)
+				return c.createByOSReload(agentID, stemcell, cloudProps, networks, env, rollback)
 			}
 		case "vip":
 			return nil, bosherr.Error("SoftLayer Not Support VIP netowrk")
@@ -73,7 +81,7 @@ func (c *softLayerPoolCreator) Create(agentID string, stemcell bslcstem.Stemcell
 }
 
 // Private methods
-func (c *softLayerPoolCreator) createFromVMPool(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+func (c *softLayerPoolCreator) createFromVMPool(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	var err error
 	virtualGuestTemplate, err := CreateVirtualGuestTemplate(stemcell, cloudProps, networks, CreateUserDataForInstance(agentID, networks, c.registryOptions))
 	filter := &models.VMFilter{
@@ -82,14 +90,26 @@ func (c *softLayerPoolCreator) createFromVMPool(agentID string, stemcell bslcste
 		PrivateVlan: int32(virtualGuestTemplate.PrimaryBackendNetworkComponent.NetworkVlan.Id),
 		PublicVlan:  int32(virtualGuestTemplate.PrimaryNetworkComponent.NetworkVlan.Id),
 		State:       models.StateFree,
+		Lease: &models.Lease{
+			Holder:   agentID,
+			Duration: int32(c.featureOptions.PoolLease() / time.Second),
+		},
+	}
+
+	reclaimed, err := reclaimExpiredLeases(c.softLayerVmPoolClient, time.Now(), c.logger)
+	if err != nil {
+		c.logger.Warn(SOFTLAYER_POOL_CREATOR_LOG_TAG, fmt.Sprintf("Reclaiming vms with expired leases in pool: %s", err.Error()))
+	} else if len(reclaimed) > 0 {
+		c.logger.Info(SOFTLAYER_POOL_CREATOR_LOG_TAG, fmt.Sprintf("Reclaimed vms %v with expired leases to pool", reclaimed))
 	}
+
 	orderVmResp, err := c.softLayerVmPoolClient.OrderVMByFilter(operations.NewOrderVMByFilterParams().WithBody(filter))
 	if err != nil {
 		_, ok := err.(*operations.OrderVMByFilterNotFound)
 		if !ok {
 			return nil, bosherr.WrapError(err, "Ordering vm from pool")
 		} else {
-			sl_vm, err := c.createBySoftlayer(agentID, stemcell, cloudProps, networks, env)
+			sl_vm, err := c.createBySoftlayer(agentID, stemcell, cloudProps, networks, env, rollback)
 			if err != nil {
 				return nil, bosherr.WrapError(err, "Creating vm in SoftLayer")
 			}
@@ -117,13 +137,30 @@ func (c *softLayerPoolCreator) createFromVMPool(agentID string, stemcell bslcste
 
 	vm = orderVmResp.Payload.VM
 	virtualGuestId = int((*vm).Cid)
+	lease := newPoolLease(c.softLayerVmPoolClient, virtualGuestId, agentID, c.featureOptions.PoolLease(), c.logger)
+	releaseState := models.StateFree
+	rollback.Add(fmt.Sprintf("Releasing vm %d back to pool", virtualGuestId), func() error {
+		// A lost lease was reclaimed by the pool, which may have handed the vm out again since
+		if lease.lostError() != nil {
+			return nil
+		}
+		return c.releaseVM(virtualGuestId, releaseState)
+	})
 
 	c.logger.Info(SOFTLAYER_POOL_CREATOR_LOG_TAG, fmt.Sprintf("OS reload on VirtualGuest %d using stemcell %d", virtualGuestId, stemcell.ID()))
 
-	sl_vm_os, err := c.oSReloadVMInPool(virtualGuestId, agentID, stemcell, cloudProps, networks, env)
+	lease.keep()
+	sl_vm_os, err := c.oSReloadVMInPool(virtualGuestId, agentID, stemcell, cloudProps, networks, env, rollback)
+	leaseErr := lease.release()
 	if err != nil {
+		// The vm may be left half reloaded, so it goes back to the pool for an operator to look at
+		// instead of to the next order
+		releaseState = models.StateUnknown
 		return nil, bosherr.WrapError(err, "Os reloading vm in SoftLayer")
 	}
+	if leaseErr != nil {
+		return nil, leaseErr
+	}
 
 	virtualGuest, err := slhelper.GetObjectDetailsOnVirtualGuest(c.softLayerClient, virtualGuestId)
 	if err != nil {
@@ -138,6 +175,9 @@ func (c *softLayerPoolCreator) createFromVMPool(agentID string, stemcell bslcste
 		PrivateVlan: int32(virtualGuest.PrimaryBackendNetworkComponent.NetworkVlan.Id),
 		PublicVlan:  int32(virtualGuest.PrimaryNetworkComponent.NetworkVlan.Id),
 		State:       models.StateUsing,
+		Lease: &models.Lease{
+			Holder: agentID,
+		},
 	}
 	_, err = c.softLayerVmPoolClient.UpdateVM(operations.NewUpdateVMParams().WithBody(deviceName))
 	if err != nil {
@@ -151,29 +191,37 @@ func (c *softLayerPoolCreator) createFromVMPool(agentID string, stemcell bslcste
 
 func (c *softLayerPoolCreator) GetAgentOptions() AgentOptions { return c.agentOptions }
 
-func (c *softLayerPoolCreator) createBySoftlayer(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+func (c *softLayerPoolCreator) createBySoftlayer(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	virtualGuestTemplate, err := CreateVirtualGuestTemplate(stemcell, cloudProps, networks, CreateUserDataForInstance(agentID, networks, c.registryOptions))
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Creating VirtualGuest template")
 	}
+	virtualGuestTemplate.TagReferences = slhelper.AgentIDTagReferences(agentID)
 
-	virtualGuestService, err := c.softLayerClient.GetSoftLayer_Virtual_Guest_Service()
+	virtualGuest, found, err := slhelper.FindVirtualGuestByAgentID(c.softLayerClient, agentID)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
+		return nil, bosherr.WrapErrorf(err, "Finding VirtualGuest already ordered for agent ID `%s`", agentID)
 	}
 
-	virtualGuest, err := virtualGuestService.CreateObject(virtualGuestTemplate)
-	if err != nil {
-		return nil, bosherr.WrapError(err, "Creating VirtualGuest from SoftLayer client")
+	if found {
+		c.logger.Info(SOFTLAYER_POOL_CREATOR_LOG_TAG, fmt.Sprintf("Resuming setup of VirtualGuest %d already ordered for agent ID %s", virtualGuest.Id, agentID))
+	} else {
+		virtualGuest, err = slhelper.CreateVirtualGuest(c.softLayerClient, virtualGuestTemplate)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Creating VirtualGuest from SoftLayer client")
+		}
 	}
+	rollback.Add(fmt.Sprintf("Cancelling VirtualGuest `%d`", virtualGuest.Id), func() error {
+		return slhelper.CancelVirtualGuest(c.softLayerClient, virtualGuest.Id, c.featureOptions.WaitPolicies.Delete)
+	})
 
 	if cloudProps.EphemeralDiskSize == 0 {
-		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, virtualGuest.Id, "Service Setup")
+		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, virtualGuest.Id, "Service Setup", c.featureOptions.WaitPolicies.Create)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` has Service Setup transaction complete", virtualGuest.Id)
 		}
 	} else {
-		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, virtualGuest.Id, cloudProps.EphemeralDiskSize, c.logger)
+		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, virtualGuest.Id, cloudProps.EphemeralDiskSize, c.featureOptions.WaitPolicies.EphemeralDiskUpgrade, c.logger)
 		if err != nil {
 			return nil, bosherr.WrapError(err, fmt.Sprintf("Attaching ephemeral disk to VirtualGuest `%d`", virtualGuest.Id))
 		}
@@ -185,18 +233,22 @@ func (c *softLayerPoolCreator) createBySoftlayer(agentID string, stemcell bslcst
 	}
 
 	if cloudProps.DeployedByBoshCLI {
-		err := UpdateEtcHostsOfBoshInit(slhelper.LocalDNSConfigurationFile, fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName()))
+		etcHostsRecord := fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName())
+		err := UpdateEtcHostsOfBoshInit(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Updating BOSH director hostname/IP mapping entry in /etc/hosts")
 		}
+		rollback.Add(fmt.Sprintf("Removing '%s' from %s", etcHostsRecord, c.featureOptions.LocalDNSConfigurationFile), func() error {
+			return RemoveEtcHostsEntry(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
+		})
 	} else {
 		var boshIP string
 		if cloudProps.BoshIp != "" {
 			boshIP = cloudProps.BoshIp
 		} else {
-			boshIP, err = GetLocalIPAddressOfGivenInterface(slhelper.NetworkInterface)
+			boshIP, err = GetLocalIPAddressOfGivenInterface(c.featureOptions.NetworkInterface)
 			if err != nil {
-				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", slhelper.NetworkInterface))
+				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", c.featureOptions.NetworkInterface))
 			}
 		}
 
@@ -221,6 +273,7 @@ func (c *softLayerPoolCreator) createBySoftlayer(agentID string, stemcell bslcst
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Updating VM's agent env")
 	}
+	rollback.Add(fmt.Sprintf("Deleting agent env of VirtualGuest `%d`", vm.ID()), vm.DeleteAgentEnv)
 
 	if len(c.agentOptions.VcapPassword) > 0 {
 		err = vm.SetVcapPassword(c.agentOptions.VcapPassword)
@@ -232,7 +285,7 @@ func (c *softLayerPoolCreator) createBySoftlayer(agentID string, stemcell bslcst
 	return vm, nil
 }
 
-func (c *softLayerPoolCreator) createByOSReload(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+func (c *softLayerPoolCreator) createByOSReload(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	virtualGuestService, err := c.softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
@@ -265,7 +318,6 @@ func (c *softLayerPoolCreator) createByOSReload(agentID string, stemcell bslcste
 		return nil, bosherr.WrapErrorf(err, "Cannot find virtualGuest with id: %d", virtualGuest.Id)
 	}
 
-	slhelper.TIMEOUT = 4 * time.Hour
 	err = vm.ReloadOS(stemcell)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Failed to reload OS")
@@ -277,30 +329,34 @@ func (c *softLayerPoolCreator) createByOSReload(agentID string, stemcell bslcste
 	}
 
 	if cloudProps.EphemeralDiskSize == 0 {
-		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, vm.ID(), "Service Setup")
+		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, vm.ID(), "Service Setup", c.featureOptions.WaitPolicies.Create)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` has Service Setup transaction complete", vm.ID())
 		}
 	} else {
-		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, vm.ID(), cloudProps.EphemeralDiskSize, c.logger)
+		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, vm.ID(), cloudProps.EphemeralDiskSize, c.featureOptions.WaitPolicies.EphemeralDiskUpgrade, c.logger)
 		if err != nil {
 			return nil, bosherr.WrapError(err, fmt.Sprintf("Attaching ephemeral disk to VirtualGuest `%d`", vm.ID()))
 		}
 	}
 
 	if cloudProps.DeployedByBoshCLI {
-		err := UpdateEtcHostsOfBoshInit(slhelper.LocalDNSConfigurationFile, fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName()))
+		etcHostsRecord := fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName())
+		err := UpdateEtcHostsOfBoshInit(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Updating BOSH director hostname/IP mapping entry in /etc/hosts")
 		}
+		rollback.Add(fmt.Sprintf("Removing '%s' from %s", etcHostsRecord, c.featureOptions.LocalDNSConfigurationFile), func() error {
+			return RemoveEtcHostsEntry(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
+		})
 	} else {
 		var boshIP string
 		if cloudProps.BoshIp != "" {
 			boshIP = cloudProps.BoshIp
 		} else {
-			boshIP, err = GetLocalIPAddressOfGivenInterface(slhelper.NetworkInterface)
+			boshIP, err = GetLocalIPAddressOfGivenInterface(c.featureOptions.NetworkInterface)
 			if err != nil {
-				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", slhelper.NetworkInterface))
+				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", c.featureOptions.NetworkInterface))
 			}
 		}
 
@@ -333,6 +389,7 @@ func (c *softLayerPoolCreator) createByOSReload(agentID string, stemcell bslcste
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Updating VM's agent env")
 	}
+	rollback.Add(fmt.Sprintf("Deleting agent env of VirtualGuest `%d`", vm.ID()), vm.DeleteAgentEnv)
 
 	if len(c.agentOptions.VcapPassword) > 0 {
 		err = vm.SetVcapPassword(c.agentOptions.VcapPassword)
@@ -343,13 +400,12 @@ func (c *softLayerPoolCreator) createByOSReload(agentID string, stemcell bslcste
 	return vm, nil
 }
 
-func (c *softLayerPoolCreator) oSReloadVMInPool(cid int, agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+func (c *softLayerPoolCreator) oSReloadVMInPool(cid int, agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	vm, found, err := c.vmFinder.Find(cid)
 	if err != nil || !found {
 		return nil, bosherr.WrapErrorf(err, "Cannot find virtualGuest with id: %d", cid)
 	}
 
-	slhelper.TIMEOUT = 4 * time.Hour
 	err = vm.ReloadOS(stemcell)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Failed to do os_reload against %d", cid)
@@ -362,30 +418,34 @@ func (c *softLayerPoolCreator) oSReloadVMInPool(cid int, agentID string, stemcel
 	}
 
 	if cloudProps.EphemeralDiskSize == 0 {
-		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, vm.ID(), "Service Setup")
+		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, vm.ID(), "Service Setup", c.featureOptions.WaitPolicies.Create)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` has Service Setup transaction complete", vm.ID())
 		}
 	} else {
-		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, vm.ID(), cloudProps.EphemeralDiskSize, c.logger)
+		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, vm.ID(), cloudProps.EphemeralDiskSize, c.featureOptions.WaitPolicies.EphemeralDiskUpgrade, c.logger)
 		if err != nil {
 			return nil, bosherr.WrapError(err, fmt.Sprintf("Attaching ephemeral disk to VirtualGuest `%d`", vm.ID()))
 		}
 	}
 
 	if cloudProps.DeployedByBoshCLI {
-		err := UpdateEtcHostsOfBoshInit(slhelper.LocalDNSConfigurationFile, fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName()))
+		etcHostsRecord := fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName())
+		err := UpdateEtcHostsOfBoshInit(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Updating BOSH director hostname/IP mapping entry in /etc/hosts")
 		}
+		rollback.Add(fmt.Sprintf("Removing '%s' from %s", etcHostsRecord, c.featureOptions.LocalDNSConfigurationFile), func() error {
+			return RemoveEtcHostsEntry(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
+		})
 	} else {
 		var boshIP string
 		if cloudProps.BoshIp != "" {
 			boshIP = cloudProps.BoshIp
 		} else {
-			boshIP, err = GetLocalIPAddressOfGivenInterface(slhelper.NetworkInterface)
+			boshIP, err = GetLocalIPAddressOfGivenInterface(c.featureOptions.NetworkInterface)
 			if err != nil {
-				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", slhelper.NetworkInterface))
+				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", c.featureOptions.NetworkInterface))
 			}
 		}
 
@@ -418,6 +478,7 @@ func (c *softLayerPoolCreator) oSReloadVMInPool(cid int, agentID string, stemcel
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Updating VM's agent env")
 	}
+	rollback.Add(fmt.Sprintf("Deleting agent env of VirtualGuest `%d`", vm.ID()), vm.DeleteAgentEnv)
 
 	if len(c.agentOptions.VcapPassword) > 0 {
 		err = vm.SetVcapPassword(c.agentOptions.VcapPassword)
@@ -427,3 +488,15 @@ func (c *softLayerPoolCreator) oSReloadVMInPool(cid int, agentID string, stemcel
 	}
 	return vm, nil
 }
+
+func (c *softLayerPoolCreator) releaseVM(cid int, state models.State) error {
+	vmState := models.VMState{
+		State: state,
+	}
+	_, err := c.softLayerVmPoolClient.UpdateVMWithState(operations.NewUpdateVMWithStateParams().WithBody(&vmState).WithCid(int32(cid)))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Updating state of vm %d in pool to %s", cid, state)
+	}
+
+	return nil
+}
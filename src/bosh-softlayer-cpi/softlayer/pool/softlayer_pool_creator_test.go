@@ -1,6 +1,9 @@
 package pool_test
 
 import (
+	"errors"
+	"time"
+
 	. "bosh-softlayer-cpi/softlayer/common"
 	. "bosh-softlayer-cpi/softlayer/pool"
 
@@ -17,10 +20,12 @@ import (
 	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
 
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
 	fakesutil "bosh-softlayer-cpi/util/fakes"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/go-openapi/strfmt"
 	sldatatypes "github.com/maximilien/softlayer-go/data_types"
 )
 
@@ -37,6 +42,7 @@ var _ = Describe("SoftlayerPoolCreator", func() {
 	BeforeEach(func() {
 		softLayerClient = fakeslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
 		fakePoolClient = &fakespool.FakeSoftLayerPoolClient{}
+		fakePoolClient.FindVmsByStatesReturns(nil, vm.NewFindVmsByStatesNotFound())
 		sshClient = &fakesutil.FakeSshClient{}
 		agentOptions = AgentOptions{Mbus: "fake-mbus"}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
@@ -81,7 +87,7 @@ var _ = Describe("SoftlayerPoolCreator", func() {
 			}
 
 			agentID = "fake-agent-id"
-			stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+			stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
 			env = Environment{}
 			cloudProps = VMCloudProperties{
 				StartCpus: 4,
@@ -127,7 +133,8 @@ var _ = Describe("SoftlayerPoolCreator", func() {
 			}
 
 			featureOptions = &FeatureOptions{
-				EnablePool: true,
+				EnablePool:   true,
+				WaitPolicies: slh.DefaultWaitPolicies(),
 			}
 			registryOptions = &RegistryOptions{}
 
@@ -209,6 +216,32 @@ var _ = Describe("SoftlayerPoolCreator", func() {
 			})
 		})
 
+		Context("when the lease on a vm in pool expired", func() {
+			BeforeEach(func() {
+				fakePoolClient.FindVmsByStatesReturns(&vm.FindVmsByStatesOK{
+					Payload: &models.VmsResponse{
+						Vms: []*models.VM{
+							{Cid: 7654321, State: models.StateProvisioning, Lease: &models.Lease{Holder: "other-agent-id", Expiry: strfmt.DateTime(time.Now().Add(-time.Minute))}},
+							{Cid: 7654322, State: models.StateProvisioning, Lease: &models.Lease{Holder: "other-agent-id", Expiry: strfmt.DateTime(time.Now().Add(time.Minute))}},
+						},
+					},
+				}, nil)
+				fakePoolClient.OrderVMByFilterReturns(nil, vm.NewOrderVMByFilterDefault(500))
+			})
+
+			It("orders a vm leased to the agent after reclaiming the expired vm", func() {
+				Expect(fakePoolClient.FindVmsByStatesArgsForCall(0).States).To(Equal([]string{"provisioning"}))
+				Expect(fakePoolClient.UpdateVMWithStateCallCount()).To(Equal(1))
+				updateVMWithStateParams := fakePoolClient.UpdateVMWithStateArgsForCall(0)
+				Expect(updateVMWithStateParams.Cid).To(Equal(int32(7654321)))
+				Expect(updateVMWithStateParams.Body.State).To(Equal(models.StateFree))
+
+				orderVMByFilterParams := fakePoolClient.OrderVMByFilterArgsForCall(0)
+				Expect(orderVMByFilterParams.Body.Lease.Holder).To(Equal("fake-agent-id"))
+				Expect(orderVMByFilterParams.Body.Lease.Duration).To(Equal(int32(600)))
+			})
+		})
+
 		Context("when order vm by filter from pool error out", func() {
 			BeforeEach(func() {
 				fakePoolClient.OrderVMByFilterReturns(nil, vm.NewOrderVMByFilterDefault(500))
@@ -257,6 +290,33 @@ var _ = Describe("SoftlayerPoolCreator", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("Updating the hostname of vm"))
 			})
+
+			It("drops the agent env and releases the vm back to pool", func() {
+				Expect(fakeVm.DeleteAgentEnvCallCount()).To(Equal(1))
+				Expect(fakePoolClient.UpdateVMWithStateCallCount()).To(Equal(1))
+				updateVMWithStateParams := fakePoolClient.UpdateVMWithStateArgsForCall(0)
+				Expect(updateVMWithStateParams.Cid).To(Equal(int32(1234567)))
+				Expect(updateVMWithStateParams.Body.State).To(Equal(models.StateFree))
+			})
+		})
+
+		Context("when os reload of a vm ordered from pool error out", func() {
+			BeforeEach(func() {
+				fakePoolClient.OrderVMByFilterReturns(vm.NewOrderVMByFilterOK().WithPayload(poolVmResponse), nil)
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+				fakeVm.ReloadOSReturns(errors.New("kaboom"))
+			})
+
+			It("provides relevant error information", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Os reloading vm in SoftLayer"))
+			})
+
+			It("releases the vm back to pool in state unknown", func() {
+				Expect(fakePoolClient.UpdateVMWithStateCallCount()).To(Equal(1))
+				updateVMWithStateParams := fakePoolClient.UpdateVMWithStateArgsForCall(0)
+				Expect(updateVMWithStateParams.Body.State).To(Equal(models.StateUnknown))
+			})
 		})
 
 		Context("when doing os_reload directly without operation of pool succeeds", func() {
@@ -334,6 +394,7 @@ func setFakeSoftlayerClientCreateObjectTestFixturesWithEphemeralDiskSize_OS_Relo
 
 func setFakeSoftlayerClientCreateObjectTestFixturesWithEphemeralDiskSize(fakeSoftLayerClient *fakeslclient.FakeSoftLayerClient) {
 	fileNames := []string{
+		"SoftLayer_Account_Service_getVirtualGuests_None.json",
 		"SoftLayer_Virtual_Guest_Service_createObject.json",
 
 		"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
@@ -0,0 +1,331 @@
+package pool_test
+
+import (
+	. "bosh-softlayer-cpi/softlayer/common"
+	. "bosh-softlayer-cpi/softlayer/pool"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sync"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+
+	operations "bosh-softlayer-cpi/softlayer/pool/client/vm"
+	"bosh-softlayer-cpi/softlayer/pool/models"
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+	"bosh-softlayer-cpi/test_helpers/poolserver"
+
+	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+var _ = Describe("Softlayer pool against a pool server", func() {
+	var (
+		server *poolserver.Server
+		logger boshlog.Logger
+
+		cloudProps        VMCloudProperties
+		networks          Networks
+		poolLeaseDuration int
+	)
+
+	BeforeEach(func() {
+		var err error
+		server, err = poolserver.New(poolserver.Options{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.Start()).To(Succeed())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		poolLeaseDuration = 0
+
+		cloudProps = VMCloudProperties{
+			StartCpus: 4,
+			MaxMemory: 2048,
+			Domain:    "fake-domain.com",
+			BlockDeviceTemplateGroup: sldatatypes.BlockDeviceTemplateGroup{
+				GlobalIdentifier: "fake-uuid",
+			},
+			RootDiskSize:      25,
+			BoshIp:            "10.0.0.1",
+			EphemeralDiskSize: 25,
+			Datacenter:        sldatatypes.Datacenter{Name: "fake-datacenter"},
+			HourlyBillingFlag: true,
+			LocalDiskFlag:     true,
+			VmNamePrefix:      "bosh-test",
+			SshKeys:           []sldatatypes.SshKey{{Id: 74826}},
+			PrimaryNetworkComponent: sldatatypes.PrimaryNetworkComponent{
+				NetworkVlan: sldatatypes.NetworkVlan{Id: 524956}},
+			PrimaryBackendNetworkComponent: sldatatypes.PrimaryBackendNetworkComponent{
+				NetworkVlan: sldatatypes.NetworkVlan{Id: 524956}},
+		}
+
+		networks = map[string]Network{
+			"fake-network0": Network{
+				Type:            "dynamic",
+				Netmask:         "fake-Netmask",
+				Gateway:         "fake-Gateway",
+				DNS:             []string{"fake-dns0"},
+				Default:         []string{},
+				Preconfigured:   true,
+				CloudProperties: map[string]interface{}{},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(server.Close()).To(Succeed())
+	})
+
+	addFreeVM := func(cid int32) {
+		Expect(server.Add(models.VM{
+			Cid:         cid,
+			CPU:         4,
+			MemoryMb:    2048,
+			PrivateVlan: 524956,
+			PublicVlan:  524956,
+			State:       models.StateFree,
+		})).To(Succeed())
+	}
+
+	// newCreator returns a pool creator with a SoftLayer client of its own, set up to OS reload the
+	// vm it orders, and the finder that creator looks the vm up with
+	newCreator := func() (VMCreator, *fakescommon.FakeVMFinder, bslcstem.SoftLayerStemcell) {
+		softLayerClient := fakeslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
+		setFakeSoftlayerClientCreateObjectTestFixturesWithEphemeralDiskSize_OS_Reload(softLayerClient)
+
+		vmFinder := &fakescommon.FakeVMFinder{}
+		vmFinder.FindStub = func(id int) (VM, bool, error) {
+			vm := &fakescommon.FakeVM{}
+			vm.IDReturns(id)
+			return vm, true, nil
+		}
+
+		featureOptions := FeatureOptions{
+			EnablePool:        true,
+			WaitPolicies:      slh.DefaultWaitPolicies(),
+			PoolLeaseDuration: poolLeaseDuration,
+		}
+		creator := NewSoftLayerPoolCreator(vmFinder, server.Client(), softLayerClient, AgentOptions{Mbus: "fake-mbus"}, featureOptions, RegistryOptions{}, logger)
+		stemcell := bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, slh.DefaultWaitPolicies(), logger)
+
+		return creator, vmFinder, stemcell
+	}
+
+	Describe("creating from the pool", func() {
+		It("OS reloads the free vm the pool hands out and marks it using", func() {
+			addFreeVM(1234567)
+			creator, vmFinder, stemcell := newCreator()
+
+			vm, err := creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vm.ID()).To(Equal(1234567))
+			Expect(vmFinder.FindArgsForCall(0)).To(Equal(1234567))
+
+			vms := server.VMs()
+			Expect(vms).To(HaveLen(1))
+			Expect(vms[0].State).To(Equal(models.StateUsing))
+			Expect(vms[0].Hostname).To(Equal("bosh-test.fake-domain.com"))
+		})
+
+		It("hands each free vm to only one of concurrent creators ordering the same filter", func() {
+			addFreeVM(1234567)
+			addFreeVM(1234568)
+
+			creators := 5
+			ordered := make([]int, creators)
+			errs := make([]error, creators)
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < creators; i++ {
+				creator, vmFinder, stemcell := newCreator()
+
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					_, errs[i] = creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+					if vmFinder.FindCallCount() > 0 {
+						ordered[i] = vmFinder.FindArgsForCall(0)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			handedOut := []int{}
+			for i := 0; i < creators; i++ {
+				if ordered[i] != 0 {
+					Expect(errs[i]).ToNot(HaveOccurred())
+					handedOut = append(handedOut, ordered[i])
+				} else {
+					// The pool has no vm left for this creator, which goes on to order one in
+					// SoftLayer, where the fixtures of an OS reload fail it
+					Expect(errs[i]).To(HaveOccurred())
+					Expect(errs[i].Error()).To(ContainSubstring("Creating vm in SoftLayer"))
+				}
+			}
+			Expect(handedOut).To(ConsistOf(1234567, 1234568))
+
+			for _, vm := range server.VMs() {
+				Expect(vm.State).To(Equal(models.StateUsing))
+			}
+		})
+	})
+
+	Describe("leasing vms of the pool", func() {
+		It("reclaims a vm left provisioning by a CPI that stopped, and orders it", func() {
+			Expect(server.Add(models.VM{
+				Cid:         1234567,
+				CPU:         4,
+				MemoryMb:    2048,
+				PrivateVlan: 524956,
+				PublicVlan:  524956,
+				State:       models.StateProvisioning,
+				Lease: &models.Lease{
+					Holder: "stopped-agent-id",
+					Expiry: strfmt.DateTime(time.Now().Add(-time.Minute)),
+				},
+			})).To(Succeed())
+			creator, vmFinder, stemcell := newCreator()
+
+			_, err := creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmFinder.FindArgsForCall(0)).To(Equal(1234567))
+			Expect(server.VMs()[0].State).To(Equal(models.StateUsing))
+		})
+
+		It("leaves a vm provisioning by a running CPI alone", func() {
+			Expect(server.Add(models.VM{
+				Cid:        1234567,
+				CPU:        4,
+				State:      models.StateProvisioning,
+				ModifyDate: strfmt.DateTime(time.Now()),
+			})).To(Succeed())
+			creator, _, stemcell := newCreator()
+
+			_, err := creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+			Expect(err).To(HaveOccurred())
+			Expect(server.VMs()[0].State).To(Equal(models.StateProvisioning))
+		})
+
+		It("leaves a vm provisioning without a lease alone, however long ago it was modified", func() {
+			Expect(server.Add(models.VM{
+				Cid:        1234567,
+				CPU:        4,
+				State:      models.StateProvisioning,
+				ModifyDate: strfmt.DateTime(time.Now().Add(-time.Hour)),
+			})).To(Succeed())
+			creator, _, stemcell := newCreator()
+
+			_, err := creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+			Expect(err).To(HaveOccurred())
+			Expect(server.VMs()[0].State).To(Equal(models.StateProvisioning))
+		})
+
+		It("orders from a pool server without lease support", func() {
+			Expect(server.Close()).To(Succeed())
+			var err error
+			server, err = poolserver.New(poolserver.Options{WithoutLeases: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.Start()).To(Succeed())
+
+			poolLeaseDuration = 1
+			addFreeVM(1234567)
+			creator, vmFinder, stemcell := newCreator()
+
+			find := vmFinder.FindStub
+			vmFinder.FindStub = func(id int) (VM, bool, error) {
+				time.Sleep(700 * time.Millisecond)
+				return find(id)
+			}
+
+			_, err = creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.VMs()[0].State).To(Equal(models.StateUsing))
+		})
+
+		It("renews the lease of the agent while the vm is OS reloaded", func() {
+			poolLeaseDuration = 1
+			addFreeVM(1234567)
+			creator, vmFinder, stemcell := newCreator()
+
+			find := vmFinder.FindStub
+			vmFinder.FindStub = func(id int) (VM, bool, error) {
+				leased := server.VMs()[0]
+				Expect(leased.State).To(Equal(models.StateProvisioning))
+				Expect(leased.Lease.Holder).To(Equal("fake-agent-id"))
+
+				Eventually(func() time.Time {
+					return time.Time(server.VMs()[0].Lease.Expiry)
+				}).Should(BeTemporally(">", time.Time(leased.Lease.Expiry)))
+
+				return find(id)
+			}
+
+			_, err := creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.VMs()[0].State).To(Equal(models.StateUsing))
+			Expect(server.VMs()[0].Lease).To(BeNil())
+		})
+
+		It("fails without giving back a vm whose lease was lost during the OS reload", func() {
+			poolLeaseDuration = 1
+			addFreeVM(1234567)
+			creator, vmFinder, stemcell := newCreator()
+
+			find := vmFinder.FindStub
+			vmFinder.FindStub = func(id int) (VM, bool, error) {
+				client := server.Client()
+				_, err := client.UpdateVMWithState(operations.NewUpdateVMWithStateParams().WithCid(1234567).WithBody(&models.VMState{State: models.StateFree}))
+				Expect(err).ToNot(HaveOccurred())
+				_, err = client.OrderVMByFilter(operations.NewOrderVMByFilterParams().WithBody(&models.VMFilter{Cid: 1234567, Lease: &models.Lease{Holder: "other-agent-id", Duration: 60}}))
+				Expect(err).ToNot(HaveOccurred())
+
+				time.Sleep(700 * time.Millisecond)
+				return find(id)
+			}
+
+			_, err := creator.Create("fake-agent-id", stemcell, cloudProps, networks, Environment{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Lease of fake-agent-id on vm 1234567 in pool was lost"))
+
+			vms := server.VMs()
+			Expect(vms[0].State).To(Equal(models.StateProvisioning))
+			Expect(vms[0].Lease.Holder).To(Equal("other-agent-id"))
+		})
+	})
+
+	Describe("deleting into the pool", func() {
+		It("frees a vm of the pool", func() {
+			addFreeVM(1234567)
+			_, err := server.Client().UpdateVMWithState(operations.NewUpdateVMWithStateParams().WithCid(1234567).WithBody(&models.VMState{State: models.StateUsing}))
+			Expect(err).ToNot(HaveOccurred())
+
+			softLayerClient := fakeslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
+			deleter := NewSoftLayerPoolDeleter(server.Client(), softLayerClient, logger)
+
+			Expect(deleter.Delete(1234567)).To(Succeed())
+			Expect(server.VMs()[0].State).To(Equal(models.StateFree))
+		})
+
+		It("adds a vm missing from the pool as free", func() {
+			softLayerClient := fakeslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
+			testhelpers.SetTestFixtureForFakeSoftLayerClient(softLayerClient, "SoftLayer_Virtual_Guest_Service_getObject.json")
+			deleter := NewSoftLayerPoolDeleter(server.Client(), softLayerClient, logger)
+
+			Expect(deleter.Delete(1234567)).To(Succeed())
+
+			vms := server.VMs()
+			Expect(vms).To(HaveLen(1))
+			Expect(vms[0].Cid).To(Equal(int32(1234567)))
+			Expect(vms[0].State).To(Equal(models.StateFree))
+		})
+	})
+})
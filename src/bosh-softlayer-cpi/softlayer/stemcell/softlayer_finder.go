@@ -11,17 +11,16 @@ import (
 	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
 
 	"fmt"
-	"github.com/pivotal-golang/clock"
-	"strings"
 )
 
 type SoftLayerStemcellFinder struct {
-	client sl.Client
-	logger boshlog.Logger
+	client       sl.Client
+	waitPolicies slh.WaitPolicies
+	logger       boshlog.Logger
 }
 
-func NewSoftLayerStemcellFinder(client sl.Client, logger boshlog.Logger) SoftLayerStemcellFinder {
-	return SoftLayerStemcellFinder{client: client, logger: logger}
+func NewSoftLayerStemcellFinder(client sl.Client, waitPolicies slh.WaitPolicies, logger boshlog.Logger) SoftLayerStemcellFinder {
+	return SoftLayerStemcellFinder{client: client, waitPolicies: waitPolicies, logger: logger}
 }
 
 func (f SoftLayerStemcellFinder) FindById(id int) (Stemcell, error) {
@@ -32,21 +31,19 @@ func (f SoftLayerStemcellFinder) FindById(id int) (Stemcell, error) {
 		func() (bool, error) {
 			vgbdtg, err = vgdtgService.GetObject(id)
 			if err != nil {
-				if strings.Contains(err.Error(), "404") {
-					return false, bosherr.Error(fmt.Sprintf("Failed to get VirtualGuestBlockDeviceTemplateGroup with id `%d`", id))
-				} else {
-					return true, bosherr.Error(fmt.Sprintf("The VirtualGuestBlockDeviceTemplateGroup with id `%d` does not exist", id))
+				if slh.IsObjectNotFoundError(err) {
+					return false, bosherr.Error(fmt.Sprintf("The VirtualGuestBlockDeviceTemplateGroup with id `%d` does not exist", id))
 				}
+
+				return slh.IsAPIErrorRetryable(err), bosherr.WrapErrorf(err, "Failed to get VirtualGuestBlockDeviceTemplateGroup with id `%d`", id)
 			}
 
 			return false, nil
 		})
-	timeService := clock.NewClock()
-	timeoutRetryStrategy := boshretry.NewTimeoutRetryStrategy(slh.TIMEOUT, slh.POLLING_INTERVAL, execStmtRetryable, timeService, boshlog.NewLogger(boshlog.LevelInfo))
-	err = timeoutRetryStrategy.Try()
+	err = f.waitPolicies.StemcellLookup.RetryStrategy(execStmtRetryable, boshlog.NewLogger(boshlog.LevelInfo)).Try()
 	if err != nil {
-		return SoftLayerStemcell{}, bosherr.Error(fmt.Sprintf("Can not find VirtualGuestBlockDeviceTemplateGroup with id `%d`", id))
+		return SoftLayerStemcell{}, bosherr.WrapErrorf(err, "Can not find VirtualGuestBlockDeviceTemplateGroup with id `%d`", id)
 	}
 
-	return NewSoftLayerStemcell(vgbdtg.Id, vgbdtg.GlobalIdentifier, f.client, f.logger), nil
+	return NewSoftLayerStemcell(vgbdtg.Id, vgbdtg.GlobalIdentifier, f.client, f.waitPolicies, f.logger), nil
 }
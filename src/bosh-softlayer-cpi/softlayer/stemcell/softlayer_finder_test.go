@@ -21,16 +21,17 @@ var _ = Describe("SoftLayerFinder", func() {
 		logger           boshlog.Logger
 		finder           SoftLayerStemcellFinder
 		expectedStemcell SoftLayerStemcell
+		waitPolicies     slhelper.WaitPolicies
 	)
 
 	BeforeEach(func() {
 		softLayerClient = fakesslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
 
-		slhelper.TIMEOUT = 10 * time.Millisecond
-		slhelper.POLLING_INTERVAL = 2 * time.Millisecond
+		waitPolicy := slhelper.NewWaitPolicy(10*time.Millisecond, 2*time.Millisecond)
+		waitPolicies = slhelper.WaitPolicies{StemcellLookup: waitPolicy, Delete: waitPolicy}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
-		expectedStemcell = NewSoftLayerStemcell(200150, "8071601b-5ee1-483e-a9e8-6e5582dcb9f7", softLayerClient, logger)
+		expectedStemcell = NewSoftLayerStemcell(200150, "8071601b-5ee1-483e-a9e8-6e5582dcb9f7", softLayerClient, waitPolicies, logger)
 	})
 
 	Describe("FindById", func() {
@@ -39,7 +40,7 @@ var _ = Describe("SoftLayerFinder", func() {
 				testhelpers.SetTestFixtureForFakeSoftLayerClient(softLayerClient, "SoftLayer_Virtual_Guest_Block_Device_Template_Group_Service_getObject.json")
 
 				softLayerClient.FakeHttpClient.DoRawHttpRequestInt = 200
-				finder = NewSoftLayerStemcellFinder(softLayerClient, logger)
+				finder = NewSoftLayerStemcellFinder(softLayerClient, waitPolicies, logger)
 
 				stemcell, err := finder.FindById(200150)
 				Expect(err).ToNot(HaveOccurred())
@@ -50,7 +51,7 @@ var _ = Describe("SoftLayerFinder", func() {
 		Context("Failed if the stemcell does not exists, 404 error returned", func() {
 			It("returns error if stemcell does not exist", func() {
 				softLayerClient.FakeHttpClient.DoRawHttpRequestInt = 404
-				finder = NewSoftLayerStemcellFinder(softLayerClient, logger)
+				finder = NewSoftLayerStemcellFinder(softLayerClient, waitPolicies, logger)
 
 				_, err := finder.FindById(200150)
 				Expect(err).To(HaveOccurred())
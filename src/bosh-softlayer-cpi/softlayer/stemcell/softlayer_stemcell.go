@@ -9,7 +9,6 @@ import (
 	sl "github.com/maximilien/softlayer-go/softlayer"
 
 	"fmt"
-	"time"
 )
 
 type SoftLayerStemcell struct {
@@ -19,13 +18,11 @@ type SoftLayerStemcell struct {
 	softLayerFinder SoftLayerStemcellFinder
 }
 
-func NewSoftLayerStemcell(id int, uuid string, softLayerClient sl.Client, logger boshlog.Logger) SoftLayerStemcell {
-	slh.TIMEOUT = 60 * time.Minute
-	slh.POLLING_INTERVAL = 10 * time.Second
-
+func NewSoftLayerStemcell(id int, uuid string, softLayerClient sl.Client, waitPolicies slh.WaitPolicies, logger boshlog.Logger) SoftLayerStemcell {
 	softLayerFinder := SoftLayerStemcellFinder{
-		client: softLayerClient,
-		logger: logger,
+		client:       softLayerClient,
+		waitPolicies: waitPolicies,
+		logger:       logger,
 	}
 
 	return SoftLayerStemcell{
@@ -50,7 +47,7 @@ func (s SoftLayerStemcell) Delete() error {
 		return bosherr.WrapError(err, "Deleting VirtualGuestBlockDeviceTemplateGroup from service")
 	}
 
-	err = slh.WaitForVirtualGuestToHaveNoRunningTransactions(s.softLayerFinder.client, s.id)
+	err = slh.WaitForVirtualGuestToHaveNoRunningTransactions(s.softLayerFinder.client, s.id, s.softLayerFinder.waitPolicies.Delete)
 	if err != nil {
 		return bosherr.WrapError(err, fmt.Sprintf("Waiting for VirtualGuest `%d` to have no pending transactions", s.id))
 	}
@@ -26,10 +26,9 @@ var _ = Describe("SoftLayerStemcell", func() {
 
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
-		stemcell = NewSoftLayerStemcell(1234, "fake-stemcell-uuid", fakeSoftLayerClient, logger)
+		waitPolicy := slhelper.NewWaitPolicy(10*time.Millisecond, 2*time.Millisecond)
 
-		slhelper.TIMEOUT = 10 * time.Millisecond
-		slhelper.POLLING_INTERVAL = 2 * time.Millisecond
+		stemcell = NewSoftLayerStemcell(1234, "fake-stemcell-uuid", fakeSoftLayerClient, slhelper.WaitPolicies{StemcellLookup: waitPolicy, Delete: waitPolicy}, logger)
 	})
 
 	Describe("#Delete", func() {
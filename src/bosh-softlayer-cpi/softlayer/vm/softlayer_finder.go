@@ -18,14 +18,18 @@ type softLayerFinder struct {
 	softLayerClient        sl.Client
 	baremetalClient        bmscl.BmpClient
 	agentEnvServiceFactory AgentEnvServiceFactory
+	waitPolicies           slhelper.WaitPolicies
+	diskAttachMode         string
 	logger                 boshlog.Logger
 }
 
-func NewSoftLayerFinder(softLayerClient sl.Client, baremetalClient bmscl.BmpClient, agentEnvServiceFactory AgentEnvServiceFactory, logger boshlog.Logger) VMFinder {
+func NewSoftLayerFinder(softLayerClient sl.Client, baremetalClient bmscl.BmpClient, agentEnvServiceFactory AgentEnvServiceFactory, waitPolicies slhelper.WaitPolicies, diskAttachMode string, logger boshlog.Logger) VMFinder {
 	return &softLayerFinder{
 		softLayerClient:        softLayerClient,
 		baremetalClient:        baremetalClient,
 		agentEnvServiceFactory: agentEnvServiceFactory,
+		waitPolicies:           waitPolicies,
+		diskAttachMode:         diskAttachMode,
 		logger:                 logger,
 	}
 }
@@ -33,14 +37,25 @@ func NewSoftLayerFinder(softLayerClient sl.Client, baremetalClient bmscl.BmpClie
 func (f *softLayerFinder) Find(vmID int) (VM, bool, error) {
 	var vm VM
 	virtualGuest, err := slhelper.GetObjectDetailsOnVirtualGuest(f.softLayerClient, vmID)
-	if err != nil {
+	if err != nil && !slhelper.IsObjectNotFoundError(err) {
+		return nil, false, bosherr.WrapErrorf(err, "Failed to find VM %d", vmID)
+	}
+
+	if err == nil && virtualGuest.Id != 0 {
+		vm = NewSoftLayerVirtualGuest(virtualGuest, f.softLayerClient, util.GetSshClient(), f.waitPolicies, f.diskAttachMode, f.logger)
+	} else {
 		hardware, err := slhelper.GetObjectDetailsOnHardware(f.softLayerClient, vmID)
 		if err != nil {
-			return nil, false, bosherr.Errorf("Failed to find VM or Baremetal %d", vmID)
+			if slhelper.IsObjectNotFoundError(err) {
+				return nil, false, nil
+			}
+			return nil, false, bosherr.WrapErrorf(err, "Failed to find Baremetal %d", vmID)
 		}
-		vm = slhw.NewSoftLayerHardware(hardware, f.softLayerClient, f.baremetalClient, util.GetSshClient(), f.logger)
-	} else {
-		vm = NewSoftLayerVirtualGuest(virtualGuest, f.softLayerClient, util.GetSshClient(), f.logger)
+
+		if hardware.Id == 0 {
+			return nil, false, nil
+		}
+		vm = slhw.NewSoftLayerHardware(hardware, f.softLayerClient, f.baremetalClient, util.GetSshClient(), f.waitPolicies, f.diskAttachMode, f.logger)
 	}
 
 	softlayerFileService := NewSoftlayerFileService(util.GetSshClient(), f.logger)
@@ -12,6 +12,7 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	fakescommon "bosh-softlayer-cpi/softlayer/common/fakes"
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
 	fakebmsclient "github.com/cloudfoundry-community/bosh-softlayer-tools/clients/fakes"
 	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
 )
@@ -35,6 +36,8 @@ var _ = Describe("SoftLayerFinder", func() {
 			softLayerClient,
 			baremetalClient,
 			agentEnvServiceFactory,
+			slh.DefaultWaitPolicies(),
+			DiskAttachModeSSH,
 			logger,
 		)
 	})
@@ -58,5 +61,66 @@ var _ = Describe("SoftLayerFinder", func() {
 			})
 		})
 
+		Context("when the VM ID belongs to a baremetal server", func() {
+			BeforeEach(func() {
+				vmID = 1234567
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Virtual_Guest_Service_getEmptyObject.json",
+					"SoftLayer_Hardware_Service_getObject.json",
+				})
+			})
+
+			It("finds and returns the baremetal server", func() {
+				vm, found, err := finder.Find(vmID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue(), "found VM")
+				Expect(vm.ID()).To(Equal(vmID), "VM ID match")
+			})
+		})
+
+		Context("when neither a virtual guest nor a baremetal server exists", func() {
+			BeforeEach(func() {
+				vmID = 1234567
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Virtual_Guest_Service_getEmptyObject.json",
+					"SoftLayer_Hardware_Service_getObject_None_Exist.json",
+				})
+			})
+
+			It("returns found as false without error", func() {
+				vm, found, err := finder.Find(vmID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+				Expect(vm).To(BeNil())
+			})
+		})
+
+		Context("when SoftLayer returns 404", func() {
+			BeforeEach(func() {
+				vmID = 1234567
+				softLayerClient.FakeHttpClient.DoRawHttpRequestInt = 404
+				testhelpers.SetTestFixtureForFakeSoftLayerClient(softLayerClient, "SoftLayer_Virtual_Guest_Service_getEmptyObject.json")
+			})
+
+			It("returns found as false without error", func() {
+				_, found, err := finder.Find(vmID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+		})
+
+		Context("when SoftLayer fails with another error", func() {
+			BeforeEach(func() {
+				vmID = 1234567
+				softLayerClient.FakeHttpClient.DoRawHttpRequestInt = 500
+				testhelpers.SetTestFixtureForFakeSoftLayerClient(softLayerClient, "SoftLayer_Virtual_Guest_Service_getEmptyObject.json")
+			})
+
+			It("returns an error", func() {
+				_, found, err := finder.Find(vmID)
+				Expect(err).To(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+		})
 	})
 })
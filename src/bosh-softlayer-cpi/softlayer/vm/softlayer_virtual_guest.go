@@ -6,11 +6,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
-	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -38,13 +36,13 @@ type softLayerVirtualGuest struct {
 
 	agentEnvService AgentEnvService
 
+	waitPolicies   slh.WaitPolicies
+	diskAttachMode string
+
 	logger boshlog.Logger
 }
 
-func NewSoftLayerVirtualGuest(virtualGuest datatypes.SoftLayer_Virtual_Guest, softLayerClient sl.Client, sshClient util.SshClient, logger boshlog.Logger) VM {
-	slh.TIMEOUT = 60 * time.Minute
-	slh.POLLING_INTERVAL = 10 * time.Second
-
+func NewSoftLayerVirtualGuest(virtualGuest datatypes.SoftLayer_Virtual_Guest, softLayerClient sl.Client, sshClient util.SshClient, waitPolicies slh.WaitPolicies, diskAttachMode string, logger boshlog.Logger) VM {
 	return &softLayerVirtualGuest{
 		id: virtualGuest.Id,
 
@@ -53,6 +51,9 @@ func NewSoftLayerVirtualGuest(virtualGuest datatypes.SoftLayer_Virtual_Guest, so
 		softLayerClient: softLayerClient,
 		sshClient:       sshClient,
 
+		waitPolicies:   waitPolicies,
+		diskAttachMode: diskAttachMode,
+
 		logger: logger,
 	}
 }
@@ -86,6 +87,10 @@ func (vm *softLayerVirtualGuest) GetFullyQualifiedDomainName() string {
 	return vm.virtualGuest.FullyQualifiedDomainName
 }
 
+func (vm *softLayerVirtualGuest) GetAllowedHostType() string {
+	return bslcdisk.ALLOWED_VIRTUAL_GUESTS
+}
+
 func (vm *softLayerVirtualGuest) Delete(agentID string) error {
 	return nil
 }
@@ -134,7 +139,7 @@ func (vm *softLayerVirtualGuest) ReloadOS(stemcell bslcstem.Stemcell) error {
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	err = slh.WaitForVirtualGuestToHaveNoRunningTransactions(vm.softLayerClient, vm.ID())
+	err = slh.WaitForVirtualGuestToHaveNoRunningTransactions(vm.softLayerClient, vm.ID(), vm.waitPolicies.OSReload)
 	if err != nil {
 		return bosherr.WrapError(err, fmt.Sprintf("Waiting for VirtualGuest %d to have no pending transactions before os reload", vm.ID()))
 	}
@@ -163,16 +168,8 @@ func (vm *softLayerVirtualGuest) SetMetadata(vmMetadata VMMetadata) error {
 		return err
 	}
 
-	virtualGuestService, err := vm.softLayerClient.GetSoftLayer_Virtual_Guest_Service()
-	if err != nil {
-		return bosherr.WrapError(err, "Creating SoftLayer VirtualGuestService from client")
-	}
-
-	success, err := virtualGuestService.SetTags(vm.ID(), tags)
-	if !success {
-		return bosherr.WrapErrorf(err, "Settings tags on SoftLayer VirtualGuest `%d`", vm.ID())
-	}
-
+	// Keep the agent ID tag create_vm relies on, and the tags the metadata does not set
+	err = slh.SetVirtualGuestTags(vm.softLayerClient, vm.ID(), tags)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Settings tags on SoftLayer VirtualGuest `%d`", vm.ID())
 	}
@@ -227,68 +224,67 @@ func (vm *softLayerVirtualGuest) ConfigureNetworks2(networks Networks) error {
 	return nil
 }
 
-func (vm *softLayerVirtualGuest) AttachDisk(disk bslcdisk.Disk) error {
+func (vm *softLayerVirtualGuest) AttachDisk(disk bslcdisk.Disk) (interface{}, error) {
 	volume, err := vm.fetchIscsiVolume(disk.ID())
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d`", disk.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d`", disk.ID()))
 	}
 
 	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
 	if err != nil {
-		return bosherr.WrapError(err, "Cannot get network storage service.")
+		return nil, bosherr.WrapError(err, "Cannot get network storage service.")
 	}
 
 	allowed, err := networkStorageService.HasAllowedVirtualGuest(disk.ID(), vm.ID())
 
-	totalTime := time.Duration(0)
 	if err == nil && allowed == false {
-		for totalTime < slh.TIMEOUT {
+		granted, err := vm.waitPolicies.DiskAttach.Wait(func() (bool, error) {
 			allowable, err := networkStorageService.AttachNetworkStorageToVirtualGuest(vm.virtualGuest, disk.ID())
 			if err != nil {
-				if !strings.Contains(err.Error(), "please try again after Volume Provisioning is complete") {
-					return bosherr.WrapError(err, fmt.Sprintf("Granting volume access to virtual guest %d", vm.ID()))
-				}
-			} else {
-				if allowable {
-					break
+				if !slh.IsAPIErrorRetryable(err) {
+					return false, bosherr.WrapError(err, fmt.Sprintf("Granting volume access to virtual guest %d", vm.ID()))
 				}
+
+				return false, nil
 			}
 
-			totalTime += slh.POLLING_INTERVAL
-			time.Sleep(slh.POLLING_INTERVAL)
+			return allowable, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !granted {
+			return nil, bosherr.Error("Waiting for grantting access to virutal guest TIME OUT!")
 		}
 	}
-	if totalTime >= slh.TIMEOUT {
-		return bosherr.Error("Waiting for grantting access to virutal guest TIME OUT!")
+
+	if vm.diskAttachMode == DiskAttachModeAgent {
+		return vm.attachDiskThroughAgent(volume)
 	}
 
 	hasMultiPath, err := vm.hasMulitPathToolBasedOnShellScript()
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to get multipath information from virtual guest `%d`", vm.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to get multipath information from virtual guest `%d`", vm.ID()))
 	}
 
-	deviceName, err := vm.waitForVolumeAttached(volume, hasMultiPath)
+	devicePath, err := vm.waitForVolumeAttached(volume, hasMultiPath)
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to attach volume `%d` to virtual guest `%d`", disk.ID(), vm.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to attach volume `%d` to virtual guest `%d`", disk.ID(), vm.ID()))
 	}
 	oldAgentEnv, err := vm.agentEnvService.Fetch()
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to unmarshal userdata from virutal guest with id: %d.", vm.ID())
+		return nil, bosherr.WrapErrorf(err, "Failed to unmarshal userdata from virutal guest with id: %d.", vm.ID())
 	}
 
-	var newAgentEnv AgentEnv
-	if hasMultiPath {
-		newAgentEnv = oldAgentEnv.AttachPersistentDisk(strconv.Itoa(disk.ID()), "/dev/mapper/"+deviceName)
-	} else {
-		newAgentEnv = oldAgentEnv.AttachPersistentDisk(strconv.Itoa(disk.ID()), "/dev/"+deviceName)
-	}
+	newAgentEnv := oldAgentEnv.AttachPersistentDisk(strconv.Itoa(disk.ID()), devicePath)
 
 	err = vm.agentEnvService.Update(newAgentEnv)
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on VirtualGuest with id: `%d`", vm.ID()))
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on VirtualGuest with id: `%d`", vm.ID()))
 	}
 
-	return nil
+	return devicePath, nil
 }
 
 func (vm *softLayerVirtualGuest) DetachDisk(disk bslcdisk.Disk) error {
@@ -297,59 +293,69 @@ func (vm *softLayerVirtualGuest) DetachDisk(disk bslcdisk.Disk) error {
 		return bosherr.WrapError(err, fmt.Sprintf("failed in disk `%d`", disk.ID()))
 	}
 
+	if vm.diskAttachMode == DiskAttachModeAgent {
+		// Logging out of the iSCSI target of the disk is left to the agent
+		_, err = vm.revokeVolumeAccess(disk)
+		return err
+	}
+
 	hasMultiPath, err := vm.hasMulitPathToolBasedOnShellScript()
 	if err != nil {
 		return bosherr.WrapError(err, fmt.Sprintf("Failed to get multipath information from virtual guest `%d`", vm.ID()))
 	}
 
-	err = vm.detachVolumeBasedOnShellScript(volume, hasMultiPath)
+	// Detaching unmounts /var/vcap/store, so the disk mounted there is mounted again once found anew
+	storeDiskID, storePartitionSuffix, err := vm.findStoreDisk()
 	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to detach volume with id %d from virtual guest with id: %d.", volume.Id, vm.ID())
+		return bosherr.WrapError(err, "Finding the disk mounted at /var/vcap/store")
 	}
 
-	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	err = vm.detachVolumeBasedOnShellScript(volume, hasMultiPath)
 	if err != nil {
-		return bosherr.WrapError(err, "Cannot get network storage service.")
+		return bosherr.WrapErrorf(err, "Failed to detach volume with id %d from virtual guest with id: %d.", volume.Id, vm.ID())
 	}
 
-	allowed, err := networkStorageService.HasAllowedVirtualGuest(disk.ID(), vm.ID())
-	if err == nil && allowed == true {
-		err = networkStorageService.DetachNetworkStorageFromVirtualGuest(vm.virtualGuest, disk.ID())
-	}
+	newAgentEnv, err := vm.revokeVolumeAccess(disk)
 	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Failed to revoke access of disk `%d` from virtual gusest `%d`", disk.ID(), vm.ID()))
+		return err
 	}
 
-	oldAgentEnv, err := vm.agentEnvService.Fetch()
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to unmarshal userdata from virutal guest with id: %d.", vm.ID())
-	}
+	// Detaching logs out of every iSCSI session, so the volumes left are logged in to again, and
+	// their devices found anew, since a device without multipath may come back under another name
+	for key := range newAgentEnv.Disks.Persistent {
+		devicePath := newAgentEnv.Disks.Persistent.DevicePath(key)
+		leftDiskId, err := strconv.Atoi(key)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to transfer disk id %s from string to int", key))
+		}
+		vm.logger.Debug(SOFTLAYER_VM_LOG_TAG, "Left Disk Id %d", leftDiskId)
+		vm.logger.Debug(SOFTLAYER_VM_LOG_TAG, "Left Disk device path %s", devicePath)
+		volume, err := vm.fetchIscsiVolume(leftDiskId)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d` and virtual guest `%d`", disk.ID(), vm.ID()))
+		}
 
-	newAgentEnv := oldAgentEnv.DetachPersistentDisk(strconv.Itoa(disk.ID()))
-	err = vm.UpdateAgentEnv(newAgentEnv)
-	if err != nil {
-		return bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on VirtualGuest with id: `%d`", vm.ID()))
-	}
+		discovery, err := vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to reattach volume `%s` to virtual guest `%d`", key, vm.ID()))
+		}
 
-	if len(newAgentEnv.Disks.Persistent) == 1 {
-		for key, devicePath := range newAgentEnv.Disks.Persistent {
-			leftDiskId, err := strconv.Atoi(key)
-			if err != nil {
-				return bosherr.WrapError(err, fmt.Sprintf("Failed to transfer disk id %s from string to int", key))
-			}
-			vm.logger.Debug(SOFTLAYER_VM_LOG_TAG, "Left Disk Id %d", leftDiskId)
-			vm.logger.Debug(SOFTLAYER_VM_LOG_TAG, "Left Disk device path %s", devicePath)
-			volume, err := vm.fetchIscsiVolume(leftDiskId)
-			if err != nil {
-				return bosherr.WrapError(err, fmt.Sprintf("Failed to fetch disk `%d` and virtual gusest `%d`", disk.ID(), vm.ID()))
-			}
+		newDevicePath, err := vm.findVolumeDevice(volume, discovery, hasMultiPath)
+		if err != nil {
+			return bosherr.WrapError(err, fmt.Sprintf("Failed to reattach volume `%s` to virtual guest `%d`", key, vm.ID()))
+		}
 
-			_, err = vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
+		if newDevicePath != devicePath {
+			vm.logger.Info(SOFTLAYER_VM_LOG_TAG, fmt.Sprintf("Device of disk %s moved from %s to %s", key, devicePath, newDevicePath))
+			newAgentEnv = newAgentEnv.AttachPersistentDisk(key, newDevicePath)
+			err = vm.UpdateAgentEnv(newAgentEnv)
 			if err != nil {
-				return bosherr.WrapError(err, fmt.Sprintf("Failed to reattach volume `%s` to virtual guest `%d`", key, vm.ID()))
+				return bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on virtual guest with id: `%d`", vm.ID()))
 			}
+		}
 
-			command := fmt.Sprintf("sleep 5; mount %s-part1 /var/vcap/store", devicePath)
+		if key == storeDiskID {
+			command := fmt.Sprintf("sleep 5; mount %s%s /var/vcap/store", newDevicePath, storePartitionSuffix)
 			_, err = vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
 			if err != nil {
 				return bosherr.WrapError(err, "mount /var/vcap/store")
@@ -360,6 +366,26 @@ func (vm *softLayerVirtualGuest) DetachDisk(disk bslcdisk.Disk) error {
 	return nil
 }
 
+func (vm *softLayerVirtualGuest) RescanDisk(disk bslcdisk.Disk) error {
+	if vm.diskAttachMode == DiskAttachModeAgent {
+		vm.logger.Warn(SOFTLAYER_VM_LOG_TAG, "Disk %d is attached by the agent, which picks up its new size when it logs in to it again", disk.ID())
+		return nil
+	}
+
+	agentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to unmarshal userdata from virtual guest with id: %d.", vm.ID())
+	}
+
+	devicePath := agentEnv.Disks.Persistent.DevicePath(strconv.Itoa(disk.ID()))
+	err = RescanIscsiDevice(vm.sshClient, ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), devicePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to rescan disk `%d` on virtual guest `%d`", disk.ID(), vm.ID())
+	}
+
+	return nil
+}
+
 func (vm *softLayerVirtualGuest) UpdateAgentEnv(agentEnv AgentEnv) error {
 	return vm.agentEnvService.Update(agentEnv)
 }
@@ -369,11 +395,67 @@ func (vm *softLayerVirtualGuest) DeleteAgentEnv() error {
 }
 
 // Private methods
+
+// attachDiskThroughAgent puts the iSCSI target of volume and the CHAP credentials of the virtual
+// guest into the agent env as the disk hint, so that the agent logs in to the target itself
+func (vm *softLayerVirtualGuest) attachDiskThroughAgent(volume datatypes.SoftLayer_Network_Storage) (interface{}, error) {
+	credential, err := vm.getAllowedHostCredential()
+	if err != nil {
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Failed to get iscsi host auth from virtual guest `%d`", vm.ID()))
+	}
+
+	diskSettings := NewPersistentDiskSettings(volume, credential)
+
+	oldAgentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Failed to unmarshal userdata from virutal guest with id: %d.", vm.ID())
+	}
+
+	newAgentEnv := oldAgentEnv.AttachPersistentDiskSettings(diskSettings.ID, diskSettings)
+
+	err = vm.agentEnvService.Update(newAgentEnv)
+	if err != nil {
+		return nil, bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on VirtualGuest with id: `%d`", vm.ID()))
+	}
+
+	return diskSettings, nil
+}
+
+// revokeVolumeAccess revokes the access of the virtual guest to disk and drops the disk from the
+// agent env, returning the agent env with the disks left
+func (vm *softLayerVirtualGuest) revokeVolumeAccess(disk bslcdisk.Disk) (AgentEnv, error) {
+	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapError(err, "Cannot get network storage service.")
+	}
+
+	allowed, err := networkStorageService.HasAllowedVirtualGuest(disk.ID(), vm.ID())
+	if err == nil && allowed == true {
+		err = networkStorageService.DetachNetworkStorageFromVirtualGuest(vm.virtualGuest, disk.ID())
+	}
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapError(err, fmt.Sprintf("Failed to revoke access of disk `%d` from virtual gusest `%d`", disk.ID(), vm.ID()))
+	}
+
+	oldAgentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapErrorf(err, "Failed to unmarshal userdata from virutal guest with id: %d.", vm.ID())
+	}
+
+	newAgentEnv := oldAgentEnv.DetachPersistentDisk(strconv.Itoa(disk.ID()))
+	err = vm.UpdateAgentEnv(newAgentEnv)
+	if err != nil {
+		return AgentEnv{}, bosherr.WrapError(err, fmt.Sprintf("Configuring userdata on VirtualGuest with id: `%d`", vm.ID()))
+	}
+
+	return newAgentEnv, nil
+}
+
 func (vm *softLayerVirtualGuest) extractTagsFromVMMetadata(vmMetadata VMMetadata) ([]string, error) {
 	tags := []string{}
 	status := ""
 	for key, value := range vmMetadata {
-		if key == "compiling" || key == "job" || key == "index" || key == "deployment" || key == "deleted" {
+		if key == "compiling" || key == "job" || key == "index" || key == "deployment" || key == "deleted" || key == "director_uuid" {
 			stringValue, err := value.(string)
 			if !err {
 				return []string{}, bosherr.Errorf("Cannot convert tags metadata value `%v` to string", value)
@@ -396,15 +478,6 @@ func (vm *softLayerVirtualGuest) parseTags(value string) []string {
 }
 
 func (vm *softLayerVirtualGuest) waitForVolumeAttached(volume datatypes.SoftLayer_Network_Storage, hasMultiPath bool) (string, error) {
-
-	oldDisks, err := vm.getIscsiDeviceNamesBasedOnShellScript(hasMultiPath)
-	if err != nil {
-		return "", bosherr.WrapError(err, fmt.Sprintf("Failed to get devices names from virtual guest `%d`", vm.ID()))
-	}
-	if len(oldDisks) > 2 {
-		return "", bosherr.Error(fmt.Sprintf("Too manay persistent disks attached to virtual guest `%d`", vm.ID()))
-	}
-
 	credential, err := vm.getAllowedHostCredential()
 	if err != nil {
 		return "", bosherr.WrapError(err, fmt.Sprintf("Failed to get iscsi host auth from virtual guest `%d`", vm.ID()))
@@ -430,45 +503,40 @@ func (vm *softLayerVirtualGuest) waitForVolumeAttached(volume datatypes.SoftLaye
 		return "", bosherr.WrapError(err, fmt.Sprintf("Failed to restart open iscsi from virtual guest `%d`", vm.ID()))
 	}
 
-	_, err = vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
+	discovery, err := vm.discoveryOpenIscsiTargetsBasedOnShellScript(volume)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Failed to attach volume with id %d to virtual guest with id: %d.", volume.Id, vm.ID())
 	}
 
-	var deviceName string
-	totalTime := time.Duration(0)
-	for totalTime < slh.TIMEOUT {
-		newDisks, err := vm.getIscsiDeviceNamesBasedOnShellScript(hasMultiPath)
-		if err != nil {
-			return "", bosherr.WrapError(err, fmt.Sprintf("Failed to get devices names from virtual guest `%d`", vm.ID()))
-		}
+	return vm.findVolumeDevice(volume, discovery, hasMultiPath)
+}
 
-		if len(oldDisks) == 0 {
-			if len(newDisks) > 0 {
-				deviceName = newDisks[0]
-				return deviceName, nil
-			}
-		}
+// findVolumeDevice waits for the device of volume, logged in to the targets in discovery, to show up
+func (vm *softLayerVirtualGuest) findVolumeDevice(volume datatypes.SoftLayer_Network_Storage, discovery string, hasMultiPath bool) (string, error) {
+	targets := IscsiTargets(discovery)
+	if len(targets) == 0 {
+		return "", bosherr.Errorf("No iSCSI targets discovered at `%s` for volume `%d`", volume.ServiceResourceBackendIpAddress, volume.Id)
+	}
+	vm.logger.Info(SOFTLAYER_VM_LOG_TAG, fmt.Sprintf("Volume %d is LUN %s on iSCSI targets %v", volume.Id, volume.LunId, targets))
 
-		var included bool
-		for _, newDisk := range newDisks {
-			for _, oldDisk := range oldDisks {
-				if strings.EqualFold(newDisk, oldDisk) {
-					included = true
-				}
-			}
-			if !included {
-				deviceName = newDisk
-			}
-			included = false
-		}
+	deviceFinder := NewIscsiDeviceFinder(vm.sshClient, ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), vm.logger)
 
-		if len(deviceName) > 0 {
-			return deviceName, nil
+	var devicePath string
+	found, err := vm.waitPolicies.DiskAttach.Wait(func() (bool, error) {
+		var err error
+		devicePath, err = deviceFinder.Find(targets, volume.LunId, hasMultiPath)
+		if err != nil {
+			return false, bosherr.WrapError(err, fmt.Sprintf("Failed to find device of volume `%d` on virtual guest `%d`", volume.Id, vm.ID()))
 		}
 
-		totalTime += slh.POLLING_INTERVAL
-		time.Sleep(slh.POLLING_INTERVAL)
+		return len(devicePath) > 0, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if found {
+		return devicePath, nil
 	}
 
 	return "", bosherr.Errorf("Failed to attach disk '%d' to virtual guest '%d'", volume.Id, vm.ID())
@@ -488,46 +556,6 @@ func (vm *softLayerVirtualGuest) hasMulitPathToolBasedOnShellScript() (bool, err
 	return false, nil
 }
 
-func (vm *softLayerVirtualGuest) getIscsiDeviceNamesBasedOnShellScript(hasMultiPath bool) ([]string, error) {
-	devices := []string{}
-
-	command1 := fmt.Sprintf("dmsetup ls")
-	command2 := fmt.Sprintf("cat /proc/partitions")
-
-	if hasMultiPath {
-		result, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command1)
-		if err != nil {
-			return devices, err
-		}
-		if strings.Contains(result, "No devices found") {
-			return devices, nil
-		}
-		vm.logger.Info(SOFTLAYER_VM_LOG_TAG, fmt.Sprintf("Devices on VM %d: %s", vm.ID(), result))
-		lines := strings.Split(strings.Trim(result, "\n"), "\n")
-		for i := 0; i < len(lines); i++ {
-			if match, _ := regexp.MatchString("-part1", lines[i]); !match {
-				devices = append(devices, strings.Fields(lines[i])[0])
-			}
-		}
-	} else {
-		result, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command2)
-		if err != nil {
-			return devices, err
-		}
-
-		vm.logger.Info(SOFTLAYER_VM_LOG_TAG, fmt.Sprintf("Devices on VM %d: %s", vm.ID(), result))
-		lines := strings.Split(strings.Trim(result, "\n"), "\n")
-		for i := 0; i < len(lines); i++ {
-			if match, _ := regexp.MatchString("sd[a-z]$", lines[i]); match {
-				vals := strings.Fields(lines[i])
-				devices = append(devices, vals[len(vals)-1])
-			}
-		}
-	}
-
-	return devices, nil
-}
-
 func (vm *softLayerVirtualGuest) fetchIscsiVolume(volumeId int) (datatypes.SoftLayer_Network_Storage, error) {
 	networkStorageService, err := vm.softLayerClient.GetSoftLayer_Network_Storage_Service()
 	if err != nil {
@@ -597,20 +625,20 @@ func (vm *softLayerVirtualGuest) restartOpenIscsiBasedOnShellScript() (bool, err
 	return true, nil
 }
 
-func (vm *softLayerVirtualGuest) discoveryOpenIscsiTargetsBasedOnShellScript(volume datatypes.SoftLayer_Network_Storage) (bool, error) {
+func (vm *softLayerVirtualGuest) discoveryOpenIscsiTargetsBasedOnShellScript(volume datatypes.SoftLayer_Network_Storage) (string, error) {
 	command := fmt.Sprintf("sleep 5; iscsiadm -m discovery -t sendtargets -p %s", volume.ServiceResourceBackendIpAddress)
-	_, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
+	discovery, err := vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
 	if err != nil {
-		return false, bosherr.WrapError(err, "discoverying open iscsi targets")
+		return "", bosherr.WrapError(err, "discoverying open iscsi targets")
 	}
 
 	command = "sleep 5; echo `iscsiadm -m node -l`"
 	_, err = vm.sshClient.ExecCommand(ROOT_USER_NAME, vm.GetRootPassword(), vm.GetPrimaryBackendIP(), command)
 	if err != nil {
-		return false, bosherr.WrapError(err, "login iscsi targets")
+		return "", bosherr.WrapError(err, "login iscsi targets")
 	}
 
-	return true, nil
+	return discovery, nil
 }
 
 func (vm *softLayerVirtualGuest) writeOpenIscsiInitiatornameBasedOnShellScript(credential AllowedHostCredential) (bool, error) {
@@ -727,31 +755,32 @@ func (vm *softLayerVirtualGuest) postCheckActiveTransactionsForOSReload(softLaye
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < slh.TIMEOUT {
+	started, err := vm.waitPolicies.OSReload.Wait(func() (bool, error) {
 		activeTransactions, err := virtualGuestService.GetActiveTransactions(vm.ID())
 		if err != nil {
-			if !strings.Contains(err.Error(), "HTTP error code") {
-				return bosherr.WrapError(err, "Getting active transactions from SoftLayer client")
+			if !slh.IsAPIErrorRetryable(err) {
+				return false, bosherr.WrapError(err, "Getting active transactions from SoftLayer client")
 			}
 		}
 
 		if len(activeTransactions) > 0 {
 			vm.logger.Info(SOFTLAYER_VM_OS_RELOAD_TAG, "OS Reload transaction started")
-			break
+			return true, nil
 		}
 
-		totalTime += slh.POLLING_INTERVAL
-		time.Sleep(slh.POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if totalTime >= slh.TIMEOUT {
+	if !started {
 		return errors.New(fmt.Sprintf("Waiting for OS Reload transaction to start TIME OUT!"))
 	}
 
-	err = slh.WaitForVirtualGuest(vm.softLayerClient, vm.ID(), "RUNNING")
+	err = slh.WaitForVirtualGuest(vm.softLayerClient, vm.ID(), "RUNNING", vm.waitPolicies.OSReload)
 	if err != nil {
-		if !strings.Contains(err.Error(), "HTTP error code") {
+		if !slh.IsAPIErrorRetryable(err) {
 			return bosherr.WrapError(err, fmt.Sprintf("PowerOn failed with VirtualGuest id %d", vm.ID()))
 		}
 	}
@@ -767,40 +796,40 @@ func (vm *softLayerVirtualGuest) postCheckActiveTransactionsForDeleteVM(softLaye
 		return bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
 	}
 
-	totalTime := time.Duration(0)
-	for totalTime < slh.TIMEOUT {
+	started, err := vm.waitPolicies.Delete.Wait(func() (bool, error) {
 		activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuestId)
 		if err != nil {
-			if !strings.Contains(err.Error(), "HTTP error code") {
-				return bosherr.WrapError(err, "Getting active transactions from SoftLayer client")
+			if !slh.IsAPIErrorRetryable(err) {
+				return false, bosherr.WrapError(err, "Getting active transactions from SoftLayer client")
 			}
 		}
 
 		if len(activeTransactions) > 0 {
 			vm.logger.Info(SOFTLAYER_VM_LOG_TAG, "Delete VM transaction started", nil)
-			break
+			return true, nil
 		}
 
-		totalTime += slh.POLLING_INTERVAL
-		time.Sleep(slh.POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if totalTime >= slh.TIMEOUT {
+	if !started {
 		return errors.New(fmt.Sprintf("Waiting for DeleteVM transaction to start TIME OUT!"))
 	}
 
-	totalTime = time.Duration(0)
-	for totalTime < slh.TIMEOUT {
+	completed, err := vm.waitPolicies.Delete.Wait(func() (bool, error) {
 		vm1, err := virtualGuestService.GetObject(virtualGuestId)
 		if err != nil || vm1.Id == 0 {
 			vm.logger.Info(SOFTLAYER_VM_LOG_TAG, "VM doesn't exist. Delete done", nil)
-			break
+			return true, nil
 		}
 
 		activeTransaction, err := virtualGuestService.GetActiveTransaction(virtualGuestId)
 		if err != nil {
-			if !strings.Contains(err.Error(), "HTTP error code") {
-				return bosherr.WrapError(err, "Getting active transactions from SoftLayer client")
+			if !slh.IsAPIErrorRetryable(err) {
+				return false, bosherr.WrapError(err, "Getting active transactions from SoftLayer client")
 			}
 		}
 
@@ -816,21 +845,40 @@ func (vm *softLayerVirtualGuest) postCheckActiveTransactionsForDeleteVM(softLaye
 
 		if averageTransactionDuration > 30 {
 			vm.logger.Info(SOFTLAYER_VM_LOG_TAG, "Deleting VM instance had been launched and it is a long transaction. Please check Softlayer Portal", nil)
-			break
+			return true, nil
 		}
 
 		vm.logger.Info(SOFTLAYER_VM_LOG_TAG, "This is a short transaction, waiting for all active transactions to complete", nil)
-		totalTime += slh.POLLING_INTERVAL
-		time.Sleep(slh.POLLING_INTERVAL)
+		return false, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if totalTime >= slh.TIMEOUT {
+	if !completed {
 		return errors.New(fmt.Sprintf("After deleting a vm, waiting for active transactions to complete TIME OUT!"))
 	}
 
 	return nil
 }
 
+// findStoreDisk returns the id of the persistent disk mounted at /var/vcap/store and the suffix
+// naming the mounted partition after its device, or empty strings when no persistent disk is
+func (vm *softLayerVirtualGuest) findStoreDisk() (string, string, error) {
+	agentEnv, err := vm.agentEnvService.Fetch()
+	if err != nil {
+		return "", "", bosherr.WrapErrorf(err, "Failed to unmarshal userdata from virtual guest with id: %d.", vm.ID())
+	}
+
+	mounts, err := vm.searchMounts()
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Searching mounts")
+	}
+
+	diskID, partitionSuffix := agentEnv.Disks.Persistent.MountedDisk(mounts, "/var/vcap/store")
+	return diskID, partitionSuffix, nil
+}
+
 func (vm *softLayerVirtualGuest) isMountPoint(path string) (bool, error) {
 	mounts, err := vm.searchMounts()
 	if err != nil {
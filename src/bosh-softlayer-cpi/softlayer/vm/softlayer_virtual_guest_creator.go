@@ -3,7 +3,7 @@ package vm
 import (
 	"fmt"
 	"net"
-	"time"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -27,9 +27,6 @@ type softLayerVirtualGuestCreator struct {
 }
 
 func NewSoftLayerCreator(vmFinder VMFinder, softLayerClient sl.Client, agentOptions AgentOptions, featureOptions FeatureOptions, registryOptions RegistryOptions, logger boshlog.Logger) VMCreator {
-	slhelper.TIMEOUT = 120 * time.Minute
-	slhelper.POLLING_INTERVAL = 5 * time.Second
-
 	return &softLayerVirtualGuestCreator{
 		vmFinder:        vmFinder,
 		softLayerClient: softLayerClient,
@@ -41,16 +38,27 @@ func NewSoftLayerCreator(vmFinder VMFinder, softLayerClient sl.Client, agentOpti
 }
 
 func (c *softLayerVirtualGuestCreator) Create(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+	rollback := slhelper.NewRollback(c.featureOptions.KeepFailedVMs, c.logger)
+
+	vm, err := c.create(agentID, stemcell, cloudProps, networks, env, rollback)
+	if err != nil {
+		return nil, rollback.Fail(err)
+	}
+
+	return vm, nil
+}
+
+func (c *softLayerVirtualGuestCreator) create(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	for _, network := range networks {
 		switch network.Type {
 		case "dynamic":
 			if cloudProps.DisableOsReload || c.featureOptions.DisableOsReload {
-				return c.createBySoftlayer(agentID, stemcell, cloudProps, networks, env)
+				return c.createBySoftlayer(agentID, stemcell, cloudProps, networks, env, rollback)
 			} else {
 				if len(network.IP) == 0 {
-					return c.createBySoftlayer(agentID, stemcell, cloudProps, networks, env)
+					return c.createBySoftlayer(agentID, stemcell, cloudProps, networks, env, rollback)
 				} else {
-					return c.createByOSReload(agentID, stemcell, cloudProps, networks, env)
+					return c.createByOSReload(agentID, stemcell, cloudProps, networks, env, rollback)
 				}
 
 			}
@@ -67,29 +75,42 @@ func (c *softLayerVirtualGuestCreator) Create(agentID string, stemcell bslcstem.
 func (c *softLayerVirtualGuestCreator) GetAgentOptions() AgentOptions { return c.agentOptions }
 
 // Private methods
-func (c *softLayerVirtualGuestCreator) createBySoftlayer(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+func (c *softLayerVirtualGuestCreator) createBySoftlayer(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
+	err := c.resolvePlacement(&cloudProps, env)
+	if err != nil {
+		return nil, err
+	}
+
 	virtualGuestTemplate, err := CreateVirtualGuestTemplate(stemcell, cloudProps, networks, CreateUserDataForInstance(agentID, networks, c.registryOptions))
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Creating VirtualGuest template")
 	}
+	virtualGuestTemplate.TagReferences = slhelper.AgentIDTagReferences(agentID)
 
-	virtualGuestService, err := c.softLayerClient.GetSoftLayer_Virtual_Guest_Service()
+	virtualGuest, found, err := slhelper.FindVirtualGuestByAgentID(c.softLayerClient, agentID)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
+		return nil, bosherr.WrapErrorf(err, "Finding VirtualGuest already ordered for agent ID `%s`", agentID)
 	}
 
-	virtualGuest, err := virtualGuestService.CreateObject(virtualGuestTemplate)
-	if err != nil {
-		return nil, bosherr.WrapError(err, "Creating VirtualGuest from SoftLayer client")
+	if found {
+		c.logger.Info(SOFTLAYER_VM_CREATOR_LOG_TAG, fmt.Sprintf("Resuming setup of VirtualGuest %d already ordered for agent ID %s", virtualGuest.Id, agentID))
+	} else {
+		virtualGuest, err = c.orderVirtualGuest(stemcell, cloudProps.Datacenters, virtualGuestTemplate)
+		if err != nil {
+			return nil, err
+		}
 	}
+	rollback.Add(fmt.Sprintf("Cancelling VirtualGuest `%d`", virtualGuest.Id), func() error {
+		return slhelper.CancelVirtualGuest(c.softLayerClient, virtualGuest.Id, c.featureOptions.WaitPolicies.Delete)
+	})
 
 	if cloudProps.EphemeralDiskSize == 0 {
-		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, virtualGuest.Id, "Service Setup")
+		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, virtualGuest.Id, "Service Setup", c.featureOptions.WaitPolicies.Create)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` has Service Setup transaction complete", virtualGuest.Id)
 		}
 	} else {
-		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, virtualGuest.Id, cloudProps.EphemeralDiskSize, c.logger)
+		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, virtualGuest.Id, cloudProps.EphemeralDiskSize, c.featureOptions.WaitPolicies.EphemeralDiskUpgrade, c.logger)
 		if err != nil {
 			return nil, bosherr.WrapError(err, fmt.Sprintf("Attaching ephemeral disk to VirtualGuest `%d`", virtualGuest.Id))
 		}
@@ -101,18 +122,22 @@ func (c *softLayerVirtualGuestCreator) createBySoftlayer(agentID string, stemcel
 	}
 
 	if cloudProps.DeployedByBoshCLI {
-		err := UpdateEtcHostsOfBoshInit(slhelper.LocalDNSConfigurationFile, fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName()))
+		etcHostsRecord := fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName())
+		err := UpdateEtcHostsOfBoshInit(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Updating BOSH director hostname/IP mapping entry in /etc/hosts")
 		}
+		rollback.Add(fmt.Sprintf("Removing '%s' from %s", etcHostsRecord, c.featureOptions.LocalDNSConfigurationFile), func() error {
+			return RemoveEtcHostsEntry(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
+		})
 	} else {
 		var boshIP string
 		if cloudProps.BoshIp != "" {
 			boshIP = cloudProps.BoshIp
 		} else {
-			boshIP, err = GetLocalIPAddressOfGivenInterface(slhelper.NetworkInterface)
+			boshIP, err = GetLocalIPAddressOfGivenInterface(c.featureOptions.NetworkInterface)
 			if err != nil {
-				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", slhelper.NetworkInterface))
+				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", c.featureOptions.NetworkInterface))
 			}
 		}
 
@@ -137,6 +162,7 @@ func (c *softLayerVirtualGuestCreator) createBySoftlayer(agentID string, stemcel
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Updating VM's agent env")
 	}
+	rollback.Add(fmt.Sprintf("Deleting agent env of VirtualGuest `%d`", vm.ID()), vm.DeleteAgentEnv)
 
 	if len(c.agentOptions.VcapPassword) > 0 {
 		err = vm.SetVcapPassword(c.agentOptions.VcapPassword)
@@ -148,7 +174,120 @@ func (c *softLayerVirtualGuestCreator) createBySoftlayer(agentID string, stemcel
 	return vm, nil
 }
 
-func (c *softLayerVirtualGuestCreator) createByOSReload(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment) (VM, error) {
+// resolvePlacement replaces the names of the dedicated host and the placement group in the cloud
+// properties with their IDs. With createPlacementGroup it creates the placement group when it does
+// not exist yet.
+func (c *softLayerVirtualGuestCreator) resolvePlacement(cloudProps *VMCloudProperties, env Environment) error {
+	if cloudProps.DedicatedHostName != "" {
+		dedicatedHost, err := slhelper.FindDedicatedHostByName(c.softLayerClient, cloudProps.DedicatedHostName)
+		if err != nil {
+			return bosherr.WrapError(err, "Resolving dedicatedHostName")
+		}
+
+		if dedicatedHost.Datacenter != nil && cloudProps.Datacenter.Name != "" && dedicatedHost.Datacenter.Name != cloudProps.Datacenter.Name {
+			return bosherr.Errorf("Dedicated host `%s` is in datacenter %s, not %s", dedicatedHost.Name, dedicatedHost.Datacenter.Name, cloudProps.Datacenter.Name)
+		}
+
+		cloudProps.DedicatedHostId = dedicatedHost.Id
+	}
+
+	placementGroupName, err := cloudProps.PlacementGroupNameFor(env)
+	if err != nil {
+		return err
+	}
+	if placementGroupName == "" {
+		return nil
+	}
+
+	placementGroup, found, err := slhelper.FindPlacementGroupByName(c.softLayerClient, placementGroupName)
+	if err != nil {
+		return bosherr.WrapError(err, "Resolving placementGroupName")
+	}
+
+	if !found {
+		if !cloudProps.CreatePlacementGroup {
+			return bosherr.Errorf("Placement group `%s` does not exist, set createPlacementGroup to create it", placementGroupName)
+		}
+
+		placementGroup, err = slhelper.CreatePlacementGroup(c.softLayerClient, placementGroupName, cloudProps.Datacenter.Name, c.logger)
+		if err != nil {
+			return err
+		}
+		c.logger.Info(SOFTLAYER_VM_CREATOR_LOG_TAG, fmt.Sprintf("Created placement group %d named %s", placementGroup.Id, placementGroupName))
+	}
+
+	cloudProps.PlacementGroupId = placementGroup.Id
+
+	return nil
+}
+
+// orderVirtualGuest orders the virtual guest in the first of the failover datacenters with capacity
+// for it, skipping those the stemcell image has not been copied to. Without failover datacenters
+// it orders the virtual guest in the datacenter of the template.
+func (c *softLayerVirtualGuestCreator) orderVirtualGuest(stemcell bslcstem.Stemcell, datacenters []FailoverDatacenter, template slhelper.VirtualGuestTemplate) (datatypes.SoftLayer_Virtual_Guest, error) {
+	if len(datacenters) == 0 {
+		virtualGuest, err := slhelper.CreateVirtualGuest(c.softLayerClient, template)
+		if err != nil {
+			return datatypes.SoftLayer_Virtual_Guest{}, bosherr.WrapError(err, "Creating VirtualGuest from SoftLayer client")
+		}
+
+		return virtualGuest, nil
+	}
+
+	imageDatacenters, err := c.imageDatacenters(stemcell)
+	if err != nil {
+		return datatypes.SoftLayer_Virtual_Guest{}, err
+	}
+
+	failures := []string{}
+	for _, datacenter := range datacenters {
+		if len(imageDatacenters) > 0 && !imageDatacenters[datacenter.Name] {
+			c.logger.Info(SOFTLAYER_VM_CREATOR_LOG_TAG, fmt.Sprintf("Skipping datacenter %s, stemcell %d has not been copied to it", datacenter.Name, stemcell.ID()))
+			failures = append(failures, fmt.Sprintf("%s: stemcell %d has not been copied to it", datacenter.Name, stemcell.ID()))
+			continue
+		}
+
+		datacenter.ApplyTo(&template.SoftLayer_Virtual_Guest_Template)
+		virtualGuest, err := slhelper.CreateVirtualGuest(c.softLayerClient, template)
+		if err == nil {
+			c.logger.Info(SOFTLAYER_VM_CREATOR_LOG_TAG, fmt.Sprintf("Ordered VirtualGuest %d in datacenter %s", virtualGuest.Id, datacenter.Name))
+			return virtualGuest, nil
+		}
+
+		if !slhelper.IsCapacityError(err) {
+			return datatypes.SoftLayer_Virtual_Guest{}, bosherr.WrapErrorf(err, "Creating VirtualGuest in datacenter %s from SoftLayer client", datacenter.Name)
+		}
+
+		c.logger.Warn(SOFTLAYER_VM_CREATOR_LOG_TAG, fmt.Sprintf("Datacenter %s has no capacity for the VirtualGuest, trying the next datacenter: %s", datacenter.Name, err.Error()))
+		failures = append(failures, fmt.Sprintf("%s: %s", datacenter.Name, err.Error()))
+	}
+
+	return datatypes.SoftLayer_Virtual_Guest{}, bosherr.Errorf("No datacenter could take the VirtualGuest: %s", strings.Join(failures, "; "))
+}
+
+// imageDatacenters returns the names of the datacenters the stemcell image has been copied to.
+// SoftLayer lists none for images available everywhere, such as public images.
+func (c *softLayerVirtualGuestCreator) imageDatacenters(stemcell bslcstem.Stemcell) (map[string]bool, error) {
+	imageDatacenters := map[string]bool{}
+
+	templateGroupService, err := c.softLayerClient.GetSoftLayer_Virtual_Guest_Block_Device_Template_Group_Service()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating VirtualGuestBlockDeviceTemplateGroupService from SoftLayer client")
+	}
+
+	locations, err := templateGroupService.GetDatacenters(stemcell.ID())
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding the datacenters of stemcell `%d`", stemcell.ID())
+	}
+
+	for _, location := range locations {
+		imageDatacenters[location.Name] = true
+	}
+
+	return imageDatacenters, nil
+}
+
+func (c *softLayerVirtualGuestCreator) createByOSReload(agentID string, stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks, env Environment, rollback *slhelper.Rollback) (VM, error) {
 	virtualGuestService, err := c.softLayerClient.GetSoftLayer_Virtual_Guest_Service()
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Creating VirtualGuestService from SoftLayer client")
@@ -181,7 +320,6 @@ func (c *softLayerVirtualGuestCreator) createByOSReload(agentID string, stemcell
 		return nil, bosherr.WrapErrorf(err, "Cannot find virtualGuest with id: %d", virtualGuest.Id)
 	}
 
-	slhelper.TIMEOUT = 4 * time.Hour
 	err = vm.ReloadOS(stemcell)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Failed to reload OS")
@@ -193,30 +331,34 @@ func (c *softLayerVirtualGuestCreator) createByOSReload(agentID string, stemcell
 	}
 
 	if cloudProps.EphemeralDiskSize == 0 {
-		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, vm.ID(), "Service Setup")
+		err = slhelper.WaitForVirtualGuestLastCompleteTransaction(c.softLayerClient, vm.ID(), "Service Setup", c.featureOptions.WaitPolicies.Create)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Waiting for VirtualGuest `%d` has Service Setup transaction complete", vm.ID())
 		}
 	} else {
-		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, vm.ID(), cloudProps.EphemeralDiskSize, c.logger)
+		err = slhelper.AttachEphemeralDiskToVirtualGuest(c.softLayerClient, vm.ID(), cloudProps.EphemeralDiskSize, c.featureOptions.WaitPolicies.EphemeralDiskUpgrade, c.logger)
 		if err != nil {
 			return nil, bosherr.WrapError(err, fmt.Sprintf("Attaching ephemeral disk to VirtualGuest `%d`", vm.ID()))
 		}
 	}
 
 	if cloudProps.DeployedByBoshCLI {
-		err := UpdateEtcHostsOfBoshInit(slhelper.LocalDNSConfigurationFile, fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName()))
+		etcHostsRecord := fmt.Sprintf("%s  %s", vm.GetPrimaryBackendIP(), vm.GetFullyQualifiedDomainName())
+		err := UpdateEtcHostsOfBoshInit(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
 		if err != nil {
 			return nil, bosherr.WrapErrorf(err, "Updating BOSH director hostname/IP mapping entry in /etc/hosts")
 		}
+		rollback.Add(fmt.Sprintf("Removing '%s' from %s", etcHostsRecord, c.featureOptions.LocalDNSConfigurationFile), func() error {
+			return RemoveEtcHostsEntry(c.featureOptions.LocalDNSConfigurationFile, etcHostsRecord)
+		})
 	} else {
 		var boshIP string
 		if cloudProps.BoshIp != "" {
 			boshIP = cloudProps.BoshIp
 		} else {
-			boshIP, err = GetLocalIPAddressOfGivenInterface(slhelper.NetworkInterface)
+			boshIP, err = GetLocalIPAddressOfGivenInterface(c.featureOptions.NetworkInterface)
 			if err != nil {
-				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", slhelper.NetworkInterface))
+				return nil, bosherr.WrapErrorf(err, fmt.Sprintf("Failed to get IP address of %s in local", c.featureOptions.NetworkInterface))
 			}
 		}
 
@@ -249,6 +391,7 @@ func (c *softLayerVirtualGuestCreator) createByOSReload(agentID string, stemcell
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Updating VM's agent env")
 	}
+	rollback.Add(fmt.Sprintf("Deleting agent env of VirtualGuest `%d`", vm.ID()), vm.DeleteAgentEnv)
 
 	if len(c.agentOptions.VcapPassword) > 0 {
 		err = vm.SetVcapPassword(c.agentOptions.VcapPassword)
@@ -35,6 +35,8 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 		creator         VMCreator
 		featureOptions  FeatureOptions
 		registryOptions RegistryOptions
+		waitPolicies    slh.WaitPolicies
+		netInterface    string
 	)
 
 	BeforeEach(func() {
@@ -45,8 +47,22 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 		fakeVmFinder = &fakescommon.FakeVMFinder{}
 		fakeVm = &fakescommon.FakeVM{}
 
-		slh.TIMEOUT = 2 * time.Second
-		slh.POLLING_INTERVAL = 1 * time.Second
+		waitPolicy := slh.NewWaitPolicy(2*time.Second, 1*time.Second)
+		waitPolicies = slh.WaitPolicies{
+			Create:               waitPolicy,
+			OSReload:             waitPolicy,
+			EphemeralDiskUpgrade: waitPolicy,
+			DiskAttach:           waitPolicy,
+			StemcellLookup:       waitPolicy,
+			Delete:               waitPolicy,
+		}
+
+		switch runtime.GOOS {
+		case "darwin":
+			netInterface = "en0"
+		default:
+			netInterface = "eth0"
+		}
 	})
 
 	Describe("#Create", func() {
@@ -61,10 +77,15 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 		Context("valid arguments with os_reload enabled", func() {
 			BeforeEach(func() {
 				agentID = "fake-agent-id"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, waitPolicies, logger)
 
 				env = Environment{}
-				featureOptions = FeatureOptions{DisableOsReload: false}
+				featureOptions = FeatureOptions{
+					DisableOsReload:           false,
+					NetworkInterface:          netInterface,
+					LocalDNSConfigurationFile: "/tmp/hosts",
+					WaitPolicies:              waitPolicies,
+				}
 				registryOptions = RegistryOptions{}
 
 				fakeVm.IDReturns(1234567)
@@ -215,16 +236,6 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 						}
 						setFakeSoftlayerClientCreateObjectTestFixturesWithoutBoshIP_OS_Reload(softLayerClient)
 
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
-						slh.LocalDNSConfigurationFile = "/tmp/hosts"
-
 						vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(vm.ID()).To(Equal(1234567))
@@ -266,14 +277,6 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 							return expectedCmdResults[sshClient.ExecCommandCallCount()-1], nil
 						}
 						setFakeSoftlayerClientCreateObjectTestFixturesWithoutBoshIP_OS_Reload(softLayerClient)
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
 						vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(vm.ID()).To(Equal(1234567))
@@ -501,16 +504,6 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 						}
 						setFakeSoftlayerClientCreateObjectTestFixturesWithoutBoshIP(softLayerClient)
 
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
-						slh.LocalDNSConfigurationFile = "/tmp/hosts"
-
 						vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(vm.ID()).To(Equal(1234567))
@@ -552,15 +545,6 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 							return expectedCmdResults[sshClient.ExecCommandCallCount()-1], nil
 						}
 						setFakeSoftlayerClientCreateObjectTestFixturesWithoutBoshIP(softLayerClient)
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
-						slh.LocalDNSConfigurationFile = "/tmp/hosts"
 
 						vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
@@ -572,14 +556,282 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 			})
 		})
 
+		Context("when a step after creating the virtual guest fails", func() {
+			BeforeEach(func() {
+				agentID = "fake-agent-id"
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, waitPolicies, logger)
+				env = Environment{}
+				networks = map[string]Network{
+					"fake-network0": Network{
+						Type:            "dynamic",
+						Netmask:         "fake-Netmask",
+						Gateway:         "fake-Gateway",
+						Default:         []string{},
+						CloudProperties: map[string]interface{}{},
+					},
+				}
+				cloudProps = VMCloudProperties{
+					StartCpus: 4,
+					MaxMemory: 2048,
+					Domain:    "fake-domain.com",
+					BlockDeviceTemplateGroup: sldatatypes.BlockDeviceTemplateGroup{
+						GlobalIdentifier: "fake-uuid",
+					},
+					BoshIp:       "10.0.0.1",
+					Datacenter:   sldatatypes.Datacenter{Name: "fake-datacenter"},
+					VmNamePrefix: "bosh-test",
+				}
+				featureOptions = FeatureOptions{
+					DisableOsReload:           true,
+					NetworkInterface:          netInterface,
+					LocalDNSConfigurationFile: "/tmp/hosts",
+					WaitPolicies:              waitPolicies,
+				}
+
+				fakeVm.IDReturns(1234567)
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getVirtualGuests_None.json",
+					"SoftLayer_Virtual_Guest_Service_createObject.json",
+					"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
+					"SoftLayer_Virtual_Guest_Service_getActiveTransactions_None.json",
+					"SoftLayer_Virtual_Guest_Service_deleteObject_true.json",
+				})
+			})
+
+			It("cancels the virtual guest when updating the agent env fails", func() {
+				fakeVm.UpdateAgentEnvReturns(bosherr.Error("fake-update-agent-env-error"))
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-update-agent-env-error"))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestRequestType).To(Equal("DELETE"))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Virtual_Guest/1234567.json"))
+				Expect(fakeVm.DeleteAgentEnvCallCount()).To(Equal(0))
+			})
+
+			It("drops the agent env and cancels the virtual guest when setting the vcap password fails", func() {
+				fakeVm.SetVcapPasswordReturns(bosherr.Error("fake-set-vcap-password-error"))
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeVm.DeleteAgentEnvCallCount()).To(Equal(1))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestRequestType).To(Equal("DELETE"))
+			})
+
+			It("keeps the failed virtual guest when configured to", func() {
+				featureOptions.KeepFailedVMs = true
+				fakeVm.SetVcapPasswordReturns(bosherr.Error("fake-set-vcap-password-error"))
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeVm.DeleteAgentEnvCallCount()).To(Equal(0))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestRequestType).ToNot(Equal("DELETE"))
+			})
+		})
+
+		Context("when the cloud properties list several datacenters", func() {
+			BeforeEach(func() {
+				agentID = "fake-agent-id"
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, waitPolicies, logger)
+				env = Environment{}
+				networks = map[string]Network{
+					"fake-network0": Network{
+						Type:            "dynamic",
+						Default:         []string{},
+						CloudProperties: map[string]interface{}{},
+					},
+				}
+				cloudProps = VMCloudProperties{
+					StartCpus:    4,
+					MaxMemory:    2048,
+					Domain:       "fake-domain.com",
+					BoshIp:       "10.0.0.1",
+					Datacenter:   sldatatypes.Datacenter{Name: "ams01"},
+					VmNamePrefix: "bosh-test",
+					Datacenters: []FailoverDatacenter{
+						{Name: "ams01"},
+						{Name: "dal09"},
+						{
+							Name: "lon02",
+							PrimaryBackendNetworkComponent: &sldatatypes.PrimaryBackendNetworkComponent{
+								NetworkVlan: sldatatypes.NetworkVlan{Id: 524956},
+							},
+						},
+					},
+				}
+				featureOptions = FeatureOptions{
+					DisableOsReload:           true,
+					NetworkInterface:          netInterface,
+					LocalDNSConfigurationFile: "/tmp/hosts",
+					WaitPolicies:              waitPolicies,
+				}
+
+				fakeVm.IDReturns(1234567)
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+			})
+
+			It("skips datacenters without the stemcell and fails over datacenters without capacity", func() {
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getVirtualGuests_None.json",
+					"SoftLayer_Virtual_Guest_Block_Device_Template_Group_Service_getDatacenters.json",
+					"SoftLayer_Virtual_Guest_Service_createObject_insufficientCapacity.json",
+					"SoftLayer_Virtual_Guest_Service_createObject.json",
+					"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
+				})
+				softLayerClient.FakeHttpClient.DoRawHttpRequestInts = []int{200, 200, 500, 200}
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(vm.ID()).To(Equal(1234567))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(5))
+			})
+
+			It("fails without trying further datacenters when SoftLayer rejects the order for another reason", func() {
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getVirtualGuests_None.json",
+					"SoftLayer_Virtual_Guest_Block_Device_Template_Group_Service_getDatacenters.json",
+					"SoftLayer_Virtual_Guest_Service_createObject.json",
+				})
+				softLayerClient.FakeHttpClient.DoRawHttpRequestInts = []int{200, 200, 400}
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Creating VirtualGuest in datacenter dal09"))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(3))
+			})
+
+			It("reports every datacenter when none can take the virtual guest", func() {
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getVirtualGuests_None.json",
+					"SoftLayer_Virtual_Guest_Block_Device_Template_Group_Service_getDatacenters.json",
+					"SoftLayer_Virtual_Guest_Service_createObject_insufficientCapacity.json",
+					"SoftLayer_Virtual_Guest_Service_createObject_insufficientCapacity.json",
+				})
+				softLayerClient.FakeHttpClient.DoRawHttpRequestInts = []int{200, 200, 500, 500}
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("ams01: stemcell 1234 has not been copied to it"))
+				Expect(err.Error()).To(ContainSubstring("dal09: Calling SoftLayer_Virtual_Guest#createObject, HTTP error code: '500', error: 'There is insufficient capacity to complete the request.'"))
+				Expect(err.Error()).To(ContainSubstring("lon02: "))
+			})
+		})
+
+		Context("when the cloud properties place the virtual guest", func() {
+			BeforeEach(func() {
+				agentID = "fake-agent-id"
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, waitPolicies, logger)
+				env = Environment{"bosh": map[string]interface{}{"group": "fake-director-fake-deployment-fake-job"}}
+				networks = map[string]Network{
+					"fake-network0": Network{
+						Type:            "dynamic",
+						Default:         []string{},
+						CloudProperties: map[string]interface{}{},
+					},
+				}
+				cloudProps = VMCloudProperties{
+					StartCpus:    4,
+					MaxMemory:    2048,
+					Domain:       "fake-domain.com",
+					BoshIp:       "10.0.0.1",
+					Datacenter:   sldatatypes.Datacenter{Name: "dal09"},
+					VmNamePrefix: "bosh-test",
+				}
+				featureOptions = FeatureOptions{
+					DisableOsReload:           true,
+					NetworkInterface:          netInterface,
+					LocalDNSConfigurationFile: "/tmp/hosts",
+					WaitPolicies:              waitPolicies,
+				}
+
+				fakeVm.IDReturns(1234567)
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+			})
+
+			It("orders the virtual guest on the dedicated host with the name", func() {
+				cloudProps.DedicatedHostName = "fake-dedicated-host"
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getDedicatedHosts.json",
+					"SoftLayer_Account_Service_getVirtualGuests_None.json",
+					"SoftLayer_Virtual_Guest_Service_createObject.json",
+					"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
+				})
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(vm.ID()).To(Equal(1234567))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(4))
+			})
+
+			It("fails when the dedicated host is in another datacenter", func() {
+				cloudProps.DedicatedHostName = "fake-dedicated-host"
+				cloudProps.Datacenter = sldatatypes.Datacenter{Name: "lon02"}
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getDedicatedHosts.json",
+				})
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Dedicated host `fake-dedicated-host` is in datacenter dal09, not lon02"))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(1))
+			})
+
+			It("creates the placement group of the bosh group when it does not exist", func() {
+				cloudProps.CreatePlacementGroup = true
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getPlacementGroups_None.json",
+					"SoftLayer_Virtual_PlacementGroup_Rule_Service_getAllObjects.json",
+					"SoftLayer_Virtual_PlacementGroup_Service_getAvailableRouters.json",
+					"SoftLayer_Virtual_PlacementGroup_Service_createObject.json",
+					"SoftLayer_Account_Service_getPlacementGroups_Created.json",
+					"SoftLayer_Account_Service_getVirtualGuests_None.json",
+					"SoftLayer_Virtual_Guest_Service_createObject.json",
+					"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
+				})
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(vm.ID()).To(Equal(1234567))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestResponsesCount).To(Equal(8))
+			})
+
+			It("fails when the placement group with the name does not exist", func() {
+				cloudProps.PlacementGroupName = "fake-placement-group"
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, []string{
+					"SoftLayer_Account_Service_getPlacementGroups_None.json",
+				})
+				creator = NewSoftLayerCreator(fakeVmFinder, softLayerClient, agentOptions, featureOptions, registryOptions, logger)
+
+				_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Placement group `fake-placement-group` does not exist"))
+			})
+		})
+
 		Context("valid arguments with os_reload disabled", func() {
 			BeforeEach(func() {
 				agentID = "fake-agent-id"
-				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, logger)
+				stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-stemcell-uuid", softLayerClient, waitPolicies, logger)
 
 				env = Environment{}
 
-				featureOptions = FeatureOptions{DisableOsReload: true}
+				featureOptions = FeatureOptions{
+					DisableOsReload:           true,
+					NetworkInterface:          netInterface,
+					LocalDNSConfigurationFile: "/tmp/hosts",
+					WaitPolicies:              waitPolicies,
+				}
 				registryOptions = RegistryOptions{}
 
 				fakeVm.IDReturns(1234567)
@@ -730,16 +982,6 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 						}
 						setFakeSoftlayerClientCreateObjectTestFixturesWithoutBoshIP(softLayerClient)
 
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
-						slh.LocalDNSConfigurationFile = "/tmp/hosts"
-
 						vm, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(vm.ID()).To(Equal(1234567))
@@ -781,16 +1023,6 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 						}
 						setFakeSoftlayerClientCreateObjectTestFixturesWithoutBoshIP(softLayerClient)
 
-						switch runtime.GOOS {
-						case "darwin":
-							slh.NetworkInterface = "en0"
-						case "linux":
-							slh.NetworkInterface = "eth0"
-						default:
-							slh.NetworkInterface = "eth0"
-						}
-
-						slh.LocalDNSConfigurationFile = "/tmp/hosts"
 						_, err := creator.Create(agentID, stemcell, cloudProps, networks, env)
 						Expect(err).ToNot(HaveOccurred())
 					})
@@ -802,6 +1034,7 @@ var _ = Describe("SoftLayer_Virtual_Guest_Creator", func() {
 
 func setFakeSoftlayerClientCreateObjectTestFixturesWithEphemeralDiskSize(fakeSoftLayerClient *fakeslclient.FakeSoftLayerClient) {
 	fileNames := []string{
+		"SoftLayer_Account_Service_getVirtualGuests_None.json",
 		"SoftLayer_Virtual_Guest_Service_createObject.json",
 
 		"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
@@ -843,6 +1076,7 @@ func setFakeSoftlayerClientCreateObjectTestFixturesWithEphemeralDiskSize_OS_Relo
 
 func setFakeSoftlayerClientCreateObjectTestFixturesWithoutEphemeralDiskSize(fakeSoftLayerClient *fakeslclient.FakeSoftLayerClient) {
 	fileNames := []string{
+		"SoftLayer_Account_Service_getVirtualGuests_None.json",
 		"SoftLayer_Virtual_Guest_Service_createObject.json",
 
 		"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
@@ -867,6 +1101,7 @@ func setFakeSoflayerClientCreateObjectTestFixturesWithoutEphemeralDiskSize_OS_Re
 
 func setFakeSoftlayerClientCreateObjectTestFixturesWithoutBoshIP(fakeSoftLayerClient *fakeslclient.FakeSoftLayerClient) {
 	fileNames := []string{
+		"SoftLayer_Account_Service_getVirtualGuests_None.json",
 		"SoftLayer_Virtual_Guest_Service_createObject.json",
 		"SoftLayer_Virtual_Guest_Service_getLastTransaction.json",
 		"SoftLayer_Virtual_Guest_Service_getActiveTransactions_None.json",
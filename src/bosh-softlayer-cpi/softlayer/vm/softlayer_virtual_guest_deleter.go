@@ -2,7 +2,6 @@ package vm
 
 import (
 	"fmt"
-	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -17,13 +16,15 @@ const SOFTLAYER_VM_DELETER_LOG_TAG = "SoftLayerVMDeleter"
 
 type softLayerVMDeleter struct {
 	softLayerClient sl.Client
+	waitPolicies    slh.WaitPolicies
 	logger          boshlog.Logger
 	vmFinder        VMFinder
 }
 
-func NewSoftLayerVMDeleter(softLayerClient sl.Client, logger boshlog.Logger, vmFinder VMFinder) VMDeleter {
+func NewSoftLayerVMDeleter(softLayerClient sl.Client, waitPolicies slh.WaitPolicies, logger boshlog.Logger, vmFinder VMFinder) VMDeleter {
 	return &softLayerVMDeleter{
 		softLayerClient: softLayerClient,
+		waitPolicies:    waitPolicies,
 		logger:          logger,
 		vmFinder:        vmFinder,
 	}
@@ -35,9 +36,9 @@ func (c *softLayerVMDeleter) Delete(cid int) error {
 		return bosherr.WrapError(err, "Creating SoftLayer VirtualGuestService from client")
 	}
 
-	err = slh.WaitForVirtualGuestToHaveNoRunningTransactions(c.softLayerClient, cid)
+	err = slh.WaitForVirtualGuestToHaveNoRunningTransactions(c.softLayerClient, cid, c.waitPolicies.Delete)
 	if err != nil {
-		if !strings.Contains(err.Error(), "HTTP error code") {
+		if class := slh.ClassifyAPIError(err); class != slh.APIErrorNotFound && !class.Retryable() {
 			return bosherr.WrapError(err, fmt.Sprintf("Waiting for VirtualGuest `%d` to have no pending transactions before deleting vm", cid))
 		}
 	}
@@ -58,7 +59,7 @@ func (c *softLayerVMDeleter) Delete(cid int) error {
 
 	_, err = virtualGuestService.DeleteObject(cid)
 	if err != nil {
-		if !strings.Contains(err.Error(), "HTTP error code") {
+		if !slh.IsObjectNotFoundError(err) {
 			return bosherr.WrapError(err, "Deleting SoftLayer VirtualGuest from client")
 		}
 	}
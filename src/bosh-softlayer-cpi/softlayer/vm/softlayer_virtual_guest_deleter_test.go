@@ -30,9 +30,7 @@ var _ = Describe("SoftlayerVirtualGuestDeleter", func() {
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		fakeVmFinder = &fakescommon.FakeVMFinder{}
 		fakeVm = &fakescommon.FakeVM{}
-		deleter = NewSoftLayerVMDeleter(fakeSoftLayerClient, logger, fakeVmFinder)
-		slh.TIMEOUT = 2 * time.Second
-		slh.POLLING_INTERVAL = 1 * time.Second
+		deleter = NewSoftLayerVMDeleter(fakeSoftLayerClient, slh.WaitPolicies{Delete: slh.NewWaitPolicy(2*time.Second, 1*time.Second)}, logger, fakeVmFinder)
 	})
 
 	Describe("Delete", func() {
@@ -113,6 +111,22 @@ var _ = Describe("SoftlayerVirtualGuestDeleter", func() {
 			})
 		})
 
+		Context("when the virtual guest is gone before it is deleted", func() {
+			BeforeEach(func() {
+				fakeVm.IDReturns(1234567)
+				fakeVmFinder.FindReturns(fakeVm, true, nil)
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(fakeSoftLayerClient, []string{
+					"SoftLayer_Virtual_Guest_Service_getActiveTransactions_None.json",
+					"SoftLayer_Virtual_Guest_Service_deleteObject_notFound.json",
+				})
+				fakeSoftLayerClient.FakeHttpClient.DoRawHttpRequestInts = []int{200, 404}
+			})
+
+			It("returns no error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when deleting object and error occurs", func() {
 			BeforeEach(func() {
 				fakeVm.IDReturns(1234567)
@@ -0,0 +1,90 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
+	slcommon "github.com/maximilien/softlayer-go/common"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+)
+
+type softLayerVirtualGuestQuoter struct {
+	softLayerClient sl.Client
+	logger          boshlog.Logger
+}
+
+func NewSoftLayerVirtualGuestQuoter(softLayerClient sl.Client, logger boshlog.Logger) VMQuoter {
+	return &softLayerVirtualGuestQuoter{
+		softLayerClient: softLayerClient,
+		logger:          logger,
+	}
+}
+
+// Quote has SoftLayer verify the order create_vm would place for the given
+// cloud properties and returns its price. Nothing is provisioned.
+func (q *softLayerVirtualGuestQuoter) Quote(stemcell bslcstem.Stemcell, cloudProps VMCloudProperties, networks Networks) (slhelper.OrderQuote, error) {
+	if cloudProps.Baremetal {
+		return slhelper.OrderQuote{}, bosherr.Error("Quoting baremetal servers is not supported")
+	}
+
+	virtualGuestTemplate, err := CreateVirtualGuestTemplate(stemcell, cloudProps, networks, "")
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapError(err, "Creating VirtualGuest template")
+	}
+
+	order, err := q.post("SoftLayer_Virtual_Guest/generateOrderTemplate.json", virtualGuestTemplate)
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapError(err, "Generating order template for VirtualGuest")
+	}
+
+	q.logger.Debug(SOFTLAYER_VM_QUOTER_LOG_TAG, "Verifying order of VirtualGuest '%s.%s' in datacenter '%s'", virtualGuestTemplate.Hostname, virtualGuestTemplate.Domain, virtualGuestTemplate.Datacenter.Name)
+
+	// The order template goes to verifyOrder as SoftLayer generated it
+	response, err := q.post("SoftLayer_Product_Order/verifyOrder.json", json.RawMessage(order))
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapError(err, "Verifying order of VirtualGuest")
+	}
+
+	verifiedOrder := slhelper.VerifiedOrder{}
+	err = json.Unmarshal(response, &verifiedOrder)
+	if err != nil {
+		return slhelper.OrderQuote{}, bosherr.WrapError(err, "Unmarshalling verified order of VirtualGuest")
+	}
+
+	return slhelper.NewOrderQuote(verifiedOrder)
+}
+
+// post calls the SoftLayer method at path with the parameter and returns the response. The error
+// of a rejected call carries the reason SoftLayer gives.
+func (q *softLayerVirtualGuestQuoter) post(path string, parameter interface{}) ([]byte, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"parameters": []interface{}{parameter},
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Marshalling parameters of %s", path)
+	}
+
+	response, errorCode, err := q.softLayerClient.GetHttpClient().DoRawHttpRequest(path, "POST", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Calling %s", path)
+	}
+
+	if slcommon.IsHttpErrorCode(errorCode) {
+		apiError := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(response, &apiError) == nil && apiError.Error != "" {
+			return nil, bosherr.Errorf("Calling %s, HTTP error code: '%d', error: '%s'", path, errorCode, apiError.Error)
+		}
+
+		return nil, bosherr.Errorf("Calling %s, HTTP error code: '%d'", path, errorCode)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,85 @@
+package vm_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/softlayer/common"
+	. "bosh-softlayer-cpi/softlayer/vm"
+
+	testhelpers "bosh-softlayer-cpi/test_helpers"
+
+	fakeslclient "github.com/maximilien/softlayer-go/client/fakes"
+
+	bslcstem "bosh-softlayer-cpi/softlayer/stemcell"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+	sldatatypes "github.com/maximilien/softlayer-go/data_types"
+)
+
+var _ = Describe("SoftLayer_Virtual_Guest_Quoter", func() {
+	var (
+		softLayerClient *fakeslclient.FakeSoftLayerClient
+		logger          boshlog.Logger
+		quoter          VMQuoter
+		stemcell        bslcstem.SoftLayerStemcell
+		cloudProps      VMCloudProperties
+		networks        Networks
+	)
+
+	BeforeEach(func() {
+		softLayerClient = fakeslclient.NewFakeSoftLayerClient("fake-username", "fake-api-key")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		quoter = NewSoftLayerVirtualGuestQuoter(softLayerClient, logger)
+
+		waitPolicy := slh.NewWaitPolicy(2*time.Second, 1*time.Second)
+		stemcell = bslcstem.NewSoftLayerStemcell(1234, "fake-uuid", softLayerClient, slh.WaitPolicies{StemcellLookup: waitPolicy}, logger)
+		cloudProps = VMCloudProperties{
+			VmNamePrefix:      "fake-hostname",
+			Domain:            "fake-domain.com",
+			StartCpus:         2,
+			MaxMemory:         4096,
+			Datacenter:        sldatatypes.Datacenter{Name: "fake-datacenter"},
+			HourlyBillingFlag: true,
+		}
+		networks = Networks{}
+	})
+
+	Describe("#Quote", func() {
+		Context("when SoftLayer verifies the order", func() {
+			BeforeEach(func() {
+				fileNames := []string{
+					"SoftLayer_Virtual_Guest_Service_generateOrderTemplate.json",
+					"SoftLayer_Product_Order_Service_verifyOrder.json",
+				}
+				testhelpers.SetTestFixturesForFakeSoftLayerClient(softLayerClient, fileNames)
+			})
+
+			It("returns the itemized price without creating the virtual guest", func() {
+				quote, err := quoter.Quote(stemcell, cloudProps, networks)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(quote.Items).To(HaveLen(4))
+				Expect(quote.Items[0]).To(Equal(slh.OrderQuoteItem{Category: "guest_core", Description: "2 x 2.0 GHz Cores", Hourly: 0.06}))
+				Expect(quote.Hourly).To(BeNumerically("~", 0.129))
+				Expect(quote.Monthly).To(BeNumerically("~", 0))
+				Expect(softLayerClient.FakeHttpClient.DoRawHttpRequestPath).To(Equal("SoftLayer_Product_Order/verifyOrder.json"))
+			})
+		})
+
+		Context("when the cloud properties ask for a baremetal server", func() {
+			BeforeEach(func() {
+				cloudProps.Baremetal = true
+			})
+
+			It("returns an error", func() {
+				_, err := quoter.Quote(stemcell, cloudProps, networks)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("baremetal"))
+			})
+		})
+	})
+})
@@ -20,8 +20,11 @@ type CpiTemplate struct {
 }
 
 type ConfigTemplate struct {
-	Username string
-	ApiKey   string
+	Username     string
+	ApiKey       string
+	ApiEndpoint  string
+	RegistryHost string
+	RegistryPort int
 }
 
 const templatePath = "../test_fixtures/cpi_methods"
@@ -87,6 +90,7 @@ func CreateTmpConfigPath(rootTemplatePath string, configPath string, username st
 		Username: username,
 		ApiKey:   apiKey,
 	}
+	configTemplate.ApiEndpoint, configTemplate.RegistryHost, configTemplate.RegistryPort = simulatorConfig()
 
 	t := template.New("config.json")
 
@@ -101,6 +101,20 @@ var _ = Describe("helper functions for integration tests", func() {
 				`))
 			})
 
+			It("fills the template with the values of a CpiTemplate", func() {
+				replacementMap = map[string]string{
+					"ID":             cpiTemplate.ID,
+					"DirectorUuid":   cpiTemplate.DirectorUuid,
+					"Tag_deployment": cpiTemplate.Tag_deployment,
+					"Tag_compiling":  cpiTemplate.Tag_compiling,
+				}
+
+				payload, err := testhelpers.GenerateCpiJsonPayload("set_vm_metadata", rootTemplatePath, replacementMap)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(payload).To(ContainSubstring(`"fake-id"`))
+				Expect(payload).To(ContainSubstring(`"director_uuid": "fake-director-uuid"`))
+			})
+
 			It("fails due to non-existant json template", func() {
 				_, err := testhelpers.GenerateCpiJsonPayload("does_not_exist", rootTemplatePath, replacementMap)
 				Expect(err).To(HaveOccurred())
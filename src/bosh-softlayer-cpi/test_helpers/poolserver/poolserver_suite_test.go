@@ -0,0 +1,13 @@
+package poolserver_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPoolserver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Poolserver Suite")
+}
@@ -0,0 +1,511 @@
+// Package poolserver is an in-process stand-in for the SoftLayer VM pool server, so that the pool
+// creator and deleter can be tested through the generated client in softlayer/pool/client.
+//
+// The Server implements the API of softlayer/pool/swagger.yaml under its /v2 base path. It keeps
+// the pool in memory, and in a JSON file as well when Options name one, so that a pool outlives
+// the server. Orders lease the vms they hand out, and a vm whose lease expires goes back to free.
+package poolserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+
+	apiclient "bosh-softlayer-cpi/softlayer/pool/client"
+	operations "bosh-softlayer-cpi/softlayer/pool/client/vm"
+	"bosh-softlayer-cpi/softlayer/pool/models"
+
+	httptransport "github.com/go-openapi/runtime/client"
+)
+
+// BasePath is the path the pool API is served under
+const BasePath = "/v2"
+
+// Options configure how the Server behaves
+type Options struct {
+	// StateFile, when set, is where the pool is loaded from and saved to on every change
+	StateFile string
+
+	// Now is the clock the create and modify dates of vms and the expiry of leases are taken from
+	Now func() time.Time
+
+	// WithoutLeases serves the pool like a server from before leases, which has no lease endpoint
+	// and keeps vms provisioning until they are marked using or freed
+	WithoutLeases bool
+}
+
+// Server serves the VM pool API under /v2/vms
+type Server struct {
+	options Options
+	store   *store
+
+	listener net.Listener
+	server   *http.Server
+}
+
+func New(options Options) (*Server, error) {
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	store, err := newStore(options.StateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		options: options,
+		store:   store,
+	}, nil
+}
+
+// Start serves the pool on a free port of the loopback interface
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+	s.server = &http.Server{Handler: s}
+	go s.server.Serve(listener)
+
+	return nil
+}
+
+// Close stops serving the pool
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+
+	return s.server.Close()
+}
+
+// Address returns the host and port of the running server, such as 127.0.0.1:34567
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// Client returns a pool client that talks to the running server over http
+func (s *Server) Client() operations.SoftLayerPoolClient {
+	return apiclient.New(httptransport.New(s.Address(), BasePath, []string{"http"}), strfmt.Default).VM
+}
+
+// Add puts vm into the pool as it is, replacing any vm with the same cid
+func (s *Server) Add(vm models.VM) error {
+	return s.store.put(vm)
+}
+
+// VMs returns the vms of the pool ordered by cid
+func (s *Server) VMs() []models.VM {
+	return s.store.list()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, BasePath+"/vms") {
+		writeError(w, http.StatusNotFound, models.ErrorTypeRouterError, fmt.Sprintf("No route for %s %s", r.Method, r.URL.Path))
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, BasePath+"/vms")
+
+	err := s.store.expireLeases(s.options.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, models.ErrorTypeUnknownError, err.Error())
+		return
+	}
+
+	switch {
+	case path == "" && r.Method == "POST":
+		s.addVM(w, r)
+	case path == "" && r.Method == "GET":
+		s.listVM(w, r)
+	case path == "" && r.Method == "PUT":
+		s.updateVM(w, r)
+	case path == "/findByFilters" && r.Method == "POST":
+		s.findVMsByFilters(w, r)
+	case path == "/order" && r.Method == "POST":
+		s.orderVMByFilter(w, r)
+	case path == "/findByDeployment" && r.Method == "GET":
+		s.findVMsByDeployment(w, r)
+	case path == "/findByState" && r.Method == "GET":
+		s.findVMsByStates(w, r)
+	case strings.HasSuffix(path, "/lease") && r.Method == "PUT":
+		if s.options.WithoutLeases {
+			writeError(w, http.StatusNotFound, models.ErrorTypeRouterError, fmt.Sprintf("No route for %s %s", r.Method, r.URL.Path))
+			return
+		}
+
+		cid, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/lease"), 10, 32)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, models.ErrorTypeInvalidRequest, fmt.Sprintf("Invalid cid: %s", err.Error()))
+			return
+		}
+
+		s.renewVMLease(w, r, int32(cid))
+	case strings.HasPrefix(path, "/"):
+		cid, err := strconv.ParseInt(strings.TrimPrefix(path, "/"), 10, 32)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, models.ErrorTypeInvalidRequest, fmt.Sprintf("Invalid cid: %s", err.Error()))
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			s.getVMByCid(w, int32(cid))
+		case "PUT":
+			s.updateVMWithState(w, r, int32(cid))
+		case "DELETE":
+			s.deleteVM(w, int32(cid))
+		default:
+			writeError(w, http.StatusMethodNotAllowed, models.ErrorTypeRouterError, fmt.Sprintf("No route for %s %s", r.Method, r.URL.Path))
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, models.ErrorTypeRouterError, fmt.Sprintf("No route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) addVM(w http.ResponseWriter, r *http.Request) {
+	vm := models.VM{}
+	if !readBody(w, r, &vm) {
+		return
+	}
+	if vm.Cid == 0 {
+		writeError(w, http.StatusBadRequest, models.ErrorTypeInvalidRequest, "The cid of a vm is required")
+		return
+	}
+
+	now := strfmt.DateTime(s.options.Now())
+	vm.CreateDate = now
+	vm.ModifyDate = now
+	if vm.State == "" {
+		vm.State = models.StateUnknown
+	}
+
+	added, err := s.store.add(vm)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, models.ErrorTypeUnknownError, err.Error())
+		return
+	}
+	if !added {
+		writeError(w, http.StatusConflict, models.ErrorTypeResourceExist, fmt.Sprintf("vm %d is already in the pool", vm.Cid))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fmt.Sprintf("added vm %d", vm.Cid))
+}
+
+func (s *Server) listVM(w http.ResponseWriter, r *http.Request) {
+	writeVMs(w, s.store.list())
+}
+
+func (s *Server) updateVM(w http.ResponseWriter, r *http.Request) {
+	vm := models.VM{}
+	if !readBody(w, r, &vm) {
+		return
+	}
+
+	conflict := false
+	found, err := s.store.update(vm.Cid, func(stored *models.VM) {
+		if vm.Lease != nil && !s.options.WithoutLeases && !leasedTo(*stored, vm.Lease.Holder) {
+			conflict = true
+			return
+		}
+
+		mergeVM(stored, vm)
+		stored.ModifyDate = strfmt.DateTime(s.options.Now())
+	})
+	if !writeUpdateResult(w, vm.Cid, found, err) {
+		return
+	}
+	if conflict {
+		writeError(w, http.StatusConflict, models.ErrorTypeResourceConflict, fmt.Sprintf("vm %d is not leased to %s", vm.Cid, vm.Lease.Holder))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fmt.Sprintf("updated vm %d", vm.Cid))
+}
+
+func (s *Server) updateVMWithState(w http.ResponseWriter, r *http.Request, cid int32) {
+	state := models.VMState{}
+	if !readBody(w, r, &state) {
+		return
+	}
+	if state.State == "" {
+		writeError(w, http.StatusBadRequest, models.ErrorTypeInvalidRequest, "The state of a vm is required")
+		return
+	}
+
+	found, err := s.store.update(cid, func(stored *models.VM) {
+		stored.State = state.State
+		if stored.State != models.StateProvisioning {
+			stored.Lease = nil
+		}
+		stored.ModifyDate = strfmt.DateTime(s.options.Now())
+	})
+	if !writeUpdateResult(w, cid, found, err) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fmt.Sprintf("updated the state of vm %d to %s", cid, state.State))
+}
+
+func (s *Server) findVMsByFilters(w http.ResponseWriter, r *http.Request) {
+	filter := models.VMFilter{}
+	if !readBody(w, r, &filter) {
+		return
+	}
+
+	writeVMs(w, s.store.find(func(vm models.VM) bool { return matchesFilter(vm, filter) }))
+}
+
+// orderVMByFilter hands out the free vm with the lowest cid matching the filter, and moves it to
+// provisioning so that no other order gets it. The vm is leased to the holder the filter names,
+// unless the server keeps no leases.
+func (s *Server) orderVMByFilter(w http.ResponseWriter, r *http.Request) {
+	filter := models.VMFilter{}
+	if !readBody(w, r, &filter) {
+		return
+	}
+	filter.State = models.StateFree
+
+	ordered, found, err := s.store.take(func(vm models.VM) bool { return matchesFilter(vm, filter) }, func(vm *models.VM) {
+		vm.State = models.StateProvisioning
+		if !s.options.WithoutLeases {
+			vm.Lease = s.lease(filter.Lease)
+		}
+		vm.ModifyDate = strfmt.DateTime(s.options.Now())
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, models.ErrorTypeUnknownError, err.Error())
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.VMResponse{VM: &ordered})
+}
+
+// renewVMLease extends the lease of a provisioning vm by the duration asked, when the vm is leased
+// to the holder asking
+func (s *Server) renewVMLease(w http.ResponseWriter, r *http.Request, cid int32) {
+	lease := models.Lease{}
+	if !readBody(w, r, &lease) {
+		return
+	}
+
+	var renewed models.VM
+	conflict := false
+	found, err := s.store.update(cid, func(stored *models.VM) {
+		if stored.State != models.StateProvisioning || !leasedTo(*stored, lease.Holder) {
+			conflict = true
+			return
+		}
+
+		if lease.Duration == 0 {
+			lease.Duration = stored.Lease.Duration
+		}
+		stored.Lease = s.lease(&lease)
+		renewed = *stored
+	})
+	if !writeUpdateResult(w, cid, found, err) {
+		return
+	}
+	if conflict {
+		writeError(w, http.StatusConflict, models.ErrorTypeResourceConflict, fmt.Sprintf("vm %d is not leased to %s", cid, lease.Holder))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.VMResponse{VM: &renewed})
+}
+
+// lease returns the lease asked for, expiring its duration from now. Leases without a duration
+// never expire.
+func (s *Server) lease(asked *models.Lease) *models.Lease {
+	if asked == nil || asked.Holder == "" {
+		return nil
+	}
+
+	lease := &models.Lease{
+		Holder:   asked.Holder,
+		Duration: asked.Duration,
+	}
+	if lease.Duration > 0 {
+		lease.Expiry = strfmt.DateTime(s.options.Now().Add(time.Duration(lease.Duration) * time.Second))
+	}
+
+	return lease
+}
+
+func (s *Server) findVMsByDeployment(w http.ResponseWriter, r *http.Request) {
+	deployments := r.URL.Query()["deployment"]
+
+	writeVMs(w, s.store.find(func(vm models.VM) bool {
+		for _, deployment := range deployments {
+			if vm.DeploymentName == deployment {
+				return true
+			}
+		}
+		return false
+	}))
+}
+
+func (s *Server) findVMsByStates(w http.ResponseWriter, r *http.Request) {
+	states := r.URL.Query()["states"]
+	for _, state := range states {
+		if err := models.State(state).Validate(strfmt.Default); err != nil {
+			writeError(w, http.StatusBadRequest, models.ErrorTypeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	writeVMs(w, s.store.find(func(vm models.VM) bool {
+		for _, state := range states {
+			if string(vm.State) == state {
+				return true
+			}
+		}
+		return false
+	}))
+}
+
+func (s *Server) getVMByCid(w http.ResponseWriter, cid int32) {
+	vm, found := s.store.get(cid)
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.VMResponse{VM: &vm})
+}
+
+func (s *Server) deleteVM(w http.ResponseWriter, cid int32) {
+	found, err := s.store.remove(cid)
+	if !writeUpdateResult(w, cid, found, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mergeVM copies the fields set in update onto vm
+func mergeVM(vm *models.VM, update models.VM) {
+	if update.CPU != 0 {
+		vm.CPU = update.CPU
+	}
+	if update.DeploymentName != "" {
+		vm.DeploymentName = update.DeploymentName
+	}
+	if update.Hostname != "" {
+		vm.Hostname = update.Hostname
+	}
+	if update.IP != "" {
+		vm.IP = update.IP
+	}
+	if update.MemoryMb != 0 {
+		vm.MemoryMb = update.MemoryMb
+	}
+	if update.PrivateVlan != 0 {
+		vm.PrivateVlan = update.PrivateVlan
+	}
+	if update.PublicVlan != 0 {
+		vm.PublicVlan = update.PublicVlan
+	}
+	if update.State != "" {
+		vm.State = update.State
+	}
+	if vm.State != models.StateProvisioning {
+		vm.Lease = nil
+	}
+}
+
+// leasedTo tells whether vm is leased to holder
+func leasedTo(vm models.VM, holder string) bool {
+	return vm.Lease != nil && vm.Lease.Holder == holder
+}
+
+// matchesFilter tells whether vm has every field set in filter
+func matchesFilter(vm models.VM, filter models.VMFilter) bool {
+	return (filter.Cid == 0 || vm.Cid == filter.Cid) &&
+		(filter.CPU == 0 || vm.CPU == filter.CPU) &&
+		(filter.IP == "" || vm.IP == filter.IP) &&
+		(filter.MemoryMb == 0 || vm.MemoryMb == filter.MemoryMb) &&
+		(filter.PrivateVlan == 0 || vm.PrivateVlan == filter.PrivateVlan) &&
+		(filter.PublicVlan == 0 || vm.PublicVlan == filter.PublicVlan) &&
+		(filter.State == "" || vm.State == filter.State)
+}
+
+type validatable interface {
+	Validate(strfmt.Registry) error
+}
+
+// readBody decodes and validates the JSON body of r into value, answering the request itself when
+// that fails
+func readBody(w http.ResponseWriter, r *http.Request, value validatable) bool {
+	err := json.NewDecoder(r.Body).Decode(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, models.ErrorTypeInvalidJSON, err.Error())
+		return false
+	}
+
+	err = value.Validate(strfmt.Default)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, models.ErrorTypeInvalidRequest, err.Error())
+		return false
+	}
+
+	return true
+}
+
+// writeUpdateResult answers the request when the vm with cid is missing or the store failed
+func writeUpdateResult(w http.ResponseWriter, cid int32, found bool, err error) bool {
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, models.ErrorTypeUnknownError, err.Error())
+		return false
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return false
+	}
+
+	return true
+}
+
+// writeVMs answers with vms, or with 404 when there are none
+func writeVMs(w http.ResponseWriter, vms []models.VM) {
+	if len(vms) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response := models.VmsResponse{}
+	for i := range vms {
+		response.Vms = append(response.Vms, &vms[i])
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, errorType models.ErrorType, message string) {
+	writeJSON(w, statusCode, models.Error{Type: errorType, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		body, _ = json.Marshal(models.Error{Type: models.ErrorTypeUnknownError, Message: err.Error()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
@@ -0,0 +1,260 @@
+package poolserver_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-openapi/strfmt"
+
+	operations "bosh-softlayer-cpi/softlayer/pool/client/vm"
+	"bosh-softlayer-cpi/softlayer/pool/models"
+
+	. "bosh-softlayer-cpi/test_helpers/poolserver"
+)
+
+func freeVM(cid int32) *models.VM {
+	return &models.VM{
+		Cid:         cid,
+		CPU:         4,
+		MemoryMb:    2048,
+		PrivateVlan: 524956,
+		PublicVlan:  524956,
+		State:       models.StateFree,
+	}
+}
+
+var _ = Describe("Server", func() {
+	var (
+		now    time.Time
+		server *Server
+		client operations.SoftLayerPoolClient
+	)
+
+	BeforeEach(func() {
+		var err error
+		now = time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+		server, err = New(Options{Now: func() time.Time { return now }})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.Start()).To(Succeed())
+
+		client = server.Client()
+	})
+
+	AfterEach(func() {
+		Expect(server.Close()).To(Succeed())
+	})
+
+	It("adds vms and gets them by cid", func() {
+		_, err := client.AddVM(operations.NewAddVMParams().WithBody(freeVM(1234567)))
+		Expect(err).ToNot(HaveOccurred())
+
+		response, err := client.GetVMByCid(operations.NewGetVMByCidParams().WithCid(1234567))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Payload.VM.CPU).To(Equal(int32(4)))
+		Expect(response.Payload.VM.State).To(Equal(models.StateFree))
+		Expect(response.Payload.VM.CreateDate.String()).To(Equal(strfmt.DateTime(now).String()))
+	})
+
+	It("refuses to add a vm twice", func() {
+		_, err := client.AddVM(operations.NewAddVMParams().WithBody(freeVM(1234567)))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.AddVM(operations.NewAddVMParams().WithBody(freeVM(1234567)))
+		Expect(err).To(BeAssignableToTypeOf(&operations.AddVMDefault{}))
+		Expect(err.(*operations.AddVMDefault).Code()).To(Equal(http.StatusConflict))
+		Expect(err.(*operations.AddVMDefault).Payload.Type).To(Equal(models.ErrorTypeResourceExist))
+	})
+
+	It("rejects a vm in a state the API does not know", func() {
+		vm := freeVM(1234567)
+		vm.State = "fake-state"
+
+		_, err := client.AddVM(operations.NewAddVMParams().WithBody(vm))
+		Expect(err).To(BeAssignableToTypeOf(&operations.AddVMDefault{}))
+		Expect(err.(*operations.AddVMDefault).Code()).To(Equal(http.StatusBadRequest))
+		Expect(err.(*operations.AddVMDefault).Payload.Type).To(Equal(models.ErrorTypeInvalidRequest))
+	})
+
+	It("returns not found for a vm missing from the pool", func() {
+		_, err := client.GetVMByCid(operations.NewGetVMByCidParams().WithCid(1234567))
+		Expect(err).To(BeAssignableToTypeOf(&operations.GetVMByCidNotFound{}))
+
+		_, err = client.UpdateVMWithState(operations.NewUpdateVMWithStateParams().WithCid(1234567).WithBody(&models.VMState{State: models.StateFree}))
+		Expect(err).To(BeAssignableToTypeOf(&operations.UpdateVMWithStateNotFound{}))
+
+		_, err = client.UpdateVM(operations.NewUpdateVMParams().WithBody(freeVM(1234567)))
+		Expect(err).To(BeAssignableToTypeOf(&operations.UpdateVMNotFound{}))
+
+		_, err = client.DeleteVM(operations.NewDeleteVMParams().WithCid(1234567))
+		Expect(err).To(BeAssignableToTypeOf(&operations.DeleteVMNotFound{}))
+
+		_, err = client.ListVM(operations.NewListVMParams())
+		Expect(err).To(BeAssignableToTypeOf(&operations.ListVMNotFound{}))
+	})
+
+	It("updates vms and their state", func() {
+		_, err := client.AddVM(operations.NewAddVMParams().WithBody(freeVM(1234567)))
+		Expect(err).ToNot(HaveOccurred())
+		now = now.Add(time.Hour)
+
+		_, err = client.UpdateVM(operations.NewUpdateVMParams().WithBody(&models.VM{Cid: 1234567, Hostname: "bosh-test.fake-domain.com", DeploymentName: "fake-deployment"}))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.UpdateVMWithState(operations.NewUpdateVMWithStateParams().WithCid(1234567).WithBody(&models.VMState{State: models.StateUsing}))
+		Expect(err).ToNot(HaveOccurred())
+
+		vms := server.VMs()
+		Expect(vms).To(HaveLen(1))
+		Expect(vms[0].Hostname).To(Equal("bosh-test.fake-domain.com"))
+		Expect(vms[0].DeploymentName).To(Equal("fake-deployment"))
+		Expect(vms[0].CPU).To(Equal(int32(4)))
+		Expect(vms[0].State).To(Equal(models.StateUsing))
+		Expect(vms[0].ModifyDate.String()).To(Equal(strfmt.DateTime(now).String()))
+	})
+
+	It("finds vms by filters, states and deployment", func() {
+		for _, cid := range []int32{1, 2, 3} {
+			vm := freeVM(cid)
+			if cid == 2 {
+				vm.CPU = 2
+				vm.State = models.StateUsing
+				vm.DeploymentName = "fake-deployment"
+			}
+			Expect(server.Add(*vm)).To(Succeed())
+		}
+
+		byFilters, err := client.FindVmsByFilters(operations.NewFindVmsByFiltersParams().WithBody(&models.VMFilter{CPU: 4, PublicVlan: 524956}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(byFilters.Payload.Vms).To(HaveLen(2))
+		Expect(byFilters.Payload.Vms[0].Cid).To(Equal(int32(1)))
+		Expect(byFilters.Payload.Vms[1].Cid).To(Equal(int32(3)))
+
+		byStates, err := client.FindVmsByStates(operations.NewFindVmsByStatesParams().WithStates([]string{"using", "provisioning"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(byStates.Payload.Vms).To(HaveLen(1))
+		Expect(byStates.Payload.Vms[0].Cid).To(Equal(int32(2)))
+
+		byDeployment, err := client.FindVmsByDeployment(operations.NewFindVmsByDeploymentParams().WithDeployment([]string{"fake-deployment"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(byDeployment.Payload.Vms).To(HaveLen(1))
+
+		_, err = client.FindVmsByFilters(operations.NewFindVmsByFiltersParams().WithBody(&models.VMFilter{CPU: 8}))
+		Expect(err).To(BeAssignableToTypeOf(&operations.FindVmsByFiltersNotFound{}))
+	})
+
+	It("orders the free vm with the lowest cid matching the filter", func() {
+		Expect(server.Add(*freeVM(2))).To(Succeed())
+		Expect(server.Add(*freeVM(1))).To(Succeed())
+
+		ordered, err := client.OrderVMByFilter(operations.NewOrderVMByFilterParams().WithBody(&models.VMFilter{CPU: 4, MemoryMb: 2048, State: models.StateFree}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ordered.Payload.VM.Cid).To(Equal(int32(1)))
+		Expect(ordered.Payload.VM.State).To(Equal(models.StateProvisioning))
+
+		_, err = client.OrderVMByFilter(operations.NewOrderVMByFilterParams().WithBody(&models.VMFilter{CPU: 8, State: models.StateFree}))
+		Expect(err).To(BeAssignableToTypeOf(&operations.OrderVMByFilterNotFound{}))
+	})
+
+	Context("leases", func() {
+		var leased *models.VM
+
+		BeforeEach(func() {
+			Expect(server.Add(*freeVM(1234567))).To(Succeed())
+
+			ordered, err := client.OrderVMByFilter(operations.NewOrderVMByFilterParams().WithBody(&models.VMFilter{CPU: 4, Lease: &models.Lease{Holder: "fake-agent-id", Duration: 60}}))
+			Expect(err).ToNot(HaveOccurred())
+			leased = ordered.Payload.VM
+		})
+
+		It("leases the ordered vm to the holder of the filter", func() {
+			Expect(leased.Lease.Holder).To(Equal("fake-agent-id"))
+			Expect(leased.Lease.Duration).To(Equal(int32(60)))
+			Expect(leased.Lease.Expiry.String()).To(Equal(strfmt.DateTime(now.Add(time.Minute)).String()))
+		})
+
+		It("renews the lease of its holder only", func() {
+			now = now.Add(30 * time.Second)
+
+			renewed, err := client.RenewVMLease(operations.NewRenewVMLeaseParams().WithCid(1234567).WithBody(&models.Lease{Holder: "fake-agent-id", Duration: 120}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(renewed.Payload.VM.Lease.Expiry.String()).To(Equal(strfmt.DateTime(now.Add(2 * time.Minute)).String()))
+
+			_, err = client.RenewVMLease(operations.NewRenewVMLeaseParams().WithCid(1234567).WithBody(&models.Lease{Holder: "other-agent-id"}))
+			Expect(err).To(BeAssignableToTypeOf(&operations.RenewVMLeaseConflict{}))
+			Expect(err.(*operations.RenewVMLeaseConflict).Payload.Type).To(Equal(models.ErrorTypeResourceConflict))
+
+			_, err = client.RenewVMLease(operations.NewRenewVMLeaseParams().WithCid(7654321).WithBody(&models.Lease{Holder: "fake-agent-id"}))
+			Expect(err).To(BeAssignableToTypeOf(&operations.RenewVMLeaseNotFound{}))
+		})
+
+		It("frees the vm once the lease expires", func() {
+			now = now.Add(time.Minute + time.Second)
+
+			response, err := client.GetVMByCid(operations.NewGetVMByCidParams().WithCid(1234567))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Payload.VM.State).To(Equal(models.StateFree))
+			Expect(response.Payload.VM.Lease).To(BeNil())
+
+			_, err = client.RenewVMLease(operations.NewRenewVMLeaseParams().WithCid(1234567).WithBody(&models.Lease{Holder: "fake-agent-id"}))
+			Expect(err).To(BeAssignableToTypeOf(&operations.RenewVMLeaseConflict{}))
+		})
+
+		It("lets only the holder update the leased vm, which ends the lease", func() {
+			vm := freeVM(1234567)
+			vm.State = models.StateUsing
+			vm.Lease = &models.Lease{Holder: "other-agent-id"}
+
+			_, err := client.UpdateVM(operations.NewUpdateVMParams().WithBody(vm))
+			Expect(err).To(BeAssignableToTypeOf(&operations.UpdateVMDefault{}))
+			Expect(err.(*operations.UpdateVMDefault).Code()).To(Equal(http.StatusConflict))
+
+			vm.Lease.Holder = "fake-agent-id"
+			_, err = client.UpdateVM(operations.NewUpdateVMParams().WithBody(vm))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(server.VMs()[0].State).To(Equal(models.StateUsing))
+			Expect(server.VMs()[0].Lease).To(BeNil())
+		})
+	})
+
+	It("deletes vms", func() {
+		Expect(server.Add(*freeVM(1234567))).To(Succeed())
+
+		_, err := client.DeleteVM(operations.NewDeleteVMParams().WithCid(1234567))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.VMs()).To(BeEmpty())
+	})
+
+	Context("with a state file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "poolserver")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("keeps the pool across servers", func() {
+			stateFile := filepath.Join(dir, "pool.json")
+
+			first, err := New(Options{StateFile: stateFile})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first.Add(*freeVM(1234567))).To(Succeed())
+
+			second, err := New(Options{StateFile: stateFile})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second.VMs()).To(HaveLen(1))
+			Expect(second.VMs()[0].Cid).To(Equal(int32(1234567)))
+		})
+	})
+})
@@ -0,0 +1,183 @@
+package poolserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"bosh-softlayer-cpi/softlayer/pool/models"
+)
+
+// store holds the vms of the pool keyed by cid. Every method runs under one lock, so that an
+// order cannot hand out a vm another order is handing out.
+type store struct {
+	lock sync.Mutex
+	vms  map[int32]models.VM
+	path string
+}
+
+// newStore returns an empty store, or the one saved at path when path is set and exists
+func newStore(path string) (*store, error) {
+	s := &store{
+		vms:  map[int32]models.VM{},
+		path: path,
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	vms := []models.VM{}
+	err = json.Unmarshal(contents, &vms)
+	if err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		s.vms[vm.Cid] = vm
+	}
+
+	return s, nil
+}
+
+func (s *store) get(cid int32) (models.VM, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	vm, found := s.vms[cid]
+	return vm, found
+}
+
+func (s *store) list() []models.VM {
+	return s.find(func(models.VM) bool { return true })
+}
+
+// find returns the vms matching, ordered by cid
+func (s *store) find(matching func(models.VM) bool) []models.VM {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.sorted(matching)
+}
+
+func (s *store) put(vm models.VM) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.vms[vm.Cid] = vm
+	return s.save()
+}
+
+// add puts vm into the store unless one with its cid is there already
+func (s *store) add(vm models.VM) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, found := s.vms[vm.Cid]; found {
+		return false, nil
+	}
+
+	s.vms[vm.Cid] = vm
+	return true, s.save()
+}
+
+// update applies change to the vm with cid, if there is one
+func (s *store) update(cid int32, change func(*models.VM)) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	vm, found := s.vms[cid]
+	if !found {
+		return false, nil
+	}
+
+	change(&vm)
+	s.vms[cid] = vm
+	return true, s.save()
+}
+
+// take applies change to the first vm matching and returns it changed
+func (s *store) take(matching func(models.VM) bool, change func(*models.VM)) (models.VM, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	vms := s.sorted(matching)
+	if len(vms) == 0 {
+		return models.VM{}, false, nil
+	}
+
+	vm := vms[0]
+	change(&vm)
+	s.vms[vm.Cid] = vm
+	return vm, true, s.save()
+}
+
+func (s *store) remove(cid int32) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, found := s.vms[cid]; !found {
+		return false, nil
+	}
+
+	delete(s.vms, cid)
+	return true, s.save()
+}
+
+// expireLeases frees the provisioning vms whose leases expired before now
+func (s *store) expireLeases(now time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	expired := false
+	for cid, vm := range s.vms {
+		if vm.State != models.StateProvisioning || vm.Lease == nil || time.Time(vm.Lease.Expiry).IsZero() || !time.Time(vm.Lease.Expiry).Before(now) {
+			continue
+		}
+
+		vm.State = models.StateFree
+		vm.Lease = nil
+		s.vms[cid] = vm
+		expired = true
+	}
+	if !expired {
+		return nil
+	}
+
+	return s.save()
+}
+
+func (s *store) sorted(matching func(models.VM) bool) []models.VM {
+	vms := []models.VM{}
+	for _, vm := range s.vms {
+		if matching(vm) {
+			vms = append(vms, vm)
+		}
+	}
+	sort.Slice(vms, func(i, j int) bool { return vms[i].Cid < vms[j].Cid })
+
+	return vms
+}
+
+// save writes the store to its file, if it has one. The caller holds the lock.
+func (s *store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	contents, err := json.MarshalIndent(s.sorted(func(models.VM) bool { return true }), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, contents, 0644)
+}
@@ -0,0 +1,94 @@
+package simulator
+
+import (
+	"net/http"
+	"time"
+)
+
+// Fault makes calls of an API method fail
+type Fault struct {
+	// Method is the API method, such as SoftLayer_Virtual_Guest::getPowerState. An empty Method
+	// matches every call.
+	Method string
+
+	// StatusCode and Message make up the error response. A StatusCode of 0 drops the connection
+	// without a response.
+	StatusCode int
+	Message    string
+
+	// Times is how many calls fail, 0 for every call
+	Times int
+
+	// Delay holds the response back, such as to make the client time out
+	Delay time.Duration
+
+	failed int
+}
+
+// Faults commonly injected
+var (
+	// FaultThrottled is the response of SoftLayer to a client calling it too often
+	FaultThrottled = Fault{StatusCode: 429, Message: "Rate limit exceeded, too many requests"}
+	// FaultTransactionInProgress is the response of SoftLayer to a change of an object a
+	// transaction is running on
+	FaultTransactionInProgress = Fault{StatusCode: 500, Message: "A transaction is in progress, please try again after it completes"}
+	// FaultUnavailable is the response of a SoftLayer endpoint under maintenance
+	FaultUnavailable = Fault{StatusCode: 503, Message: "Service Unavailable"}
+	// FaultConnectionDropped closes the connection without a response
+	FaultConnectionDropped = Fault{StatusCode: 0}
+)
+
+// InjectFault makes the calls of fault.Method fail until it has failed fault.Times calls.
+// Faults are matched in the order they were injected.
+func (s *Simulator) InjectFault(fault Fault) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes every injected fault
+func (s *Simulator) ClearFaults() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.faults = nil
+}
+
+// takeFault returns the fault the call of the method fails with, if any
+func (s *Simulator) takeFault(method string) *Fault {
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != method {
+			continue
+		}
+
+		fault.failed++
+		if fault.Times > 0 && fault.failed >= fault.Times {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+
+		return fault
+	}
+
+	return nil
+}
+
+func (f *Fault) write(w http.ResponseWriter) {
+	if f.Delay > 0 {
+		time.Sleep(f.Delay)
+	}
+
+	statusCode := f.StatusCode
+	if statusCode == 0 {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			connection, _, err := hijacker.Hijack()
+			if err == nil {
+				connection.Close()
+				return
+			}
+		}
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	writeResponse(w, statusCode, apiError{Code: "SoftLayer_Exception_Public", Message: f.Message})
+}
@@ -0,0 +1,333 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// object is a SoftLayer object the way the API renders it, decoded from JSON
+type object map[string]interface{}
+
+func (o object) id() int {
+	return intValue(o["id"])
+}
+
+// Services the simulator keeps objects of
+const (
+	VirtualGuestService  = "SoftLayer_Virtual_Guest"
+	HardwareService      = "SoftLayer_Hardware"
+	NetworkStorage       = "SoftLayer_Network_Storage"
+	ImageTemplateService = "SoftLayer_Virtual_Guest_Block_Device_Template_Group"
+	TagService           = "SoftLayer_Tag"
+	SshKeyService        = "SoftLayer_Security_Ssh_Key"
+)
+
+// Services whose objects are kept under another name, such as SoftLayer_Hardware_Server
+var serviceAliases = map[string]string{
+	"SoftLayer_Hardware_Server":       HardwareService,
+	"SoftLayer_Network_Storage_Iscsi": NetworkStorage,
+}
+
+// Lists of SoftLayer_Account and the services of their objects
+var accountLists = map[string]string{
+	"virtualGuests":             VirtualGuestService,
+	"hardware":                  HardwareService,
+	"networkStorage":            NetworkStorage,
+	"iscsiNetworkStorage":       NetworkStorage,
+	"blockDeviceTemplateGroups": ImageTemplateService,
+	"sshKeys":                   SshKeyService,
+	"tags":                      TagService,
+	"dedicatedHosts":            "SoftLayer_Virtual_DedicatedHost",
+	"placementGroups":           "SoftLayer_Virtual_PlacementGroup",
+}
+
+func canonicalService(service string) string {
+	if alias, found := serviceAliases[service]; found {
+		return alias
+	}
+
+	return service
+}
+
+// Add stores an object of the service, such as a stemcell the tests boot virtual guests from, and
+// returns its ID. An object without an ID gets a new one.
+func (s *Simulator) Add(service string, properties map[string]interface{}) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.add(service, properties).id()
+}
+
+// Object returns the object of the service with the ID as the API would
+func (s *Simulator) Object(service string, id int) (map[string]interface{}, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	o, found := s.get(service, id)
+	if !found {
+		return nil, false
+	}
+
+	return normalize(o).(map[string]interface{}), true
+}
+
+// Objects returns every object of the service, ordered by ID
+func (s *Simulator) Objects(service string) []map[string]interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	objects := []map[string]interface{}{}
+	for _, o := range s.list(service) {
+		objects = append(objects, normalize(o).(map[string]interface{}))
+	}
+
+	return objects
+}
+
+// Load adds the objects of a seed file, which holds a list of objects for each service:
+//
+//	{"SoftLayer_Virtual_Guest_Block_Device_Template_Group": [{"id": 879741, "globalIdentifier": "..."}]}
+func (s *Simulator) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	seed := map[string][]map[string]interface{}{}
+	err = json.Unmarshal(data, &seed)
+	if err != nil {
+		return fmt.Errorf("Unmarshalling seed file %s: %s", path, err.Error())
+	}
+
+	for service, objects := range seed {
+		for _, properties := range objects {
+			s.Add(service, properties)
+		}
+	}
+
+	return nil
+}
+
+func (s *Simulator) add(service string, properties map[string]interface{}) object {
+	service = canonicalService(service)
+
+	o := object(normalize(properties).(map[string]interface{}))
+	if o.id() == 0 {
+		o["id"] = float64(s.newID())
+	} else if o.id() >= s.nextID {
+		s.nextID = o.id() + 1
+	}
+
+	if s.objects[service] == nil {
+		s.objects[service] = map[int]object{}
+	}
+	s.objects[service][o.id()] = o
+
+	return o
+}
+
+func (s *Simulator) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Simulator) get(service string, id int) (object, bool) {
+	o, found := s.objects[canonicalService(service)][id]
+	return o, found
+}
+
+func (s *Simulator) remove(service string, id int) {
+	delete(s.objects[canonicalService(service)], id)
+}
+
+func (s *Simulator) list(service string) []object {
+	objects := []object{}
+	for _, o := range s.objects[canonicalService(service)] {
+		objects = append(objects, o)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].id() < objects[j].id() })
+
+	return objects
+}
+
+// filter returns the objects matching the objectFilter given for them
+func filter(objects []object, objectFilter interface{}) []object {
+	if objectFilter == nil {
+		return objects
+	}
+
+	matching := []object{}
+	for _, o := range objects {
+		if matches(map[string]interface{}(o), objectFilter) {
+			matching = append(matching, o)
+		}
+	}
+
+	return matching
+}
+
+// matches tells whether the value satisfies an objectFilter, which names the properties to look
+// into down to an operation such as {"operation": "~ bosh"}. A list matches when one of its
+// elements does.
+func matches(value interface{}, objectFilter interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		for _, element := range list {
+			if matches(element, objectFilter) {
+				return true
+			}
+		}
+		return false
+	}
+
+	conditions, ok := objectFilter.(map[string]interface{})
+	if !ok {
+		return true
+	}
+
+	if operation, found := conditions["operation"]; found {
+		return matchesOperation(value, operation, conditions["options"])
+	}
+
+	properties, _ := value.(map[string]interface{})
+	for property, condition := range conditions {
+		if !matches(properties[property], condition) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var stringOperators = []string{"~ ", "*= ", "^= ", "$= ", "_= ", "!= ", ">= ", "<= ", "> ", "< "}
+
+func matchesOperation(value interface{}, operation interface{}, options interface{}) bool {
+	text := stringValue(value)
+
+	op, isString := operation.(string)
+	if !isString {
+		return value != nil && text == stringValue(operation)
+	}
+
+	switch op {
+	case "is null":
+		return value == nil
+	case "not null":
+		return value != nil
+	case "in":
+		for _, option := range optionValues(options) {
+			if value != nil && text == stringValue(option) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, operator := range stringOperators {
+		if !strings.HasPrefix(op, operator) {
+			continue
+		}
+
+		operand := strings.TrimPrefix(op, operator)
+		switch operator {
+		case "~ ", "*= ":
+			return strings.Contains(strings.ToLower(text), strings.ToLower(operand))
+		case "^= ":
+			return strings.HasPrefix(strings.ToLower(text), strings.ToLower(operand))
+		case "$= ":
+			return strings.HasSuffix(strings.ToLower(text), strings.ToLower(operand))
+		case "_= ":
+			return strings.EqualFold(text, operand)
+		case "!= ":
+			return text != operand
+		default:
+			return compareNumbers(text, operand, operator)
+		}
+	}
+
+	return value != nil && text == op
+}
+
+func compareNumbers(value string, operand string, operator string) bool {
+	a, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+
+	b, err := strconv.ParseFloat(operand, 64)
+	if err != nil {
+		return false
+	}
+
+	switch operator {
+	case ">= ":
+		return a >= b
+	case "<= ":
+		return a <= b
+	case "> ":
+		return a > b
+	default:
+		return a < b
+	}
+}
+
+// optionValues returns the values of the "data" option of an "in" operation
+func optionValues(options interface{}) []interface{} {
+	list, _ := options.([]interface{})
+	for _, option := range list {
+		properties, _ := option.(map[string]interface{})
+		if properties["name"] == "data" {
+			values, _ := properties["value"].([]interface{})
+			return values
+		}
+	}
+
+	return nil
+}
+
+// normalize turns a value into what decoding its JSON gives, with maps, lists and float64 numbers
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	if err != nil {
+		panic(err)
+	}
+
+	return normalized
+}
+
+func intValue(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+
+	return 0
+}
+
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
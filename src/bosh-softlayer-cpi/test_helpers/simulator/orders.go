@@ -0,0 +1,254 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AccountID is the ID of the account the simulator serves
+const AccountID = 278444
+
+// Services of the product catalog the simulator keeps objects of
+const (
+	ItemPriceService  = "SoftLayer_Product_Item_Price"
+	ItemService       = "SoftLayer_Product_Item"
+	DatacenterService = "SoftLayer_Location_Datacenter"
+)
+
+// Datacenters the simulator can place objects in
+var Datacenters = map[string]int{
+	"ams01": 265592,
+	"dal05": 138124,
+	"dal09": 449494,
+	"fra02": 449506,
+	"lon02": 138125,
+	"sjc01": 168642,
+	"tok02": 449604,
+	"wdc01": 37473,
+}
+
+// Sizes in GB of the iSCSI volumes and ephemeral disks in the catalog
+var (
+	storageSizes       = []int{20, 40, 80, 100, 250, 500, 1000, 2000, 4000, 8000, 12000}
+	storageIops        = []int{100, 200, 400, 600, 1000, 2000, 3000, 4000, 6000}
+	ephemeralDiskSizes = []int{25, 100, 250, 500, 1000, 2000}
+)
+
+const (
+	blockStoragePriceID     = 40672
+	storageSpacePriceIDBase = 100000
+	storageIopsPriceIDBase  = 200000
+	diskPriceIDBase         = 300000
+)
+
+// seedCatalog adds the datacenters and the item prices of the orders the CPI places
+func (s *Simulator) seedCatalog() {
+	for name, id := range Datacenters {
+		s.add(DatacenterService, map[string]interface{}{"id": id, "name": name, "longName": strings.ToUpper(name)})
+	}
+
+	s.add(ItemService, map[string]interface{}{
+		"id":          blockStoragePriceID,
+		"description": "Block Storage (Performance)",
+		"categories":  []interface{}{category("performance_storage_iscsi")},
+		"prices":      []interface{}{map[string]interface{}{"id": blockStoragePriceID}},
+	})
+
+	for _, size := range storageSizes {
+		s.add(ItemPriceService, price(storageSpacePriceIDBase+size, "performance_storage_space",
+			fmt.Sprintf("%d_GB_PERFORMANCE_STORAGE_SPACE", size), fmt.Sprintf("%d GB Storage Space", size), size, ""))
+
+		for _, iops := range storageIops {
+			s.add(ItemPriceService, price(storageIopsPriceIDBase+size*10+iops/100, "performance_storage_iops",
+				fmt.Sprintf("%d_IOPS", iops), fmt.Sprintf("%d IOPS", iops), iops, strconv.Itoa(size)))
+		}
+	}
+
+	for _, size := range ephemeralDiskSizes {
+		for i, diskType := range []string{"LOCAL", "SAN"} {
+			s.add(ItemPriceService, price(diskPriceIDBase+size*10+i, "guest_disk1",
+				fmt.Sprintf("GUEST_DISK_%d_GB_%s", size, diskType), fmt.Sprintf("%d GB (%s)", size, diskType), size, ""))
+		}
+	}
+}
+
+func category(code string) map[string]interface{} {
+	return map[string]interface{}{"categoryCode": code}
+}
+
+func price(id int, categoryCode string, keyName string, description string, capacity int, attribute string) map[string]interface{} {
+	p := map[string]interface{}{
+		"id":                 id,
+		"hourlyRecurringFee": ".01",
+		"recurringFee":       "5",
+		"categories":         []interface{}{category(categoryCode)},
+		"item": map[string]interface{}{
+			"id":          id,
+			"keyName":     keyName,
+			"description": description,
+			"capacity":    strconv.Itoa(capacity),
+		},
+	}
+
+	if attribute != "" {
+		p["attributes"] = map[string]interface{}{"value": attribute}
+	}
+
+	return p
+}
+
+// guestPrices returns the prices of the CPUs and memory of a virtual guest template
+func (s *Simulator) guestPrices(template map[string]interface{}) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"hourlyRecurringFee": fmt.Sprintf("%.3f", 0.02*float64(intValue(template["startCpus"]))),
+			"recurringFee":       fmt.Sprintf("%.2f", 15*float64(intValue(template["startCpus"]))),
+			"categories":         []interface{}{category("guest_core")},
+			"item":               map[string]interface{}{"description": fmt.Sprintf("%d x 2.0 GHz Cores", intValue(template["startCpus"]))},
+		},
+		map[string]interface{}{
+			"hourlyRecurringFee": fmt.Sprintf("%.3f", 0.01*float64(intValue(template["maxMemory"]))/1024),
+			"recurringFee":       fmt.Sprintf("%.2f", 10*float64(intValue(template["maxMemory"]))/1024),
+			"categories":         []interface{}{category("ram")},
+			"item":               map[string]interface{}{"description": fmt.Sprintf("%d GB", intValue(template["maxMemory"])/1024)},
+		},
+	}
+}
+
+// verifyOrder returns the order with the fees of its prices, the way SoftLayer quotes it
+func verifyOrder(s *Simulator, c call) (interface{}, error) {
+	order := map[string]interface{}{}
+	err := c.parameter(0, &order)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := s.orderPrices(order)
+	if err != nil {
+		return nil, err
+	}
+
+	if virtualGuests, _ := order["virtualGuests"].([]interface{}); len(virtualGuests) > 0 && len(prices) == 0 {
+		template, _ := virtualGuests[0].(map[string]interface{})
+		prices = s.guestPrices(template)
+	}
+
+	order["prices"] = prices
+
+	return order, nil
+}
+
+// orderPrices returns the catalog prices the order names by ID
+func (s *Simulator) orderPrices(order map[string]interface{}) ([]interface{}, error) {
+	named, _ := order["prices"].([]interface{})
+
+	prices := []interface{}{}
+	for _, p := range named {
+		id := intValue(p.(map[string]interface{})["id"])
+		if id == blockStoragePriceID {
+			continue
+		}
+
+		catalogPrice, found := s.get(ItemPriceService, id)
+		if !found {
+			return nil, apiError{
+				StatusCode: http.StatusInternalServerError,
+				Code:       "SoftLayer_Exception_Order_Item_Invalid",
+				Message:    fmt.Sprintf("Price # %d does not exist.", id),
+			}
+		}
+		prices = append(prices, map[string]interface{}(catalogPrice))
+	}
+
+	return prices, nil
+}
+
+// placeOrder creates what the order is for: virtual guests, an upgrade of a virtual guest or an
+// iSCSI volume
+func placeOrder(s *Simulator, c call) (interface{}, error) {
+	order := map[string]interface{}{}
+	err := c.parameter(0, &order)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := s.orderPrices(order)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID := s.newID()
+	complexType, _ := order["complexType"].(string)
+	virtualGuests, _ := order["virtualGuests"].([]interface{})
+	placed := []interface{}{}
+
+	switch {
+	case strings.HasSuffix(complexType, "Virtual_Guest_Upgrade"):
+		for _, v := range virtualGuests {
+			id := intValue(v.(map[string]interface{})["id"])
+			upgrade := call{Service: VirtualGuestService, ID: id}
+			o, err := s.mustGet(upgrade)
+			if err == nil {
+				err = s.busy(upgrade)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			o["powerState"] = powerState("HALTED")
+			s.schedule(VirtualGuestService, id, TransactionUpgrade, func() {
+				o["powerState"] = powerState("RUNNING")
+			})
+			placed = append(placed, o)
+		}
+
+	case strings.HasSuffix(complexType, "Virtual_Guest"):
+		for _, v := range virtualGuests {
+			template, _ := v.(map[string]interface{})
+			o, err := s.newVirtualGuest(template)
+			if err != nil {
+				return nil, err
+			}
+			placed = append(placed, o)
+		}
+
+	case strings.Contains(complexType, "Storage"):
+		capacityGb, iops := 0, ""
+		for _, p := range prices {
+			item := p.(map[string]interface{})["item"].(map[string]interface{})
+			switch p.(map[string]interface{})["categories"].([]interface{})[0].(map[string]interface{})["categoryCode"] {
+			case "performance_storage_space":
+				capacityGb = intValue(item["capacity"])
+			case "performance_storage_iops":
+				iops = stringValue(item["capacity"])
+			}
+		}
+		if capacityGb == 0 {
+			return nil, apiError{
+				StatusCode: http.StatusInternalServerError,
+				Code:       "SoftLayer_Exception_Order_MissingItemCategory",
+				Message:    "The order is missing a price of category performance_storage_space.",
+			}
+		}
+		s.newStorage(orderID, stringValue(order["location"]), capacityGb, iops)
+
+	default:
+		return nil, apiError{
+			StatusCode: http.StatusInternalServerError,
+			Code:       "SoftLayer_Exception_Order_InvalidContainer",
+			Message:    fmt.Sprintf("Invalid order container type '%s'.", complexType),
+		}
+	}
+
+	return map[string]interface{}{
+		"orderId": orderID,
+		"placedOrder": map[string]interface{}{
+			"id":            orderID,
+			"status":        "APPROVED",
+			"virtualGuests": placed,
+		},
+		"orderDetails": order,
+	}, nil
+}
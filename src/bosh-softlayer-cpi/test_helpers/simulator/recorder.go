@@ -0,0 +1,245 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+// Exchange is a call of the SoftLayer API and its response, as recorded to a fixture
+type Exchange struct {
+	// Method is the HTTP method of the request
+	Method string `json:"method"`
+	// Path is the path of the request below /rest/v3, such as SoftLayer_Virtual_Guest/1/getObject.json
+	Path         string          `json:"path"`
+	Query        string          `json:"query,omitempty"`
+	RequestBody  json.RawMessage `json:"requestBody,omitempty"`
+	StatusCode   int             `json:"statusCode"`
+	ResponseBody json.RawMessage `json:"responseBody"`
+}
+
+// APIMethod names the API method the exchange called, such as SoftLayer_Virtual_Guest::getObject
+func (e Exchange) APIMethod() string {
+	return slh.APIMethod(e.Path, e.Method)
+}
+
+// Recorder passes the calls of the SoftLayer API on to a real endpoint and writes every exchange
+// to a fixture in its directory. Credentials are not recorded, and the passwords in the responses
+// are masked.
+type Recorder struct {
+	upstream string
+	dir      string
+	client   *http.Client
+
+	lock  sync.Mutex
+	count int
+
+	listener net.Listener
+	server   *http.Server
+}
+
+// NewRecorder creates a Recorder passing calls on to upstream, such as https://api.softlayer.com,
+// and writing fixtures to dir
+func NewRecorder(upstream string, dir string) *Recorder {
+	return &Recorder{
+		upstream: strings.TrimSuffix(upstream, "/"),
+		dir:      dir,
+		client:   http.DefaultClient,
+	}
+}
+
+// Start serves the recorder on a free port of the loopback interface
+func (r *Recorder) Start() error {
+	err := os.MkdirAll(r.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	r.listener = listener
+	r.server = &http.Server{Handler: r}
+	go r.server.Serve(listener)
+
+	return nil
+}
+
+// Close stops serving the recorder
+func (r *Recorder) Close() error {
+	if r.server == nil {
+		return nil
+	}
+
+	return r.server.Close()
+}
+
+// Endpoint returns the URL of the running recorder, to use as SL_API_ENDPOINT
+func (r *Recorder) Endpoint() string {
+	return fmt.Sprintf("http://%s", r.listener.Addr().String())
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	requestBody, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	url := r.upstream + req.URL.Path
+	if req.URL.RawQuery != "" {
+		url += "?" + req.URL.RawQuery
+	}
+
+	upstreamRequest, err := http.NewRequest(req.Method, url, bytes.NewReader(requestBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	upstreamRequest.Header = req.Header
+
+	response, err := r.client.Do(upstreamRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	err = r.record(Exchange{
+		Method:       req.Method,
+		Path:         strings.TrimPrefix(req.URL.Path, apiRoot),
+		Query:        req.URL.RawQuery,
+		RequestBody:  rawJSON(requestBody),
+		StatusCode:   response.StatusCode,
+		ResponseBody: rawJSON(maskPasswords(responseBody)),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Recording exchange: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", response.Header.Get("Content-Type"))
+	w.WriteHeader(response.StatusCode)
+	w.Write(responseBody)
+}
+
+func (r *Recorder) record(exchange Exchange) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.count++
+	name := fmt.Sprintf("%04d_%s.json", r.count, strings.Replace(exchange.APIMethod(), "::", "_", 1))
+
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(r.dir, name), data, 0644)
+}
+
+var passwordPattern = regexp.MustCompile(`"password":"[^"]*"`)
+
+func maskPasswords(body []byte) []byte {
+	return passwordPattern.ReplaceAll(body, []byte(`"password":"******"`))
+}
+
+// rawJSON keeps a body that is JSON as it is and turns any other into a JSON string
+func rawJSON(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+
+	quoted, _ := json.Marshal(string(body))
+	return json.RawMessage(quoted)
+}
+
+// LoadExchanges reads the fixtures a Recorder wrote to dir, in the order they were recorded
+func LoadExchanges(dir string) ([]Exchange, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	exchanges := []Exchange{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var exchange Exchange
+		err = json.Unmarshal(data, &exchange)
+		if err != nil {
+			return nil, fmt.Errorf("Unmarshalling exchange %s: %s", path, err.Error())
+		}
+
+		exchanges = append(exchanges, exchange)
+	}
+
+	return exchanges, nil
+}
+
+// replay holds the recorded responses to the calls of an API method
+type replay struct {
+	exchanges []Exchange
+	next      int
+}
+
+func replayKey(httpMethod string, apiMethod string) string {
+	return fmt.Sprintf("%s %s", httpMethod, apiMethod)
+}
+
+// Replay makes the simulator answer the calls of every API method recorded in the exchanges with
+// the recorded responses, in the order they were recorded. Once the responses to a method run out
+// the last one is repeated. Calls of methods not recorded are simulated.
+func (s *Simulator) Replay(exchanges []Exchange) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, exchange := range exchanges {
+		key := replayKey(exchange.Method, exchange.APIMethod())
+		if s.replays[key] == nil {
+			s.replays[key] = &replay{}
+		}
+		s.replays[key].exchanges = append(s.replays[key].exchanges, exchange)
+	}
+}
+
+func (s *Simulator) takeReplay(httpMethod string, apiMethod string) (Exchange, bool) {
+	r, found := s.replays[replayKey(httpMethod, apiMethod)]
+	if !found {
+		return Exchange{}, false
+	}
+
+	exchange := r.exchanges[r.next]
+	if r.next < len(r.exchanges)-1 {
+		r.next++
+	}
+
+	return exchange, true
+}
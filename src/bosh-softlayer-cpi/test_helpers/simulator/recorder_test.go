@@ -0,0 +1,75 @@
+package simulator_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-softlayer-cpi/test_helpers/simulator"
+)
+
+var _ = Describe("Recorder", func() {
+	var (
+		upstream *Simulator
+		recorder *Recorder
+		dir      string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "recorder")
+		Expect(err).ToNot(HaveOccurred())
+
+		upstream = New(Options{})
+		Expect(upstream.Start()).To(Succeed())
+		upstream.Add(ImageTemplateService, map[string]interface{}{
+			"id":               879741,
+			"globalIdentifier": stemcellGlobalIdentifier,
+		})
+
+		recorder = NewRecorder(upstream.Endpoint(), filepath.Join(dir, "fixtures"))
+		Expect(recorder.Start()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(recorder.Close()).To(Succeed())
+		Expect(upstream.Close()).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("records the calls passed on to the upstream API, which a simulator replays", func() {
+		virtualGuestService, err := newClient(recorder.Endpoint(), dir).GetSoftLayer_Virtual_Guest_Service()
+		Expect(err).ToNot(HaveOccurred())
+
+		virtualGuest, err := virtualGuestService.CreateObject(guestTemplate())
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = virtualGuestService.GetObject(virtualGuest.Id)
+		Expect(err).ToNot(HaveOccurred())
+
+		exchanges, err := LoadExchanges(filepath.Join(dir, "fixtures"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exchanges).To(HaveLen(2))
+		Expect(exchanges[0].APIMethod()).To(Equal("SoftLayer_Virtual_Guest::createObject"))
+		Expect(exchanges[1].APIMethod()).To(Equal("SoftLayer_Virtual_Guest::getObject"))
+		Expect(string(exchanges[1].ResponseBody)).ToNot(ContainSubstring("simulated-password"))
+
+		replaying := New(Options{Now: func() time.Time { return time.Now().Add(time.Hour) }})
+		replaying.Replay(exchanges)
+		Expect(replaying.Start()).To(Succeed())
+		defer replaying.Close()
+
+		virtualGuestService, err = newClient(replaying.Endpoint(), dir).GetSoftLayer_Virtual_Guest_Service()
+		Expect(err).ToNot(HaveOccurred())
+
+		replayed, err := virtualGuestService.GetObject(virtualGuest.Id)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replayed.Id).To(Equal(virtualGuest.Id))
+		Expect(replayed.FullyQualifiedDomainName).To(Equal("bosh-1.softlayer.com"))
+		Expect(replaying.Objects(VirtualGuestService)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,445 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// handler answers a call of an API method. It runs with the simulator locked.
+type handler func(s *Simulator, c call) (interface{}, error)
+
+// Methods the simulator models. The objects of the services can also be created, read, edited
+// and deleted, and their properties read through getX methods, without a handler.
+var handlers = map[string]handler{
+	"SoftLayer_Virtual_Guest::createObject":             createVirtualGuest,
+	"SoftLayer_Virtual_Guest::generateOrderTemplate":    generateOrderTemplate,
+	"SoftLayer_Virtual_Guest::deleteObject":             reclaim,
+	"SoftLayer_Virtual_Guest::getActiveTransaction":     getActiveTransaction,
+	"SoftLayer_Virtual_Guest::getActiveTransactions":    getActiveTransactions,
+	"SoftLayer_Virtual_Guest::getLastTransaction":       getLastTransaction,
+	"SoftLayer_Virtual_Guest::reloadOperatingSystem":    reloadOperatingSystem,
+	"SoftLayer_Virtual_Guest::powerOn":                  setPowerState("RUNNING"),
+	"SoftLayer_Virtual_Guest::powerOff":                 setPowerState("HALTED"),
+	"SoftLayer_Virtual_Guest::powerOffSoft":             setPowerState("HALTED"),
+	"SoftLayer_Virtual_Guest::powerCycle":               setPowerState("RUNNING"),
+	"SoftLayer_Virtual_Guest::rebootDefault":            setPowerState("RUNNING"),
+	"SoftLayer_Virtual_Guest::rebootSoft":               setPowerState("RUNNING"),
+	"SoftLayer_Virtual_Guest::rebootHard":               setPowerState("RUNNING"),
+	"SoftLayer_Virtual_Guest::isPingable":               isPingable,
+	"SoftLayer_Virtual_Guest::isBackendPingable":        isPingable,
+	"SoftLayer_Virtual_Guest::setTags":                  setTags,
+	"SoftLayer_Virtual_Guest::setUserMetadata":          setUserMetadata,
+	"SoftLayer_Virtual_Guest::getAllowedHost":           getAllowedHost,
+	"SoftLayer_Virtual_Guest::getUpgradeItemPrices":     getUpgradeItemPrices,
+	"SoftLayer_Virtual_Guest::createArchiveTransaction": createArchiveTransaction,
+
+	"SoftLayer_Hardware::getActiveTransaction":  getActiveTransaction,
+	"SoftLayer_Hardware::getActiveTransactions": getActiveTransactions,
+	"SoftLayer_Hardware::getLastTransaction":    getLastTransaction,
+	"SoftLayer_Hardware::reloadOperatingSystem": reloadOperatingSystem,
+	"SoftLayer_Hardware::powerOn":               setPowerState("RUNNING"),
+	"SoftLayer_Hardware::powerOff":              setPowerState("HALTED"),
+	"SoftLayer_Hardware::setTags":               setTags,
+	"SoftLayer_Hardware::getAllowedHost":        getAllowedHost,
+	"SoftLayer_Hardware::findByIpAddress":       findHardwareByIpAddress,
+
+	"SoftLayer_Network_Storage::allowAccessFromVirtualGuest":  allowAccess("allowedVirtualGuests", VirtualGuestService),
+	"SoftLayer_Network_Storage::removeAccessFromVirtualGuest": removeAccess("allowedVirtualGuests"),
+	"SoftLayer_Network_Storage::allowAccessFromHardware":      allowAccess("allowedHardware", HardwareService),
+	"SoftLayer_Network_Storage::removeAccessFromHardware":     removeAccess("allowedHardware"),
+	"SoftLayer_Network_Storage::getCredential":                getStorageCredential,
+
+	"SoftLayer_Network_Storage_Allowed_Host::getCredential": getAllowedHostCredential,
+
+	"SoftLayer_Virtual_Guest_Block_Device_Template_Group::createFromExternalSource": createImageTemplate,
+	"SoftLayer_Virtual_Guest_Block_Device_Template_Group::deleteObject":             deleteImageTemplate,
+
+	"SoftLayer_Security_Ssh_Key::createObject": createObject,
+
+	"SoftLayer_Billing_Item::cancelService": cancelService,
+	"SoftLayer_Billing_Item::cancelItem":    cancelService,
+
+	"SoftLayer_Product_Order::verifyOrder": verifyOrder,
+	"SoftLayer_Product_Order::placeOrder":  placeOrder,
+
+	"SoftLayer_Product_Package::getItemPrices": listFiltered(ItemPriceService, "itemPrices"),
+	"SoftLayer_Product_Package::getItems":      listFiltered(ItemService, "items"),
+
+	"SoftLayer_Location_Datacenter::getDatacenters": listFiltered(DatacenterService, "datacenters"),
+}
+
+func (s *Simulator) handle(c call) (interface{}, error) {
+	s.advance()
+
+	if h, found := handlers[fmt.Sprintf("%s::%s", canonicalService(c.Service), c.Method)]; found {
+		return h(s, c)
+	}
+
+	if c.Service == "SoftLayer_Account" {
+		return getAccountList(s, c)
+	}
+
+	switch c.Method {
+	case "createObject":
+		return createObject(s, c)
+	case "getAllObjects":
+		return filter(s.list(c.Service), nil), nil
+	}
+
+	o, found := s.get(c.Service, c.ID)
+	if !found {
+		if c.ID == 0 {
+			return nil, unknownMethod(c)
+		}
+		return nil, notFound(c.Service, c.ID)
+	}
+
+	switch c.Method {
+	case "getObject":
+		return o, nil
+	case "deleteObject":
+		s.remove(c.Service, c.ID)
+		return true, nil
+	case "editObject":
+		changes := map[string]interface{}{}
+		err := c.parameter(0, &changes)
+		if err != nil {
+			return nil, err
+		}
+		// Clients send whole objects, whose relational properties are null and left alone
+		for property, value := range normalize(changes).(map[string]interface{}) {
+			if value != nil {
+				o[property] = value
+			}
+		}
+		return true, nil
+	}
+
+	if strings.HasPrefix(c.Method, "get") {
+		return o[lowerFirst(strings.TrimPrefix(c.Method, "get"))], nil
+	}
+
+	return nil, unknownMethod(c)
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToLower(runes[0])
+	}
+
+	return string(runes)
+}
+
+func (s *Simulator) mustGet(c call) (object, error) {
+	o, found := s.get(c.Service, c.ID)
+	if !found {
+		return nil, notFound(c.Service, c.ID)
+	}
+
+	return o, nil
+}
+
+// mustHaveTransactions fails unless the object exists or transactions ran on it. The transactions
+// of a reclaimed virtual guest can still be read, the way SoftLayer keeps them for a while.
+func (s *Simulator) mustHaveTransactions(c call) error {
+	if _, found := s.transactions[transactionKey(c.Service, c.ID)]; found {
+		return nil
+	}
+
+	_, err := s.mustGet(c)
+	return err
+}
+
+// busy fails changes of an object a transaction is running on, the way SoftLayer does
+func (s *Simulator) busy(c call) error {
+	if len(s.activeTransactions(c.Service, c.ID)) == 0 {
+		return nil
+	}
+
+	return apiError{
+		StatusCode: http.StatusInternalServerError,
+		Code:       "SoftLayer_Exception_Public",
+		Message:    fmt.Sprintf("A transaction is in progress on %s %d, please try again after it completes.", canonicalService(c.Service), c.ID),
+	}
+}
+
+func createObject(s *Simulator, c call) (interface{}, error) {
+	properties := map[string]interface{}{}
+	err := c.parameter(0, &properties)
+	if err != nil {
+		return nil, err
+	}
+
+	properties["createDate"] = s.options.Now().Format(time.RFC3339)
+
+	return s.add(c.Service, properties), nil
+}
+
+func getUpgradeItemPrices(s *Simulator, c call) (interface{}, error) {
+	if _, err := s.mustGet(c); err != nil {
+		return nil, err
+	}
+
+	return filter(s.list(ItemPriceService), map[string]interface{}{
+		"categories": map[string]interface{}{"categoryCode": map[string]interface{}{"operation": "guest_disk1"}},
+	}), nil
+}
+
+func listFiltered(service string, property string) handler {
+	return func(s *Simulator, c call) (interface{}, error) {
+		return filter(s.list(service), c.Filter[property]), nil
+	}
+}
+
+func getAccountList(s *Simulator, c call) (interface{}, error) {
+	if c.Method == "getObject" {
+		return map[string]interface{}{"id": AccountID}, nil
+	}
+
+	property := lowerFirst(strings.TrimPrefix(c.Method, "get"))
+	service, found := accountLists[property]
+	if !found || !strings.HasPrefix(c.Method, "get") {
+		return nil, unknownMethod(c)
+	}
+
+	return filter(s.list(service), c.Filter[property]), nil
+}
+
+func getActiveTransaction(s *Simulator, c call) (interface{}, error) {
+	if err := s.mustHaveTransactions(c); err != nil {
+		return nil, err
+	}
+
+	active := s.activeTransactions(c.Service, c.ID)
+	if len(active) == 0 {
+		return nil, nil
+	}
+
+	return active[0].render(c.Service, c.ID, s.options.Now()), nil
+}
+
+func getActiveTransactions(s *Simulator, c call) (interface{}, error) {
+	if err := s.mustHaveTransactions(c); err != nil {
+		return nil, err
+	}
+
+	rendered := []interface{}{}
+	for _, t := range s.activeTransactions(c.Service, c.ID) {
+		rendered = append(rendered, t.render(c.Service, c.ID, s.options.Now()))
+	}
+
+	return rendered, nil
+}
+
+func getLastTransaction(s *Simulator, c call) (interface{}, error) {
+	if err := s.mustHaveTransactions(c); err != nil {
+		return nil, err
+	}
+
+	last := s.lastTransaction(c.Service, c.ID)
+	if last == nil {
+		return nil, nil
+	}
+
+	return last.render(c.Service, c.ID, s.options.Now()), nil
+}
+
+func setPowerState(keyName string) handler {
+	return func(s *Simulator, c call) (interface{}, error) {
+		o, err := s.mustGet(c)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.busy(c)
+		if err != nil {
+			return nil, err
+		}
+
+		o["powerState"] = powerState(keyName)
+
+		return true, nil
+	}
+}
+
+func powerState(keyName string) map[string]interface{} {
+	return map[string]interface{}{"keyName": keyName, "name": strings.Title(strings.ToLower(keyName))}
+}
+
+func isPingable(s *Simulator, c call) (interface{}, error) {
+	o, err := s.mustGet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	state, _ := o["powerState"].(map[string]interface{})
+
+	return state["keyName"] == "RUNNING", nil
+}
+
+func setTags(s *Simulator, c call) (interface{}, error) {
+	o, err := s.mustGet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags string
+	err = c.parameter(0, &tags)
+	if err != nil {
+		return nil, err
+	}
+
+	references := []interface{}{}
+	for _, name := range strings.Split(tags, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		references = append(references, s.tagReference(c.ID, name))
+	}
+	o["tagReferences"] = references
+
+	return true, nil
+}
+
+// tagReference tags the object with id with name, keeping the tag among the tags of the account
+func (s *Simulator) tagReference(id int, name string) map[string]interface{} {
+	if len(filter(s.list(TagService), map[string]interface{}{"name": map[string]interface{}{"operation": name}})) == 0 {
+		s.add(TagService, map[string]interface{}{"name": name})
+	}
+
+	return map[string]interface{}{
+		"resourceTableId": float64(id),
+		"tag":             map[string]interface{}{"name": name},
+	}
+}
+
+func setUserMetadata(s *Simulator, c call) (interface{}, error) {
+	o, err := s.mustGet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	err = c.parameter(0, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	userData := []interface{}{}
+	for _, value := range values {
+		userData = append(userData, map[string]interface{}{"value": value})
+	}
+	o["userData"] = userData
+
+	return true, nil
+}
+
+func getAllowedHost(s *Simulator, c call) (interface{}, error) {
+	o, err := s.mustGet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if o["allowedHost"] == nil {
+		o["allowedHost"] = map[string]interface{}{
+			"id":   float64(s.newID()),
+			"name": fmt.Sprintf("iqn.2005-05.com.softlayer:%s%d", strings.ToLower(strings.TrimPrefix(canonicalService(c.Service), "SoftLayer_")), c.ID),
+			"credential": map[string]interface{}{
+				"username": fmt.Sprintf("IBM%d", c.ID),
+				"password": "simulated-password",
+			},
+		}
+	}
+
+	return o["allowedHost"], nil
+}
+
+// getAllowedHostCredential returns the credential of the allowed host of a virtual guest or hardware
+func getAllowedHostCredential(s *Simulator, c call) (interface{}, error) {
+	for _, service := range []string{VirtualGuestService, HardwareService} {
+		for _, o := range s.list(service) {
+			allowedHost, _ := o["allowedHost"].(map[string]interface{})
+			if allowedHost != nil && intValue(allowedHost["id"]) == c.ID {
+				return allowedHost["credential"], nil
+			}
+		}
+	}
+
+	return nil, notFound(c.Service, c.ID)
+}
+
+func findHardwareByIpAddress(s *Simulator, c call) (interface{}, error) {
+	var ipAddress string
+	err := c.parameter(0, &ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range s.list(HardwareService) {
+		if o["primaryIpAddress"] == ipAddress || o["primaryBackendIpAddress"] == ipAddress {
+			return o, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func reloadOperatingSystem(s *Simulator, c call) (interface{}, error) {
+	o, err := s.mustGet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.busy(c)
+	if err != nil {
+		return nil, err
+	}
+
+	config := map[string]interface{}{}
+	err = c.parameter(1, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	if imageTemplateID := intValue(config["imageTemplateId"]); imageTemplateID != 0 {
+		image, found := s.get(ImageTemplateService, imageTemplateID)
+		if !found {
+			return nil, notFound(ImageTemplateService, imageTemplateID)
+		}
+		o["blockDeviceTemplateGroup"] = map[string]interface{}{"globalIdentifier": image["globalIdentifier"]}
+	}
+
+	o["powerState"] = powerState("HALTED")
+	s.schedule(c.Service, c.ID, TransactionOSReload, func() {
+		o["powerState"] = powerState("RUNNING")
+	})
+
+	return "1", nil
+}
+
+func createArchiveTransaction(s *Simulator, c call) (interface{}, error) {
+	if _, err := s.mustGet(c); err != nil {
+		return nil, err
+	}
+
+	var name, note string
+	err := c.parameter(0, &name)
+	if err == nil {
+		err = c.parameter(2, &note)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.addImageTemplate(map[string]interface{}{"name": name, "note": note})
+	s.schedule(c.Service, c.ID, TransactionImageCapture, nil)
+
+	active := s.activeTransactions(c.Service, c.ID)
+	return active[len(active)-1].render(c.Service, c.ID, s.options.Now()), nil
+}
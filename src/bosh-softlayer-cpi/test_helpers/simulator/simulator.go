@@ -0,0 +1,307 @@
+// Package simulator is an in-process stand-in for the SoftLayer REST API, so that the integration
+// tests can run without a SoftLayer account.
+//
+// The Simulator models virtual guests, hardware, network storage, image templates and tags, and
+// runs the transactions SoftLayer runs on them, such as provisioning, Service Setup, OS reload and
+// upgrade, for a configurable time each. Faults can be injected into any API method. A Recorder
+// proxies the real API and writes its traffic to fixtures, which the Simulator can replay.
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	slh "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+const apiRoot = "/rest/v3/"
+
+// Options configure how the Simulator behaves
+type Options struct {
+	// Durations holds how long each transaction group runs, such as ServiceSetup.
+	// Groups not listed run for DefaultTransactionDuration.
+	Durations map[string]time.Duration
+
+	// Latency delays every response
+	Latency time.Duration
+
+	// Now is the clock the transactions run on
+	Now func() time.Time
+}
+
+// DefaultTransactionDuration is how long a transaction runs unless Options say otherwise
+const DefaultTransactionDuration = 2 * time.Second
+
+// Simulator serves the SoftLayer REST API under /rest/v3 and a BOSH registry under /instances
+type Simulator struct {
+	options Options
+
+	lock         sync.Mutex
+	objects      map[string]map[int]object
+	nextID       int
+	transactions map[string][]*transaction
+	billingItems map[int]owner
+	faults       []*Fault
+	replays      map[string]*replay
+	registry     map[string][]byte
+	calls        map[string]int
+
+	listener net.Listener
+	server   *http.Server
+}
+
+func New(options Options) *Simulator {
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	s := &Simulator{
+		options:      options,
+		objects:      map[string]map[int]object{},
+		nextID:       10000000,
+		transactions: map[string][]*transaction{},
+		billingItems: map[int]owner{},
+		replays:      map[string]*replay{},
+		registry:     map[string][]byte{},
+		calls:        map[string]int{},
+	}
+	s.seedCatalog()
+
+	return s
+}
+
+// Start serves the simulator on a free port of the loopback interface
+func (s *Simulator) Start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+	s.server = &http.Server{Handler: s}
+	go s.server.Serve(listener)
+
+	return nil
+}
+
+// Close stops serving the simulator
+func (s *Simulator) Close() error {
+	if s.server == nil {
+		return nil
+	}
+
+	return s.server.Close()
+}
+
+// Endpoint returns the URL of the running simulator, such as http://127.0.0.1:34567, to use as
+// SL_API_ENDPOINT and as the apiEndpoint feature option of the CPI
+func (s *Simulator) Endpoint() string {
+	return fmt.Sprintf("http://%s", s.listener.Addr().String())
+}
+
+// Calls returns how often each API method was called, keyed like SoftLayer_Virtual_Guest::getObject
+func (s *Simulator) Calls() map[string]int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	calls := map[string]int{}
+	for method, count := range s.calls {
+		calls[method] = count
+	}
+
+	return calls
+}
+
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.options.Latency > 0 {
+		time.Sleep(s.options.Latency)
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/instances/") {
+		s.serveRegistry(w, r)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, apiRoot) {
+		http.NotFound(w, r)
+		return
+	}
+
+	c, err := parseCall(r)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, apiError{Code: "SoftLayer_Exception_Public", Message: err.Error()})
+		return
+	}
+
+	s.lock.Lock()
+	s.calls[c.key()]++
+	fault := s.takeFault(c.key())
+	replayed, isReplayed := s.takeReplay(r.Method, c.key())
+	s.lock.Unlock()
+
+	if fault != nil {
+		fault.write(w)
+		return
+	}
+
+	if isReplayed {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(replayed.StatusCode)
+		w.Write(replayed.ResponseBody)
+		return
+	}
+
+	s.lock.Lock()
+	result, err := s.handle(c)
+	s.lock.Unlock()
+
+	if err != nil {
+		if e, ok := err.(apiError); ok {
+			writeResponse(w, e.StatusCode, e)
+			return
+		}
+		writeResponse(w, http.StatusInternalServerError, apiError{Code: "SoftLayer_Exception", Message: err.Error()})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, result)
+}
+
+// call is a request to a method of a SoftLayer service, on the object with ID unless that is 0
+type call struct {
+	Service    string
+	ID         int
+	Method     string
+	Parameters []json.RawMessage
+	Filter     map[string]interface{}
+}
+
+func (c call) key() string {
+	return fmt.Sprintf("%s::%s", c.Service, c.Method)
+}
+
+// parameter decodes the parameter at index into value, leaving value unchanged when it is missing
+func (c call) parameter(index int, value interface{}) error {
+	if index >= len(c.Parameters) {
+		return nil
+	}
+
+	err := json.Unmarshal(c.Parameters[index], value)
+	if err != nil {
+		return apiError{StatusCode: http.StatusBadRequest, Code: "SoftLayer_Exception_Public", Message: fmt.Sprintf("Invalid parameter %d of %s: %s", index, c.key(), err.Error())}
+	}
+
+	return nil
+}
+
+func parseCall(r *http.Request) (call, error) {
+	path := strings.TrimPrefix(r.URL.Path, apiRoot)
+	c := call{Method: slh.APIMethod(path, r.Method)}
+
+	parts := strings.Split(strings.TrimSuffix(path, ".json"), "/")
+	c.Service = parts[0]
+	c.Method = strings.TrimPrefix(c.Method, c.Service+"::")
+	if len(parts) > 1 {
+		c.ID, _ = strconv.Atoi(parts[1])
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return c, err
+	}
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		parameters := struct {
+			Parameters []json.RawMessage `json:"parameters"`
+		}{}
+		err = json.Unmarshal(body, &parameters)
+		if err != nil {
+			return c, fmt.Errorf("Invalid request body: %s", err.Error())
+		}
+		c.Parameters = parameters.Parameters
+	}
+
+	if filter := r.URL.Query().Get("objectFilter"); filter != "" {
+		err = json.Unmarshal([]byte(filter), &c.Filter)
+		if err != nil {
+			return c, fmt.Errorf("Invalid objectFilter: %s", err.Error())
+		}
+	}
+
+	return c, nil
+}
+
+// apiError is rendered the way SoftLayer renders the errors of its API
+type apiError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"error"`
+}
+
+func (e apiError) Error() string { return e.Message }
+
+func notFound(service string, id int) apiError {
+	return apiError{
+		StatusCode: http.StatusNotFound,
+		Code:       "SoftLayer_Exception_ObjectNotFound",
+		Message:    fmt.Sprintf("Unable to find object with id of '%d'.", id),
+	}
+}
+
+func unknownMethod(c call) apiError {
+	return apiError{
+		StatusCode: http.StatusInternalServerError,
+		Code:       "SoftLayer_Exception_Public",
+		Message:    fmt.Sprintf("Function (\"%s\") is not a valid method for this service.", c.Method),
+	}
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, result interface{}) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		body, _ = json.Marshal(apiError{Code: "SoftLayer_Exception", Message: err.Error()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+func (s *Simulator) serveRegistry(w http.ResponseWriter, r *http.Request) {
+	instanceID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/instances/"), "/settings")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch r.Method {
+	case "GET":
+		settings, found := s.registry[instanceID]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		writeResponse(w, http.StatusOK, map[string]string{"status": "ok", "settings": string(settings)})
+	case "PUT":
+		settings, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.registry[instanceID] = settings
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		delete(s.registry, instanceID)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
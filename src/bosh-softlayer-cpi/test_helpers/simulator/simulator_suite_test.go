@@ -0,0 +1,16 @@
+package simulator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"os"
+	"testing"
+)
+
+func TestSimulator(t *testing.T) {
+	os.Setenv("NON_VERBOSE", "TRUE")
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Simulator Suite")
+}
@@ -0,0 +1,307 @@
+package simulator_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	sl "github.com/maximilien/softlayer-go/softlayer"
+
+	. "bosh-softlayer-cpi/test_helpers/simulator"
+
+	"bosh-softlayer-cpi/softlayer/common"
+	slhelper "bosh-softlayer-cpi/softlayer/common/helper"
+)
+
+const stemcellGlobalIdentifier = "8c7a8358-d9a9-4e4d-9345-6f637e10ccb7"
+
+func newClient(endpoint string, stateDir string) sl.Client {
+	options := common.DefaultRuntimeOptions()
+	options.ApiEndpoint = endpoint
+	options.ApiBackoff.Delay = 10 * time.Millisecond
+	options.ApiRateLimit.StateFile = filepath.Join(stateDir, "rate_limit.json")
+
+	return common.NewSoftLayerClient("fake-username", "fake-api-key", options, boshlog.NewLogger(boshlog.LevelNone))
+}
+
+func guestTemplate() datatypes.SoftLayer_Virtual_Guest_Template {
+	return datatypes.SoftLayer_Virtual_Guest_Template{
+		Hostname:          "bosh-1",
+		Domain:            "softlayer.com",
+		StartCpus:         2,
+		MaxMemory:         4096,
+		Datacenter:        datatypes.Datacenter{Name: "lon02"},
+		HourlyBillingFlag: true,
+		LocalDiskFlag:     true,
+		BlockDeviceTemplateGroup: &datatypes.BlockDeviceTemplateGroup{
+			GlobalIdentifier: stemcellGlobalIdentifier,
+		},
+	}
+}
+
+var _ = Describe("Simulator", func() {
+	var (
+		now       time.Time
+		simulator *Simulator
+		client    sl.Client
+		stateDir  string
+
+		virtualGuestService sl.SoftLayer_Virtual_Guest_Service
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = ioutil.TempDir("", "simulator")
+		Expect(err).ToNot(HaveOccurred())
+
+		now = time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+		simulator = New(Options{
+			Durations: map[string]time.Duration{TransactionServiceSetup: 5 * time.Minute},
+			Now:       func() time.Time { return now },
+		})
+		Expect(simulator.Start()).To(Succeed())
+
+		simulator.Add(ImageTemplateService, map[string]interface{}{
+			"id":               879741,
+			"name":             "light-bosh-stemcell",
+			"globalIdentifier": stemcellGlobalIdentifier,
+		})
+
+		client = newClient(simulator.Endpoint(), stateDir)
+		virtualGuestService, err = client.GetSoftLayer_Virtual_Guest_Service()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(simulator.Close()).To(Succeed())
+		Expect(os.RemoveAll(stateDir)).To(Succeed())
+	})
+
+	Context("virtual guests", func() {
+		It("provisions a virtual guest through Service Setup before it is running", func() {
+			virtualGuest, err := virtualGuestService.CreateObject(guestTemplate())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(virtualGuest.Id).ToNot(BeZero())
+			Expect(virtualGuest.FullyQualifiedDomainName).To(Equal("bosh-1.softlayer.com"))
+
+			powerState, err := virtualGuestService.GetPowerState(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(powerState.KeyName).To(Equal("HALTED"))
+
+			activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(activeTransactions).To(HaveLen(2))
+			Expect(activeTransactions[0].TransactionGroup.Name).To(Equal(TransactionProvisioning))
+			Expect(activeTransactions[1].TransactionGroup.Name).To(Equal(TransactionServiceSetup))
+
+			now = now.Add(DefaultTransactionDuration + time.Minute)
+
+			lastTransaction, err := virtualGuestService.GetLastTransaction(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lastTransaction.TransactionGroup.Name).To(Equal(TransactionServiceSetup))
+			Expect(lastTransaction.TransactionStatus.FriendlyName).To(Equal("In Progress"))
+
+			now = now.Add(5 * time.Minute)
+
+			lastTransaction, err = virtualGuestService.GetLastTransaction(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lastTransaction.TransactionStatus.FriendlyName).To(Equal("Complete"))
+
+			powerState, err = virtualGuestService.GetPowerState(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(powerState.KeyName).To(Equal("RUNNING"))
+
+			virtualGuest, err = virtualGuestService.GetObject(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(virtualGuest.Datacenter.Name).To(Equal("lon02"))
+		})
+
+		It("tags a virtual guest with the tags of its template", func() {
+			virtualGuest, err := slhelper.CreateVirtualGuest(client, slhelper.VirtualGuestTemplate{
+				SoftLayer_Virtual_Guest_Template: guestTemplate(),
+				TagReferences:                    slhelper.AgentIDTagReferences("agent-1"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			tagReferences, err := virtualGuestService.GetTagReferences(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tagReferences).To(HaveLen(1))
+			Expect(tagReferences[0].Tag.Name).To(Equal("agent_id:agent-1"))
+		})
+
+		It("rejects a template whose image does not exist", func() {
+			template := guestTemplate()
+			template.BlockDeviceTemplateGroup.GlobalIdentifier = "fake-global-identifier"
+
+			_, err := slhelper.CreateVirtualGuest(client, slhelper.VirtualGuestTemplate{SoftLayer_Virtual_Guest_Template: template})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-global-identifier does not exist"))
+		})
+
+		It("reclaims a deleted virtual guest once its transaction has run", func() {
+			virtualGuest, err := virtualGuestService.CreateObject(guestTemplate())
+			Expect(err).ToNot(HaveOccurred())
+			now = now.Add(10 * time.Minute)
+
+			deleted, err := virtualGuestService.DeleteObject(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(BeTrue())
+
+			activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(activeTransactions).To(HaveLen(1))
+			Expect(activeTransactions[0].TransactionGroup.Name).To(Equal(TransactionReclaim))
+
+			now = now.Add(DefaultTransactionDuration)
+
+			_, err = virtualGuestService.GetObject(virtualGuest.Id)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unable to find object"))
+			Expect(simulator.Objects(VirtualGuestService)).To(BeEmpty())
+		})
+
+		It("fails to reload the OS while a transaction is running", func() {
+			virtualGuest, err := virtualGuestService.CreateObject(guestTemplate())
+			Expect(err).ToNot(HaveOccurred())
+
+			err = virtualGuestService.ReloadOperatingSystem(virtualGuest.Id, datatypes.Image_Template_Config{ImageTemplateId: "879741"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("A transaction is in progress"))
+		})
+	})
+
+	Context("object filters", func() {
+		BeforeEach(func() {
+			for i, hostname := range []string{"bosh-1", "bosh-2", "other-1"} {
+				tag := "agent-1"
+				if i == 1 {
+					tag = "agent-2"
+				}
+				simulator.Add(VirtualGuestService, map[string]interface{}{
+					"hostname":   hostname,
+					"datacenter": map[string]interface{}{"name": "lon02"},
+					"tagReferences": []interface{}{
+						map[string]interface{}{"tag": map[string]interface{}{"name": "bosh"}},
+						map[string]interface{}{"tag": map[string]interface{}{"name": tag}},
+					},
+				})
+			}
+		})
+
+		It("returns the objects of an account list matching the filter", func() {
+			accountService, err := client.GetSoftLayer_Account_Service()
+			Expect(err).ToNot(HaveOccurred())
+
+			virtualGuests, err := accountService.GetVirtualGuestsByFilter(`{"virtualGuests":{"tagReferences":{"tag":{"name":{"operation":"agent-1"}}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(virtualGuests).To(HaveLen(2))
+			Expect(virtualGuests[0].Hostname).To(Equal("bosh-1"))
+			Expect(virtualGuests[1].Hostname).To(Equal("other-1"))
+
+			virtualGuests, err = accountService.GetVirtualGuestsByFilter(`{"virtualGuests":{"hostname":{"operation":"other-1"},"datacenter":{"name":{"operation":"lon02"}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(virtualGuests).To(HaveLen(1))
+		})
+	})
+
+	Context("network storage", func() {
+		It("orders an iSCSI volume of the size and IOPS ordered", func() {
+			networkStorageService, err := client.GetSoftLayer_Network_Storage_Service()
+			Expect(err).ToNot(HaveOccurred())
+
+			volume, err := networkStorageService.CreateNetworkStorage(20, 1000, "138125", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(volume.Id).ToNot(BeZero())
+			Expect(volume.CapacityGb).To(Equal(20))
+
+			virtualGuest, err := virtualGuestService.CreateObject(guestTemplate())
+			Expect(err).ToNot(HaveOccurred())
+
+			allowed, err := networkStorageService.AttachNetworkStorageToVirtualGuest(virtualGuest, volume.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+
+			allowed, err = networkStorageService.HasAllowedVirtualGuest(volume.Id, virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+		})
+
+		It("returns the CHAP credential of the allowed host of a virtual guest", func() {
+			virtualGuest, err := virtualGuestService.CreateObject(guestTemplate())
+			Expect(err).ToNot(HaveOccurred())
+
+			allowedHost, err := virtualGuestService.GetAllowedHost(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowedHost.Name).To(HavePrefix("iqn.2005-05.com.softlayer:"))
+
+			allowedHostService, err := client.GetSoftLayer_Network_Storage_Allowed_Host_Service()
+			Expect(err).ToNot(HaveOccurred())
+
+			credential, err := allowedHostService.GetCredential(allowedHost.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(credential.Username).ToNot(BeEmpty())
+			Expect(credential.Password).To(Equal("simulated-password"))
+		})
+	})
+
+	Context("faults", func() {
+		It("fails the calls of a method as many times as asked", func() {
+			virtualGuest, err := virtualGuestService.CreateObject(guestTemplate())
+			Expect(err).ToNot(HaveOccurred())
+
+			fault := FaultThrottled
+			fault.Method = "SoftLayer_Virtual_Guest::getPowerState"
+			fault.Times = 2
+			simulator.InjectFault(fault)
+
+			powerState, err := virtualGuestService.GetPowerState(virtualGuest.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(powerState.KeyName).To(Equal("HALTED"))
+			Expect(simulator.Calls()["SoftLayer_Virtual_Guest::getPowerState"]).To(Equal(3))
+		})
+
+		It("drops the connection", func() {
+			fault := FaultConnectionDropped
+			fault.Method = "SoftLayer_Virtual_Guest::getObject"
+			simulator.InjectFault(fault)
+
+			_, err := virtualGuestService.GetObject(12345)
+			Expect(err).To(HaveOccurred())
+
+			simulator.ClearFaults()
+
+			_, err = virtualGuestService.GetObject(12345)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unable to find object with id of '12345'"))
+		})
+	})
+
+	Context("registry", func() {
+		It("stores the settings of instances", func() {
+			settingsURL := simulator.Endpoint() + "/instances/12345/settings"
+
+			request, err := http.NewRequest("PUT", settingsURL, bytes.NewBufferString(`{"agent_id":"fake-agent-id"}`))
+			Expect(err).ToNot(HaveOccurred())
+			response, err := http.DefaultClient.Do(request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusCreated))
+
+			response, err = http.Get(settingsURL)
+			Expect(err).ToNot(HaveOccurred())
+			defer response.Body.Close()
+
+			settings := map[string]string{}
+			Expect(json.NewDecoder(response.Body).Decode(&settings)).To(Succeed())
+			Expect(settings["settings"]).To(MatchJSON(`{"agent_id":"fake-agent-id"}`))
+		})
+	})
+})
@@ -0,0 +1,141 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+)
+
+// newStorage creates the iSCSI volume of an order for the given size in GB and IOPS
+func (s *Simulator) newStorage(orderID int, location string, capacityGb int, iops string) object {
+	id := s.newID()
+
+	return s.add(NetworkStorage, map[string]interface{}{
+		"id":                              id,
+		"accountId":                       AccountID,
+		"username":                        fmt.Sprintf("SL02SEL%d-%d", AccountID, id%1000),
+		"password":                        "simulated-password",
+		"capacityGb":                      capacityGb,
+		"iops":                            iops,
+		"lunId":                           "0",
+		"nasType":                         "ISCSI",
+		"createDate":                      s.options.Now().Format(time.RFC3339),
+		"serviceResourceBackendIpAddress": "10.1.0.10",
+		"serviceResource": map[string]interface{}{
+			"datacenter": map[string]interface{}{"id": intValue(location)},
+		},
+		"storageType":          map[string]interface{}{"keyName": "PERFORMANCE_BLOCK_STORAGE"},
+		"billingItem":          s.newBillingItem(NetworkStorage, id, orderID),
+		"allowedVirtualGuests": []interface{}{},
+		"allowedHardware":      []interface{}{},
+	})
+}
+
+// allowAccess lets the virtual guest or hardware given as parameter reach the iSCSI volume
+func allowAccess(property string, service string) handler {
+	return func(s *Simulator, c call) (interface{}, error) {
+		o, err := s.mustGet(c)
+		if err != nil {
+			return nil, err
+		}
+
+		host := map[string]interface{}{}
+		err = c.parameter(0, &host)
+		if err != nil {
+			return nil, err
+		}
+
+		hostObject, found := s.get(service, intValue(host["id"]))
+		if !found {
+			return nil, notFound(service, intValue(host["id"]))
+		}
+
+		allowed, _ := o[property].([]interface{})
+		for _, a := range allowed {
+			if intValue(a.(map[string]interface{})["id"]) == hostObject.id() {
+				return true, nil
+			}
+		}
+
+		o[property] = append(allowed, map[string]interface{}{
+			"id":                       hostObject.id(),
+			"fullyQualifiedDomainName": hostObject["fullyQualifiedDomainName"],
+			"primaryBackendIpAddress":  hostObject["primaryBackendIpAddress"],
+		})
+
+		return true, nil
+	}
+}
+
+func removeAccess(property string) handler {
+	return func(s *Simulator, c call) (interface{}, error) {
+		o, err := s.mustGet(c)
+		if err != nil {
+			return nil, err
+		}
+
+		host := map[string]interface{}{}
+		err = c.parameter(0, &host)
+		if err != nil {
+			return nil, err
+		}
+
+		allowed, _ := o[property].([]interface{})
+		remaining := []interface{}{}
+		for _, a := range allowed {
+			if intValue(a.(map[string]interface{})["id"]) != intValue(host["id"]) {
+				remaining = append(remaining, a)
+			}
+		}
+		o[property] = remaining
+
+		return true, nil
+	}
+}
+
+func getStorageCredential(s *Simulator, c call) (interface{}, error) {
+	o, err := s.mustGet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"username": o["username"], "password": o["password"]}, nil
+}
+
+func createImageTemplate(s *Simulator, c call) (interface{}, error) {
+	configuration := map[string]interface{}{}
+	err := c.parameter(0, &configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.addImageTemplate(map[string]interface{}{
+		"name": configuration["name"],
+		"note": configuration["note"],
+	}), nil
+}
+
+func (s *Simulator) addImageTemplate(properties map[string]interface{}) object {
+	id := s.newID()
+
+	properties["id"] = id
+	properties["accountId"] = AccountID
+	properties["globalIdentifier"] = globalIdentifier(id)
+	properties["createDate"] = s.options.Now().Format(time.RFC3339)
+	properties["status"] = map[string]interface{}{"keyName": "ACTIVE", "name": "Active"}
+
+	return s.add(ImageTemplateService, properties)
+}
+
+// deleteImageTemplate deletes the image template once TransactionImageDelete has run on it
+func deleteImageTemplate(s *Simulator, c call) (interface{}, error) {
+	if _, err := s.mustGet(c); err != nil {
+		return nil, err
+	}
+
+	s.schedule(c.Service, c.ID, TransactionImageDelete, func() {
+		s.remove(ImageTemplateService, c.ID)
+	})
+
+	active := s.activeTransactions(c.Service, c.ID)
+	return active[len(active)-1].render(c.Service, c.ID, s.options.Now()), nil
+}
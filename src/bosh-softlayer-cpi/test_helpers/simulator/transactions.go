@@ -0,0 +1,135 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+)
+
+// Transaction groups the simulator runs, named the way SoftLayer names them
+const (
+	TransactionProvisioning = "Cloud Instance Provisioning"
+	TransactionServiceSetup = "Service Setup"
+	TransactionOSReload     = "OS Reload"
+	TransactionUpgrade      = "Cloud Instance Upgrade"
+	TransactionReclaim      = "Cloud Instance Reclaim"
+	TransactionImageCapture = "Image Capture"
+	TransactionImageDelete  = "Delete Template"
+)
+
+// transaction runs on an object from start to end, and changes the object through complete
+// once it has ended
+type transaction struct {
+	id       int
+	group    string
+	start    time.Time
+	end      time.Time
+	complete func()
+	applied  bool
+}
+
+func transactionKey(service string, id int) string {
+	return fmt.Sprintf("%s/%d", canonicalService(service), id)
+}
+
+func (s *Simulator) duration(group string) time.Duration {
+	if duration, found := s.options.Durations[group]; found {
+		return duration
+	}
+
+	return DefaultTransactionDuration
+}
+
+// schedule queues a transaction on the object after the ones already queued on it
+func (s *Simulator) schedule(service string, id int, group string, complete func()) {
+	key := transactionKey(service, id)
+
+	start := s.options.Now()
+	if queued := s.transactions[key]; len(queued) > 0 && queued[len(queued)-1].end.After(start) {
+		start = queued[len(queued)-1].end
+	}
+
+	s.transactions[key] = append(s.transactions[key], &transaction{
+		id:       s.newID(),
+		group:    group,
+		start:    start,
+		end:      start.Add(s.duration(group)),
+		complete: complete,
+	})
+}
+
+// advance completes every transaction that has ended, in the order they were queued
+func (s *Simulator) advance() {
+	now := s.options.Now()
+
+	for _, queued := range s.transactions {
+		for _, t := range queued {
+			if t.applied || t.end.After(now) {
+				continue
+			}
+
+			t.applied = true
+			if t.complete != nil {
+				t.complete()
+			}
+		}
+	}
+}
+
+// activeTransactions returns the transactions running on the object now, the first one first
+func (s *Simulator) activeTransactions(service string, id int) []*transaction {
+	now := s.options.Now()
+
+	active := []*transaction{}
+	for _, t := range s.transactions[transactionKey(service, id)] {
+		if t.end.After(now) {
+			active = append(active, t)
+		}
+	}
+
+	return active
+}
+
+// lastTransaction returns the transaction that started last on the object
+func (s *Simulator) lastTransaction(service string, id int) *transaction {
+	now := s.options.Now()
+
+	var last *transaction
+	for _, t := range s.transactions[transactionKey(service, id)] {
+		if !t.start.After(now) {
+			last = t
+		}
+	}
+
+	return last
+}
+
+// render returns the transaction as a SoftLayer_Provisioning_Version1_Transaction of the object
+func (t *transaction) render(service string, id int, now time.Time) map[string]interface{} {
+	status := map[string]interface{}{
+		"name":            "COMPLETE",
+		"friendlyName":    "Complete",
+		"averageDuration": fmt.Sprintf("%.2f", t.end.Sub(t.start).Minutes()),
+	}
+	if t.end.After(now) {
+		status["name"] = "IN_PROGRESS"
+		status["friendlyName"] = "In Progress"
+	}
+
+	rendered := map[string]interface{}{
+		"id":             t.id,
+		"createDate":     t.start.Format(time.RFC3339),
+		"elapsedSeconds": int(now.Sub(t.start).Seconds()),
+		"transactionGroup": map[string]interface{}{
+			"name": t.group,
+		},
+		"transactionStatus": status,
+	}
+
+	if canonicalService(service) == HardwareService {
+		rendered["hardwareId"] = id
+	} else {
+		rendered["guestId"] = id
+	}
+
+	return rendered
+}
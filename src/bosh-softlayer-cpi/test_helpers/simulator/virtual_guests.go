@@ -0,0 +1,206 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// owner is the object a billing item bills for
+type owner struct {
+	service string
+	id      int
+}
+
+func createVirtualGuest(s *Simulator, c call) (interface{}, error) {
+	template := map[string]interface{}{}
+	err := c.parameter(0, &template)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newVirtualGuest(template)
+}
+
+// newVirtualGuest orders a virtual guest from the template, which starts HALTED and runs
+// TransactionProvisioning and TransactionServiceSetup before it is RUNNING
+func (s *Simulator) newVirtualGuest(template map[string]interface{}) (object, error) {
+	for _, property := range []string{"hostname", "domain", "startCpus", "maxMemory"} {
+		if template[property] == nil || template[property] == "" {
+			return nil, requiredProperty(property)
+		}
+	}
+
+	datacenter, err := s.datacenter(template)
+	if err != nil {
+		return nil, err
+	}
+
+	if image, found := template["blockDeviceTemplateGroup"].(map[string]interface{}); found {
+		globalIdentifier := image["globalIdentifier"]
+		if len(filter(s.list(ImageTemplateService), map[string]interface{}{"globalIdentifier": map[string]interface{}{"operation": globalIdentifier}})) == 0 {
+			return nil, apiError{
+				StatusCode: http.StatusInternalServerError,
+				Code:       "SoftLayer_Exception_Public",
+				Message:    fmt.Sprintf("The image template with global identifier %v does not exist.", globalIdentifier),
+			}
+		}
+	}
+
+	id := s.newID()
+	guest := map[string]interface{}{}
+	for property, value := range template {
+		guest[property] = value
+	}
+
+	guest["id"] = id
+	guest["accountId"] = AccountID
+	guest["globalIdentifier"] = globalIdentifier(id)
+	guest["fullyQualifiedDomainName"] = fmt.Sprintf("%s.%s", template["hostname"], template["domain"])
+	guest["datacenter"] = datacenter
+	guest["createDate"] = s.options.Now().Format(time.RFC3339)
+	guest["powerState"] = powerState("HALTED")
+	guest["status"] = map[string]interface{}{"keyName": "ACTIVE", "name": "Active"}
+	guest["primaryBackendIpAddress"] = fmt.Sprintf("10.%d.%d.%d", id/65536%256, id/256%256, id%256)
+	if template["privateNetworkOnlyFlag"] != true {
+		guest["primaryIpAddress"] = fmt.Sprintf("169.%d.%d.%d", id/65536%256, id/256%256, id%256)
+	}
+	guest["operatingSystem"] = map[string]interface{}{
+		"passwords": []interface{}{
+			map[string]interface{}{"username": "root", "password": "simulated-password"},
+		},
+	}
+	guest["billingItem"] = s.newBillingItem(VirtualGuestService, id, 0)
+	references := []interface{}{}
+	templateReferences, _ := template["tagReferences"].([]interface{})
+	for _, reference := range templateReferences {
+		fields, _ := reference.(map[string]interface{})
+		tag, _ := fields["tag"].(map[string]interface{})
+		if name, _ := tag["name"].(string); name != "" {
+			references = append(references, s.tagReference(id, name))
+		}
+	}
+	guest["tagReferences"] = references
+
+	o := s.add(VirtualGuestService, guest)
+
+	s.schedule(VirtualGuestService, id, TransactionProvisioning, nil)
+	s.schedule(VirtualGuestService, id, TransactionServiceSetup, func() {
+		o["powerState"] = powerState("RUNNING")
+	})
+
+	return o, nil
+}
+
+// datacenter returns the datacenter the template names, which must be one the simulator knows of
+func (s *Simulator) datacenter(template map[string]interface{}) (map[string]interface{}, error) {
+	named, _ := template["datacenter"].(map[string]interface{})
+	name, _ := named["name"].(string)
+	if name == "" {
+		return nil, requiredProperty("datacenter.name")
+	}
+
+	for _, datacenter := range s.list(DatacenterService) {
+		if datacenter["name"] == name {
+			return map[string]interface{}(datacenter), nil
+		}
+	}
+
+	return nil, apiError{
+		StatusCode: http.StatusInternalServerError,
+		Code:       "SoftLayer_Exception_Public",
+		Message:    fmt.Sprintf("Location '%s' is not a valid location.", name),
+	}
+}
+
+func requiredProperty(property string) apiError {
+	return apiError{
+		StatusCode: http.StatusInternalServerError,
+		Code:       "SoftLayer_Exception_MissingCreationProperty",
+		Message:    fmt.Sprintf("Property '%s' must be set to create an instance of 'SoftLayer_Virtual_Guest'.", property),
+	}
+}
+
+func globalIdentifier(id int) string {
+	return fmt.Sprintf("%08x-0000-4000-8000-%012x", id, id)
+}
+
+// newBillingItem bills for the object, for the order unless orderID is 0
+func (s *Simulator) newBillingItem(service string, id int, orderID int) map[string]interface{} {
+	billingItemID := s.newID()
+	s.billingItems[billingItemID] = owner{service: service, id: id}
+
+	billingItem := map[string]interface{}{"id": billingItemID}
+	if orderID != 0 {
+		billingItem["orderItem"] = map[string]interface{}{
+			"order": map[string]interface{}{"id": orderID},
+		}
+	}
+
+	return billingItem
+}
+
+func generateOrderTemplate(s *Simulator, c call) (interface{}, error) {
+	template := map[string]interface{}{}
+	err := c.parameter(0, &template)
+	if err != nil {
+		return nil, err
+	}
+
+	datacenter, err := s.datacenter(template)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"complexType":      "SoftLayer_Container_Product_Order_Virtual_Guest",
+		"location":         stringValue(datacenter["id"]),
+		"packageId":        46,
+		"quantity":         1,
+		"useHourlyPricing": template["hourlyBillingFlag"] == true,
+		"prices":           s.guestPrices(template),
+		"virtualGuests": []interface{}{
+			map[string]interface{}{"hostname": template["hostname"], "domain": template["domain"]},
+		},
+	}, nil
+}
+
+// reclaim deletes the object once TransactionReclaim has run on it
+func reclaim(s *Simulator, c call) (interface{}, error) {
+	o, err := s.mustGet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	s.reclaim(c.Service, c.ID, o)
+
+	return true, nil
+}
+
+func (s *Simulator) reclaim(service string, id int, o object) {
+	o["powerState"] = powerState("HALTED")
+	s.schedule(service, id, TransactionReclaim, func() {
+		s.remove(service, id)
+	})
+}
+
+func cancelService(s *Simulator, c call) (interface{}, error) {
+	billed, found := s.billingItems[c.ID]
+	if !found {
+		return nil, notFound(c.Service, c.ID)
+	}
+
+	o, found := s.get(billed.service, billed.id)
+	if !found {
+		return nil, notFound(c.Service, c.ID)
+	}
+
+	delete(s.billingItems, c.ID)
+	if billed.service == VirtualGuestService {
+		s.reclaim(billed.service, billed.id, o)
+	} else {
+		s.remove(billed.service, billed.id)
+	}
+
+	return true, nil
+}
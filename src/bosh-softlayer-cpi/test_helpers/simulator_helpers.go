@@ -0,0 +1,162 @@
+package test_helpers
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-softlayer-cpi/test_helpers/simulator"
+)
+
+// SimulatorEndpoint is the value of SL_API_ENDPOINT that runs the integration tests against a
+// SoftLayer API simulator instead of a SoftLayer account
+const SimulatorEndpoint = "simulator"
+
+const (
+	simulatorSeedPath = "test_fixtures/simulator/seed.json"
+	softLayerApiUrl   = "https://api.softlayer.com"
+)
+
+// simulatorEnv holds the defaults of the environment the integration tests need to run against
+// the simulator
+var simulatorEnv = map[string]string{
+	"SL_USERNAME":    "simulator",
+	"SL_API_KEY":     "simulator",
+	"SWIFT_USERNAME": "simulator:simulator",
+	"SWIFT_CLUSTER":  "simulator",
+}
+
+var (
+	apiSimulator *simulator.Simulator
+	apiRecorder  *simulator.Recorder
+)
+
+// StartSoftLayerSimulator points SL_API_ENDPOINT at a SoftLayer API simulator when it is set to
+// "simulator". The simulator is seeded from test_fixtures/simulator/seed.json and replays the
+// fixtures of the directory in SL_API_SIMULATOR_REPLAY, if any.
+//
+// When SL_API_SIMULATOR_RECORD names a directory instead, SL_API_ENDPOINT is pointed at a recorder
+// writing the traffic of the real SoftLayer API there.
+func StartSoftLayerSimulator(rootTemplatePath string) error {
+	if recordDir := os.Getenv("SL_API_SIMULATOR_RECORD"); recordDir != "" {
+		apiRecorder = simulator.NewRecorder(softLayerApiUrl, recordDir)
+		err := apiRecorder.Start()
+		if err != nil {
+			return err
+		}
+
+		return os.Setenv("SL_API_ENDPOINT", apiRecorder.Endpoint())
+	}
+
+	if os.Getenv("SL_API_ENDPOINT") != SimulatorEndpoint {
+		return nil
+	}
+
+	apiSimulator = simulator.New(simulator.Options{})
+	err := apiSimulator.Load(filepath.Join(rootTemplatePath, simulatorSeedPath))
+	if err != nil {
+		return err
+	}
+
+	if replayDir := os.Getenv("SL_API_SIMULATOR_REPLAY"); replayDir != "" {
+		exchanges, err := simulator.LoadExchanges(replayDir)
+		if err != nil {
+			return err
+		}
+		apiSimulator.Replay(exchanges)
+	}
+
+	err = apiSimulator.Start()
+	if err != nil {
+		return err
+	}
+
+	for name, value := range simulatorEnv {
+		if os.Getenv(name) == "" {
+			os.Setenv(name, value)
+		}
+	}
+
+	return os.Setenv("SL_API_ENDPOINT", apiSimulator.Endpoint())
+}
+
+// StopSoftLayerSimulator stops the simulator or recorder StartSoftLayerSimulator started
+func StopSoftLayerSimulator() error {
+	if apiRecorder != nil {
+		err := apiRecorder.Close()
+		apiRecorder = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	if apiSimulator != nil {
+		err := apiSimulator.Close()
+		apiSimulator = nil
+		if err != nil {
+			return err
+		}
+		return os.Setenv("SL_API_ENDPOINT", SimulatorEndpoint)
+	}
+
+	return nil
+}
+
+// SoftLayerSimulatorSuite registers the BeforeSuite and AfterSuite of an integration test suite in
+// integration/<cpi method> that run it against the simulator StartSoftLayerSimulator starts, if
+// any. It is called as var _ = SoftLayerSimulatorSuite() in the suite file.
+func SoftLayerSimulatorSuite() bool {
+	BeforeSuite(func() {
+		pwd, err := os.Getwd()
+		Expect(err).ToNot(HaveOccurred())
+
+		err = StartSoftLayerSimulator(filepath.Join(pwd, "..", ".."))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterSuite(func() {
+		err := StopSoftLayerSimulator()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	return true
+}
+
+// SoftLayerSimulator returns the simulator StartSoftLayerSimulator started, or nil when the
+// integration tests run against SoftLayer
+func SoftLayerSimulator() *simulator.Simulator {
+	return apiSimulator
+}
+
+// simulatorConfig returns the apiEndpoint of the CPI and the host and port of its registry when
+// SL_API_ENDPOINT points at a simulator or recorder. The simulator serves the registry as well, so
+// that the CPI does not need to reach the virtual guests it creates.
+func simulatorConfig() (apiEndpoint string, registryHost string, registryPort int) {
+	endpoint := os.Getenv("SL_API_ENDPOINT")
+	if !strings.HasPrefix(endpoint, "http://") {
+		return "", "", 0
+	}
+
+	if apiSimulator == nil {
+		return endpoint, "", 0
+	}
+
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint, "", 0
+	}
+
+	host, port, err := net.SplitHostPort(endpointURL.Host)
+	if err != nil {
+		return endpoint, "", 0
+	}
+	registryPort, _ = strconv.Atoi(port)
+
+	return endpoint, host, registryPort
+}
@@ -0,0 +1,364 @@
+package test_helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	datatypes "github.com/maximilien/softlayer-go/data_types"
+	"github.com/maximilien/softlayer-go/softlayer"
+	testhelpers "github.com/maximilien/softlayer-go/test_helpers"
+
+	"bosh-softlayer-cpi/softlayer/common"
+)
+
+// The helpers below mirror the ones of softlayer-go the integration tests use, with their
+// SoftLayer client built by NewSoftLayerClient so that they reach the endpoint in SL_API_ENDPOINT
+// the way the CPI does. They wait for testhelpers.TIMEOUT, polling every
+// testhelpers.POLLING_INTERVAL.
+
+// NewSoftLayerClient creates the SoftLayer client of the CPI for the integration tests, talking to
+// the endpoint in SL_API_ENDPOINT, if set, which must be one of common.ApiEndpoints or an http://
+// URL on the loopback interface, such as the one of a SoftLayer API simulator
+func NewSoftLayerClient(username string, apiKey string) (softlayer.Client, error) {
+	options := common.DefaultRuntimeOptions()
+	if endpoint := os.Getenv("SL_API_ENDPOINT"); endpoint != "" {
+		options.ApiEndpoint = endpoint
+	}
+
+	err := options.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return common.NewSoftLayerClient(username, apiKey, options, boshlog.NewLogger(boshlog.LevelNone)), nil
+}
+
+func CreateAccountService() (softlayer.SoftLayer_Account_Service, error) {
+	client, err := createSoftLayerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetSoftLayer_Account_Service()
+}
+
+func CreateVirtualGuestService() (softlayer.SoftLayer_Virtual_Guest_Service, error) {
+	client, err := createSoftLayerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetSoftLayer_Virtual_Guest_Service()
+}
+
+func CreateVirtualGuestBlockDeviceTemplateGroupService() (softlayer.SoftLayer_Virtual_Guest_Block_Device_Template_Group_Service, error) {
+	client, err := createSoftLayerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetSoftLayer_Virtual_Guest_Block_Device_Template_Group_Service()
+}
+
+func CreateSecuritySshKeyService() (softlayer.SoftLayer_Security_Ssh_Key_Service, error) {
+	client, err := createSoftLayerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetSoftLayer_Security_Ssh_Key_Service()
+}
+
+func CreateNetworkStorageService() (softlayer.SoftLayer_Network_Storage_Service, error) {
+	client, err := createSoftLayerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetSoftLayer_Network_Storage_Service()
+}
+
+func FindTestSshKeys() ([]datatypes.SoftLayer_Security_Ssh_Key, error) {
+	accountService, err := CreateAccountService()
+	if err != nil {
+		return []datatypes.SoftLayer_Security_Ssh_Key{}, err
+	}
+
+	sshKeys, err := accountService.GetSshKeys()
+	if err != nil {
+		return []datatypes.SoftLayer_Security_Ssh_Key{}, err
+	}
+
+	testSshKeys := []datatypes.SoftLayer_Security_Ssh_Key{}
+	for _, key := range sshKeys {
+		if key.Notes == testhelpers.TEST_NOTES_PREFIX {
+			testSshKeys = append(testSshKeys, key)
+		}
+	}
+
+	return testSshKeys, nil
+}
+
+func FindAndDeleteTestSshKeys() error {
+	sshKeys, err := FindTestSshKeys()
+	if err != nil {
+		return err
+	}
+
+	sshKeyService, err := CreateSecuritySshKeyService()
+	if err != nil {
+		return err
+	}
+
+	for _, sshKey := range sshKeys {
+		deleted, err := sshKeyService.DeleteObject(sshKey.Id)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return errors.New(fmt.Sprintf("Could not delete ssh key with id: %d", sshKey.Id))
+		}
+	}
+
+	return nil
+}
+
+func MarkVirtualGuestAsTest(virtualGuest datatypes.SoftLayer_Virtual_Guest) error {
+	virtualGuestService, err := CreateVirtualGuestService()
+	if err != nil {
+		return err
+	}
+
+	vgTemplate := datatypes.SoftLayer_Virtual_Guest{
+		Notes: testhelpers.TEST_NOTES_PREFIX,
+	}
+
+	edited, err := virtualGuestService.EditObject(virtualGuest.Id, vgTemplate)
+	if err != nil {
+		return err
+	}
+	if !edited {
+		return errors.New(fmt.Sprintf("Could not edit virtual guest with id: %d", virtualGuest.Id))
+	}
+
+	return nil
+}
+
+func CreateTestSshKey() (datatypes.SoftLayer_Security_Ssh_Key, string) {
+	_, testSshKeyValue, err := testhelpers.GenerateSshKey()
+	Expect(err).ToNot(HaveOccurred())
+
+	sshKey := datatypes.SoftLayer_Security_Ssh_Key{
+		Key:   strings.Trim(string(testSshKeyValue), "\n"),
+		Label: testhelpers.TEST_LABEL_PREFIX,
+		Notes: testhelpers.TEST_NOTES_PREFIX,
+	}
+
+	sshKeyService, err := CreateSecuritySshKeyService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> creating ssh key in SL\n")
+	createdSshKey, err := sshKeyService.CreateObject(sshKey)
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(createdSshKey.Key).To(Equal(sshKey.Key), "key")
+	Expect(createdSshKey.Label).To(Equal(sshKey.Label), "label")
+	Expect(createdSshKey.Notes).To(Equal(sshKey.Notes), "notes")
+	Expect(createdSshKey.CreateDate).ToNot(BeNil(), "createDate")
+	Expect(createdSshKey.Id).To(BeNumerically(">", 0), "id")
+	Expect(createdSshKey.ModifyDate).To(BeNil(), "modifyDate")
+	fmt.Printf("----> created ssh key: %d\n in SL", createdSshKey.Id)
+
+	return createdSshKey, string(testSshKeyValue)
+}
+
+func CreateDisk(size int, location string) datatypes.SoftLayer_Network_Storage {
+	networkStorageService, err := CreateNetworkStorageService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> creating new disk\n")
+	disk, err := networkStorageService.CreateNetworkStorage(size, 1000, location, true)
+	Expect(err).ToNot(HaveOccurred())
+	fmt.Printf("----> created disk: %d\n", disk.Id)
+
+	return disk
+}
+
+func CreateVirtualGuestAndMarkItTest(securitySshKeys []datatypes.SoftLayer_Security_Ssh_Key) datatypes.SoftLayer_Virtual_Guest {
+	sshKeys := make([]datatypes.SshKey, len(securitySshKeys))
+	for i, securitySshKey := range securitySshKeys {
+		sshKeys[i] = datatypes.SshKey{Id: securitySshKey.Id}
+	}
+
+	virtualGuestTemplate := datatypes.SoftLayer_Virtual_Guest_Template{
+		Hostname:  "test",
+		Domain:    "softlayergo.com",
+		StartCpus: 1,
+		MaxMemory: 1024,
+		Datacenter: datatypes.Datacenter{
+			Name: testhelpers.GetDatacenter(),
+		},
+		SshKeys:                      sshKeys,
+		HourlyBillingFlag:            true,
+		LocalDiskFlag:                true,
+		OperatingSystemReferenceCode: "UBUNTU_LATEST",
+	}
+
+	virtualGuestService, err := CreateVirtualGuestService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> creating new virtual guest\n")
+	virtualGuest, err := virtualGuestService.CreateObject(virtualGuestTemplate)
+	Expect(err).ToNot(HaveOccurred())
+	fmt.Printf("----> created virtual guest: %d\n", virtualGuest.Id)
+
+	WaitForVirtualGuestToBeRunning(virtualGuest.Id)
+	WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuest.Id)
+
+	fmt.Printf("----> marking virtual guest with TEST:softlayer-go\n")
+	err = MarkVirtualGuestAsTest(virtualGuest)
+	Expect(err).ToNot(HaveOccurred(), "Could not mark virtual guest as test")
+	fmt.Printf("----> marked virtual guest with TEST:softlayer-go\n")
+
+	return virtualGuest
+}
+
+func DeleteVirtualGuest(virtualGuestId int) {
+	virtualGuestService, err := CreateVirtualGuestService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> deleting virtual guest: %d\n", virtualGuestId)
+	deleted, err := virtualGuestService.DeleteObject(virtualGuestId)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(deleted).To(BeTrue(), "could not delete virtual guest")
+
+	WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuestId)
+}
+
+func DeleteSshKey(sshKeyId int) {
+	sshKeyService, err := CreateSecuritySshKeyService()
+	Expect(err).ToNot(HaveOccurred())
+
+	if SshKeyPresent(sshKeyId) {
+		fmt.Printf("----> deleting ssh key: %d\n", sshKeyId)
+		deleted, err := sshKeyService.DeleteObject(sshKeyId)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(BeTrue(), "could not delete ssh key")
+	} else {
+		fmt.Printf("----> ssh key %d already not present\n", sshKeyId)
+	}
+
+	WaitForDeletedSshKeyToNoLongerBePresent(sshKeyId)
+}
+
+func DeleteDisk(diskId int) {
+	networkStorageService, err := CreateNetworkStorageService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> deleting disk: %d\n", diskId)
+	err = networkStorageService.DeleteNetworkStorage(diskId, true)
+	Expect(err).ToNot(HaveOccurred())
+}
+
+func WaitForVirtualGuestToBeRunning(virtualGuestId int) {
+	virtualGuestService, err := CreateVirtualGuestService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> waiting for virtual guest: %d, until RUNNING\n", virtualGuestId)
+	Eventually(func() string {
+		vgPowerState, err := virtualGuestService.GetPowerState(virtualGuestId)
+		Expect(err).ToNot(HaveOccurred())
+		fmt.Printf("----> virtual guest: %d, has power state: %s\n", virtualGuestId, vgPowerState.KeyName)
+		return vgPowerState.KeyName
+	}, testhelpers.TIMEOUT, testhelpers.POLLING_INTERVAL).Should(Equal("RUNNING"), "failed waiting for virtual guest to be RUNNING")
+}
+
+func WaitForVirtualGuestToHaveNoActiveTransactions(virtualGuestId int) {
+	virtualGuestService, err := CreateVirtualGuestService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> waiting for virtual guest to have no active transactions pending\n")
+	Eventually(func() int {
+		activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuestId)
+		Expect(err).ToNot(HaveOccurred())
+		fmt.Printf("----> virtual guest: %d, has %d active transactions\n", virtualGuestId, len(activeTransactions))
+		return len(activeTransactions)
+	}, testhelpers.TIMEOUT, testhelpers.POLLING_INTERVAL).Should(Equal(0), "failed waiting for virtual guest to have no active transactions")
+}
+
+func WaitForVirtualGuestToHaveNoActiveTransactionsOrToErr(virtualGuestId int) {
+	virtualGuestService, err := CreateVirtualGuestService()
+	if err != nil {
+		return
+	}
+
+	fmt.Printf("----> waiting for virtual guest to have no active transactions pending\n")
+	Eventually(func() int {
+		activeTransactions, err := virtualGuestService.GetActiveTransactions(virtualGuestId)
+		if err != nil {
+			return 0
+		}
+		fmt.Printf("----> virtual guest: %d, has %d active transactions\n", virtualGuestId, len(activeTransactions))
+		return len(activeTransactions)
+	}, testhelpers.TIMEOUT, testhelpers.POLLING_INTERVAL).Should(Equal(0), "failed waiting for virtual guest to have no active transactions")
+}
+
+func WaitForVirtualGuestBlockTemplateGroupToHaveNoActiveTransactions(virtualGuestBlockTemplateGroupId int) {
+	vgbdtgService, err := CreateVirtualGuestBlockDeviceTemplateGroupService()
+	Expect(err).ToNot(HaveOccurred())
+
+	fmt.Printf("----> waiting for virtual guest block template group to have no active transactions pending\n")
+	Eventually(func() bool {
+		activeTransaction, err := vgbdtgService.GetTransaction(virtualGuestBlockTemplateGroupId)
+		Expect(err).ToNot(HaveOccurred())
+
+		emptyTransaction := datatypes.SoftLayer_Provisioning_Version1_Transaction{}
+		if activeTransaction != emptyTransaction {
+			fmt.Printf("----> virtual guest template group: %d, has %#v pending\n", virtualGuestBlockTemplateGroupId, activeTransaction)
+			return true
+		}
+		return false
+	}, testhelpers.TIMEOUT, testhelpers.POLLING_INTERVAL).Should(BeFalse(), "failed waiting for virtual guest block template group to have no active transactions")
+}
+
+func SshKeyPresent(sshKeyId int) bool {
+	accountService, err := CreateAccountService()
+	Expect(err).ToNot(HaveOccurred())
+	sshKeys, err := accountService.GetSshKeys()
+	Expect(err).ToNot(HaveOccurred())
+
+	for _, sshKey := range sshKeys {
+		if sshKey.Id == sshKeyId {
+			return true
+		}
+	}
+	return false
+}
+
+func WaitForCreatedSshKeyToBePresent(sshKeyId int) {
+	fmt.Printf("----> waiting for created ssh key to be present\n")
+	Eventually(func() bool {
+		return SshKeyPresent(sshKeyId)
+	}, testhelpers.TIMEOUT, testhelpers.POLLING_INTERVAL).Should(BeTrue(), "created ssh key but not in the list of ssh keys")
+}
+
+func WaitForDeletedSshKeyToNoLongerBePresent(sshKeyId int) {
+	fmt.Printf("----> waiting for deleted ssh key to no longer be present\n")
+	Eventually(func() bool {
+		return SshKeyPresent(sshKeyId)
+	}, testhelpers.TIMEOUT, testhelpers.POLLING_INTERVAL).Should(BeFalse(), "failed waiting for deleted ssh key to be removed from list of ssh keys")
+}
+
+func createSoftLayerClient() (softlayer.Client, error) {
+	username, apiKey, err := testhelpers.GetUsernameAndApiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSoftLayerClient(username, apiKey)
+}